@@ -2,16 +2,20 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -22,10 +26,22 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	// Import node implementations to register them
-	_ "github.com/linkflow-ai/linkflow-ai/internal/node/runtime/nodes"
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime/nodes"
 
 	"github.com/linkflow-ai/linkflow-ai/internal/engine"
+	gatewayhandlers "github.com/linkflow-ai/linkflow-ai/internal/gateway/handlers"
 	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/config"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/database"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/logger"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/messaging/kafka"
+	platformevents "github.com/linkflow-ai/linkflow-ai/internal/shared/events"
+	storageservice "github.com/linkflow-ai/linkflow-ai/internal/storage/app/service"
+	webhookpostgres "github.com/linkflow-ai/linkflow-ai/internal/webhook/adapters/repository/postgres"
+	webhookservice "github.com/linkflow-ai/linkflow-ai/internal/webhook/app/service"
+	webhookrepository "github.com/linkflow-ai/linkflow-ai/internal/webhook/domain/repository"
+	"github.com/linkflow-ai/linkflow-ai/internal/workflow/features"
 	"github.com/linkflow-ai/linkflow-ai/pkg/middleware"
 )
 
@@ -50,6 +66,40 @@ var db *sql.DB
 // Workflow engine
 var eng *engine.Engine
 
+// Scheduler firing the schedule triggers of active workflows
+var scheduler *engine.Scheduler
+
+// Egress policy outbound requests of workflows are held to
+var egressGuard *egress.Guard
+
+// Binary data of executions, such as files workflows reply to webhooks with
+var binaryStore *storageservice.BinaryStore
+
+// Routes of the webhook triggers of active workflows, shared by all replicas
+var routeRegistry *webhookservice.RouteRegistry
+
+// Kafka and platform event triggers of active workflows
+var subscriptions *engine.Subscriptions
+
+// Events of the platform, which event triggers subscribe to; published
+// through Kafka when there are brokers, so that every replica sees them
+var platformEvents *platformevents.Bus
+var eventPublisher *kafka.EventPublisher
+
+// WebSocket hub streaming events, such as those of debug sessions, to clients
+var hub *gatewayhandlers.Hub
+
+// Debug sessions, whose runs pause at breakpoints
+var debugSessions *features.DebugManager
+
+// Recordings of the nodes of finished executions, which re-runs from a node
+// reuse instead of running the nodes upstream of it again
+var recorder *features.ExecutionRecorder
+var replayer *features.ExecutionReplayer
+
+// Saved versions of workflows, which can be compared and restored
+var versions *features.PostgresVersionStore
+
 func main() {
 	// Load configuration from environment
 	cfg := loadConfig()
@@ -68,11 +118,88 @@ func main() {
 	}
 	log.Println("Connected to PostgreSQL")
 
-	// Initialize workflow engine
-	eng = engine.NewEngine()
+	// Binary data of executions is kept as files of the storage service
+	storageService := storageservice.NewStorageService(
+		getEnvOrDefault("EXECUTION_BINARY_DIR", "/tmp/linkflow-storage"),
+		logger.New(config.LoggerConfig{Level: "info", Format: "json", OutputPath: "stdout"}),
+	)
+	binaryStore = storageservice.NewBinaryStore(storageService, "executions")
+
+	// Outbound requests of workflows cannot reach internal addresses unless
+	// the base policy allows them; workspaces narrow it with their own
+	basePolicy := egress.Policy{
+		Allow:        envList("EGRESS_ALLOW"),
+		Deny:         envList("EGRESS_DENY"),
+		AllowPrivate: getEnvOrDefault("EGRESS_ALLOW_PRIVATE", "false") == "true",
+	}
+	if err := basePolicy.Validate(); err != nil {
+		log.Fatalf("Invalid egress policy: %v", err)
+	}
+	egressGuard = egress.NewGuard(basePolicy).
+		WithPolicyLoader(loadEgressPolicy).
+		WithViolationHandler(auditEgressViolation)
+
+	platformEvents = platformevents.NewBus()
+	if brokers := envList("KAFKA_BROKERS"); len(brokers) > 0 {
+		startEventForwarding(brokers)
+	}
+
+	// Initialize workflow engine; executions are checkpointed to the database
+	// so parked and interrupted runs survive restarts, and leased so that
+	// only one replica resumes each
+	hostname, _ := os.Hostname()
+	executionLease, err := time.ParseDuration(getEnvOrDefault("EXECUTION_LEASE", "1m"))
+	if err != nil {
+		log.Fatalf("Invalid EXECUTION_LEASE: %v", err)
+	}
+	eng = engine.NewEngine().
+		WithRepository(engine.NewPostgresExecutionRepository(db).WithTable("execution_service.engine_executions")).
+		WithExecutionLease(fmt.Sprintf("%s-%d", hostname, os.Getpid()), executionLease).
+		WithWorkflowLoader(loadEngineWorkflow).
+		WithBinaryStore(binaryStore).
+		WithEgressGuard(egressGuard).
+		WithEventBus(platformEvents)
+	eng.WithWorkflowRunner(features.NewSubWorkflowExecutor(loadEngineWorkflow, eng))
+	nodes.GetWebhookHandler().WithBinaryStore(binaryStore)
+	routeRegistry = webhookservice.NewRouteRegistry(webhookpostgres.NewWebhookRepository(&database.DB{DB: db}))
 	nodeCount := len(runtime.List())
 	log.Printf("Registered %d node types", nodeCount)
 
+	recorder = features.NewExecutionRecorder(100).WithStore(features.NewPostgresRecordingStore(db))
+	versions = features.NewPostgresVersionStore(db)
+	replayer = features.NewExecutionReplayer(recorder, eng)
+	watchEngineExecutions()
+	recoverExecutions()
+	go func() {
+		// Runs of replicas that stopped are taken over once their leases expire
+		for range time.Tick(executionLease) {
+			recoverExecutions()
+		}
+	}()
+
+	// Debug sessions stream their events to the client debugging
+	hub = gatewayhandlers.NewHub().WithAuthorizer(authorizeChannel)
+	go hub.Run()
+	debugSessions = features.NewDebugManager().WithEventHandler(func(session *features.DebugSession, event *features.DebugEvent) {
+		hub.BroadcastDebugEvent(session.ID, string(event.Type), event)
+	})
+
+	// Initialize scheduler; replicas claim each run and share the cursors of
+	// polling triggers through the database
+	maxScheduled, _ := strconv.Atoi(getEnvOrDefault("SCHEDULE_MAX_CONCURRENT", "0"))
+	scheduler = engine.NewScheduler(eng, nil, nil, &engine.SchedulerConfig{
+		MaxConcurrent:   maxScheduled,
+		MissedRunPolicy: getEnvOrDefault("SCHEDULE_MISSED_RUN_POLICY", engine.MissedRunSkip),
+	}).WithRunClaimer(engine.NewPostgresRunClaimer(db, hostname)).
+		WithPollStateStore(engine.NewPostgresPollStateStore(db))
+	if err := scheduler.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start scheduler: %v", err)
+	}
+	defer scheduler.Stop()
+	subscriptions = engine.NewSubscriptions(eng, nil)
+	defer subscriptions.Stop()
+	syncActiveWorkflowSchedules()
+
 	// Create router
 	router := mux.NewRouter()
 
@@ -154,11 +281,25 @@ func getEnvOrDefault(key, defaultVal string) string {
 	return defaultVal
 }
 
+// envList splits a comma-separated environment variable
+func envList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func registerRoutes(r *mux.Router) {
 	// Health check
 	r.HandleFunc("/health", healthHandler).Methods("GET")
 	r.HandleFunc("/api/health", healthHandler).Methods("GET")
 
+	// Webhook trigger routes of active workflows (public, authenticated per route)
+	r.HandleFunc("/webhook/{path:.+}", webhookRouteHandler)
+
 	// API v1 routes
 	api := r.PathPrefix("/api/v1").Subrouter()
 
@@ -188,11 +329,38 @@ func registerRoutes(r *mux.Router) {
 	api.HandleFunc("/workflows/{id}/deactivate", authMiddleware(deactivateWorkflowHandler)).Methods("POST")
 	api.HandleFunc("/workflows/{id}/execute", authMiddleware(executeWorkflowHandler)).Methods("POST")
 	api.HandleFunc("/workflows/{id}/clone", authMiddleware(cloneWorkflowHandler)).Methods("POST")
+	api.HandleFunc("/workflows/{id}/debug", authMiddleware(startDebugHandler)).Methods("POST")
+	api.HandleFunc("/workflows/{id}/nodes/{nodeId}/pinned-data", authMiddleware(pinNodeDataHandler)).Methods("PUT")
+	api.HandleFunc("/workflows/{id}/nodes/{nodeId}/pinned-data", authMiddleware(unpinNodeDataHandler)).Methods("DELETE")
+	api.HandleFunc("/workflows/{id}/versions", authMiddleware(listWorkflowVersionsHandler)).Methods("GET")
+	api.HandleFunc("/workflows/{id}/versions/diff", authMiddleware(diffWorkflowVersionsHandler)).Methods("GET")
+	api.HandleFunc("/workflows/{id}/versions/{version:[0-9]+}", authMiddleware(getWorkflowVersionHandler)).Methods("GET")
+	api.HandleFunc("/workflows/{id}/versions/{version:[0-9]+}/restore", authMiddleware(restoreWorkflowVersionHandler)).Methods("POST")
+	api.HandleFunc("/workflows/{id}/versions/{version:[0-9]+}/activate", authMiddleware(activateWorkflowVersionHandler)).Methods("POST")
+
+	// Debug routes
+	api.HandleFunc("/debug/sessions", authMiddleware(listDebugSessionsHandler)).Methods("GET")
+	api.HandleFunc("/debug/sessions/{sessionId}", authMiddleware(getDebugSessionHandler)).Methods("GET")
+	api.HandleFunc("/debug/sessions/{sessionId}", authMiddleware(endDebugSessionHandler)).Methods("DELETE")
+	api.HandleFunc("/debug/sessions/{sessionId}/breakpoints", authMiddleware(setBreakpointHandler)).Methods("POST")
+	api.HandleFunc("/debug/sessions/{sessionId}/breakpoints/{nodeId}", authMiddleware(removeBreakpointHandler)).Methods("DELETE")
+	api.HandleFunc("/debug/sessions/{sessionId}/watches", authMiddleware(addWatchHandler)).Methods("POST")
+	api.HandleFunc("/debug/sessions/{sessionId}/watches/{watchId}", authMiddleware(removeWatchHandler)).Methods("DELETE")
+	api.HandleFunc("/debug/sessions/{sessionId}/input", authMiddleware(modifyDebugInputHandler)).Methods("PUT")
+	api.HandleFunc("/debug/sessions/{sessionId}/{action:resume|pause|step-into|step-over|step-out|run-to|stop}", authMiddleware(debugControlHandler)).Methods("POST")
+
+	// WebSocket of the events of debug sessions, subscribed to by channel
+	ws := gatewayhandlers.NewWebSocketHandler(hub).WithUserResolver(getUserIDFromContext)
+	api.HandleFunc("/ws", websocketAuth(authMiddleware(ws.ServeHTTP))).Methods("GET")
 
 	// Execution routes
 	api.HandleFunc("/executions", authMiddleware(listExecutionsHandler)).Methods("GET")
 	api.HandleFunc("/executions/{id}", authMiddleware(getExecutionHandler)).Methods("GET")
 	api.HandleFunc("/executions/{id}/cancel", authMiddleware(cancelExecutionHandler)).Methods("POST")
+	api.HandleFunc("/executions/{id}/retry", authMiddleware(retryExecutionHandler)).Methods("POST")
+	api.HandleFunc("/executions/{id}/recording", authMiddleware(getExecutionRecordingHandler)).Methods("GET")
+	api.HandleFunc("/executions/{id}/retry-failed", authMiddleware(retryFailedNodeHandler)).Methods("POST")
+	api.HandleFunc("/executions/{id}/rerun", authMiddleware(rerunExecutionHandler)).Methods("POST")
 	api.HandleFunc("/execute", authMiddleware(directExecuteHandler)).Methods("POST")
 
 	// Node routes
@@ -238,6 +406,7 @@ func registerRoutes(r *mux.Router) {
 	api.HandleFunc("/webhooks/{id}", authMiddleware(updateWebhookHandler)).Methods("PUT")
 	api.HandleFunc("/webhooks/{id}", authMiddleware(deleteWebhookHandler)).Methods("DELETE")
 	api.HandleFunc("/webhooks/{endpointId}/trigger", triggerWebhookHandler).Methods("POST")
+	api.HandleFunc("/webhooks/resume/{executionId}/{token}", resumeWebhookHandler).Methods("POST")
 
 	// Notification routes
 	api.HandleFunc("/notifications", authMiddleware(listNotificationsHandler)).Methods("GET")
@@ -310,6 +479,30 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// websocketAuth lets browsers, which cannot set headers on WebSocket
+// requests, pass their token as the access_token query parameter
+func websocketAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next(w, r)
+	}
+}
+
+// authorizeChannel keeps the WebSocket channels of debug sessions and
+// notifications to the user they belong to
+func authorizeChannel(client *gatewayhandlers.Client, channel string) bool {
+	if sessionID, ok := strings.CutPrefix(channel, gatewayhandlers.ChannelDebug+"."); ok {
+		session, exists := debugSessions.GetSession(sessionID)
+		return exists && session.UserID == client.UserID
+	}
+	if userID, ok := strings.CutPrefix(channel, gatewayhandlers.ChannelNotifications+"."); ok {
+		return userID == client.UserID
+	}
+	return true
+}
+
 func getUserIDFromContext(r *http.Request) string {
 	if v := r.Context().Value("userID"); v != nil {
 		return v.(string)
@@ -426,6 +619,15 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 		VALUES ($1, $2, $3, $4, NOW(), NOW())
 	`, uuid.New().String(), userID, req.FirstName, req.LastName)
 
+	// New users are a platform wide event, not one of the user themself
+	if event, err := platformevents.NewEvent(platformevents.UserCreated, userID, "user", platformevents.UserCreatedData{
+		UserID: userID,
+		Email:  req.Email,
+		Name:   strings.TrimSpace(req.FirstName + " " + req.LastName),
+	}); err == nil {
+		publishPlatformEvent(r.Context(), event.WithSource("api"))
+	}
+
 	// Create session token
 	token := generateToken()
 	expiresAt := time.Now().Add(24 * time.Hour)
@@ -780,6 +982,7 @@ func createWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 		Nodes       []interface{} `json:"nodes"`
 		Connections []interface{} `json:"connections"`
 		Settings    interface{}   `json:"settings"`
+		Message     string        `json:"message"` // Of the first version
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
@@ -795,10 +998,13 @@ func createWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	settingsJSON, _ := json.Marshal(req.Settings)
 
 	workflowID := uuid.New().String()
-	_, err := db.Exec(`
-		INSERT INTO workflow_service.workflows (id, user_id, name, description, status, nodes, connections, settings, version, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, 'draft', $5, $6, $7, 1, NOW(), NOW())
-	`, workflowID, userID, req.Name, req.Description, nodesJSON, connectionsJSON, settingsJSON)
+	_, err := saveWorkflow(r.Context(), workflowID, userID, req.Message, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO workflow_service.workflows (id, user_id, name, description, status, nodes, connections, settings, version, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, 'draft', $5, $6, $7, 1, NOW(), NOW())
+		`, workflowID, userID, req.Name, req.Description, nodesJSON, connectionsJSON, settingsJSON)
+		return err
+	})
 
 	if err != nil {
 		log.Printf("Insert workflow error: %v", err)
@@ -867,34 +1073,122 @@ func updateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	userID := getUserIDFromContext(r)
 
-	var req map[string]interface{}
-	json.NewDecoder(r.Body).Decode(&req)
-
-	if name, ok := req["name"].(string); ok {
-		db.Exec("UPDATE workflow_service.workflows SET name = $1, updated_at = NOW() WHERE id = $2 AND user_id = $3", name, id, userID)
+	// Fields left out keep their value
+	var req struct {
+		Name        *string         `json:"name"`
+		Description *string         `json:"description"`
+		Nodes       json.RawMessage `json:"nodes"`
+		Connections json.RawMessage `json:"connections"`
+		Settings    json.RawMessage `json:"settings"`
+		Message     string          `json:"message"` // Of the version the update saves
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
 	}
-	if desc, ok := req["description"].(string); ok {
-		db.Exec("UPDATE workflow_service.workflows SET description = $1, updated_at = NOW() WHERE id = $2 AND user_id = $3", desc, id, userID)
+
+	// Every update is saved as a new version
+	version, err := saveWorkflow(r.Context(), id, userID, req.Message, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			UPDATE workflow_service.workflows
+			SET name = COALESCE($3, name), description = COALESCE($4, description), nodes = COALESCE($5, nodes),
+				connections = COALESCE($6, connections), settings = COALESCE($7, settings),
+				version = version + 1, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+		`, id, userID, req.Name, req.Description, jsonOrNil(req.Nodes), jsonOrNil(req.Connections), jsonOrNil(req.Settings))
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Workflow not found")
+		return
 	}
-	if nodes, ok := req["nodes"]; ok {
-		nodesJSON, _ := json.Marshal(nodes)
-		db.Exec("UPDATE workflow_service.workflows SET nodes = $1, updated_at = NOW() WHERE id = $2 AND user_id = $3", nodesJSON, id, userID)
+	if err != nil {
+		log.Printf("Update workflow error: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to update workflow")
+		return
 	}
-	if connections, ok := req["connections"]; ok {
-		connectionsJSON, _ := json.Marshal(connections)
-		db.Exec("UPDATE workflow_service.workflows SET connections = $1, updated_at = NOW() WHERE id = $2 AND user_id = $3", connectionsJSON, id, userID)
+	if err := resyncWebhookRoutes(r.Context(), id); err != nil {
+		respondResyncError(w, id, err)
+		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"id":      id,
+		"version": version,
 		"message": "Workflow updated",
 	})
 }
 
+// saveWorkflow writes a workflow with write and stores what it wrote as the
+// workflow's current version, in one transaction, returning the version
+func saveWorkflow(ctx context.Context, id, userID, message string, write func(tx *sql.Tx) error) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := write(tx); err != nil {
+		return 0, err
+	}
+	version, err := readWorkflowVersion(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	version.Author, version.Message = userID, message
+	if err := versions.Save(ctx, tx, version); err != nil {
+		return 0, err
+	}
+	return version.Version, tx.Commit()
+}
+
+// readWorkflowVersion reads the current version of a workflow from its row
+func readWorkflowVersion(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}, id string) (*features.WorkflowVersion, error) {
+	version := &features.WorkflowVersion{WorkflowID: id}
+	var description sql.NullString
+	var nodesJSON, connectionsJSON, settingsJSON []byte
+	err := q.QueryRowContext(ctx, `
+		SELECT name, description, version, nodes, connections, settings, user_id, updated_at
+		FROM workflow_service.workflows WHERE id = $1
+	`, id).Scan(&version.Name, &description, &version.Version, &nodesJSON, &connectionsJSON, &settingsJSON, &version.Author, &version.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow %s: %w", id, err)
+	}
+	version.Description = description.String
+	json.Unmarshal(nodesJSON, &version.Nodes)
+	json.Unmarshal(connectionsJSON, &version.Connections)
+	json.Unmarshal(settingsJSON, &version.Settings)
+	return version, nil
+}
+
+// jsonOrNil passes a JSON field of a request on to a query, as NULL when
+// the request left it out
+func jsonOrNil(raw json.RawMessage) interface{} {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return []byte(raw)
+}
+
 func deleteWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	userID := getUserIDFromContext(r)
-	db.Exec("DELETE FROM workflow_service.workflows WHERE id = $1 AND user_id = $2", id, userID)
+	result, err := db.Exec("DELETE FROM workflow_service.workflows WHERE id = $1 AND user_id = $2", id, userID)
+	if err == nil {
+		if n, _ := result.RowsAffected(); n > 0 {
+			scheduler.RemoveWorkflow(id)
+			subscriptions.RemoveWorkflow(id)
+			unregisterWebhookRoutes(r.Context(), id)
+		}
+	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Workflow deleted",
 	})
@@ -903,7 +1197,45 @@ func deleteWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 func activateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	userID := getUserIDFromContext(r)
-	db.Exec("UPDATE workflow_service.workflows SET status = 'active', updated_at = NOW() WHERE id = $1 AND user_id = $2", id, userID)
+	result, err := db.Exec("UPDATE workflow_service.workflows SET status = 'active', updated_at = NOW() WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to activate workflow")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		respondError(w, http.StatusNotFound, "Workflow not found")
+		return
+	}
+
+	// Start serving the workflow's webhook triggers, firing its schedule
+	// triggers and consuming the messages of its event triggers
+	if wf, err := loadEngineWorkflow(r.Context(), id); err == nil {
+		if err := features.ValidateSubWorkflowChain(r.Context(), loadEngineWorkflow, wf); err != nil {
+			db.Exec("UPDATE workflow_service.workflows SET status = 'inactive', updated_at = NOW() WHERE id = $1", id)
+			if errors.Is(err, features.ErrSubWorkflowCycle) || errors.Is(err, features.ErrSubWorkflowNotFound) {
+				respondError(w, http.StatusUnprocessableEntity, err.Error())
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "Failed to validate sub-workflows")
+			return
+		}
+		if err := routeRegistry.SyncWorkflow(r.Context(), userID, id, webhookRouteSpecs(wf)); err != nil {
+			log.Printf("Route workflow %s webhooks error: %v", id, err)
+			db.Exec("UPDATE workflow_service.workflows SET status = 'inactive', updated_at = NOW() WHERE id = $1", id)
+			if errors.Is(err, webhookrepository.ErrPathTaken) {
+				respondError(w, http.StatusConflict, "A webhook path of the workflow is served by another workflow")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "Failed to register webhook routes")
+			return
+		}
+		if err := scheduler.SyncWorkflow(r.Context(), wf); err != nil {
+			log.Printf("Schedule workflow %s error: %v", id, err)
+		}
+		if err := subscriptions.SyncWorkflow(r.Context(), wf); err != nil {
+			log.Printf("Subscribe workflow %s error: %v", id, err)
+		}
+	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"id":      id,
 		"status":  "active",
@@ -914,7 +1246,14 @@ func activateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 func deactivateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	userID := getUserIDFromContext(r)
-	db.Exec("UPDATE workflow_service.workflows SET status = 'inactive', updated_at = NOW() WHERE id = $1 AND user_id = $2", id, userID)
+	result, err := db.Exec("UPDATE workflow_service.workflows SET status = 'inactive', updated_at = NOW() WHERE id = $1 AND user_id = $2", id, userID)
+	scheduler.RemoveWorkflow(id)
+	subscriptions.RemoveWorkflow(id)
+	if err == nil {
+		if n, _ := result.RowsAffected(); n > 0 {
+			unregisterWebhookRoutes(r.Context(), id)
+		}
+	}
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"id":      id,
 		"status":  "inactive",
@@ -926,13 +1265,79 @@ func executeWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	userID := getUserIDFromContext(r)
 
+	// Parse input
+	var input map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&input)
+
+	// Start from the trigger named by the request, if any
+	startWorkflowExecution(w, id, userID, input, "manual", r.URL.Query().Get("triggerNodeId"))
+}
+
+func retryExecutionHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	userID := getUserIDFromContext(r)
+
+	var workflowID string
+	var workflowVersion sql.NullInt64
+	var triggerNodeID sql.NullString
+	var inputJSON []byte
+	err := db.QueryRow(`
+		SELECT workflow_id, workflow_version, trigger_node_id, input_data FROM execution_service.executions
+		WHERE id = $1 AND user_id = $2
+	`, id, userID).Scan(&workflowID, &workflowVersion, &triggerNodeID, &inputJSON)
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Execution not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	// Run the version of the workflow the original execution ran, from its
+	// trigger and with its input
+	current, ok := userWorkflowVersion(w, r, workflowID)
+	if !ok {
+		return
+	}
+	number := int(workflowVersion.Int64)
+	if number == 0 {
+		number = current
+	}
+	version, err := loadWorkflowVersion(r.Context(), workflowID, number, current)
+	if errors.Is(err, features.ErrVersionNotFound) {
+		respondError(w, http.StatusConflict, fmt.Sprintf("Version %d of the workflow is no longer kept", number))
+		return
+	}
+	if err != nil {
+		log.Printf("Load workflow %s version %d error: %v", workflowID, number, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load version")
+		return
+	}
+	nodesJSON, _ := json.Marshal(version.Nodes)
+	connectionsJSON, _ := json.Marshal(version.Connections)
+	settingsJSON, _ := json.Marshal(version.Settings)
+	wf := buildEngineWorkflow(workflowID, version.Name, nodesJSON, connectionsJSON, settingsJSON)
+	wf.Version = version.Version
+
+	var input map[string]interface{}
+	json.Unmarshal(inputJSON, &input)
+
+	runWorkflowExecution(w, wf, userID, input, "retry", triggerNodeID.String)
+}
+
+// startWorkflowExecution starts an execution of the current version of a
+// stored workflow
+func startWorkflowExecution(w http.ResponseWriter, id, userID string, input map[string]interface{}, triggerType, triggerNodeID string) {
 	// Get workflow data
-	var nodesJSON, connectionsJSON []byte
+	var name string
+	var nodesJSON, connectionsJSON, settingsJSON []byte
 	var version int
 	err := db.QueryRow(`
-		SELECT nodes, connections, version FROM workflow_service.workflows
+		SELECT name, nodes, connections, settings, version FROM workflow_service.workflows
 		WHERE id = $1 AND user_id = $2
-	`, id, userID).Scan(&nodesJSON, &connectionsJSON, &version)
+	`, id, userID).Scan(&name, &nodesJSON, &connectionsJSON, &settingsJSON, &version)
 
 	if err == sql.ErrNoRows {
 		respondError(w, http.StatusNotFound, "Workflow not found")
@@ -943,17 +1348,21 @@ func executeWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse input
-	var input map[string]interface{}
-	json.NewDecoder(r.Body).Decode(&input)
+	wf := buildEngineWorkflow(id, name, nodesJSON, connectionsJSON, settingsJSON)
+	wf.Version = version
+	runWorkflowExecution(w, wf, userID, input, triggerType, triggerNodeID)
+}
 
+// runWorkflowExecution records an execution of a version of a workflow, runs
+// it in the background and responds with its ID
+func runWorkflowExecution(w http.ResponseWriter, wf *engine.WorkflowDefinition, userID string, input map[string]interface{}, triggerType, triggerNodeID string) {
 	// Create execution record
 	executionID := uuid.New().String()
 	inputJSON, _ := json.Marshal(input)
-	_, err = db.Exec(`
-		INSERT INTO execution_service.executions (id, workflow_id, workflow_version, user_id, trigger_type, status, input_data, created_at, started_at)
-		VALUES ($1, $2, $3, $4, 'manual', 'running', $5, NOW(), NOW())
-	`, executionID, id, version, userID, inputJSON)
+	_, err := db.Exec(`
+		INSERT INTO execution_service.executions (id, workflow_id, workflow_version, user_id, trigger_type, trigger_node_id, status, input_data, created_at, started_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), 'running', $7, NOW(), NOW())
+	`, executionID, wf.ID, wf.Version, userID, triggerType, triggerNodeID, inputJSON)
 
 	if err != nil {
 		log.Printf("Create execution error: %v", err)
@@ -961,13 +1370,86 @@ func executeWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse and execute workflow
+	// Execute asynchronously
+	go func() {
+		result, execErr := eng.Execute(context.Background(), wf, &engine.ExecutionOptions{
+			ExecutionID:   executionID,
+			Mode:          triggerType, // Pinned data is only used by manual runs, not retries
+			TriggerNodeID: triggerNodeID,
+			TriggerData:   input,
+			UserID:        userID,
+		})
+		saveExecutionResult(executionID, result, execErr)
+	}()
+
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"executionId": executionID,
+		"workflowId":  wf.ID,
+		"version":     wf.Version,
+		"status":      "running",
+		"message":     "Workflow execution started",
+	})
+}
+
+// syncActiveWorkflowSchedules schedules and subscribes the triggers of every
+// active workflow when the server starts
+func syncActiveWorkflowSchedules() {
+	rows, err := db.Query("SELECT id FROM workflow_service.workflows WHERE status = 'active'")
+	if err != nil {
+		log.Printf("Load active workflows error: %v", err)
+		return
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	ctx := context.Background()
+	for _, id := range ids {
+		wf, err := loadEngineWorkflow(ctx, id)
+		if err != nil {
+			continue
+		}
+		if err := scheduler.SyncWorkflow(ctx, wf); err != nil {
+			log.Printf("Schedule workflow %s error: %v", id, err)
+		}
+		if err := subscriptions.SyncWorkflow(ctx, wf); err != nil {
+			log.Printf("Subscribe workflow %s error: %v", id, err)
+		}
+	}
+}
+
+// loadEngineWorkflow loads a stored workflow for the engine, which starts
+// error workflows and scheduled runs through it
+func loadEngineWorkflow(ctx context.Context, id string) (*engine.WorkflowDefinition, error) {
+	var name, userID string
+	var version int
+	var nodesJSON, connectionsJSON, settingsJSON []byte
+	err := db.QueryRowContext(ctx, `
+		SELECT name, user_id, version, nodes, connections, settings FROM workflow_service.workflows
+		WHERE id = $1
+	`, id).Scan(&name, &userID, &version, &nodesJSON, &connectionsJSON, &settingsJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow %s: %w", id, err)
+	}
+	wf := buildEngineWorkflow(id, name, nodesJSON, connectionsJSON, settingsJSON)
+	wf.UserID, wf.Version = userID, version
+	return wf, nil
+}
+
+// buildEngineWorkflow converts the stored nodes, connections and settings of
+// a workflow into an engine definition
+func buildEngineWorkflow(id, name string, nodesJSON, connectionsJSON, settingsJSON []byte) *engine.WorkflowDefinition {
 	var nodeList []map[string]interface{}
 	var connectionList []map[string]interface{}
+	var settings map[string]interface{}
 	json.Unmarshal(nodesJSON, &nodeList)
 	json.Unmarshal(connectionsJSON, &connectionList)
+	json.Unmarshal(settingsJSON, &settings)
 
-	// Build engine workflow
 	engineNodes := make([]engine.NodeDefinition, len(nodeList))
 	for i, n := range nodeList {
 		config := map[string]interface{}{}
@@ -975,9 +1457,12 @@ func executeWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 			config = c
 		}
 		engineNodes[i] = engine.NodeDefinition{
-			ID:     getString(n, "id"),
-			Type:   getString(n, "type"),
-			Config: config,
+			ID:       getString(n, "id"),
+			Type:     getString(n, "type"),
+			Name:     getString(n, "name"),
+			Config:     config,
+			Settings:   getNodeSettings(n),
+			PinnedData: getPinnedData(n),
 		}
 	}
 
@@ -985,42 +1470,242 @@ func executeWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	for i, c := range connectionList {
 		engineConnections[i] = engine.Connection{
 			SourceNodeID: getString(c, "source"),
+			SourcePort:   getString(c, "sourcePort"),
 			TargetNodeID: getString(c, "target"),
+			TargetPort:   getString(c, "targetPort"),
 		}
 	}
 
-	wf := &engine.WorkflowDefinition{
+	maxConcurrency, _ := settings["maxConcurrency"].(float64)
+
+	return &engine.WorkflowDefinition{
 		ID:          id,
+		Name:        name,
 		Nodes:       engineNodes,
 		Connections: engineConnections,
+		Settings: engine.WorkflowSettings{
+			ErrorHandling:   getString(settings, "errorHandling"),
+			ErrorWorkflowID: getString(settings, "errorWorkflowId"),
+			MaxConcurrency:  int(maxConcurrency),
+		},
 	}
+}
+
+// recoverExecutions re-arms the timers of parked executions and resumes the
+// ones a replica of the API was running when it stopped. Each is resumed by
+// the replica that claims it; the others leave it alone.
+func recoverExecutions() {
+	staleAfter, err := time.ParseDuration(getEnvOrDefault("EXECUTION_STALE_AFTER", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid EXECUTION_STALE_AFTER: %v", err)
+	}
+	interrupted, err := eng.RecoverExecutions(context.Background(), staleAfter)
+	if err != nil {
+		log.Printf("Failed to recover executions: %v", err)
+		return
+	}
+	for _, id := range interrupted {
+		go func(id string) {
+			result, err := eng.Resume(context.Background(), id)
+			if errors.Is(err, engine.ErrExecutionClaimed) || (result == nil && err == nil) {
+				return
+			}
+			saveExecutionResult(id, result, err)
+		}(id)
+	}
+	if len(interrupted) > 0 {
+		log.Printf("Resuming %d interrupted executions", len(interrupted))
+	}
+}
+
+// watchEngineExecutions mirrors status changes the handlers do not see into
+// the executions table: runs queued behind a concurrency limit, runs skipped
+// by a schedule's overlap policy, and scheduled, error workflow and
+// sub-workflow runs, which the engine starts on its own
+func watchEngineExecutions() {
+	events := eng.Events()
+	events.On(engine.EventTypeExecutionQueued, func(event engine.ExecutionEvent) {
+		recordExecutionStatus(event, "queued", "running")
+	})
+	events.On(engine.EventTypeExecutionStarted, func(event engine.ExecutionEvent) {
+		recordExecutionStatus(event, "running", "queued")
+	})
+	events.On(engine.EventTypeExecutionSkipped, func(event engine.ExecutionEvent) {
+		recordExecutionStatus(event, "skipped")
+	})
+	finished := func(event engine.ExecutionEvent) {
+		// Manual runs record their own result
+		if mode, _ := event.Data["mode"].(string); mode != "manual" {
+			status, _ := event.Data["status"].(string)
+			recordExecutionStatus(event, status, "queued", "running")
+		}
+	}
+	events.On(engine.EventTypeExecutionCompleted, finished)
+	events.On(engine.EventTypeExecutionFailed, finished)
+	events.On(engine.EventTypeExecutionCompleted, recordExecution)
+	events.On(engine.EventTypeExecutionFailed, recordExecution)
+	events.On(engine.EventTypeTriggerFailed, func(event engine.ExecutionEvent) {
+		log.Printf("Trigger %s of workflow %s failed: %v", event.NodeID, event.WorkflowID, event.Data["error"])
+	})
+
+	events.On(engine.EventTypeExecutionStarted, publishExecutionEvent)
+	events.On(engine.EventTypeExecutionCompleted, publishExecutionEvent)
+	events.On(engine.EventTypeExecutionFailed, publishExecutionEvent)
+}
+
+// recordExecution records the inputs and outputs of the nodes of a finished
+// execution of a stored workflow, so that it can be re-run from any node
+func recordExecution(event engine.ExecutionEvent) {
+	state, err := eng.GetExecution(event.ExecutionID)
+	if err != nil {
+		return
+	}
+	// Direct executions run no stored workflow
+	wf, err := loadEngineWorkflow(context.Background(), event.WorkflowID)
+	if err != nil {
+		return
+	}
+
+	mode, _ := event.Data["mode"].(string)
+	if _, err := recorder.Record(context.Background(), wf, state, mode); err != nil {
+		log.Printf("Record execution %s error: %v", event.ExecutionID, err)
+	}
+}
+
+// publishExecutionEvent publishes an execution event of the engine as a
+// platform event of the workflow's owner. Executions started by event
+// triggers publish none, so that workflows cannot trigger each other in a
+// loop.
+func publishExecutionEvent(event engine.ExecutionEvent) {
+	mode, _ := event.Data["mode"].(string)
+	if mode == "event" {
+		return
+	}
+
+	var userID string
+	if err := db.QueryRow("SELECT user_id FROM workflow_service.workflows WHERE id = $1", event.WorkflowID).Scan(&userID); err != nil {
+		return
+	}
+
+	var eventType platformevents.EventType
+	var data interface{}
+	switch event.Type {
+	case engine.EventTypeExecutionStarted:
+		eventType = platformevents.ExecutionStarted
+		data = platformevents.ExecutionStartedData{ExecutionID: event.ExecutionID, WorkflowID: event.WorkflowID, TriggerType: mode}
+	case engine.EventTypeExecutionCompleted:
+		status, _ := event.Data["status"].(string)
+		durationMs, _ := event.Data["durationMs"].(int64)
+		eventType = platformevents.ExecutionCompleted
+		data = platformevents.ExecutionCompletedData{ExecutionID: event.ExecutionID, WorkflowID: event.WorkflowID, Status: status, Duration: durationMs}
+	case engine.EventTypeExecutionFailed:
+		message := event.ErrorMessage()
+		eventType = platformevents.ExecutionFailed
+		data = platformevents.ExecutionFailedData{ExecutionID: event.ExecutionID, WorkflowID: event.WorkflowID, Error: message}
+	default:
+		return
+	}
+
+	platformEvent, err := platformevents.NewEvent(eventType, event.ExecutionID, "execution", data)
+	if err != nil {
+		return
+	}
+	publishPlatformEvent(context.Background(), platformEvent.WithUser(userID).WithSource("api"))
+}
+
+// publishPlatformEvent publishes an event to the event triggers of every
+// replica: through Kafka when there are brokers, else on this replica's bus
+func publishPlatformEvent(ctx context.Context, event *platformevents.Event) {
+	var err error
+	if eventPublisher != nil {
+		err = eventPublisher.Publish(ctx, event)
+	} else {
+		err = platformEvents.Publish(ctx, event)
+	}
+	if err != nil {
+		log.Printf("Publish %s event error: %v", event.Type, err)
+	}
+}
+
+// startEventForwarding publishes platform events to Kafka, and publishes
+// those consumed back from it on the bus of this replica. Replicas share a
+// consumer group, so each event reaches the event triggers of one replica
+// only; every replica runs the event triggers of every active workflow.
+func startEventForwarding(brokers []string) {
+	publisher, err := kafka.NewEventPublisher(&kafka.Config{Brokers: brokers})
+	if err != nil {
+		log.Fatalf("Failed to create event publisher: %v", err)
+	}
+	consumer, err := kafka.NewConsumer(&kafka.ConsumerConfig{
+		Brokers:    brokers,
+		GroupID:    getEnvOrDefault("KAFKA_EVENTS_GROUP", "linkflow-platform-events"),
+		Topics:     kafka.EventTopics,
+		DeadLetter: os.Getenv("KAFKA_EVENTS_DEAD_LETTER"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create event consumer: %v", err)
+	}
+	eventPublisher = publisher
 
-	// Execute asynchronously
 	go func() {
-		result, execErr := eng.Execute(context.Background(), wf, &engine.ExecutionOptions{TriggerData: input})
-
-		outputJSON, _ := json.Marshal(result)
-		if execErr != nil {
-			db.Exec(`
-				UPDATE execution_service.executions 
-				SET status = 'failed', error_message = $1, completed_at = NOW()
-				WHERE id = $2
-			`, execErr.Error(), executionID)
-		} else {
-			db.Exec(`
-				UPDATE execution_service.executions 
-				SET status = 'completed', output_data = $1, completed_at = NOW()
-				WHERE id = $2
-			`, outputJSON, executionID)
+		if err := kafka.ForwardEvents(context.Background(), consumer, platformEvents); err != nil {
+			log.Printf("Forward platform events error: %v", err)
 		}
 	}()
+}
 
-	respondJSON(w, http.StatusAccepted, map[string]interface{}{
-		"executionId": executionID,
-		"workflowId":  id,
-		"status":      "running",
-		"message":     "Workflow execution started",
-	})
+// recordExecutionStatus sets the status of an execution, creating its row
+// if the engine started it. Events are delivered concurrently, so an
+// existing row only moves on from one of the given statuses.
+func recordExecutionStatus(event engine.ExecutionEvent, status string, from ...string) {
+	mode, _ := event.Data["mode"].(string)
+	message := event.ErrorMessage()
+	parentID, _ := event.Data["parentExecutionId"].(string)
+	triggerNodeID, _ := event.Data["triggerNodeId"].(string)
+	version, _ := event.Data["workflowVersion"].(int)
+	finished := status != "queued" && status != "running" && status != "waiting"
+
+	_, err := db.Exec(`
+		INSERT INTO execution_service.executions (id, workflow_id, workflow_version, user_id, trigger_type, trigger_node_id, status, error_message, parent_execution_id, created_at, started_at, completed_at)
+		SELECT $1, id, COALESCE(NULLIF($9, 0), version), user_id, $3, NULLIF($10, ''), $4, NULLIF($5, ''), NULLIF($8, '')::uuid, NOW(), NOW(), CASE WHEN $6 THEN NOW() END
+		FROM workflow_service.workflows WHERE id = $2
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status, completed_at = EXCLUDED.completed_at,
+			error_message = COALESCE(EXCLUDED.error_message, execution_service.executions.error_message),
+			parent_execution_id = COALESCE(execution_service.executions.parent_execution_id, EXCLUDED.parent_execution_id)
+		WHERE execution_service.executions.status = ANY(string_to_array($7, ','))
+	`, event.ExecutionID, event.WorkflowID, mode, status, message, finished, strings.Join(from, ","), parentID, version, triggerNodeID)
+	if err != nil {
+		log.Printf("Record execution %s status error: %v", event.ExecutionID, err)
+	}
+}
+
+// saveExecutionResult records the outcome of an engine run
+func saveExecutionResult(executionID string, result *engine.ExecutionState, execErr error) {
+	if execErr != nil {
+		db.Exec(`
+			UPDATE execution_service.executions 
+			SET status = 'failed', error_message = $1, completed_at = NOW()
+			WHERE id = $2
+		`, execErr.Error(), executionID)
+		return
+	}
+
+	outputJSON, _ := json.Marshal(result)
+	if result.Status == "waiting" {
+		db.Exec(`
+			UPDATE execution_service.executions 
+			SET status = 'waiting', output_data = $1
+			WHERE id = $2
+		`, outputJSON, executionID)
+		return
+	}
+
+	db.Exec(`
+		UPDATE execution_service.executions 
+		SET status = 'completed', output_data = $1, completed_at = NOW()
+		WHERE id = $2
+	`, outputJSON, executionID)
 }
 
 func cloneWorkflowHandler(w http.ResponseWriter, r *http.Request) {
@@ -1047,10 +1732,13 @@ func cloneWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	// Create clone
 	cloneID := uuid.New().String()
 	cloneName := name + " (Copy)"
-	_, err = db.Exec(`
-		INSERT INTO workflow_service.workflows (id, user_id, name, description, status, nodes, connections, settings, version, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, 'draft', $5, $6, $7, 1, NOW(), NOW())
-	`, cloneID, userID, cloneName, description, nodesJSON, connectionsJSON, settingsJSON)
+	_, err = saveWorkflow(r.Context(), cloneID, userID, fmt.Sprintf("Cloned from workflow %s", id), func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO workflow_service.workflows (id, user_id, name, description, status, nodes, connections, settings, version, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, 'draft', $5, $6, $7, 1, NOW(), NOW())
+		`, cloneID, userID, cloneName, description, nodesJSON, connectionsJSON, settingsJSON)
+		return err
+	})
 
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to clone workflow")
@@ -1066,14 +1754,317 @@ func cloneWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ============================================================================
-// Execution Handlers
-// ============================================================================
-
-func listExecutionsHandler(w http.ResponseWriter, r *http.Request) {
-	userID := getUserIDFromContext(r)
-
-	rows, err := db.Query(`
+// pinNodeDataHandler pins items on a node, which manual executions output
+// instead of running it. The items are given, or captured from what the node
+// output in an earlier execution.
+func pinNodeDataHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var req struct {
+		Items       []map[string]interface{} `json:"items"`
+		ExecutionID string                   `json:"executionId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	pinned := req.Items
+	if req.ExecutionID != "" {
+		recording := userExecutionRecording(w, r, req.ExecutionID)
+		if recording == nil {
+			return
+		}
+		if recording.WorkflowID != vars["id"] {
+			respondError(w, http.StatusBadRequest, "Execution is not of this workflow")
+			return
+		}
+		items, ok := recording.OutputItems(vars["nodeId"])
+		if !ok {
+			respondError(w, http.StatusConflict, "Node did not complete in the execution")
+			return
+		}
+		pinned = make([]map[string]interface{}, len(items))
+		for i, item := range items {
+			pinned[i] = item.JSON
+		}
+	}
+	if len(pinned) == 0 {
+		respondError(w, http.StatusBadRequest, "Pinned data needs at least one item")
+		return
+	}
+
+	setPinnedData(w, r, pinned)
+}
+
+func unpinNodeDataHandler(w http.ResponseWriter, r *http.Request) {
+	setPinnedData(w, r, nil)
+}
+
+// errNodeNotFound is returned when a workflow has no node of an ID
+var errNodeNotFound = errors.New("node not found")
+
+// setPinnedData stores the items pinned on a node of a workflow with its
+// nodes; nil unpins them
+func setPinnedData(w http.ResponseWriter, r *http.Request, pinned []map[string]interface{}) {
+	vars := mux.Vars(r)
+	id, nodeID := vars["id"], vars["nodeId"]
+	userID := getUserIDFromContext(r)
+
+	// The nodes are locked while they change, so that concurrent pins of
+	// other nodes are not lost, and the change is saved as a version
+	message := fmt.Sprintf("Pinned data of node %s", nodeID)
+	if pinned == nil {
+		message = fmt.Sprintf("Unpinned data of node %s", nodeID)
+	}
+	_, err := saveWorkflow(r.Context(), id, userID, message, func(tx *sql.Tx) error {
+		var nodesJSON []byte
+		err := tx.QueryRowContext(r.Context(), `
+			SELECT nodes FROM workflow_service.workflows WHERE id = $1 AND user_id = $2 FOR UPDATE
+		`, id, userID).Scan(&nodesJSON)
+		if err != nil {
+			return err
+		}
+
+		var nodeList []map[string]interface{}
+		json.Unmarshal(nodesJSON, &nodeList)
+		found := false
+		for _, n := range nodeList {
+			if getString(n, "id") != nodeID {
+				continue
+			}
+			if pinned == nil {
+				delete(n, "pinnedData")
+			} else {
+				n["pinnedData"] = pinned
+			}
+			found = true
+		}
+		if !found {
+			return errNodeNotFound
+		}
+
+		nodesJSON, _ = json.Marshal(nodeList)
+		_, err = tx.ExecContext(r.Context(), `
+			UPDATE workflow_service.workflows SET nodes = $1, version = version + 1, updated_at = NOW()
+			WHERE id = $2 AND user_id = $3
+		`, nodesJSON, id, userID)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Workflow not found")
+		return
+	}
+	if errors.Is(err, errNodeNotFound) {
+		respondError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Pin data of workflow %s node %s error: %v", id, nodeID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to update workflow")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"workflowId": id,
+		"nodeId":     nodeID,
+		"pinnedData": pinned,
+	})
+}
+
+// userWorkflowVersion returns the current version of a workflow of the user,
+// responding with an error if there is none
+func userWorkflowVersion(w http.ResponseWriter, r *http.Request, id string) (int, bool) {
+	var current int
+	err := db.QueryRowContext(r.Context(), `
+		SELECT version FROM workflow_service.workflows WHERE id = $1 AND user_id = $2
+	`, id, getUserIDFromContext(r)).Scan(&current)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Workflow not found")
+		return 0, false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Database error")
+		return 0, false
+	}
+	return current, true
+}
+
+// loadWorkflowVersion loads a version of a workflow. The current version
+// is read from the workflow itself, so that workflows saved before their
+// versions were kept have one too.
+func loadWorkflowVersion(ctx context.Context, id string, number, current int) (*features.WorkflowVersion, error) {
+	version, err := versions.Get(ctx, id, number)
+	if errors.Is(err, features.ErrVersionNotFound) && number == current {
+		return readWorkflowVersion(ctx, db, id)
+	}
+	return version, err
+}
+
+func listWorkflowVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	current, ok := userWorkflowVersion(w, r, id)
+	if !ok {
+		return
+	}
+
+	list, err := versions.List(r.Context(), id)
+	if err != nil {
+		log.Printf("List workflow %s versions error: %v", id, err)
+		respondError(w, http.StatusInternalServerError, "Failed to list versions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"workflowId":     id,
+		"currentVersion": current,
+		"items":          list,
+		"total":          len(list),
+	})
+}
+
+func getWorkflowVersionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	current, ok := userWorkflowVersion(w, r, vars["id"])
+	if !ok {
+		return
+	}
+
+	number, _ := strconv.Atoi(vars["version"])
+	version, err := loadWorkflowVersion(r.Context(), vars["id"], number, current)
+	if errors.Is(err, features.ErrVersionNotFound) {
+		respondError(w, http.StatusNotFound, "Version not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load version")
+		return
+	}
+	respondJSON(w, http.StatusOK, version)
+}
+
+// diffWorkflowVersionsHandler compares two versions of a workflow: from and
+// to, which defaults to the current version
+func diffWorkflowVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	current, ok := userWorkflowVersion(w, r, id)
+	if !ok {
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "from must be a version number")
+		return
+	}
+	to := current
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil {
+			respondError(w, http.StatusBadRequest, "to must be a version number")
+			return
+		}
+	}
+
+	pair := make([]*features.WorkflowVersion, 2)
+	for i, number := range []int{from, to} {
+		pair[i], err = loadWorkflowVersion(r.Context(), id, number, current)
+		if errors.Is(err, features.ErrVersionNotFound) {
+			respondError(w, http.StatusNotFound, fmt.Sprintf("Version %d not found", number))
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to load version")
+			return
+		}
+	}
+	respondJSON(w, http.StatusOK, features.DiffVersions(pair[0], pair[1]))
+}
+
+func restoreWorkflowVersionHandler(w http.ResponseWriter, r *http.Request) {
+	version, ok := restoreWorkflowVersion(w, r)
+	if !ok {
+		return
+	}
+	restoredFrom, _ := strconv.Atoi(mux.Vars(r)["version"])
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"id":           mux.Vars(r)["id"],
+		"version":      version,
+		"restoredFrom": restoredFrom,
+		"message":      "Workflow version restored",
+	})
+}
+
+// activateWorkflowVersionHandler restores a version of a workflow and
+// activates the workflow with it
+func activateWorkflowVersionHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := restoreWorkflowVersion(w, r); ok {
+		activateWorkflowHandler(w, r)
+	}
+}
+
+// restoreWorkflowVersion rolls a workflow back to one of its versions by
+// saving a copy of it as a new version, so that the history is kept as it
+// was. Restoring the current version changes nothing. It responds with an
+// error if the version cannot be restored.
+func restoreWorkflowVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	vars := mux.Vars(r)
+	id, userID := vars["id"], getUserIDFromContext(r)
+	number, _ := strconv.Atoi(vars["version"])
+	var req struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.Message == "" {
+		req.Message = fmt.Sprintf("Restored version %d", number)
+	}
+
+	current, ok := userWorkflowVersion(w, r, id)
+	if !ok {
+		return 0, false
+	}
+	if number == current {
+		return current, true
+	}
+	version, err := versions.Get(r.Context(), id, number)
+	if errors.Is(err, features.ErrVersionNotFound) {
+		respondError(w, http.StatusNotFound, "Version not found")
+		return 0, false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load version")
+		return 0, false
+	}
+
+	nodesJSON, _ := json.Marshal(version.Nodes)
+	connectionsJSON, _ := json.Marshal(version.Connections)
+	settingsJSON, _ := json.Marshal(version.Settings)
+	restored, err := saveWorkflow(r.Context(), id, userID, req.Message, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE workflow_service.workflows
+			SET name = $3, description = $4, nodes = $5, connections = $6, settings = $7, version = version + 1, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+		`, id, userID, version.Name, version.Description, nodesJSON, connectionsJSON, settingsJSON)
+		return err
+	})
+	if err != nil {
+		log.Printf("Restore workflow %s version %d error: %v", id, number, err)
+		respondError(w, http.StatusInternalServerError, "Failed to restore version")
+		return 0, false
+	}
+	if err := resyncWebhookRoutes(r.Context(), id); err != nil {
+		respondResyncError(w, id, err)
+		return 0, false
+	}
+	return restored, true
+}
+
+// ============================================================================
+// Execution Handlers
+// ============================================================================
+
+func listExecutionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+
+	rows, err := db.Query(`
 		SELECT id, workflow_id, trigger_type, status, created_at, started_at, completed_at
 		FROM execution_service.executions
 		WHERE user_id = $1
@@ -1118,16 +2109,17 @@ func getExecutionHandler(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r)
 
 	var workflowID, triggerType, status string
+	var workflowVersion int
 	var inputJSON, outputJSON []byte
-	var errorMessage sql.NullString
+	var errorMessage, parentID sql.NullString
 	var createdAt time.Time
 	var startedAt, completedAt sql.NullTime
 
 	err := db.QueryRow(`
-		SELECT workflow_id, trigger_type, status, input_data, output_data, error_message, created_at, started_at, completed_at
+		SELECT workflow_id, workflow_version, trigger_type, status, input_data, output_data, error_message, parent_execution_id, created_at, started_at, completed_at
 		FROM execution_service.executions
 		WHERE id = $1 AND user_id = $2
-	`, id, userID).Scan(&workflowID, &triggerType, &status, &inputJSON, &outputJSON, &errorMessage, &createdAt, &startedAt, &completedAt)
+	`, id, userID).Scan(&workflowID, &workflowVersion, &triggerType, &status, &inputJSON, &outputJSON, &errorMessage, &parentID, &createdAt, &startedAt, &completedAt)
 
 	if err == sql.ErrNoRows {
 		respondError(w, http.StatusNotFound, "Execution not found")
@@ -1143,13 +2135,14 @@ func getExecutionHandler(w http.ResponseWriter, r *http.Request) {
 	json.Unmarshal(outputJSON, &output)
 
 	exec := map[string]interface{}{
-		"id":          id,
-		"workflowId":  workflowID,
-		"triggerType": triggerType,
-		"status":      status,
-		"input":       input,
-		"output":      output,
-		"createdAt":   createdAt.Format(time.RFC3339),
+		"id":              id,
+		"workflowId":      workflowID,
+		"workflowVersion": workflowVersion,
+		"triggerType":     triggerType,
+		"status":          status,
+		"input":           input,
+		"output":          output,
+		"createdAt":       createdAt.Format(time.RFC3339),
 	}
 	if startedAt.Valid {
 		exec["startedAt"] = startedAt.Time.Format(time.RFC3339)
@@ -1160,10 +2153,45 @@ func getExecutionHandler(w http.ResponseWriter, r *http.Request) {
 	if errorMessage.Valid {
 		exec["error"] = errorMessage.String
 	}
+	if parentID.Valid {
+		exec["parentExecutionId"] = parentID.String
+	}
+	exec["childExecutions"] = childExecutions(id)
 
 	respondJSON(w, http.StatusOK, exec)
 }
 
+// childExecutions lists the sub-workflow executions that nodes of an
+// execution started
+func childExecutions(parentID string) []map[string]interface{} {
+	children := []map[string]interface{}{}
+	rows, err := db.Query(`
+		SELECT id, workflow_id, status, created_at
+		FROM execution_service.executions
+		WHERE parent_execution_id = $1
+		ORDER BY created_at
+	`, parentID)
+	if err != nil {
+		return children
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, workflowID, status string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &workflowID, &status, &createdAt); err != nil {
+			continue
+		}
+		children = append(children, map[string]interface{}{
+			"id":         id,
+			"workflowId": workflowID,
+			"status":     status,
+			"createdAt":  createdAt.Format(time.RFC3339),
+		})
+	}
+	return children
+}
+
 func cancelExecutionHandler(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	userID := getUserIDFromContext(r)
@@ -1181,13 +2209,136 @@ func cancelExecutionHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func getExecutionRecordingHandler(w http.ResponseWriter, r *http.Request) {
+	recording := userExecutionRecording(w, r, mux.Vars(r)["id"])
+	if recording == nil {
+		return
+	}
+	respondJSON(w, http.StatusOK, recording)
+}
+
+// retryFailedNodeHandler re-runs a failed execution from the node that
+// failed, reusing the recorded outputs of the nodes before it
+func retryFailedNodeHandler(w http.ResponseWriter, r *http.Request) {
+	recording := userExecutionRecording(w, r, mux.Vars(r)["id"])
+	if recording == nil {
+		return
+	}
+	failedNode := recording.FailedNode()
+	if failedNode == "" {
+		respondError(w, http.StatusConflict, "Execution has no failed node")
+		return
+	}
+	replayExecution(w, r, recording, &features.ReplayOptions{StartFromNode: failedNode}, "retry")
+}
+
+// rerunExecutionHandler re-runs an execution from a node, reusing the
+// recorded outputs of the nodes upstream of it and of the nodes to skip
+func rerunExecutionHandler(w http.ResponseWriter, r *http.Request) {
+	recording := userExecutionRecording(w, r, mux.Vars(r)["id"])
+	if recording == nil {
+		return
+	}
+	var options features.ReplayOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil && err != io.EOF {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	replayExecution(w, r, recording, &options, "rerun")
+}
+
+// userExecutionRecording loads the recording of an execution of the user,
+// responding with an error when there is none
+func userExecutionRecording(w http.ResponseWriter, r *http.Request, id string) *features.ExecutionRecording {
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM execution_service.executions WHERE id = $1 AND user_id = $2)
+	`, id, getUserIDFromContext(r)).Scan(&exists)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Database error")
+		return nil
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, "Execution not found")
+		return nil
+	}
+
+	recording, err := recorder.Load(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Execution has no recording")
+		return nil
+	}
+	return recording
+}
+
+// replayExecution re-runs a recorded execution as a new one and responds
+// with how it differed from the original
+func replayExecution(w http.ResponseWriter, r *http.Request, recording *features.ExecutionRecording, options *features.ReplayOptions, triggerType string) {
+	userID := getUserIDFromContext(r)
+
+	wf, err := loadEngineWorkflow(r.Context(), recording.WorkflowID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Workflow not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if options.StartFromNode != "" && !workflowHasNode(wf, options.StartFromNode) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Node %s not found in workflow", options.StartFromNode))
+		return
+	}
+
+	input := recording.TriggerData
+	if options.StartFromNode == "" && options.OverrideInput != nil {
+		input = options.OverrideInput
+	}
+	executionID := uuid.New().String()
+	inputJSON, _ := json.Marshal(input)
+	_, err = db.Exec(`
+		INSERT INTO execution_service.executions (id, workflow_id, workflow_version, user_id, trigger_type, trigger_node_id, status, input_data, created_at, started_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), 'running', $7, NOW(), NOW())
+	`, executionID, wf.ID, wf.Version, userID, triggerType, recording.TriggerNodeID, inputJSON)
+	if err != nil {
+		log.Printf("Create execution error: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to create execution")
+		return
+	}
+
+	result, execErr := replayer.Replay(context.Background(), wf, recording.ExecutionID, options, &engine.ExecutionOptions{
+		ExecutionID: executionID,
+		Mode:        triggerType,
+		UserID:      userID,
+	})
+	state, _ := eng.GetExecution(executionID)
+	saveExecutionResult(executionID, state, execErr)
+	if result == nil {
+		respondError(w, http.StatusInternalServerError, execErr.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// workflowHasNode reports whether a workflow has a node
+func workflowHasNode(wf *engine.WorkflowDefinition, nodeID string) bool {
+	for _, node := range wf.Nodes {
+		if node.ID == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
 func directExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r)
 
 	var req struct {
-		Nodes       []map[string]interface{} `json:"nodes"`
-		Connections []map[string]interface{} `json:"connections"`
-		Input       map[string]interface{}   `json:"input"`
+		Nodes         []map[string]interface{} `json:"nodes"`
+		Connections   []map[string]interface{} `json:"connections"`
+		Input         map[string]interface{}   `json:"input"`
+		TriggerNodeID string                   `json:"triggerNodeId"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
@@ -1204,9 +2355,10 @@ func directExecuteHandler(w http.ResponseWriter, r *http.Request) {
 			config = c
 		}
 		engineNodes[i] = engine.NodeDefinition{
-			ID:     getString(n, "id"),
-			Type:   getString(n, "type"),
-			Config: config,
+			ID:       getString(n, "id"),
+			Type:     getString(n, "type"),
+			Config:   config,
+			Settings: getNodeSettings(n),
 		}
 	}
 
@@ -1214,7 +2366,9 @@ func directExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	for i, c := range req.Connections {
 		engineConnections[i] = engine.Connection{
 			SourceNodeID: getString(c, "source"),
+			SourcePort:   getString(c, "sourcePort"),
 			TargetNodeID: getString(c, "target"),
+			TargetPort:   getString(c, "targetPort"),
 		}
 	}
 
@@ -1225,7 +2379,10 @@ func directExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Execute synchronously for direct execution
-	result, err := eng.Execute(context.Background(), wf, &engine.ExecutionOptions{TriggerData: req.Input})
+	result, err := eng.Execute(context.Background(), wf, &engine.ExecutionOptions{
+		TriggerNodeID: req.TriggerNodeID,
+		TriggerData:   req.Input,
+	})
 
 	if err != nil {
 		respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -1244,6 +2401,246 @@ func directExecuteHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ============================================================================
+// Debug Handlers
+// ============================================================================
+
+// startDebugHandler starts a debug run of a workflow, pausing at the given
+// breakpoints. The events of the session stream over its WebSocket channel.
+func startDebugHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	userID := getUserIDFromContext(r)
+
+	var req struct {
+		Input         map[string]interface{} `json:"input"`
+		TriggerNodeID string                 `json:"triggerNodeId"`
+		Breakpoints   []json.RawMessage      `json:"breakpoints"`
+		Watches       []string               `json:"watches"`
+		PauseAtStart  bool                   `json:"pauseAtStart"` // Pause at the trigger
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	breakpoints := make([]*features.Breakpoint, len(req.Breakpoints))
+	for i, raw := range req.Breakpoints {
+		bp, err := decodeBreakpoint(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		breakpoints[i] = bp
+	}
+
+	wf, err := loadEngineWorkflow(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && wf.UserID != userID) {
+		respondError(w, http.StatusNotFound, "Workflow not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	session, err := debugSessions.CreateSession(id, userID)
+	if err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	for _, bp := range breakpoints {
+		session.AddBreakpoint(bp)
+	}
+	for _, expr := range req.Watches {
+		session.AddWatch(expr)
+	}
+	if req.PauseAtStart {
+		session.Pause()
+	}
+
+	// Debug runs are recorded as manual executions
+	executionID := uuid.New().String()
+	go func() {
+		result, execErr := features.NewDebugExecutor(session, eng).Execute(context.Background(), wf, &engine.ExecutionOptions{
+			ExecutionID:   executionID,
+			Mode:          "manual",
+			TriggerNodeID: req.TriggerNodeID,
+			TriggerData:   req.Input,
+			UserID:        userID,
+		})
+		saveExecutionResult(executionID, result, execErr)
+	}()
+
+	response := session.Snapshot()
+	response["executionId"] = executionID
+	response["channel"] = gatewayhandlers.ChannelDebug + "." + session.ID
+	respondJSON(w, http.StatusAccepted, response)
+}
+
+// decodeBreakpoint decodes a breakpoint, which is enabled unless it says
+// otherwise
+func decodeBreakpoint(raw []byte) (*features.Breakpoint, error) {
+	bp := &features.Breakpoint{Enabled: true}
+	if err := json.Unmarshal(raw, bp); err != nil {
+		return nil, fmt.Errorf("invalid breakpoint: %w", err)
+	}
+	if bp.NodeID == "" {
+		return nil, fmt.Errorf("breakpoint nodeId is required")
+	}
+	return bp, nil
+}
+
+// userDebugSession returns the debug session of the request if it belongs to
+// the user, else responds that it was not found
+func userDebugSession(w http.ResponseWriter, r *http.Request) *features.DebugSession {
+	session, ok := debugSessions.GetSession(mux.Vars(r)["sessionId"])
+	if !ok || session.UserID != getUserIDFromContext(r) {
+		respondError(w, http.StatusNotFound, "Debug session not found")
+		return nil
+	}
+	return session
+}
+
+func listDebugSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	sessions := debugSessions.ListSessions(getUserIDFromContext(r))
+	result := make([]map[string]interface{}, len(sessions))
+	for i, session := range sessions {
+		result[i] = session.Snapshot()
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"sessions": result,
+		"total":    len(result),
+	})
+}
+
+func getDebugSessionHandler(w http.ResponseWriter, r *http.Request) {
+	session := userDebugSession(w, r)
+	if session == nil {
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("historyLimit"))
+	if limit <= 0 {
+		limit = 100
+	}
+
+	response := session.Snapshot()
+	response["history"] = session.GetHistory(limit)
+	respondJSON(w, http.StatusOK, response)
+}
+
+func endDebugSessionHandler(w http.ResponseWriter, r *http.Request) {
+	session := userDebugSession(w, r)
+	if session == nil {
+		return
+	}
+	debugSessions.EndSession(session.ID)
+	respondJSON(w, http.StatusOK, session.Snapshot())
+}
+
+func setBreakpointHandler(w http.ResponseWriter, r *http.Request) {
+	session := userDebugSession(w, r)
+	if session == nil {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	bp, err := decodeBreakpoint(body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, session.AddBreakpoint(bp))
+}
+
+func removeBreakpointHandler(w http.ResponseWriter, r *http.Request) {
+	session := userDebugSession(w, r)
+	if session == nil {
+		return
+	}
+	session.RemoveBreakpoint(mux.Vars(r)["nodeId"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func addWatchHandler(w http.ResponseWriter, r *http.Request) {
+	session := userDebugSession(w, r)
+	if session == nil {
+		return
+	}
+	var req struct {
+		Expression string `json:"expression"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Expression == "" {
+		respondError(w, http.StatusBadRequest, "expression is required")
+		return
+	}
+	respondJSON(w, http.StatusOK, session.AddWatch(req.Expression))
+}
+
+func removeWatchHandler(w http.ResponseWriter, r *http.Request) {
+	session := userDebugSession(w, r)
+	if session == nil {
+		return
+	}
+	session.RemoveWatch(mux.Vars(r)["watchId"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// modifyDebugInputHandler replaces the input of the node a session is
+// paused at with a modify breakpoint
+func modifyDebugInputHandler(w http.ResponseWriter, r *http.Request) {
+	session := userDebugSession(w, r)
+	if session == nil {
+		return
+	}
+	var req struct {
+		Input map[string]interface{}   `json:"input"`
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := session.ModifyInput(req.Input, req.Items); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, session.Snapshot())
+}
+
+func debugControlHandler(w http.ResponseWriter, r *http.Request) {
+	session := userDebugSession(w, r)
+	if session == nil {
+		return
+	}
+
+	switch mux.Vars(r)["action"] {
+	case "resume":
+		session.Resume()
+	case "pause":
+		session.Pause()
+	case "step-into":
+		session.StepInto()
+	case "step-over":
+		session.StepOver()
+	case "step-out":
+		session.StepOut()
+	case "run-to":
+		var req struct {
+			NodeID string `json:"nodeId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.NodeID == "" {
+			respondError(w, http.StatusBadRequest, "nodeId is required")
+			return
+		}
+		session.RunToNode(req.NodeID)
+	case "stop":
+		session.Stop()
+	}
+	respondJSON(w, http.StatusOK, session.Snapshot())
+}
+
 // ============================================================================
 // Node Handlers
 // ============================================================================
@@ -1687,15 +3084,20 @@ func deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]interface{}{"message": "Webhook deleted"})
 }
 
+// triggerWebhookHandler starts an execution of the webhook's workflow. In a
+// synchronous response mode the call is held open until the workflow replies
+// through a respond to webhook node or finishes, up to the trigger's timeout.
 func triggerWebhookHandler(w http.ResponseWriter, r *http.Request) {
 	endpointID := mux.Vars(r)["endpointId"]
 
 	// Find webhook
-	var webhookID, workflowID string
+	var webhookID, workflowID, userID string
 	err := db.QueryRow(`
-		SELECT id, workflow_id FROM webhook_service.webhooks
-		WHERE endpoint_id = $1 AND is_active = true
-	`, endpointID).Scan(&webhookID, &workflowID)
+		SELECT wh.id, wh.workflow_id, wf.user_id
+		FROM webhook_service.webhooks wh
+		JOIN workflow_service.workflows wf ON wf.id = wh.workflow_id
+		WHERE wh.endpoint_id = $1 AND wh.is_active = true
+	`, endpointID).Scan(&webhookID, &workflowID, &userID)
 
 	if err == sql.ErrNoRows {
 		respondError(w, http.StatusNotFound, "Webhook not found")
@@ -1706,15 +3108,260 @@ func triggerWebhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log the webhook call
-	var body interface{}
+	data, err := nodes.ParseWebhookRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read body")
+		return
+	}
+	data["webhookId"] = webhookID
+
+	wf, err := loadEngineWorkflow(r.Context(), workflowID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load workflow")
+		return
+	}
+
+	// The workflow's webhook trigger decides how the call is replied to
+	var triggerNodeID string
+	for _, node := range wf.Nodes {
+		if node.Type == "webhook_trigger" {
+			triggerNodeID = node.ID
+			break
+		}
+	}
+
+	executeWebhookCall(w, r, webhookID, wf, triggerNodeID, userID, data)
+}
+
+// webhookRouteHandler serves the webhook triggers of active workflows at
+// the paths they are routed on
+func webhookRouteHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, err := routeRegistry.Resolve(r.Context(), r.Method, mux.Vars(r)["path"])
+	switch {
+	case errors.Is(err, webhookservice.ErrRouteNotFound):
+		respondError(w, http.StatusNotFound, "Webhook not found")
+		return
+	case errors.Is(err, webhookservice.ErrMethodNotAllowed):
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	case err != nil:
+		log.Printf("Resolve webhook route error: %v", err)
+		respondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read body")
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = routeRegistry.Authenticate(r.Context(), webhook, r.Header, body)
+	switch {
+	case errors.Is(err, webhookservice.ErrUnauthorized):
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	case errors.Is(err, webhookservice.ErrReplayed):
+		respondError(w, http.StatusConflict, "Delivery already received")
+		return
+	case err != nil:
+		log.Printf("Webhook %s delivery error: %v", webhook.ID(), err)
+		respondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	var status string
+	err = db.QueryRowContext(r.Context(), `
+		SELECT status FROM workflow_service.workflows WHERE id = $1 AND status = 'active'
+	`, webhook.WorkflowID()).Scan(&status)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	data, err := nodes.ParseWebhookRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read body")
+		return
+	}
+	data["webhookId"] = webhook.ID().String()
+
+	wf, err := loadEngineWorkflow(r.Context(), webhook.WorkflowID())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load workflow")
+		return
+	}
+	executeWebhookCall(w, r, webhook.ID().String(), wf, webhook.TriggerNodeID(), webhook.UserID(), data)
+}
+
+// executeWebhookCall runs a workflow for a webhook call and replies to the
+// call as its webhook trigger node is configured to
+func executeWebhookCall(w http.ResponseWriter, r *http.Request, webhookID string, wf *engine.WorkflowDefinition, triggerNodeID, userID string, data map[string]interface{}) {
+	reply := nodes.WebhookReplyFromConfig(nil)
+	for _, node := range wf.Nodes {
+		if node.ID == triggerNodeID {
+			reply = nodes.WebhookReplyFromConfig(node.Config)
+			break
+		}
+	}
+
+	executionID := uuid.New().String()
+	inputJSON, _ := json.Marshal(data)
+	_, err := db.Exec(`
+		INSERT INTO execution_service.executions (id, workflow_id, workflow_version, user_id, trigger_type, trigger_node_id, status, input_data, created_at, started_at)
+		VALUES ($1, $2, $3, $4, 'webhook', NULLIF($5, ''), 'running', $6, NOW(), NOW())
+	`, executionID, wf.ID, wf.Version, userID, triggerNodeID, inputJSON)
+	if err != nil {
+		log.Printf("Create execution error: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to create execution")
+		return
+	}
+
+	replies := make(chan *runtime.WebhookResponse, 1)
+	finished := make(chan *runtime.WebhookResponse, 1)
+	go func() {
+		result, execErr := eng.Execute(context.Background(), wf, &engine.ExecutionOptions{
+			ExecutionID:   executionID,
+			Mode:          "webhook",
+			TriggerNodeID: triggerNodeID,
+			TriggerData:   data,
+			UserID:        userID,
+			Respond: func(resp *runtime.WebhookResponse) {
+				replies <- resp
+			},
+		})
+		saveExecutionResult(executionID, result, execErr)
+
+		// An execution parked at a wait cannot hold the call open
+		switch {
+		case result != nil && result.Status == "waiting":
+			finished <- reply.Received()
+		case result != nil && result.Result != nil:
+			finished <- reply.Finished(result.Result.LastOutput(), execErr)
+		default:
+			finished <- reply.Finished(nil, execErr)
+		}
+	}()
+
+	if !reply.Synchronous() {
+		nodes.WriteWebhookResponse(r.Context(), w, binaryStore, reply.Received())
+		return
+	}
+
+	reply.Hold(w)
+	timer := time.NewTimer(reply.Timeout)
+	defer timer.Stop()
+
+	var resp *runtime.WebhookResponse
+	select {
+	case resp = <-replies:
+	case resp = <-finished:
+		// A node may have replied just before the execution finished
+		select {
+		case nodeResp := <-replies:
+			resp = nodeResp
+		default:
+		}
+	case <-timer.C:
+		resp = reply.TimedOut(executionID)
+	case <-r.Context().Done():
+		return
+	}
+
+	if err := nodes.WriteWebhookResponse(r.Context(), w, binaryStore, resp); err != nil {
+		log.Printf("Webhook %s response error: %v", webhookID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to send response")
+	}
+}
+
+// webhookRouteSpecs returns the routes the webhook trigger nodes of a
+// workflow are served at; nodes without a path get one made of the
+// workflow and node IDs
+func webhookRouteSpecs(wf *engine.WorkflowDefinition) []webhookservice.RouteSpec {
+	var specs []webhookservice.RouteSpec
+	for _, node := range wf.Nodes {
+		if node.Type != "webhook_trigger" {
+			continue
+		}
+		path, _ := node.Config["path"].(string)
+		if path == "" {
+			path = wf.ID + "/" + node.ID
+		}
+		method, _ := node.Config["httpMethod"].(string)
+		authType, authConfig := nodes.WebhookAuthFromConfig(node.Config)
+		specs = append(specs, webhookservice.RouteSpec{
+			TriggerNodeID: node.ID,
+			Path:          path,
+			Method:        method,
+			AuthType:      authType,
+			AuthConfig:    authConfig,
+		})
+	}
+	return specs
+}
+
+// resyncWebhookRoutes brings the webhook routes of a workflow in line with
+// its saved nodes, if it is active, so that edited trigger paths, methods
+// and authentication take effect without reactivating it
+func resyncWebhookRoutes(ctx context.Context, workflowID string) error {
+	var status, userID string
+	err := db.QueryRowContext(ctx, "SELECT status, user_id FROM workflow_service.workflows WHERE id = $1", workflowID).Scan(&status, &userID)
+	if err != nil {
+		return fmt.Errorf("failed to load workflow %s: %w", workflowID, err)
+	}
+	if status != "active" {
+		return nil
+	}
+	wf, err := loadEngineWorkflow(ctx, workflowID)
+	if err != nil {
+		return err
+	}
+	return routeRegistry.SyncWorkflow(ctx, userID, workflowID, webhookRouteSpecs(wf))
+}
+
+// respondResyncError responds to a save whose webhook routes could not be
+// brought in line with the saved workflow
+func respondResyncError(w http.ResponseWriter, workflowID string, err error) {
+	log.Printf("Route workflow %s webhooks error: %v", workflowID, err)
+	if errors.Is(err, webhookrepository.ErrPathTaken) {
+		respondError(w, http.StatusConflict, "Workflow saved, but a webhook path of the workflow is served by another workflow")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, "Workflow saved, but its webhook routes could not be updated")
+}
+
+// unregisterWebhookRoutes stops serving the webhook triggers of a workflow
+func unregisterWebhookRoutes(ctx context.Context, workflowID string) {
+	nodes.GetWebhookHandler().StopWorkflow(workflowID)
+	if err := routeRegistry.UnregisterWorkflow(ctx, workflowID); err != nil {
+		log.Printf("Unregister workflow %s webhooks error: %v", workflowID, err)
+	}
+}
+
+func resumeWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	executionID := vars["executionId"]
+
+	var body map[string]interface{}
 	json.NewDecoder(r.Body).Decode(&body)
 
+	// The token in the URL authorizes the call, so no user auth is required
+	result, err := eng.ResumeWebhook(context.Background(), executionID, vars["token"], body)
+	if result == nil && err != nil {
+		respondError(w, http.StatusNotFound, "Execution not found or not waiting")
+		return
+	}
+	saveExecutionResult(executionID, result, err)
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"webhookId":  webhookID,
-		"workflowId": workflowID,
-		"received":   body,
-		"message":    "Webhook triggered",
+		"executionId": executionID,
+		"status":      result.Status,
+		"message":     "Execution resumed",
 	})
 }
 
@@ -1918,9 +3565,72 @@ func updateWorkspaceHandler(w http.ResponseWriter, r *http.Request) {
 		db.Exec("UPDATE user_service.organizations SET name = $1, updated_at = NOW() WHERE id = $2", name, id)
 	}
 
+	if raw, ok := req["egress"]; ok {
+		var policy egress.Policy
+		policyJSON, _ := json.Marshal(raw)
+		if err := json.Unmarshal(policyJSON, &policy); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid egress policy")
+			return
+		}
+		if err := egressGuard.ValidatePolicy(&policy); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		policyJSON, _ = json.Marshal(policy)
+		_, err := db.Exec(`
+			UPDATE user_service.organizations
+			SET settings = jsonb_set(COALESCE(settings, '{}'::jsonb), '{egress}', $1::jsonb), updated_at = NOW()
+			WHERE id = $2
+		`, string(policyJSON), id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to update egress policy")
+			return
+		}
+		egressGuard.Invalidate(id)
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{"id": id, "message": "Workspace updated"})
 }
 
+// loadEgressPolicy loads the egress policy stored in a workspace's settings
+func loadEgressPolicy(ctx context.Context, workspaceID string) (*egress.Policy, error) {
+	var policyJSON []byte
+	err := db.QueryRowContext(ctx, `
+		SELECT settings->'egress' FROM user_service.organizations WHERE id = $1
+	`, workspaceID).Scan(&policyJSON)
+	if err == sql.ErrNoRows || policyJSON == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var policy egress.Policy
+	if err := json.Unmarshal(policyJSON, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// auditEgressViolation records a request the egress policy blocked
+func auditEgressViolation(ctx context.Context, violation egress.Violation) {
+	log.Printf("Egress blocked: %s to %s (%s): %s", violation.Source, violation.Host, violation.IP, violation.Reason)
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"workspaceId": violation.WorkspaceID,
+		"source":      violation.Source,
+		"ip":          violation.IP,
+		"reason":      violation.Reason,
+	})
+	_, err := db.Exec(`
+		INSERT INTO audit_logs (action, resource_type, resource_id, metadata, created_at)
+		VALUES ('egress_blocked', 'egress', $1, $2, $3)
+	`, violation.Host, metadata, violation.Timestamp)
+	if err != nil {
+		log.Printf("Record egress violation error: %v", err)
+	}
+}
+
 func deleteWorkspaceHandler(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	db.Exec("DELETE FROM user_service.organizations WHERE id = $1", id)
@@ -2037,3 +3747,38 @@ func getString(m map[string]interface{}, key string) string {
 	}
 	return ""
 }
+
+// getNodeSettings reads the retry, timeout and on-error settings of a node;
+// durations are given in milliseconds
+// getPinnedData reads the items pinned on a node, nil when there are none
+func getPinnedData(n map[string]interface{}) []map[string]interface{} {
+	list, _ := n["pinnedData"].([]interface{})
+	var pinned []map[string]interface{}
+	for _, entry := range list {
+		if data, ok := entry.(map[string]interface{}); ok {
+			pinned = append(pinned, data)
+		}
+	}
+	return pinned
+}
+
+func getNodeSettings(n map[string]interface{}) engine.NodeSettings {
+	m, ok := n["settings"].(map[string]interface{})
+	if !ok {
+		return engine.NodeSettings{}
+	}
+
+	retryOnFail, _ := m["retryOnFail"].(bool)
+	maxTries, _ := m["maxTries"].(float64)
+	waitBetweenTries, _ := m["waitBetweenTries"].(float64)
+	timeout, _ := m["timeout"].(float64)
+
+	return engine.NodeSettings{
+		RetryOnFail:      retryOnFail,
+		MaxTries:         int(maxTries),
+		WaitBetweenTries: time.Duration(waitBetweenTries) * time.Millisecond,
+		Backoff:          getString(m, "backoff"),
+		Timeout:          time.Duration(timeout) * time.Millisecond,
+		OnError:          getString(m, "onError"),
+	}
+}
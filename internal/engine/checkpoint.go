@@ -0,0 +1,588 @@
+// Package engine provides execution checkpointing and resumption
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// resumeBatchSize bounds how many unfinished executions are recovered at once
+const resumeBatchSize = 500
+
+// defaultExecutionLease is how long an engine holds a running execution
+// without renewing its lease before other engines may resume it
+const defaultExecutionLease = time.Minute
+
+// waitTimeoutPort is the output taken when a webhook wait runs out of time
+const waitTimeoutPort = "timeout"
+
+// errParked signals that an execution stopped at a wait and will be resumed
+var errParked = errors.New("execution parked")
+
+// Checkpoint is the persisted progress of an execution after its last
+// completed node. It holds everything needed to continue on another worker.
+type Checkpoint struct {
+	Workflow    *WorkflowDefinition                  `json:"workflow"`
+	Options     *ExecutionOptions                    `json:"options"` // Credentials are never persisted
+	StartNodeID string                               `json:"startNodeId"`
+	Queue       []string                             `json:"queue"`
+	Connections []int                                `json:"connections"`
+	Queued      []string                             `json:"queued"`
+	Done        []string                             `json:"done"`
+	Looping     []string                             `json:"looping,omitempty"`    // Loop nodes whose body is running
+	Iterations  map[string]int                       `json:"iterations,omitempty"` // Loop node re-entries so far
+	NodeOutputs map[string]map[string]interface{}    `json:"nodeOutputs"`
+	NodeItems   map[string]map[string][]runtime.Item `json:"nodeItems"`
+	ParkedNode  string                               `json:"parkedNode,omitempty"`
+	ParkedPorts []string                             `json:"parkedPorts,omitempty"`
+	ResumeAt    *time.Time                           `json:"resumeAt,omitempty"`
+	Webhook     bool                                 `json:"webhook,omitempty"` // Parked until the resume URL is called
+	ResumeToken string                               `json:"resumeToken"`
+}
+
+// CredentialResolver loads credentials for an execution resumed from a
+// checkpoint, since credentials are not stored with it
+type CredentialResolver func(ctx context.Context, workflow *WorkflowDefinition, options *ExecutionOptions) (map[string]map[string]interface{}, error)
+
+// WaitHandler is notified when an execution parks at a wait node. resumeAt is
+// nil for webhook waits without a deadline.
+type WaitHandler func(ctx context.Context, state *ExecutionState, resumeAt *time.Time)
+
+// executionRun is the in-flight progress of a single execution
+type executionRun struct {
+	workflow    *WorkflowDefinition
+	options     *ExecutionOptions
+	state       *ExecutionState
+	graph       *executionGraph
+	tracker     *joinTracker
+	startID     string
+	queue       []string
+	running     []string // Nodes dispatched but not yet finished
+	record      *ExecutionRecord
+	parkedNode  string
+	parkedPorts []string
+	resumeAt    *time.Time
+	webhook     bool
+	token       string
+	failedNode  string // First node that failed, passed to the error workflow
+	responded   bool   // A node replied to the webhook call that started the run
+}
+
+// WithRepository enables checkpointing through the given repository
+func (e *Engine) WithRepository(repo ExecutionRepository) *Engine {
+	e.repo = repo
+	return e
+}
+
+// WithExecutionLease sets the owner the engine holds the leases on its
+// persisted executions as, and how long a lease lasts. Leases are renewed
+// while an execution runs; once one expires, another engine may claim the
+// execution and resume it.
+func (e *Engine) WithExecutionLease(owner string, lease time.Duration) *Engine {
+	if owner != "" {
+		e.owner = owner
+	}
+	if lease > 0 {
+		e.lease = lease
+	}
+	return e
+}
+
+// WithCredentialResolver sets how credentials are loaded on resume
+func (e *Engine) WithCredentialResolver(resolver CredentialResolver) *Engine {
+	e.credentials = resolver
+	return e
+}
+
+// WithWaitHandler sets the handler notified when an execution parks
+func (e *Engine) WithWaitHandler(handler WaitHandler) *Engine {
+	e.onWait = handler
+	return e
+}
+
+// WithResumeURL sets the base URL that resume URLs are built from; the
+// execution ID and its resume token are appended as path segments
+func (e *Engine) WithResumeURL(baseURL string) *Engine {
+	e.resumeBase = baseURL
+	return e
+}
+
+func (e *Engine) resumeURL(executionID, token string) string {
+	return fmt.Sprintf("%s/%s/%s", e.resumeBase, executionID, token)
+}
+
+func newResumeToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// createRecord persists the initial execution record. Persistence is best
+// effort: on failure the execution continues without checkpoints.
+func (e *Engine) createRecord(ctx context.Context, run *executionRun) {
+	if e.repo == nil {
+		return
+	}
+
+	record := &ExecutionRecord{
+		ID:           run.state.ID,
+		WorkflowID:   run.workflow.ID,
+		WorkflowName: run.workflow.Name,
+		Status:       ExecutionStatusRunning,
+		Mode:         run.options.Mode,
+		StartedAt:    run.state.StartedAt,
+		TriggerData:  run.options.TriggerData,
+		UserID:       run.options.UserID,
+		WorkspaceID:  run.options.WorkspaceID,
+		Checkpoint:   run.snapshot(),
+		Owner:        e.owner,
+	}
+	leaseUntil := time.Now().Add(e.lease)
+	record.LeaseUntil = &leaseUntil
+	if run.options.ParentID != "" {
+		record.ParentID = &run.options.ParentID
+	}
+
+	if err := e.repo.Create(ctx, record); err != nil {
+		run.warn(fmt.Sprintf("Failed to persist execution, continuing without checkpoints: %v", err))
+		return
+	}
+	run.record = record
+}
+
+// checkpoint persists the progress of a run
+func (e *Engine) checkpoint(ctx context.Context, run *executionRun) error {
+	if run.record == nil {
+		return nil
+	}
+
+	run.record.Status = ExecutionStatus(run.state.Status)
+	run.record.Checkpoint = run.snapshot()
+	run.record.WaitUntil = run.resumeAt
+	run.record.NodeOutputs = nodeOutputsRecord(run.state.NodeOutputs)
+
+	return e.repo.Update(ctx, run.record)
+}
+
+// finishRecord persists the final status of a run and drops its checkpoint
+func (e *Engine) finishRecord(ctx context.Context, run *executionRun) {
+	if run.record == nil {
+		return
+	}
+
+	state := run.state
+	run.record.Status = ExecutionStatus(state.Status)
+	run.record.CompletedAt = state.CompletedAt
+	if state.CompletedAt != nil {
+		run.record.DurationMs = state.CompletedAt.Sub(state.StartedAt).Milliseconds()
+	}
+	if state.Error != nil {
+		run.record.Error = state.Error.Error()
+	}
+	run.record.NodeOutputs = nodeOutputsRecord(state.NodeOutputs)
+	run.record.Checkpoint = nil
+	run.record.WaitUntil = nil
+
+	if err := e.repo.Update(ctx, run.record); err != nil {
+		run.warn(fmt.Sprintf("Failed to persist execution result: %v", err))
+	}
+}
+
+// holdLease renews the lease on a persisted run until the returned function
+// is called, so that other engines do not take it for interrupted
+func (e *Engine) holdLease(run *executionRun) func() {
+	if run.record == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(e.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := e.repo.RenewLease(context.Background(), run.state.ID, e.owner, e.lease)
+				if errors.Is(err, ErrExecutionClaimed) {
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// park checkpoints a run stopped at a wait node and, for timed waits, arms a
+// timer to resume it. Without a repository, or if the checkpoint cannot be
+// saved, a timed wait is served inline instead; a webhook wait fails.
+func (e *Engine) park(ctx context.Context, run *executionRun, nodeID string, ports []string, wait *runtime.WaitRequest) error {
+	if run.record != nil {
+		run.parkedNode = nodeID
+		run.parkedPorts = ports
+		run.webhook = wait.Webhook
+		run.resumeAt = nil
+		if !wait.ResumeAt.IsZero() {
+			resumeAt := wait.ResumeAt
+			run.resumeAt = &resumeAt
+		}
+		run.state.Status = string(ExecutionStatusWaiting)
+
+		err := e.checkpoint(ctx, run)
+		if err == nil {
+			if run.resumeAt != nil {
+				e.armTimer(run.state.ID, *run.resumeAt)
+			}
+			if e.onWait != nil {
+				e.onWait(ctx, run.state, run.resumeAt)
+			}
+			return errParked
+		}
+		if wait.Webhook {
+			return fmt.Errorf("failed to park execution at node %s: %w", nodeID, err)
+		}
+
+		run.warn(fmt.Sprintf("Failed to park execution, waiting inline: %v", err))
+		run.parkedNode, run.parkedPorts, run.resumeAt, run.webhook = "", nil, nil, false
+		run.state.Status = string(ExecutionStatusRunning)
+	}
+
+	if wait.Webhook {
+		return fmt.Errorf("node %s waits for a webhook, which requires an execution repository", nodeID)
+	}
+
+	timer := time.NewTimer(time.Until(wait.ResumeAt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	run.queue = append(run.queue, run.tracker.complete(nodeID, ports)...)
+	return nil
+}
+
+// armTimer schedules a parked execution to resume at the given time
+func (e *Engine) armTimer(executionID string, resumeAt time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if timer, ok := e.timers[executionID]; ok {
+		timer.Stop()
+	}
+	e.timers[executionID] = time.AfterFunc(time.Until(resumeAt), func() {
+		e.Resume(context.Background(), executionID)
+	})
+}
+
+// stopTimer cancels a pending resume timer
+func (e *Engine) stopTimer(executionID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if timer, ok := e.timers[executionID]; ok {
+		timer.Stop()
+		delete(e.timers, executionID)
+	}
+}
+
+// Resume continues an execution from its last checkpoint. A parked execution
+// is resumed immediately, even if its wait has not yet elapsed; an execution
+// waiting for a webhook continues on its timeout branch.
+func (e *Engine) Resume(ctx context.Context, executionID string) (*ExecutionState, error) {
+	return e.resume(ctx, executionID, "", nil)
+}
+
+// ResumeWebhook continues an execution waiting for a webhook. token is the
+// last segment of the execution's resume URL, and body is merged into the
+// output of the waiting node.
+func (e *Engine) ResumeWebhook(ctx context.Context, executionID, token string, body map[string]interface{}) (*ExecutionState, error) {
+	if body == nil {
+		body = make(map[string]interface{})
+	}
+	return e.resume(ctx, executionID, token, body)
+}
+
+func (e *Engine) resume(ctx context.Context, executionID, token string, body map[string]interface{}) (*ExecutionState, error) {
+	if e.repo == nil {
+		return nil, fmt.Errorf("execution repository not configured")
+	}
+
+	record, err := e.repo.FindByID(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != ExecutionStatusRunning && record.Status != ExecutionStatusWaiting {
+		return nil, fmt.Errorf("execution %s is %s and cannot be resumed", executionID, record.Status)
+	}
+	cp := record.Checkpoint
+	if cp == nil || cp.Workflow == nil {
+		return nil, fmt.Errorf("execution %s has no checkpoint", executionID)
+	}
+	if body != nil {
+		if !cp.Webhook || record.Status != ExecutionStatusWaiting {
+			return nil, fmt.Errorf("execution %s is not waiting for a webhook", executionID)
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(cp.ResumeToken)) != 1 {
+			return nil, fmt.Errorf("invalid resume token for execution %s", executionID)
+		}
+	}
+
+	options := &ExecutionOptions{}
+	if cp.Options != nil {
+		copied := *cp.Options
+		options = &copied
+	}
+	options.ExecutionID = executionID
+	if e.credentials != nil {
+		credentials, err := e.credentials(ctx, cp.Workflow, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load credentials: %w", err)
+		}
+		options.Credentials = credentials
+	}
+
+	// Only the engine that claims the execution resumes it, so that
+	// replicas recovering the same executions do not run them twice
+	record, err = e.repo.Claim(ctx, executionID, e.owner, e.lease)
+	if err != nil {
+		return nil, err
+	}
+	e.stopTimer(executionID)
+
+	execCtx, cancel := context.WithCancel(ctx)
+	state := &ExecutionState{
+		ID:          executionID,
+		WorkflowID:  cp.Workflow.ID,
+		Status:      string(ExecutionStatusRunning),
+		StartedAt:   record.StartedAt,
+		NodeOutputs: cp.NodeOutputs,
+		NodeItems:   cp.NodeItems,
+		NodeResults: make(map[string]*NodeResult),
+		ResumeURL:   e.resumeURL(executionID, cp.ResumeToken),
+		Logs:        []runtime.LogEntry{},
+		cancel:      cancel,
+	}
+	if state.NodeOutputs == nil {
+		state.NodeOutputs = make(map[string]map[string]interface{})
+	}
+	if state.NodeItems == nil {
+		state.NodeItems = make(map[string]map[string][]runtime.Item)
+	}
+
+	e.mu.Lock()
+	if existing, ok := e.executions[executionID]; ok && existing.Status == string(ExecutionStatusRunning) {
+		e.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("execution %s is already running", executionID)
+	}
+	e.executions[executionID] = state
+	e.mu.Unlock()
+
+	graph := newExecutionGraph(cp.Workflow)
+	run := &executionRun{
+		workflow: cp.Workflow,
+		options:  options,
+		state:    state,
+		graph:    graph,
+		tracker:  restoreJoinTracker(graph, cp),
+		startID:  cp.StartNodeID,
+		queue:    cp.Queue,
+		record:   record,
+		token:    cp.ResumeToken,
+	}
+
+	state.Logs = append(state.Logs, runtime.LogEntry{
+		Level:     "info",
+		Message:   "Resuming execution from checkpoint",
+		Timestamp: time.Now().UnixMilli(),
+	})
+
+	// The parked node already produced its output; release its downstream
+	if cp.ParkedNode != "" {
+		ports := cp.ParkedPorts
+		if cp.Webhook {
+			if body != nil {
+				mergeResumeBody(state, cp.ParkedNode, body)
+				ports = graph.portsExcept(cp.ParkedNode, waitTimeoutPort)
+			} else if containsPort(graph.outboundPorts(cp.ParkedNode), waitTimeoutPort) {
+				ports = []string{waitTimeoutPort}
+			}
+		}
+		run.queue = append(run.queue, run.tracker.complete(cp.ParkedNode, ports)...)
+	}
+
+	return e.run(execCtx, run)
+}
+
+// RecoverExecutions re-arms timers for parked executions and returns the IDs
+// of running executions whose lease expired, which were interrupted and
+// should be resumed. Executions persisted without a lease are taken for
+// interrupted once their last checkpoint is older than staleAfter. Resuming
+// claims each one, so that only one engine resumes it.
+func (e *Engine) RecoverExecutions(ctx context.Context, staleAfter time.Duration) ([]string, error) {
+	if e.repo == nil {
+		return nil, nil
+	}
+
+	waiting, err := e.repo.ListByStatus(ctx, ExecutionStatusWaiting, resumeBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list waiting executions: %w", err)
+	}
+	for _, record := range waiting {
+		resumeAt := time.Now()
+		if record.WaitUntil != nil {
+			resumeAt = *record.WaitUntil
+		} else if record.Checkpoint != nil && record.Checkpoint.Webhook {
+			continue // Only its resume URL can wake it
+		}
+		e.armTimer(record.ID, resumeAt)
+	}
+
+	running, err := e.repo.ListByStatus(ctx, ExecutionStatusRunning, resumeBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list running executions: %w", err)
+	}
+
+	now := time.Now()
+	var interrupted []string
+	for _, record := range running {
+		if record.Checkpoint == nil || !record.leaseExpired(now, staleAfter) {
+			continue
+		}
+		e.mu.RLock()
+		_, local := e.executions[record.ID]
+		e.mu.RUnlock()
+		if !local {
+			interrupted = append(interrupted, record.ID)
+		}
+	}
+
+	return interrupted, nil
+}
+
+// leaseExpired reports whether the engine running an execution stopped
+// renewing its lease
+func (r *ExecutionRecord) leaseExpired(now time.Time, staleAfter time.Duration) bool {
+	if r.LeaseUntil != nil {
+		return r.LeaseUntil.Before(now)
+	}
+	return r.UpdatedAt.Add(staleAfter).Before(now)
+}
+
+// snapshot captures the progress of a run as a checkpoint
+func (run *executionRun) snapshot() *Checkpoint {
+	options := *run.options
+	options.Credentials = nil
+
+	cp := &Checkpoint{
+		Workflow:    run.workflow,
+		Options:     &options,
+		StartNodeID: run.startID,
+		Queue:       append(append([]string(nil), run.running...), run.queue...),
+		Connections: make([]int, len(run.tracker.states)),
+		NodeOutputs: run.state.NodeOutputs,
+		NodeItems:   run.state.NodeItems,
+		ParkedNode:  run.parkedNode,
+		ParkedPorts: run.parkedPorts,
+		ResumeAt:    run.resumeAt,
+		Webhook:     run.webhook,
+		ResumeToken: run.token,
+	}
+
+	for i, s := range run.tracker.states {
+		cp.Connections[i] = int(s)
+	}
+	for nodeID := range run.tracker.queued {
+		cp.Queued = append(cp.Queued, nodeID)
+	}
+	for nodeID := range run.tracker.done {
+		cp.Done = append(cp.Done, nodeID)
+	}
+	for nodeID, looping := range run.tracker.looping {
+		if looping {
+			cp.Looping = append(cp.Looping, nodeID)
+		}
+	}
+	if len(run.tracker.iterations) > 0 {
+		cp.Iterations = make(map[string]int, len(run.tracker.iterations))
+		for nodeID, n := range run.tracker.iterations {
+			cp.Iterations[nodeID] = n
+		}
+	}
+
+	return cp
+}
+
+func (run *executionRun) warn(message string) {
+	run.state.Logs = append(run.state.Logs, runtime.LogEntry{
+		Level:     "warn",
+		Message:   message,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// restoreJoinTracker rebuilds join state from a checkpoint
+func restoreJoinTracker(graph *executionGraph, cp *Checkpoint) *joinTracker {
+	t := newJoinTracker(graph, cp.StartNodeID)
+
+	for i, s := range cp.Connections {
+		if i < len(t.states) {
+			t.states[i] = connectionState(s)
+		}
+	}
+	for _, nodeID := range cp.Queued {
+		t.queued[nodeID] = true
+	}
+	for _, nodeID := range cp.Done {
+		t.done[nodeID] = true
+	}
+	for _, nodeID := range cp.Looping {
+		t.looping[nodeID] = true
+	}
+	for nodeID, n := range cp.Iterations {
+		t.iterations[nodeID] = n
+	}
+
+	return t
+}
+
+// mergeResumeBody merges the body posted to a resume URL into the output and
+// items of the node that was waiting for it
+func mergeResumeBody(state *ExecutionState, nodeID string, body map[string]interface{}) {
+	output := copyData(state.NodeOutputs[nodeID])
+	for k, v := range body {
+		output[k] = v
+	}
+	state.NodeOutputs[nodeID] = output
+
+	for port, items := range state.NodeItems[nodeID] {
+		merged := make([]runtime.Item, len(items))
+		for i, item := range items {
+			data := copyData(item.JSON)
+			for k, v := range body {
+				data[k] = v
+			}
+			merged[i] = runtime.Item{JSON: data, Binary: item.Binary, PairedItem: item.PairedItem}
+		}
+		state.NodeItems[nodeID][port] = merged
+	}
+}
+
+func nodeOutputsRecord(outputs map[string]map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(outputs))
+	for nodeID, output := range outputs {
+		result[nodeID] = output
+	}
+	return result
+}
@@ -0,0 +1,285 @@
+// Package engine provides concurrency limits on executions
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// executionSlot limits how many executions sharing a key run at once
+type executionSlot struct {
+	key   string
+	limit int
+}
+
+// slotQueue tracks the holders of a slot and the executions waiting for it,
+// in arrival order
+type slotQueue struct {
+	running int
+	waiting []chan struct{}
+}
+
+// admit waits until every slot of a run is free: those set by the scheduler
+// and the workflow's own concurrency limit. A run that has to wait is marked
+// queued until it is admitted. The returned function frees the slots.
+func (e *Engine) admit(ctx context.Context, run *executionRun) (func(), error) {
+	slots := run.options.slots
+	if limit := run.workflow.Settings.MaxConcurrency; limit > 0 {
+		slots = append(slots[:len(slots):len(slots)], executionSlot{key: "workflow:" + run.workflow.ID, limit: limit})
+	}
+
+	// Slots are always taken in the same order, so that runs waiting for
+	// each other's slots cannot deadlock
+	var held []string
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			e.releaseSlot(held[i])
+		}
+	}
+
+	queued := false
+	for _, slot := range slots {
+		ready := e.acquireSlot(slot)
+		if ready == nil {
+			held = append(held, slot.key)
+			continue
+		}
+
+		if !queued {
+			queued = true
+			e.markQueued(run, slot.key)
+		}
+		select {
+		case <-ready:
+			held = append(held, slot.key)
+		case <-ctx.Done():
+			e.abandonSlot(slot.key, ready)
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	if queued {
+		run.state.Status = string(ExecutionStatusRunning)
+		if err := e.checkpoint(ctx, run); err != nil {
+			run.warn(fmt.Sprintf("Failed to persist execution status: %v", err))
+		}
+	}
+	return release, nil
+}
+
+// acquireSlot takes a slot if one is free, returning nil. Otherwise it
+// returns a channel that is closed once the slot is handed over.
+func (e *Engine) acquireSlot(slot executionSlot) chan struct{} {
+	e.slotsMu.Lock()
+	defer e.slotsMu.Unlock()
+
+	queue := e.slots[slot.key]
+	if queue == nil {
+		queue = &slotQueue{}
+		e.slots[slot.key] = queue
+	}
+	if queue.running < slot.limit && len(queue.waiting) == 0 {
+		queue.running++
+		return nil
+	}
+
+	ready := make(chan struct{})
+	queue.waiting = append(queue.waiting, ready)
+	return ready
+}
+
+// releaseSlot hands a slot to the longest waiting execution, or frees it
+func (e *Engine) releaseSlot(key string) {
+	e.slotsMu.Lock()
+	defer e.slotsMu.Unlock()
+
+	queue := e.slots[key]
+	if queue == nil {
+		return
+	}
+	if len(queue.waiting) > 0 {
+		close(queue.waiting[0])
+		queue.waiting = queue.waiting[1:]
+		return
+	}
+	queue.running--
+	if queue.running <= 0 {
+		delete(e.slots, key)
+	}
+}
+
+// abandonSlot stops waiting for a slot. A slot handed over in the meantime
+// is passed on.
+func (e *Engine) abandonSlot(key string, ready chan struct{}) {
+	e.slotsMu.Lock()
+	select {
+	case <-ready:
+		e.slotsMu.Unlock()
+		e.releaseSlot(key)
+		return
+	default:
+	}
+	defer e.slotsMu.Unlock()
+
+	queue := e.slots[key]
+	for i, waiting := range queue.waiting {
+		if waiting == ready {
+			queue.waiting = append(queue.waiting[:i], queue.waiting[i+1:]...)
+			break
+		}
+	}
+}
+
+// markQueued records that a run waits for a slot
+func (e *Engine) markQueued(run *executionRun, key string) {
+	run.state.Status = string(ExecutionStatusQueued)
+	run.warn(fmt.Sprintf("Execution queued behind concurrency limit %s", key))
+	if err := e.checkpoint(context.Background(), run); err != nil {
+		run.warn(fmt.Sprintf("Failed to persist execution status: %v", err))
+	}
+
+	data := executionEventData(run.workflow, run.options)
+	data["limit"] = key
+	e.events.Emit(ExecutionEvent{
+		Type:        EventTypeExecutionQueued,
+		ExecutionID: run.state.ID,
+		WorkflowID:  run.workflow.ID,
+		Timestamp:   time.Now(),
+		Data:        data,
+	})
+}
+
+// skipExecution records a run that was not started, so that it shows up in
+// the execution list with the reason it was skipped
+func (e *Engine) skipExecution(workflow *WorkflowDefinition, options *ExecutionOptions, reason string) *ExecutionState {
+	executionID := options.ExecutionID
+	if executionID == "" {
+		executionID = uuid.New().String()
+	}
+	now := time.Now()
+
+	state := &ExecutionState{
+		ID:          executionID,
+		WorkflowID:  workflow.ID,
+		Status:      string(ExecutionStatusSkipped),
+		StartedAt:   now,
+		CompletedAt: &now,
+		Error:       errors.New(reason),
+		Logs:        []runtime.LogEntry{},
+	}
+
+	e.mu.Lock()
+	e.executions[executionID] = state
+	e.mu.Unlock()
+
+	if e.repo != nil {
+		e.repo.Create(context.Background(), &ExecutionRecord{
+			ID:           executionID,
+			WorkflowID:   workflow.ID,
+			WorkflowName: workflow.Name,
+			Status:       ExecutionStatusSkipped,
+			Mode:         options.Mode,
+			StartedAt:    now,
+			CompletedAt:  &now,
+			TriggerData:  options.TriggerData,
+			Error:        reason,
+			UserID:       options.UserID,
+			WorkspaceID:  options.WorkspaceID,
+		})
+	}
+
+	data := executionEventData(workflow, options)
+	data["reason"] = reason
+	e.events.Emit(ExecutionEvent{
+		Type:        EventTypeExecutionSkipped,
+		ExecutionID: executionID,
+		WorkflowID:  workflow.ID,
+		Timestamp:   now,
+		Data:        data,
+	})
+	return state
+}
+
+// expectExecution registers an execution that is about to be started in the
+// background, so that it counts as active before it gets going
+func (e *Engine) expectExecution(workflowID, executionID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.executions[executionID]; !ok {
+		e.executions[executionID] = &ExecutionState{
+			ID:         executionID,
+			WorkflowID: workflowID,
+			Status:     string(ExecutionStatusPending),
+			StartedAt:  time.Now(),
+			Logs:       []runtime.LogEntry{},
+			live:       true,
+		}
+	}
+}
+
+// submit executes a workflow in the background, on pool when there is one.
+// The execution is pending until it starts.
+func (e *Engine) submit(pool *WorkerPool, workflow *WorkflowDefinition, options *ExecutionOptions) (string, error) {
+	if options.ExecutionID == "" {
+		options.ExecutionID = uuid.New().String()
+	}
+	e.expectExecution(workflow.ID, options.ExecutionID)
+
+	if pool != nil {
+		if _, err := pool.SubmitWorkflow(workflow, options); err != nil {
+			e.forgetExecution(options.ExecutionID)
+			return "", err
+		}
+		return options.ExecutionID, nil
+	}
+
+	go e.Execute(context.Background(), workflow, options)
+	return options.ExecutionID, nil
+}
+
+// forgetExecution drops an expected execution that could not be started
+func (e *Engine) forgetExecution(executionID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if state, ok := e.executions[executionID]; ok && state.Status == string(ExecutionStatusPending) {
+		delete(e.executions, executionID)
+	}
+}
+
+// executionActive reports whether an execution may still be running. One
+// the engine no longer tracks is looked up by its record.
+func (e *Engine) executionActive(executionID string) bool {
+	e.mu.RLock()
+	state, ok := e.executions[executionID]
+	live := ok && state.live
+	e.mu.RUnlock()
+
+	if live {
+		return true
+	}
+	if ok {
+		return !finishedStatus(state.Status)
+	}
+	if e.repo != nil {
+		if record, err := e.repo.FindByID(context.Background(), executionID); err == nil {
+			return !finishedStatus(string(record.Status))
+		}
+	}
+	return false
+}
+
+func finishedStatus(status string) bool {
+	switch ExecutionStatus(status) {
+	case ExecutionStatusCompleted, ExecutionStatusFailed, ExecutionStatusCancelled, ExecutionStatusSkipped:
+		return true
+	}
+	return false
+}
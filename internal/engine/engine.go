@@ -3,12 +3,15 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
+	"github.com/linkflow-ai/linkflow-ai/internal/shared/events"
 	"github.com/linkflow-ai/linkflow-ai/pkg/expression"
 )
 
@@ -18,6 +21,22 @@ type Engine struct {
 	executions  map[string]*ExecutionState
 	mu          sync.RWMutex
 	maxParallel int
+	repo        ExecutionRepository
+	owner       string        // Holds the leases on the persisted executions this engine runs
+	lease       time.Duration // How long a lease lasts unless renewed
+	credentials CredentialResolver
+	onWait      WaitHandler
+	events      *EventEmitter
+	resumeBase  string                 // Base of the URLs that resume executions waiting for a webhook
+	retryBase   string                 // Base of the retry links passed to error workflows
+	timers      map[string]*time.Timer // parked executions awaiting resume
+	workflows   WorkflowLoader
+	runner      runtime.WorkflowRunner
+	slots       map[string]*slotQueue // Concurrency limits by key
+	slotsMu     sync.Mutex
+	binary      runtime.BinaryStore // Holds the binary data of items
+	egress      *egress.Guard       // Policy outbound requests of nodes are held to
+	platform    *events.Bus         // Platform events, which event triggers subscribe to
 }
 
 // ExecutionState tracks the state of a workflow execution
@@ -29,15 +48,22 @@ type ExecutionState struct {
 	CompletedAt  *time.Time
 	CurrentNode  string
 	NodeOutputs  map[string]map[string]interface{}
+	NodeItems    map[string]map[string][]runtime.Item // node -> output port -> items
+	NodeResults  map[string]*NodeResult
+	ResumeURL    string                               // Resumes the execution while it waits for a webhook
+	Result       *ExecutionResult                     // Set once the execution completes, fails or parks
 	Error        error
 	Logs         []runtime.LogEntry
 	cancel       context.CancelFunc
+	live         bool // Being driven by the engine; guarded by Engine.mu
 }
 
 // WorkflowDefinition represents a workflow to execute
 type WorkflowDefinition struct {
 	ID          string
 	Name        string
+	UserID      string // Owner, whose platform events the event triggers receive
+	Version     int    // Of the saved definition, which its executions link to
 	Nodes       []NodeDefinition
 	Connections []Connection
 	Settings    WorkflowSettings
@@ -51,6 +77,19 @@ type NodeDefinition struct {
 	Config     map[string]interface{}
 	Position   Position
 	Credential string
+	Settings   NodeSettings
+	PinnedData []map[string]interface{} // Items output instead of running the node in manual executions
+}
+
+// NodeSettings controls how a node is retried, timed out and how its
+// failures affect the rest of the execution
+type NodeSettings struct {
+	RetryOnFail      bool
+	MaxTries         int           // Attempts including the first, defaults to 3
+	WaitBetweenTries time.Duration // Delay before the first retry, defaults to 1s
+	Backoff          string        // fixed, exponential, jitter
+	Timeout          time.Duration // Hard limit per attempt, zero for none
+	OnError          string        // stop, continue, errorOutput
 }
 
 // Connection represents a connection between nodes
@@ -73,17 +112,36 @@ type WorkflowSettings struct {
 	RetryOnFail      bool
 	MaxRetries       int
 	ErrorHandling    string
+	ErrorWorkflowID  string // Workflow started when an execution fails
+	MaxConcurrency   int    // Executions running at once, zero for no limit; others queue
 }
 
 // ExecutionOptions represents execution options
 type ExecutionOptions struct {
-	Mode         string // manual, webhook, schedule, api
-	TriggerData  map[string]interface{}
-	Credentials  map[string]map[string]interface{}
-	Variables    map[string]interface{}
-	Environment  map[string]string
-	UserID       string
-	WorkspaceID  string
+	ExecutionID   string // Generated when empty
+	Mode          string // manual, webhook, schedule, api
+	TriggerNodeID string // Trigger that fired; defaults to one matching Mode
+	TriggerData   map[string]interface{}
+	Credentials   map[string]map[string]interface{}
+	Variables     map[string]interface{}
+	Environment   map[string]string
+	UserID        string
+	WorkspaceID   string
+	Depth         int             // Of a sub-workflow execution below the one that started it
+	ParentID      string          // Execution whose node called this sub-workflow execution
+	slots         []executionSlot // Limits set by the scheduler, taken in order
+	
+	// Respond receives the response of the first node that replies to the
+	// webhook call that started the execution, e.g. a respond to webhook node
+	Respond func(*runtime.WebhookResponse) `json:"-"`
+	
+	// Hook is called around each node, e.g. by a debugger
+	Hook NodeHook `json:"-"`
+	
+	// Recorded holds the outputs nodes had in an earlier execution. These
+	// nodes pass them on rather than run again, e.g. when the execution
+	// re-runs another from one of its nodes.
+	Recorded map[string]*RecordedNode `json:",omitempty"`
 }
 
 // NewEngine creates a new workflow engine
@@ -92,14 +150,68 @@ func NewEngine() *Engine {
 		parser:      expression.NewParser(),
 		executions:  make(map[string]*ExecutionState),
 		maxParallel: 10,
+		owner:       uuid.New().String(),
+		lease:       defaultExecutionLease,
+		events:      NewEventEmitter(),
+		resumeBase:  "/api/v1/webhooks/resume",
+		retryBase:   "/api/v1/executions",
+		timers:      make(map[string]*time.Timer),
+		slots:       make(map[string]*slotQueue),
+		binary:      runtime.NewMemoryBinaryStore(),
+		egress:      egress.Default(),
+	}
+}
+
+// WithMaxParallel sets how many nodes of an execution may run at once
+func (e *Engine) WithMaxParallel(n int) *Engine {
+	if n > 0 {
+		e.maxParallel = n
 	}
+	return e
+}
+
+// WithBinaryStore sets where the binary data of items is kept, in memory
+// by default
+func (e *Engine) WithBinaryStore(store runtime.BinaryStore) *Engine {
+	e.binary = store
+	return e
+}
+
+// WithEgressGuard sets the policy outbound requests of nodes are held to;
+// by default internal addresses cannot be reached
+func (e *Engine) WithEgressGuard(guard *egress.Guard) *Engine {
+	e.egress = guard
+	return e
+}
+
+// WithEventBus sets the bus of platform events that event triggers
+// subscribe to
+func (e *Engine) WithEventBus(bus *events.Bus) *Engine {
+	e.platform = bus
+	return e
+}
+
+// WithWorkflowRunner sets what runs the workflows that nodes call, such as
+// the execute workflow node
+func (e *Engine) WithWorkflowRunner(runner runtime.WorkflowRunner) *Engine {
+	e.runner = runner
+	return e
+}
+
+// Events returns the emitter that execution and node events are sent to
+func (e *Engine) Events() *EventEmitter {
+	return e.events
 }
 
 // Execute executes a workflow
 func (e *Engine) Execute(ctx context.Context, workflow *WorkflowDefinition, options *ExecutionOptions) (*ExecutionState, error) {
 	// Create execution state
-	executionID := uuid.New().String()
+	executionID := options.ExecutionID
+	if executionID == "" {
+		executionID = uuid.New().String()
+	}
 	execCtx, cancel := context.WithCancel(ctx)
+	resumeToken := newResumeToken()
 	
 	state := &ExecutionState{
 		ID:          executionID,
@@ -107,28 +219,24 @@ func (e *Engine) Execute(ctx context.Context, workflow *WorkflowDefinition, opti
 		Status:      "running",
 		StartedAt:   time.Now(),
 		NodeOutputs: make(map[string]map[string]interface{}),
+		NodeItems:   make(map[string]map[string][]runtime.Item),
+		NodeResults: make(map[string]*NodeResult),
+		ResumeURL:   e.resumeURL(executionID, resumeToken),
 		Logs:        []runtime.LogEntry{},
 		cancel:      cancel,
+		live:        true,
 	}
 	
 	e.mu.Lock()
 	e.executions[executionID] = state
 	e.mu.Unlock()
 	
-	// Find trigger node
-	var triggerNode *NodeDefinition
-	for i := range workflow.Nodes {
-		node := &workflow.Nodes[i]
-		meta, err := e.getNodeMetadata(node.Type)
-		if err == nil && meta.IsTrigger {
-			triggerNode = node
-			break
-		}
-	}
-	
-	if triggerNode == nil {
+	// Find the trigger the execution starts from
+	triggerNode, err := e.findTriggerNode(workflow, options)
+	if err != nil {
 		state.Status = "failed"
-		state.Error = fmt.Errorf("workflow has no trigger node")
+		state.Error = err
+		e.setLive(state, false)
 		return state, state.Error
 	}
 	
@@ -138,12 +246,83 @@ func (e *Engine) Execute(ctx context.Context, workflow *WorkflowDefinition, opti
 	}
 	
 	// Build execution graph
-	graph := e.buildExecutionGraph(workflow)
+	graph := newExecutionGraph(workflow)
 	
-	// Execute starting from trigger
-	err := e.executeFromNode(execCtx, workflow, state, triggerNode.ID, graph, options)
+	run := &executionRun{
+		workflow: workflow,
+		options:  options,
+		state:    state,
+		graph:    graph,
+		tracker:  newJoinTracker(graph, triggerNode.ID),
+		startID:  triggerNode.ID,
+		queue:    []string{triggerNode.ID},
+		token:    resumeToken,
+	}
+	e.createRecord(ctx, run)
 	
+	// Wait for the concurrency limits of the workflow
+	release, err := e.admit(execCtx, run)
 	if err != nil {
+		now := time.Now()
+		state.Status = string(ExecutionStatusCancelled)
+		state.Error = err
+		state.CompletedAt = &now
+		e.finishRecord(context.Background(), run)
+		e.setLive(state, false)
+		return state, err
+	}
+	defer release()
+	
+	e.events.Emit(ExecutionEvent{
+		Type:        EventTypeExecutionStarted,
+		ExecutionID: executionID,
+		WorkflowID:  workflow.ID,
+		Timestamp:   time.Now(),
+		Data:        executionEventData(workflow, options),
+	})
+	
+	// Execute starting from trigger
+	return e.run(execCtx, run)
+}
+
+// executionEventData describes an execution in the events announcing it: its
+// mode, the trigger it starts from and the version of the workflow it runs.
+// A sub-workflow execution names the execution that called it, so that the
+// two can be linked.
+func executionEventData(workflow *WorkflowDefinition, options *ExecutionOptions) map[string]interface{} {
+	data := map[string]interface{}{"mode": options.Mode}
+	if options.TriggerNodeID != "" {
+		data["triggerNodeId"] = options.TriggerNodeID
+	}
+	if workflow.Version > 0 {
+		data["workflowVersion"] = workflow.Version
+	}
+	if options.ParentID != "" {
+		data["parentExecutionId"] = options.ParentID
+		data["depth"] = options.Depth
+	}
+	return data
+}
+
+// run drives an execution until it completes, fails or parks at a wait
+func (e *Engine) run(ctx context.Context, run *executionRun) (*ExecutionState, error) {
+	state := run.state
+	e.setLive(state, true)
+	
+	releaseLease := e.holdLease(run)
+	err := e.executeFromNode(ctx, run)
+	releaseLease()
+	if errors.Is(err, errParked) {
+		state.Result = run.result()
+		e.setLive(state, false)
+		return state, nil
+	}
+	
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// Cancelled through CancelExecution or by the caller
+		state.Status = "cancelled"
+		state.Error = err
+	} else if err != nil {
 		state.Status = "failed"
 		state.Error = err
 	} else {
@@ -153,51 +332,392 @@ func (e *Engine) Execute(ctx context.Context, workflow *WorkflowDefinition, opti
 	now := time.Now()
 	state.CompletedAt = &now
 	
+	e.finishRecord(context.Background(), run)
+	state.Result = run.result()
+	e.setLive(state, false)
+	
+	event := ExecutionEvent{
+		Type:        EventTypeExecutionCompleted,
+		ExecutionID: state.ID,
+		WorkflowID:  state.WorkflowID,
+		Timestamp:   now,
+		Data: map[string]interface{}{
+			"status":     state.Status,
+			"durationMs": state.Result.DurationMs,
+			"mode":       run.options.Mode,
+		},
+	}
+	if err != nil {
+		event.Type = EventTypeExecutionFailed
+		event.Data["error"] = err.Error()
+	}
+	e.events.Emit(event)
+	
+	if err != nil {
+		e.startErrorWorkflow(run, err)
+	}
+	
 	return state, err
 }
 
-func (e *Engine) buildExecutionGraph(workflow *WorkflowDefinition) map[string][]string {
-	// Build adjacency list: node -> next nodes
-	graph := make(map[string][]string)
-	
-	for _, conn := range workflow.Connections {
-		graph[conn.SourceNodeID] = append(graph[conn.SourceNodeID], conn.TargetNodeID)
-	}
+// setLive marks whether the engine is driving an execution. While it is, only
+// the engine writes the execution's state.
+func (e *Engine) setLive(state *ExecutionState, live bool) {
+	e.mu.Lock()
+	state.live = live
+	e.mu.Unlock()
+}
+
+// nodeTask is a single node dispatched by the scheduler. It carries a
+// snapshot of everything the node reads, and collects what it produces, so
+// nodes can run concurrently while only the scheduler touches the run.
+type nodeTask struct {
+	nodeID    string
+	nodeDef   *NodeDefinition
+	inputData map[string]interface{}
+	items     []runtime.Item
+	outputs   map[string]map[string]interface{} // Node outputs when dispatched
 	
-	return graph
+	output      map[string]interface{}
+	portItems   map[string][]runtime.Item
+	activePorts []string // Ports that received items, nil when not branched
+	wait        *runtime.WaitRequest
+	response    *runtime.WebhookResponse
+	logs        []runtime.LogEntry
+	retries     int
+	startedAt   time.Time
+	err         error
 }
 
-func (e *Engine) executeFromNode(
-	ctx context.Context,
-	workflow *WorkflowDefinition,
-	state *ExecutionState,
-	nodeID string,
-	graph map[string][]string,
-	options *ExecutionOptions,
-) error {
-	// Find node definition
-	var nodeDef *NodeDefinition
-	for i := range workflow.Nodes {
-		if workflow.Nodes[i].ID == nodeID {
-			nodeDef = &workflow.Nodes[i]
-			break
+func (t *nodeTask) log(level, message string) {
+	t.logs = append(t.logs, runtime.LogEntry{
+		Level:     level,
+		Message:   message,
+		Timestamp: time.Now().UnixMilli(),
+		NodeID:    t.nodeID,
+	})
+}
+
+// executeFromNode schedules the nodes of a run. Nodes are queued only once all
+// of their reachable inbound connections have fired or been pruned, so
+// multi-input nodes such as merge run exactly once per execution, and only the
+// branches an IF or Switch selected are followed. Ready nodes run concurrently
+// up to the engine's parallelism limit. Results are applied, and progress
+// checkpointed, by this goroutine alone.
+func (e *Engine) executeFromNode(ctx context.Context, run *executionRun) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	
+	done := make(chan *nodeTask)
+	var failure error
+	
+	for {
+		var waiting *nodeTask
+		
+		for len(run.queue) > 0 || len(run.running) > 0 {
+			// Dispatch ready nodes unless the run is failing or about to park
+			for failure == nil && waiting == nil && len(run.queue) > 0 && len(run.running) < e.maxParallel {
+				if err := ctx.Err(); err != nil {
+					failure = err
+					break
+				}
+				
+				task, err := e.prepareNode(run, run.queue[0])
+				if err != nil {
+					failure = err
+					run.failedNode = run.queue[0]
+					break
+				}
+				run.queue = run.queue[1:]
+				run.running = append(run.running, task.nodeID)
+				
+				go func() {
+					e.executeNode(ctx, run, task)
+					done <- task
+				}()
+			}
+			if len(run.running) == 0 {
+				break
+			}
+			
+			task := <-done
+			run.running = removeNode(run.running, task.nodeID)
+			
+			if err := e.applyNode(run, task); err != nil {
+				if failure == nil {
+					failure = err
+					cancel()
+				}
+				continue
+			}
+			
+			switch {
+			case task.wait == nil:
+				run.queue = append(run.queue, run.tracker.complete(task.nodeID, task.activePorts)...)
+			case waiting == nil:
+				waiting = task
+			default:
+				// Only one wait can be parked at a time; run this node again
+				// once the execution resumes
+				run.queue = append([]string{task.nodeID}, run.queue...)
+			}
+			
+			if failure == nil {
+				if err := e.checkpoint(ctx, run); err != nil {
+					run.warn(fmt.Sprintf("Failed to checkpoint execution after node %s: %v", task.nodeID, err))
+				}
+			}
+		}
+		
+		if failure != nil {
+			return failure
+		}
+		if waiting == nil {
+			return nil
+		}
+		
+		if err := e.park(ctx, run, waiting.nodeID, waiting.activePorts, waiting.wait); err != nil {
+			return err
 		}
 	}
+}
+
+// prepareNode snapshots the input of a ready node
+func (e *Engine) prepareNode(run *executionRun, nodeID string) (*nodeTask, error) {
+	state, graph, tracker := run.state, run.graph, run.tracker
 	
+	nodeDef := graph.nodes[nodeID]
 	if nodeDef == nil {
-		return fmt.Errorf("node %s not found", nodeID)
+		return nil, fmt.Errorf("node %s not found", nodeID)
 	}
 	
 	state.CurrentNode = nodeID
 	
+	outputs := make(map[string]map[string]interface{}, len(state.NodeOutputs))
+	for id, output := range state.NodeOutputs {
+		outputs[id] = output
+	}
+	
+	task := &nodeTask{
+		nodeID:    nodeID,
+		nodeDef:   nodeDef,
+		inputData: e.buildNodeInput(graph, tracker, state, nodeID),
+		items:     e.buildNodeItems(graph, tracker, state, graph.inbound[nodeID]),
+		outputs:   outputs,
+		startedAt: time.Now(),
+	}
+	
+	// The trigger receives the data of the event that started the execution
+	if nodeID == run.startID && run.options.TriggerData != nil {
+		task.inputData = copyData(run.options.TriggerData)
+	}
+	
+	// A loop node runs again with the items its body sent back
+	if tracker.reentering(nodeID) {
+		if tracker.iterations[nodeID] > maxLoopIterations(nodeDef) {
+			return nil, fmt.Errorf("loop node %s exceeded %d iterations", nodeID, maxLoopIterations(nodeDef))
+		}
+		task.items = e.buildNodeItems(graph, tracker, state, graph.loopBack[nodeID])
+		task.inputData["_loopState"] = state.NodeOutputs[nodeID]["_loopState"]
+	}
+	
+	e.events.Emit(ExecutionEvent{
+		Type:        EventTypeNodeStarted,
+		ExecutionID: state.ID,
+		WorkflowID:  state.WorkflowID,
+		NodeID:      nodeID,
+		Timestamp:   task.startedAt,
+	})
+	
+	return task, nil
+}
+
+// applyNode records the result of a finished node on the run
+func (e *Engine) applyNode(run *executionRun, task *nodeTask) error {
+	state := run.state
+	state.Logs = append(state.Logs, task.logs...)
+	
+	completedAt := time.Now()
+	result := &NodeResult{
+		NodeID:      task.nodeID,
+		NodeType:    task.nodeDef.Type,
+		Status:      ExecutionStatusCompleted,
+		StartedAt:   task.startedAt,
+		CompletedAt: &completedAt,
+		DurationMs:  completedAt.Sub(task.startedAt).Milliseconds(),
+		Input:       task.inputData,
+		Output:      task.output,
+		Retries:     task.retries,
+	}
+	state.NodeResults[task.nodeID] = result
+	
+	event := ExecutionEvent{
+		Type:        EventTypeNodeCompleted,
+		ExecutionID: state.ID,
+		WorkflowID:  state.WorkflowID,
+		NodeID:      task.nodeID,
+		Timestamp:   completedAt,
+		Data:        map[string]interface{}{"durationMs": result.DurationMs},
+	}
+	
+	if task.err != nil {
+		result.Status = ExecutionStatusFailed
+		result.Error = task.err.Error()
+		event.Type = EventTypeNodeFailed
+		event.Data["error"] = result.Error
+		e.events.Emit(event)
+		if run.failedNode == "" {
+			run.failedNode = task.nodeID
+		}
+		return task.err
+	}
+	
+	state.NodeOutputs[task.nodeID] = task.output
+	state.NodeItems[task.nodeID] = task.portItems
+	e.events.Emit(event)
+	
+	// Only the first response reaches the webhook caller
+	if task.response != nil && !run.responded && run.options.Respond != nil {
+		run.responded = true
+		run.options.Respond(task.response)
+	}
+	return nil
+}
+
+// executeNode runs a single node, recording on the task the output ports that
+// received items (nil when the node did not branch) and the wait it asked for,
+// if any. Unless the node asks to see every item at once, it is called once
+// per input item.
+func (e *Engine) executeNode(ctx context.Context, run *executionRun, task *nodeTask) {
+	after, err := e.beforeNode(ctx, run, task)
+	if err != nil {
+		task.err = err
+		return
+	}
+	defer after()
+	
+	if e.replayNode(run, task) || e.pinNode(run, task) {
+		return
+	}
+	
+	nodeDef, items := task.nodeDef, task.items
+	
 	// Get node executor
 	executor, err := runtime.Get(nodeDef.Type)
 	if err != nil {
-		return fmt.Errorf("executor for node type %s not found: %w", nodeDef.Type, err)
+		task.err = fmt.Errorf("executor for node type %s not found: %w", nodeDef.Type, err)
+		return
 	}
+	meta := executor.GetMetadata()
+	
+	task.log("info", fmt.Sprintf("Executing node: %s (%s)", nodeDef.Name, nodeDef.Type))
+	
+	portItems := make(map[string][]runtime.Item)
+	branched := false
 	
-	// Build input from previous nodes
-	inputData := e.buildNodeInput(workflow, state, nodeID)
+	batch, _ := executor.(runtime.BatchExecutor)
+	runOnce := meta.RunOnceForAllItems || (batch != nil && batch.RunsOnceForAllItems(nodeDef.Config))
+	
+	if meta.IsTrigger || runOnce || len(items) <= 1 {
+		output, err := e.runNode(ctx, run, task, executor, task.inputData, 0, false)
+		if err != nil {
+			task.err = err
+			return
+		}
+		task.wait = output.Wait
+		task.response = output.Response
+		
+		// Store output
+		task.output = output.Data
+		
+		outputItems := nodeOutputItems(output)
+		
+		// Handle branching (IF/Switch): only the selected port fires
+		port, ok := output.Data["_output"].(string)
+		branched = ok
+		portItems[port] = outputItems
+	} else {
+		var allItems []runtime.Item
+		for i, item := range items {
+			if err := ctx.Err(); err != nil {
+				task.err = err
+				return
+			}
+			
+			output, err := e.runNode(ctx, run, task, executor, copyData(item.JSON), i, true)
+			if err != nil {
+				task.err = err
+				return
+			}
+			
+			// The node parks once, on the first wait an item asked for
+			if task.wait == nil {
+				task.wait = output.Wait
+			}
+			if task.response == nil {
+				task.response = output.Response
+			}
+			
+			outputItems := nodeOutputItems(output)
+			for j := range outputItems {
+				if outputItems[j].PairedItem == nil {
+					outputItems[j].PairedItem = &runtime.PairedItem{Item: i}
+				}
+			}
+			
+			port, ok := output.Data["_output"].(string)
+			branched = branched || ok
+			portItems[port] = append(portItems[port], outputItems...)
+			allItems = append(allItems, outputItems...)
+		}
+		
+		// Store combined output for $node expressions
+		task.output = runtime.DataFromItems(allItems)
+	}
+	
+	task.portItems = portItems
+	
+	if branched {
+		task.activePorts = activePorts(run.graph, task.nodeID, portItems)
+	}
+}
+
+// activePorts returns the output ports of a branching node that fire given
+// the items it sent to each port
+func activePorts(graph *executionGraph, nodeID string, portItems map[string][]runtime.Item) []string {
+	ports := make([]string, 0, len(portItems))
+	for port := range portItems {
+		if port != "" {
+			ports = append(ports, port)
+		}
+	}
+	
+	// Items without a port, such as the successes of a node routing its
+	// failures to the error output, go to every port no branch claimed
+	if len(portItems[""]) > 0 {
+		for _, port := range graph.outboundPorts(nodeID) {
+			if _, claimed := portItems[port]; !claimed {
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
+// runNode evaluates the node configuration against the item at itemIndex and
+// invokes the executor. With perItem set the node only receives that item.
+func (e *Engine) runNode(
+	ctx context.Context,
+	run *executionRun,
+	task *nodeTask,
+	executor runtime.NodeExecutor,
+	inputData map[string]interface{},
+	itemIndex int,
+	perItem bool,
+) (*runtime.ExecutionOutput, error) {
+	workflow, state, options := run.workflow, run.state, run.options
+	nodeDef, items := task.nodeDef, task.items
+	nodeID := nodeDef.ID
 	
 	// Build execution context
 	execCtx := &runtime.ExecutionContext{
@@ -208,6 +728,12 @@ func (e *Engine) executeFromNode(
 		Variables:   options.Variables,
 		Env:         options.Environment,
 		Mode:        options.Mode,
+		ResumeURL:   state.ResumeURL,
+		Binary:      e.binary,
+		Egress:      e.egress,
+		Events:      e.platform,
+		Workflows:   e.runner,
+		Depth:       options.Depth,
 	}
 	
 	// Get credentials if specified
@@ -217,9 +743,14 @@ func (e *Engine) executeFromNode(
 	}
 	
 	// Evaluate expressions in config
-	evaluatedConfig, err := e.evaluateConfig(nodeDef.Config, state.NodeOutputs, inputData, options)
+	evaluatedConfig, err := e.evaluateConfig(nodeDef.Config, state, task.outputs, inputData, items, itemIndex, options)
 	if err != nil {
-		return fmt.Errorf("failed to evaluate config: %w", err)
+		return nil, fmt.Errorf("failed to evaluate config: %w", err)
+	}
+	
+	nodeItems := items
+	if perItem {
+		nodeItems = items[itemIndex : itemIndex+1]
 	}
 	
 	// Execute node
@@ -227,103 +758,155 @@ func (e *Engine) executeFromNode(
 		NodeID:      nodeID,
 		NodeConfig:  evaluatedConfig,
 		InputData:   inputData,
+		Items:       nodeItems,
 		Credentials: credentials,
 		Context:     execCtx,
 	}
 	
-	state.Logs = append(state.Logs, runtime.LogEntry{
-		Level:     "info",
-		Message:   fmt.Sprintf("Executing node: %s (%s)", nodeDef.Name, nodeDef.Type),
-		Timestamp: time.Now().UnixMilli(),
-		NodeID:    nodeID,
-	})
+	settings := nodeSettings(workflow, nodeDef)
+	failedInput := inputData
+	if itemIndex < len(items) {
+		failedInput = items[itemIndex].JSON
+	}
 	
-	output, err := executor.Execute(ctx, input)
+	output, err := e.executeWithSettings(ctx, task, executor, input, settings)
 	if err != nil {
-		return fmt.Errorf("node %s execution failed: %w", nodeID, err)
+		if settings.OnError == NodeOnErrorContinue || settings.OnError == NodeOnErrorOutput {
+			return failedNodeOutput(task, failedInput, err, settings.OnError), nil
+		}
+		return nil, fmt.Errorf("node %s execution failed: %w", nodeID, err)
+	}
+	if output.Data == nil {
+		output.Data = make(map[string]interface{})
 	}
 	
 	if output.Error != nil {
 		// Handle error based on settings
-		if workflow.Settings.ErrorHandling == "stop" {
-			return fmt.Errorf("node %s error: %w", nodeID, output.Error)
+		switch {
+		case settings.OnError == NodeOnErrorContinue || settings.OnError == NodeOnErrorOutput:
+			task.logs = append(task.logs, output.Logs...)
+			return failedNodeOutput(task, failedInput, output.Error, settings.OnError), nil
+		case settings.OnError == NodeOnErrorStop || workflow.Settings.ErrorHandling == "stop":
+			return nil, fmt.Errorf("node %s error: %w", nodeID, output.Error)
 		}
 		// Continue on error
-		state.Logs = append(state.Logs, runtime.LogEntry{
-			Level:     "warn",
-			Message:   fmt.Sprintf("Node %s error (continuing): %v", nodeID, output.Error),
-			Timestamp: time.Now().UnixMilli(),
-			NodeID:    nodeID,
-		})
-	}
-	
-	// Store output
-	state.NodeOutputs[nodeID] = output.Data
-	state.Logs = append(state.Logs, output.Logs...)
-	
-	// Determine next nodes
-	nextNodes := graph[nodeID]
-	
-	// Handle branching (IF/Switch)
-	if outputPort, ok := output.Data["_output"].(string); ok {
-		// Find connections from this specific port
-		nextNodes = []string{}
-		for _, conn := range workflow.Connections {
-			if conn.SourceNodeID == nodeID && conn.SourcePort == outputPort {
-				nextNodes = append(nextNodes, conn.TargetNodeID)
-			}
-		}
+		task.log("warn", fmt.Sprintf("Node %s error (continuing): %v", nodeID, output.Error))
 	}
 	
-	// Execute next nodes
-	for _, nextNodeID := range nextNodes {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if err := e.executeFromNode(ctx, workflow, state, nextNodeID, graph, options); err != nil {
-				return err
-			}
-		}
-	}
+	task.logs = append(task.logs, output.Logs...)
 	
-	return nil
+	return output, nil
 }
 
-func (e *Engine) buildNodeInput(workflow *WorkflowDefinition, state *ExecutionState, nodeID string) map[string]interface{} {
+func (e *Engine) buildNodeInput(graph *executionGraph, tracker *joinTracker, state *ExecutionState, nodeID string) map[string]interface{} {
 	input := make(map[string]interface{})
 	
-	// Find incoming connections
-	for _, conn := range workflow.Connections {
-		if conn.TargetNodeID == nodeID {
-			if sourceOutput, ok := state.NodeOutputs[conn.SourceNodeID]; ok {
-				// Merge data from source
-				for k, v := range sourceOutput {
-					if k != "_output" && k != "_loopState" {
-						input[k] = v
-					}
+	// Collect data from incoming connections that actually fired
+	for _, idx := range graph.inbound[nodeID] {
+		if !tracker.isFired(idx) {
+			continue
+		}
+		conn := graph.conns[idx]
+		if sourceOutput, ok := state.NodeOutputs[conn.SourceNodeID]; ok {
+			// Merge data from source
+			for k, v := range sourceOutput {
+				if k != "_output" && k != "_loopState" {
+					input[k] = v
 				}
-				
-				// Also set input by port name
-				input[conn.TargetPort] = sourceOutput
 			}
+			
+			// Also set input by port name
+			input[conn.TargetPort] = sourceOutput
 		}
 	}
 	
 	return input
 }
 
+// buildNodeItems concatenates the items delivered on every fired connection
+// of conns, in connection order
+func (e *Engine) buildNodeItems(graph *executionGraph, tracker *joinTracker, state *ExecutionState, conns []int) []runtime.Item {
+	var items []runtime.Item
+	
+	for input, idx := range conns {
+		if !tracker.isFired(idx) {
+			continue
+		}
+		conn := graph.conns[idx]
+		portItems, ok := state.NodeItems[conn.SourceNodeID]
+		if !ok {
+			continue
+		}
+		
+		// Unbranched nodes store their items under "" and feed every port
+		sourceItems, ok := portItems[conn.SourcePort]
+		if !ok {
+			sourceItems = portItems[""]
+		}
+		
+		for i, item := range sourceItems {
+			items = append(items, runtime.Item{
+				JSON:       item.JSON,
+				Binary:     item.Binary,
+				PairedItem: &runtime.PairedItem{Item: i, Input: input},
+			})
+		}
+	}
+	
+	return items
+}
+
+// nodeOutputItems returns the items of a node output. Binary data the node
+// attached to the output goes with its item.
+func nodeOutputItems(output *runtime.ExecutionOutput) []runtime.Item {
+	if output.Items != nil {
+		return output.Items
+	}
+	items := runtime.ItemsFromData(output.Data)
+	if len(output.Binary) > 0 && len(items) == 1 {
+		items[0].Binary = output.Binary
+	}
+	return items
+}
+
+// removeNode removes the first occurrence of a node from a list of node IDs
+func removeNode(nodeIDs []string, nodeID string) []string {
+	for i, id := range nodeIDs {
+		if id == nodeID {
+			return append(nodeIDs[:i], nodeIDs[i+1:]...)
+		}
+	}
+	return nodeIDs
+}
+
+func copyData(data map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		result[k] = v
+	}
+	return result
+}
+
 func (e *Engine) evaluateConfig(
 	config map[string]interface{},
+	state *ExecutionState,
 	nodeOutputs map[string]map[string]interface{},
 	inputData map[string]interface{},
+	items []runtime.Item,
+	itemIndex int,
 	options *ExecutionOptions,
 ) (map[string]interface{}, error) {
 	// Create expression context
 	ctx := expression.NewContext()
+	if len(items) > 0 {
+		ctx.SetItems(runtime.ItemsJSON(items), itemIndex)
+	}
 	ctx.SetInput(inputData)
 	ctx.Env = options.Environment
 	ctx.Variables = options.Variables
+	ctx.Execution.ID = state.ID
+	ctx.Execution.Mode = options.Mode
+	ctx.Execution.ResumeURL = state.ResumeURL
 	
 	// Add node outputs to context
 	for nodeID, output := range nodeOutputs {
@@ -334,6 +917,55 @@ func (e *Engine) evaluateConfig(
 	return e.parser.EvaluateTemplate(config, ctx)
 }
 
+// triggerModes maps trigger node types to the execution mode they start
+var triggerModes = map[string]string{
+	"manual_trigger":           "manual",
+	"webhook_trigger":          "webhook",
+	"schedule_trigger":         "schedule",
+	"interval_trigger":         "schedule",
+	"error_trigger":            "error",
+	"execute_workflow_trigger": subWorkflowMode,
+}
+
+// findTriggerNode returns the entry point of an execution: the trigger named
+// by the options, else the first trigger of a type matching the mode, else
+// the first trigger. Only nodes reachable from it are executed, so the
+// subgraphs of the workflow's other triggers are skipped.
+func (e *Engine) findTriggerNode(workflow *WorkflowDefinition, options *ExecutionOptions) (*NodeDefinition, error) {
+	var first, forMode *NodeDefinition
+	for i := range workflow.Nodes {
+		node := &workflow.Nodes[i]
+		meta, err := e.getNodeMetadata(node.Type)
+		isTrigger := err == nil && meta.IsTrigger
+
+		if options.TriggerNodeID != "" && node.ID == options.TriggerNodeID {
+			if !isTrigger {
+				return nil, fmt.Errorf("node %s is not a trigger", node.ID)
+			}
+			return node, nil
+		}
+		if !isTrigger {
+			continue
+		}
+		if first == nil {
+			first = node
+		}
+		if forMode == nil && triggerModes[node.Type] == options.Mode {
+			forMode = node
+		}
+	}
+	
+	switch {
+	case options.TriggerNodeID != "":
+		return nil, fmt.Errorf("trigger node %s not found", options.TriggerNodeID)
+	case forMode != nil:
+		return forMode, nil
+	case first != nil:
+		return first, nil
+	}
+	return nil, fmt.Errorf("workflow has no trigger node")
+}
+
 func (e *Engine) getNodeMetadata(nodeType string) (runtime.NodeMetadata, error) {
 	executor, err := runtime.Get(nodeType)
 	if err != nil {
@@ -346,6 +978,7 @@ func (e *Engine) getNodeMetadata(nodeType string) (runtime.NodeMetadata, error)
 func (e *Engine) CancelExecution(executionID string) error {
 	e.mu.RLock()
 	state, exists := e.executions[executionID]
+	live := exists && state.live
 	e.mu.RUnlock()
 	
 	if !exists {
@@ -355,11 +988,27 @@ func (e *Engine) CancelExecution(executionID string) error {
 	if state.cancel != nil {
 		state.cancel()
 	}
+	e.stopTimer(executionID)
+	
+	// A running execution records its cancellation once it stops
+	if live {
+		return nil
+	}
 	
 	state.Status = "cancelled"
 	now := time.Now()
 	state.CompletedAt = &now
 	
+	if e.repo != nil {
+		if record, err := e.repo.FindByID(context.Background(), executionID); err == nil {
+			record.Status = ExecutionStatusCancelled
+			record.CompletedAt = &now
+			record.Checkpoint = nil
+			record.WaitUntil = nil
+			e.repo.Update(context.Background(), record)
+		}
+	}
+	
 	return nil
 }
 
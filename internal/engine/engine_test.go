@@ -0,0 +1,1487 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime/nodes"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
+	"github.com/linkflow-ai/linkflow-ai/internal/shared/events"
+)
+
+// testNode is a minimal executor that counts its runs and optionally selects
+// an output port taken from its config
+type testNode struct {
+	nodeType  string
+	isTrigger bool
+	runOnce   bool
+	mu        sync.Mutex
+	runs      map[string]int
+	active    int
+	maxActive int
+}
+
+func (n *testNode) GetType() string { return n.nodeType }
+
+func (n *testNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{Type: n.nodeType, IsTrigger: n.isTrigger, RunOnceForAllItems: n.runOnce}
+}
+
+func (n *testNode) Validate(config map[string]interface{}) error { return nil }
+
+func (n *testNode) RunsOnceForAllItems(config map[string]interface{}) bool {
+	_, ok := config["batch"]
+	return ok
+}
+
+func (n *testNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	n.mu.Lock()
+	n.runs[input.NodeID]++
+	runs := n.runs[input.NodeID]
+	n.mu.Unlock()
+
+	if failTimes, ok := input.NodeConfig["failTimes"].(int); ok && runs <= failTimes {
+		return nil, errors.New("simulated failure")
+	}
+
+	if gate, ok := input.NodeConfig["gate"].(chan struct{}); ok {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if _, ok := input.NodeConfig["sleep"]; ok {
+		time.Sleep(time.Second)
+	}
+	if _, ok := input.NodeConfig["hold"]; ok {
+		n.mu.Lock()
+		n.active++
+		if n.active > n.maxActive {
+			n.maxActive = n.active
+		}
+		n.mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		n.mu.Lock()
+		n.active--
+		n.mu.Unlock()
+	}
+
+	data := map[string]interface{}{"from": input.NodeID}
+	if port, ok := input.NodeConfig["port"].(string); ok {
+		data["_output"] = port
+	}
+	if property, ok := input.NodeConfig["readBinary"].(string); ok && len(input.Items) > 0 {
+		content, err := input.Context.Binary.Open(ctx, input.Items[0].Binary[property].ID)
+		if err != nil {
+			return nil, err
+		}
+		defer content.Close()
+		b, _ := io.ReadAll(content)
+		data["content"] = string(b)
+	}
+	output := &runtime.ExecutionOutput{Data: data}
+	if attach, ok := input.NodeConfig["attach"].(string); ok {
+		stored, err := input.Context.Binary.Put(ctx, "file.txt", "text/plain", strings.NewReader(attach))
+		if err != nil {
+			return nil, err
+		}
+		output.Binary = map[string]*runtime.BinaryData{"data": stored}
+	}
+	if emit, ok := input.NodeConfig["emit"].(int); ok {
+		for i := 0; i < emit; i++ {
+			output.Items = append(output.Items, runtime.NewItem(map[string]interface{}{"n": i}))
+		}
+	}
+	if _, ok := input.NodeConfig["park"]; ok {
+		output.Wait = &runtime.WaitRequest{ResumeAt: time.Now().Add(time.Hour)}
+	}
+	if _, ok := input.NodeConfig["webhook"]; ok {
+		output.Wait = &runtime.WaitRequest{Webhook: true}
+	}
+	return output, nil
+}
+
+func (n *testNode) count(nodeID string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.runs[nodeID]
+}
+
+var (
+	testTrigger  = &testNode{nodeType: "engine_test_trigger", isTrigger: true, runs: map[string]int{}}
+	testAction   = &testNode{nodeType: "engine_test_action", runs: map[string]int{}}
+	testJoin     = &testNode{nodeType: "engine_test_join", runOnce: true, runs: map[string]int{}}
+	testParallel = &testNode{nodeType: "engine_test_parallel", runs: map[string]int{}}
+	testLimited  = &testNode{nodeType: "engine_test_limited", runs: map[string]int{}}
+)
+
+func init() {
+	runtime.Register(testTrigger)
+	runtime.Register(testAction)
+	runtime.Register(testJoin)
+	runtime.Register(testParallel)
+	runtime.Register(testLimited)
+	runtime.Register(nodes.NewLoopNode())
+}
+
+func TestEngine_JoinRunsMultiInputNodeOnce(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "diamond",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "left", Type: "engine_test_action"},
+			{ID: "right", Type: "engine_test_action"},
+			{ID: "join", Type: "engine_test_join"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "left"},
+			{SourceNodeID: "trigger", TargetNodeID: "right"},
+			{SourceNodeID: "left", TargetNodeID: "join", TargetPort: "input1"},
+			{SourceNodeID: "right", TargetNodeID: "join", TargetPort: "input2"},
+		},
+	}
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", state.Status)
+	assert.Equal(t, 1, testJoin.count("join"))
+	assert.Len(t, state.NodeItems["join"][""], 1)
+}
+
+func TestEngine_JoinWaitsForPrunedBranch(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "branch",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "if", Type: "engine_test_action", Config: map[string]interface{}{"port": "true"}},
+			{ID: "onTrue", Type: "engine_test_action"},
+			{ID: "onFalse", Type: "engine_test_action"},
+			{ID: "afterFalse", Type: "engine_test_action"},
+			{ID: "merge", Type: "engine_test_join"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "if"},
+			{SourceNodeID: "if", SourcePort: "true", TargetNodeID: "onTrue"},
+			{SourceNodeID: "if", SourcePort: "false", TargetNodeID: "onFalse"},
+			{SourceNodeID: "onFalse", TargetNodeID: "afterFalse"},
+			{SourceNodeID: "onTrue", TargetNodeID: "merge", TargetPort: "input1"},
+			{SourceNodeID: "afterFalse", TargetNodeID: "merge", TargetPort: "input2"},
+		},
+	}
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, testJoin.count("merge"))
+	assert.Equal(t, 0, testAction.count("onFalse"))
+	assert.Equal(t, 0, testAction.count("afterFalse"))
+	assert.NotContains(t, state.NodeOutputs, "afterFalse")
+}
+
+func TestEngine_RunsNodePerItem(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "items",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "left", Type: "engine_test_action"},
+			{ID: "right", Type: "engine_test_action"},
+			{ID: "each", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "left"},
+			{SourceNodeID: "trigger", TargetNodeID: "right"},
+			{SourceNodeID: "left", TargetNodeID: "each"},
+			{SourceNodeID: "right", TargetNodeID: "each"},
+		},
+	}
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, testAction.count("each"))
+
+	items := state.NodeItems["each"][""]
+	require.Len(t, items, 2)
+	assert.Equal(t, 1, items[1].PairedItem.Item)
+}
+
+func TestEngine_RunsBatchOperationOnceForAllItems(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "batch",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger", Config: map[string]interface{}{"emit": 3}},
+			{ID: "batch", Type: "engine_test_action", Config: map[string]interface{}{"batch": true}},
+			{ID: "single", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "batch"},
+			{SourceNodeID: "trigger", TargetNodeID: "single"},
+		},
+	}
+
+	_, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, testAction.count("batch"))
+	assert.Equal(t, 3, testAction.count("single"))
+}
+
+func TestEngine_RepliesFromRespondToWebhookNode(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "respond",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "reply", Type: "respond_to_webhook", Config: map[string]interface{}{
+				"statusCode": 201,
+				"headers":    map[string]interface{}{"X-Request": "{{ $json.from }}"},
+			}},
+			{ID: "again", Type: "respond_to_webhook", Config: map[string]interface{}{"statusCode": 500}},
+			{ID: "replied", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "reply"},
+			{SourceNodeID: "reply", TargetNodeID: "again"},
+			{SourceNodeID: "again", TargetNodeID: "replied"},
+		},
+	}
+
+	var responses []*runtime.WebhookResponse
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{
+		Mode: "webhook",
+		Respond: func(resp *runtime.WebhookResponse) {
+			responses = append(responses, resp)
+		},
+	})
+	require.NoError(t, err)
+
+	// Only the first node replies, and the execution goes on after it
+	require.Len(t, responses, 1)
+	assert.Equal(t, 201, responses[0].StatusCode)
+	assert.Equal(t, "trigger", responses[0].Headers["X-Request"])
+	assert.Equal(t, map[string]interface{}{"from": "trigger"}, responses[0].Body)
+	assert.Equal(t, 1, testAction.count("replied"))
+	assert.Equal(t, "replied", state.Result.LastOutput()["from"])
+}
+
+func TestEngine_PassesBinaryDataByReference(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "binary",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "download", Type: "engine_test_action", Config: map[string]interface{}{"attach": "file content"}},
+			{ID: "upload", Type: "engine_test_action", Config: map[string]interface{}{"readBinary": "data"}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "download"},
+			{SourceNodeID: "download", TargetNodeID: "upload"},
+		},
+	}
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+
+	downloaded := state.NodeItems["download"][""]
+	require.Len(t, downloaded, 1)
+	require.Contains(t, downloaded[0].Binary, "data")
+	assert.Equal(t, int64(len("file content")), downloaded[0].Binary["data"].Size)
+	assert.Equal(t, "file content", state.NodeOutputs["upload"]["content"])
+}
+
+func TestEngine_HoldsNodeRequestsToEgressPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	workflow := &WorkflowDefinition{
+		ID: "egress",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "request", Type: "http_request", Config: map[string]interface{}{"url": server.URL}, Settings: NodeSettings{OnError: NodeOnErrorStop}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "request"},
+		},
+	}
+
+	// Loopback addresses are blocked by default and reported
+	var violations []egress.Violation
+	guard := egress.NewGuard(egress.Policy{}).WithViolationHandler(func(ctx context.Context, v egress.Violation) {
+		violations = append(violations, v)
+	})
+	_, err := NewEngine().WithEgressGuard(guard).Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual", WorkspaceID: "ws-1"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, egress.ErrBlocked)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "ws-1", violations[0].WorkspaceID)
+	assert.Equal(t, "loopback address", violations[0].Reason)
+
+	// The base policy can let them through, and a workspace can deny them again
+	guard = egress.NewGuard(egress.Policy{AllowPrivate: true}).WithPolicyLoader(func(ctx context.Context, workspaceID string) (*egress.Policy, error) {
+		if workspaceID == "locked" {
+			return &egress.Policy{Deny: []string{"127.0.0.0/8"}}, nil
+		}
+		return nil, nil
+	})
+	state, err := NewEngine().WithEgressGuard(guard).Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual", WorkspaceID: "open"})
+	require.NoError(t, err)
+	assert.Equal(t, true, state.NodeOutputs["request"]["ok"])
+
+	_, err = NewEngine().WithEgressGuard(guard).Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual", WorkspaceID: "locked"})
+	assert.ErrorIs(t, err, egress.ErrBlocked)
+}
+
+func TestEngine_ParksAndResumesFromCheckpoint(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "parked",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "wait", Type: "engine_test_action", Config: map[string]interface{}{"park": true}},
+			{ID: "after", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "wait"},
+			{SourceNodeID: "wait", TargetNodeID: "after"},
+		},
+	}
+
+	repo := NewInMemoryExecutionRepository()
+	state, err := NewEngine().WithRepository(repo).Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, "waiting", state.Status)
+	assert.Equal(t, 0, testAction.count("after"))
+
+	record, err := repo.FindByID(context.Background(), state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionStatusWaiting, record.Status)
+	require.NotNil(t, record.Checkpoint)
+	assert.Equal(t, "wait", record.Checkpoint.ParkedNode)
+	require.NotNil(t, record.WaitUntil)
+
+	// A fresh engine, as after a restart, picks the run up from the checkpoint
+	resumed, err := NewEngine().WithRepository(repo).Resume(context.Background(), state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", resumed.Status)
+	assert.Equal(t, 1, testAction.count("wait"))
+	assert.Equal(t, 1, testAction.count("after"))
+	record, err = repo.FindByID(context.Background(), state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionStatusCompleted, record.Status)
+	assert.Nil(t, record.Checkpoint)
+}
+
+func TestEngine_ResumesInterruptedExecutionOnOneReplica(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "leased",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "leasedWait", Type: "engine_test_action", Config: map[string]interface{}{"park": true}},
+			{ID: "leasedAfter", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "leasedWait"},
+			{SourceNodeID: "leasedWait", TargetNodeID: "leasedAfter"},
+		},
+	}
+	repo := NewInMemoryExecutionRepository()
+	state, err := NewEngine().WithRepository(repo).Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+
+	// The replica that resumed it stopped before its lease ran out
+	_, err = repo.Claim(context.Background(), state.ID, "stopped", time.Millisecond)
+	require.NoError(t, err)
+	replicas := []*Engine{
+		NewEngine().WithRepository(repo).WithExecutionLease("replica-1", time.Minute),
+		NewEngine().WithRepository(repo).WithExecutionLease("replica-2", time.Minute),
+	}
+	assert.Eventually(t, func() bool {
+		interrupted, err := replicas[0].RecoverExecutions(context.Background(), time.Hour)
+		return err == nil && len(interrupted) == 1
+	}, time.Second, time.Millisecond)
+
+	// Both replicas find it interrupted, only one resumes it
+	errs := make(chan error, len(replicas))
+	for _, replica := range replicas {
+		interrupted, err := replica.RecoverExecutions(context.Background(), time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, []string{state.ID}, interrupted)
+		go func(replica *Engine) {
+			_, err := replica.Resume(context.Background(), state.ID)
+			errs <- err
+		}(replica)
+	}
+	var resumed int
+	for range replicas {
+		if err := <-errs; err == nil {
+			resumed++
+		}
+	}
+	assert.Equal(t, 1, resumed)
+	assert.Equal(t, 1, testAction.count("leasedAfter"))
+	record, err := repo.FindByID(context.Background(), state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionStatusCompleted, record.Status)
+}
+
+func TestEngine_RenewsLeaseOfRunningExecution(t *testing.T) {
+	gate := make(chan struct{})
+	workflow := &WorkflowDefinition{
+		ID: "renewed",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "renewedWork", Type: "engine_test_action", Config: map[string]interface{}{"gate": gate}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "renewedWork"},
+		},
+	}
+	repo := NewInMemoryExecutionRepository()
+	eng := NewEngine().WithRepository(repo).WithExecutionLease("running", 30*time.Millisecond)
+	done := make(chan *ExecutionState, 1)
+	go func() {
+		state, _ := eng.Execute(context.Background(), workflow, &ExecutionOptions{ExecutionID: "renewed-run", Mode: "manual"})
+		done <- state
+	}()
+
+	// Long after its first lease ran out the run is still held
+	time.Sleep(150 * time.Millisecond)
+	other := NewEngine().WithRepository(repo)
+	interrupted, err := other.RecoverExecutions(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Empty(t, interrupted)
+	_, err = other.Resume(context.Background(), "renewed-run")
+	assert.ErrorIs(t, err, ErrExecutionClaimed)
+
+	close(gate)
+	state := <-done
+	require.NotNil(t, state)
+	assert.Equal(t, "completed", state.Status)
+}
+
+func TestEngine_ParksNodeRunPerItem(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "parked-items",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "left", Type: "engine_test_action"},
+			{ID: "right", Type: "engine_test_action"},
+			{ID: "wait-each", Type: "engine_test_action", Config: map[string]interface{}{"park": true}},
+			{ID: "after-each", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "left"},
+			{SourceNodeID: "trigger", TargetNodeID: "right"},
+			{SourceNodeID: "left", TargetNodeID: "wait-each"},
+			{SourceNodeID: "right", TargetNodeID: "wait-each"},
+			{SourceNodeID: "wait-each", TargetNodeID: "after-each"},
+		},
+	}
+
+	repo := NewInMemoryExecutionRepository()
+	state, err := NewEngine().WithRepository(repo).Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, "waiting", state.Status)
+	assert.Equal(t, 2, testAction.count("wait-each"))
+	assert.Equal(t, 0, testAction.count("after-each"))
+
+	record, err := repo.FindByID(context.Background(), state.ID)
+	require.NoError(t, err)
+	require.NotNil(t, record.Checkpoint)
+	assert.Equal(t, "wait-each", record.Checkpoint.ParkedNode)
+
+	resumed, err := NewEngine().WithRepository(repo).Resume(context.Background(), state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", resumed.Status)
+	assert.Equal(t, 2, testAction.count("wait-each"))
+	assert.Equal(t, 2, testAction.count("after-each"))
+}
+
+func TestEngine_ResumesWebhookWaitWithPostedBody(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "approval",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "approval", Type: "engine_test_action", Config: map[string]interface{}{"webhook": true}},
+			{ID: "approved", Type: "engine_test_action"},
+			{ID: "expired", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "approval"},
+			{SourceNodeID: "approval", TargetNodeID: "approved"},
+			{SourceNodeID: "approval", SourcePort: "timeout", TargetNodeID: "expired"},
+		},
+	}
+
+	repo := NewInMemoryExecutionRepository()
+	eng := NewEngine().WithRepository(repo)
+	state, err := eng.Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, "waiting", state.Status)
+	assert.Contains(t, state.ResumeURL, state.ID)
+
+	record, err := repo.FindByID(context.Background(), state.ID)
+	require.NoError(t, err)
+	require.NotNil(t, record.Checkpoint)
+	assert.Nil(t, record.WaitUntil)
+
+	_, err = eng.ResumeWebhook(context.Background(), state.ID, "wrong", nil)
+	assert.Error(t, err)
+
+	token := record.Checkpoint.ResumeToken
+	resumed, err := eng.ResumeWebhook(context.Background(), state.ID, token, map[string]interface{}{"approved": true})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", resumed.Status)
+	assert.Equal(t, true, resumed.NodeOutputs["approval"]["approved"])
+	assert.Equal(t, 1, testAction.count("approved"))
+	assert.Equal(t, 0, testAction.count("expired"))
+}
+
+func TestEngine_RetriesFailingNode(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "retry",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "flaky", Type: "engine_test_action", Config: map[string]interface{}{"failTimes": 2}, Settings: NodeSettings{
+				RetryOnFail:      true,
+				MaxTries:         3,
+				WaitBetweenTries: time.Millisecond,
+				Backoff:          NodeBackoffExponential,
+			}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "flaky"},
+		},
+	}
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", state.Status)
+	assert.Equal(t, 3, testAction.count("flaky"))
+}
+
+func TestEngine_RoutesNodeErrorToErrorOutput(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "errorOutput",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "failing", Type: "engine_test_action", Config: map[string]interface{}{"failTimes": 1}, Settings: NodeSettings{
+				OnError: NodeOnErrorOutput,
+			}},
+			{ID: "succeeded", Type: "engine_test_action"},
+			{ID: "handleError", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "failing"},
+			{SourceNodeID: "failing", TargetNodeID: "succeeded"},
+			{SourceNodeID: "failing", SourcePort: "error", TargetNodeID: "handleError"},
+		},
+	}
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", state.Status)
+	assert.Equal(t, 0, testAction.count("succeeded"))
+	assert.Equal(t, 1, testAction.count("handleError"))
+	assert.Contains(t, state.NodeOutputs["failing"]["error"], "simulated failure")
+}
+
+func TestEngine_TimesOutSlowNode(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "timeout",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "slow", Type: "engine_test_action", Config: map[string]interface{}{"sleep": true}, Settings: NodeSettings{
+				Timeout: 10 * time.Millisecond,
+			}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "slow"},
+		},
+	}
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrNodeTimeout)
+	assert.Equal(t, "failed", state.Status)
+}
+
+func TestExecutionEvent_ErrorMessageOfFailedExecution(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "failedEvent",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "failedEventAction", Type: "engine_test_action", Config: map[string]interface{}{"failTimes": 1}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "failedEventAction"},
+		},
+	}
+	eng := NewEngine()
+	failed := make(chan ExecutionEvent, 1)
+	eng.Events().On(EventTypeExecutionFailed, func(event ExecutionEvent) { failed <- event })
+
+	_, err := eng.Execute(context.Background(), workflow, &ExecutionOptions{Mode: "webhook"})
+	require.Error(t, err)
+
+	select {
+	case event := <-failed:
+		assert.Equal(t, err.Error(), event.ErrorMessage())
+		assert.Contains(t, event.ErrorMessage(), "simulated failure")
+	case <-time.After(time.Second):
+		t.Fatal("no failed event")
+	}
+
+	// Skipped executions report their reason
+	skipped := ExecutionEvent{Type: EventTypeExecutionSkipped, Data: map[string]interface{}{"reason": "previous run active"}}
+	assert.Equal(t, "previous run active", skipped.ErrorMessage())
+	assert.Empty(t, ExecutionEvent{Type: EventTypeExecutionCompleted, Data: map[string]interface{}{}}.ErrorMessage())
+}
+
+func TestEngine_RunsIndependentNodesInParallel(t *testing.T) {
+	hold := map[string]interface{}{"hold": true}
+	workflow := &WorkflowDefinition{
+		ID: "parallel",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "a", Type: "engine_test_parallel", Config: hold},
+			{ID: "b", Type: "engine_test_parallel", Config: hold},
+			{ID: "c", Type: "engine_test_parallel", Config: hold},
+			{ID: "gather", Type: "engine_test_join"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "a"},
+			{SourceNodeID: "trigger", TargetNodeID: "b"},
+			{SourceNodeID: "trigger", TargetNodeID: "c"},
+			{SourceNodeID: "a", TargetNodeID: "gather", TargetPort: "input1"},
+			{SourceNodeID: "b", TargetNodeID: "gather", TargetPort: "input2"},
+			{SourceNodeID: "c", TargetNodeID: "gather", TargetPort: "input3"},
+		},
+	}
+
+	state, err := NewEngine().WithMaxParallel(2).Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", state.Status)
+	assert.Equal(t, 2, testParallel.maxActive)
+	assert.Equal(t, 1, testJoin.count("gather"))
+	assert.Len(t, state.NodeResults, 5)
+}
+
+func TestAdvancedExecutor_FollowsSelectedBranch(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "advancedBranch",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "check", Type: "engine_test_action", Config: map[string]interface{}{"port": "true"}},
+			{ID: "whenTrue", Type: "engine_test_action"},
+			{ID: "whenFalse", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "check"},
+			{SourceNodeID: "check", SourcePort: "true", TargetNodeID: "whenTrue"},
+			{SourceNodeID: "check", SourcePort: "false", TargetNodeID: "whenFalse"},
+		},
+	}
+
+	executor := NewAdvancedExecutor(NewEngine(), nil, nil)
+	completed := make(chan ExecutionEvent, 1)
+	executor.Events().On(EventTypeExecutionCompleted, func(event ExecutionEvent) {
+		completed <- event
+	})
+
+	result, err := executor.ExecuteWorkflow(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionStatusCompleted, result.Status)
+	assert.Equal(t, 0, testAction.count("whenFalse"))
+	assert.Contains(t, result.NodeResults, "whenTrue")
+	assert.NotContains(t, result.NodeResults, "whenFalse")
+	assert.Contains(t, result.Outputs, "whenTrue")
+
+	select {
+	case event := <-completed:
+		assert.Equal(t, result.ExecutionID, event.ExecutionID)
+	case <-time.After(time.Second):
+		t.Fatal("execution completed event not emitted")
+	}
+}
+
+func loopWorkflow(id string, loopConfig map[string]interface{}) *WorkflowDefinition {
+	return &WorkflowDefinition{
+		ID: id,
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger", Config: map[string]interface{}{"emit": 5}},
+			{ID: "loop", Type: "loop", Config: loopConfig},
+			{ID: id + "Body", Type: "engine_test_action"},
+			{ID: id + "After", Type: "engine_test_join"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "loop"},
+			{SourceNodeID: "loop", SourcePort: "loop", TargetNodeID: id + "Body"},
+			{SourceNodeID: id + "Body", TargetNodeID: "loop"},
+			{SourceNodeID: "loop", SourcePort: "done", TargetNodeID: id + "After"},
+		},
+	}
+}
+
+func TestEngine_LoopRunsBodyPerBatch(t *testing.T) {
+	workflow := loopWorkflow("batches", map[string]interface{}{"batchSize": 2})
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", state.Status)
+	assert.Equal(t, 5, testAction.count("batchesBody"))
+	assert.Equal(t, 1, testJoin.count("batchesAfter"))
+	assert.Len(t, state.NodeItems["loop"]["done"], 5)
+	assert.Len(t, state.NodeItems["batchesAfter"][""], 1)
+}
+
+func TestEngine_LoopStopsAtMaxIterations(t *testing.T) {
+	workflow := loopWorkflow("guarded", map[string]interface{}{"batchSize": 1, "maxIterations": 2})
+
+	state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeded 2 iterations")
+	assert.Equal(t, "failed", state.Status)
+	assert.Equal(t, 0, testJoin.count("guardedAfter"))
+}
+
+func multiTriggerWorkflow() *WorkflowDefinition {
+	return &WorkflowDefinition{
+		ID: "multiTrigger",
+		Nodes: []NodeDefinition{
+			{ID: "manual", Type: "engine_test_trigger"},
+			{ID: "hook", Type: "webhook_trigger"},
+			{ID: "fromManual", Type: "engine_test_action"},
+			{ID: "fromHook", Type: "engine_test_action"},
+			{ID: "shared", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "manual", TargetNodeID: "fromManual"},
+			{SourceNodeID: "hook", TargetNodeID: "fromHook"},
+			{SourceNodeID: "fromManual", TargetNodeID: "shared"},
+			{SourceNodeID: "fromHook", TargetNodeID: "shared"},
+		},
+	}
+}
+
+func TestEngine_StartsFromNamedTrigger(t *testing.T) {
+	state, err := NewEngine().Execute(context.Background(), multiTriggerWorkflow(), &ExecutionOptions{
+		Mode:          "manual",
+		TriggerNodeID: "hook",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", state.Status)
+	assert.Equal(t, 1, testAction.count("fromHook"))
+	assert.Equal(t, 0, testAction.count("fromManual"))
+	assert.NotContains(t, state.NodeOutputs, "manual")
+	assert.Contains(t, state.NodeOutputs, "shared")
+}
+
+func TestEngine_PicksTriggerMatchingMode(t *testing.T) {
+	state, err := NewEngine().Execute(context.Background(), multiTriggerWorkflow(), &ExecutionOptions{Mode: "webhook"})
+	require.NoError(t, err)
+	assert.Contains(t, state.NodeOutputs, "hook")
+	assert.NotContains(t, state.NodeOutputs, "manual")
+}
+
+func TestEngine_RejectsUnknownTrigger(t *testing.T) {
+	_, err := NewEngine().Execute(context.Background(), multiTriggerWorkflow(), &ExecutionOptions{TriggerNodeID: "missing"})
+	assert.Error(t, err)
+
+	_, err = NewEngine().Execute(context.Background(), multiTriggerWorkflow(), &ExecutionOptions{TriggerNodeID: "fromManual"})
+	assert.Error(t, err)
+}
+
+func TestEngine_StartsErrorWorkflowForFailedExecution(t *testing.T) {
+	failing := &WorkflowDefinition{
+		ID:   "failingOrders",
+		Name: "Orders",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "charge", Name: "Charge card", Type: "engine_test_action", Config: map[string]interface{}{"failTimes": 1}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "charge"},
+		},
+		Settings: WorkflowSettings{ErrorWorkflowID: "onError"},
+	}
+	handler := &WorkflowDefinition{
+		ID: "onError",
+		Nodes: []NodeDefinition{
+			{ID: "otherErrors", Type: "error_trigger", Config: map[string]interface{}{"errorMode": "specific", "nodeNames": "Ship order"}},
+			{ID: "chargeErrors", Type: "error_trigger", Config: map[string]interface{}{"errorMode": "specific", "nodeNames": "Ship order, Charge card"}},
+			{ID: "ignored", Type: "engine_test_action"},
+			{ID: "notify", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "otherErrors", TargetNodeID: "ignored"},
+			{SourceNodeID: "chargeErrors", TargetNodeID: "notify"},
+		},
+	}
+
+	eng := NewEngine().WithWorkflowLoader(func(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+		require.Equal(t, "onError", workflowID)
+		return handler, nil
+	})
+	handled := make(chan ExecutionEvent, 1)
+	eng.Events().On(EventTypeExecutionCompleted, func(event ExecutionEvent) {
+		if event.WorkflowID == "onError" {
+			handled <- event
+		}
+	})
+
+	state, err := eng.Execute(context.Background(), failing, &ExecutionOptions{Mode: "manual"})
+	require.Error(t, err)
+	assert.Equal(t, "failed", state.Status)
+
+	var event ExecutionEvent
+	select {
+	case event = <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("error workflow not started")
+	}
+
+	errorState, err := eng.GetExecution(event.ExecutionID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, testAction.count("notify"))
+	assert.Equal(t, 0, testAction.count("ignored"))
+
+	caught := errorState.NodeOutputs["chargeErrors"]
+	assert.Equal(t, state.ID, caught["executionId"])
+	assert.Equal(t, "failingOrders", caught["workflowId"])
+	assert.Equal(t, "charge", caught["nodeId"])
+	assert.Equal(t, "Charge card", caught["nodeName"])
+	assert.Contains(t, caught["message"], "simulated failure")
+	assert.Equal(t, "/api/v1/executions/"+state.ID+"/retry", caught["retryUrl"])
+	assert.NotNil(t, caught["lastNodeInput"])
+}
+
+func TestEngine_DoesNotChainErrorWorkflows(t *testing.T) {
+	failing := &WorkflowDefinition{
+		ID: "failingHandler",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "report", Type: "engine_test_action", Config: map[string]interface{}{"failTimes": 1}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "report"},
+		},
+		Settings: WorkflowSettings{ErrorWorkflowID: "anotherHandler"},
+	}
+
+	loaded := make(chan string, 1)
+	eng := NewEngine().WithWorkflowLoader(func(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+		loaded <- workflowID
+		return nil, errors.New("unexpected load")
+	})
+
+	state, err := eng.Execute(context.Background(), failing, &ExecutionOptions{Mode: "error"})
+	require.Error(t, err)
+	assert.Equal(t, "failed", state.Status)
+
+	select {
+	case id := <-loaded:
+		t.Fatalf("error workflow %s started for a failed error workflow", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func scheduledWorkflow(id string) *WorkflowDefinition {
+	return &WorkflowDefinition{
+		ID: id,
+		Nodes: []NodeDefinition{
+			{ID: "every", Type: "interval_trigger", Config: map[string]interface{}{"interval": 1, "unit": "hours"}},
+			{ID: id + "Work", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "every", TargetNodeID: id + "Work"},
+		},
+	}
+}
+
+func TestScheduler_ExecutesEachRunOnceAcrossReplicas(t *testing.T) {
+	workflow := scheduledWorkflow("replicated")
+	eng := NewEngine().WithWorkflowLoader(func(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+		return workflow, nil
+	})
+
+	claimer := NewInMemoryRunClaimer()
+	replicas := []*Scheduler{
+		NewScheduler(eng, nil, nil, nil).WithRunClaimer(claimer),
+		NewScheduler(eng, nil, nil, nil).WithRunClaimer(claimer),
+	}
+
+	runAt := time.Now().Truncate(time.Hour)
+	for _, s := range replicas {
+		require.NoError(t, s.SyncWorkflow(context.Background(), workflow))
+		schedule, err := s.GetSchedule("replicated:every")
+		require.NoError(t, err)
+		s.executeScheduledWorkflow(schedule, runAt)
+	}
+
+	assert.Eventually(t, func() bool { return testAction.count("replicatedWork") == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, testAction.count("replicatedWork"))
+}
+
+func TestScheduler_CatchesUpMissedRuns(t *testing.T) {
+	workflow := scheduledWorkflow("missed")
+	eng := NewEngine().WithWorkflowLoader(func(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+		return workflow, nil
+	})
+
+	// The last run was claimed three hours ago
+	claimer := NewInMemoryRunClaimer()
+	_, err := claimer.Claim(context.Background(), "missed:every", time.Now().Truncate(time.Hour).Add(-3*time.Hour))
+	require.NoError(t, err)
+
+	skipping := NewScheduler(eng, nil, nil, &SchedulerConfig{MissedRunPolicy: MissedRunSkip}).WithRunClaimer(claimer)
+	require.NoError(t, skipping.SyncWorkflow(context.Background(), workflow))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, testAction.count("missedWork"))
+
+	catchingUp := NewScheduler(eng, nil, nil, &SchedulerConfig{MissedRunPolicy: MissedRunCatchup}).WithRunClaimer(claimer)
+	require.NoError(t, catchingUp.SyncWorkflow(context.Background(), workflow))
+	assert.Eventually(t, func() bool { return testAction.count("missedWork") == 3 }, time.Second, 10*time.Millisecond)
+}
+
+func TestMissedRuns_AlignsIntervalsAcrossReplicas(t *testing.T) {
+	schedule, err := parseSchedule("", 15*time.Minute)
+	require.NoError(t, err)
+
+	since := time.Date(2024, 1, 1, 10, 7, 0, 0, time.UTC)
+	now := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	runs := MissedRuns(schedule, since, now, MissedRunCatchup)
+	require.Len(t, runs, 4)
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC), runs[0])
+	assert.Equal(t, now, runs[3])
+
+	assert.Empty(t, MissedRuns(schedule, since, now, MissedRunSkip))
+}
+
+func TestEngine_QueuesExecutionsBeyondMaxConcurrency(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "limited",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "limitedWork", Type: "engine_test_limited", Config: map[string]interface{}{"hold": true}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "limitedWork"},
+		},
+		Settings: WorkflowSettings{MaxConcurrency: 1},
+	}
+
+	eng := NewEngine()
+	var queued int32
+	eng.Events().On(EventTypeExecutionQueued, func(event ExecutionEvent) {
+		atomic.AddInt32(&queued, 1)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state, err := eng.Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+			assert.NoError(t, err)
+			assert.Equal(t, "completed", state.Status)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, testLimited.count("limitedWork"))
+	assert.Equal(t, 1, testLimited.maxActive)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&queued) == 2 }, time.Second, 10*time.Millisecond)
+}
+
+// gatedSchedule schedules a workflow whose runs block until the returned
+// gate is closed
+func gatedSchedule(t *testing.T, id, overlap string) (*Scheduler, *ScheduleEntry, chan struct{}) {
+	gate := make(chan struct{})
+	workflow := &WorkflowDefinition{
+		ID: id,
+		Nodes: []NodeDefinition{
+			{ID: "every", Type: "interval_trigger", Config: map[string]interface{}{"interval": 1, "unit": "hours", "overlap": overlap}},
+			{ID: id + "Work", Type: "engine_test_action", Config: map[string]interface{}{"gate": gate}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "every", TargetNodeID: id + "Work"},
+		},
+	}
+	eng := NewEngine().WithWorkflowLoader(func(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+		return workflow, nil
+	})
+
+	s := NewScheduler(eng, nil, nil, nil)
+	require.NoError(t, s.SyncWorkflow(context.Background(), workflow))
+	schedule, err := s.GetSchedule(id + ":every")
+	require.NoError(t, err)
+	return s, schedule, gate
+}
+
+// statusCounter counts the executions of an engine by the status their
+// events report
+type statusCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func countStatuses(eng *Engine) *statusCounter {
+	c := &statusCounter{counts: map[string]int{}}
+	record := func(status string) EventHandler {
+		return func(event ExecutionEvent) {
+			key := status
+			if key == "" {
+				key, _ = event.Data["status"].(string)
+			}
+			c.mu.Lock()
+			c.counts[key]++
+			c.mu.Unlock()
+		}
+	}
+	eng.Events().On(EventTypeExecutionStarted, record("started"))
+	eng.Events().On(EventTypeExecutionQueued, record("queued"))
+	eng.Events().On(EventTypeExecutionSkipped, record("skipped"))
+	eng.Events().On(EventTypeExecutionCompleted, record(""))
+	eng.Events().On(EventTypeExecutionFailed, record(""))
+	return c
+}
+
+func (c *statusCounter) reaches(want map[string]int) func() bool {
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for status, n := range want {
+			if c.counts[status] != n {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestScheduler_SkipsRunWhilePreviousIsActive(t *testing.T) {
+	s, schedule, gate := gatedSchedule(t, "overlapSkip", OverlapSkip)
+	statuses := countStatuses(s.engine)
+	runAt := time.Now().Truncate(time.Hour)
+
+	s.executeScheduledWorkflow(schedule, runAt)
+	s.executeScheduledWorkflow(schedule, runAt.Add(time.Hour))
+	assert.Eventually(t, statuses.reaches(map[string]int{"started": 1, "skipped": 1}), time.Second, 10*time.Millisecond)
+
+	close(gate)
+	assert.Eventually(t, statuses.reaches(map[string]int{"completed": 1}), time.Second, 10*time.Millisecond)
+
+	// Once the previous run finished, the next one starts
+	s.executeScheduledWorkflow(schedule, runAt.Add(2*time.Hour))
+	assert.Eventually(t, statuses.reaches(map[string]int{"completed": 2, "skipped": 1}), time.Second, 10*time.Millisecond)
+}
+
+func TestScheduler_QueuesRunBehindPreviousRun(t *testing.T) {
+	s, schedule, gate := gatedSchedule(t, "overlapQueue", OverlapQueue)
+	statuses := countStatuses(s.engine)
+	runAt := time.Now().Truncate(time.Hour)
+
+	s.executeScheduledWorkflow(schedule, runAt)
+	s.executeScheduledWorkflow(schedule, runAt.Add(time.Hour))
+	assert.Eventually(t, statuses.reaches(map[string]int{"started": 1, "queued": 1}), time.Second, 10*time.Millisecond)
+
+	close(gate)
+	assert.Eventually(t, statuses.reaches(map[string]int{"started": 2, "completed": 2}), time.Second, 10*time.Millisecond)
+}
+
+func TestScheduler_CancelsPreviousRun(t *testing.T) {
+	s, schedule, gate := gatedSchedule(t, "overlapCancel", OverlapCancel)
+	defer close(gate)
+	statuses := countStatuses(s.engine)
+	runAt := time.Now().Truncate(time.Hour)
+
+	s.executeScheduledWorkflow(schedule, runAt)
+	assert.Eventually(t, statuses.reaches(map[string]int{"started": 1}), time.Second, 10*time.Millisecond)
+
+	s.executeScheduledWorkflow(schedule, runAt.Add(time.Hour))
+	assert.Eventually(t, statuses.reaches(map[string]int{"started": 2, "cancelled": 1}), time.Second, 10*time.Millisecond)
+}
+
+func TestScheduler_PollsTriggerForNewItems(t *testing.T) {
+	var mu sync.Mutex
+	issues := []map[string]interface{}{{"id": 1}, {"id": 2}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues})
+	}))
+	defer server.Close()
+
+	workflow := &WorkflowDefinition{
+		ID: "polled",
+		Nodes: []NodeDefinition{
+			{ID: "poll", Type: "http_poll_trigger", Config: map[string]interface{}{"url": server.URL, "itemsPath": "issues", "interval": 1, "unit": "hours"}},
+			{ID: "polledWork", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "poll", TargetNodeID: "polledWork"},
+		},
+	}
+	eng := NewEngine().WithEgressGuard(egress.NewGuard(egress.Policy{AllowPrivate: true})).
+		WithWorkflowLoader(func(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+			return workflow, nil
+		})
+	s := NewScheduler(eng, nil, nil, nil)
+	require.NoError(t, s.SyncWorkflow(context.Background(), workflow))
+	schedule, err := s.GetSchedule("polled:poll")
+	require.NoError(t, err)
+	runAt := time.Now().Truncate(time.Hour)
+
+	// The first poll only records the items already there
+	s.executeScheduledWorkflow(schedule, runAt)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, testAction.count("polledWork"))
+
+	mu.Lock()
+	issues = append(issues, map[string]interface{}{"id": 3}, map[string]interface{}{"id": 4})
+	mu.Unlock()
+	s.executeScheduledWorkflow(schedule, runAt.Add(time.Hour))
+	assert.Eventually(t, func() bool { return testAction.count("polledWork") == 2 }, time.Second, 10*time.Millisecond)
+
+	// Items seen before do not start executions again
+	s.executeScheduledWorkflow(schedule, runAt.Add(2*time.Hour))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, testAction.count("polledWork"))
+
+	state, err := s.pollStates.Load(context.Background(), "polled:poll")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, state.Seen)
+}
+
+func TestScheduler_PolledItemsOfRejectedSubmissionArePolledAgain(t *testing.T) {
+	var mu sync.Mutex
+	issues := []map[string]interface{}{{"id": 1}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues})
+	}))
+	defer server.Close()
+
+	workflow := &WorkflowDefinition{
+		ID: "repolled",
+		Nodes: []NodeDefinition{
+			{ID: "poll", Type: "http_poll_trigger", Config: map[string]interface{}{"url": server.URL, "itemsPath": "issues", "interval": 1, "unit": "hours"}},
+			{ID: "repolledWork", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "poll", TargetNodeID: "repolledWork"},
+		},
+	}
+	eng := NewEngine().WithEgressGuard(egress.NewGuard(egress.Policy{AllowPrivate: true})).
+		WithWorkflowLoader(func(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+			return workflow, nil
+		})
+	// A pool without workers or queue rejects every submission
+	pool := NewWorkerPool(eng, &PoolConfig{MaxWorkers: 1})
+	s := NewScheduler(eng, pool, nil, nil)
+	require.NoError(t, s.SyncWorkflow(context.Background(), workflow))
+	schedule, err := s.GetSchedule("repolled:poll")
+	require.NoError(t, err)
+	runAt := time.Now().Truncate(time.Hour)
+	s.executeScheduledWorkflow(schedule, runAt)
+
+	mu.Lock()
+	issues = append(issues, map[string]interface{}{"id": 2})
+	mu.Unlock()
+	s.executeScheduledWorkflow(schedule, runAt.Add(time.Hour))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, testAction.count("repolledWork"))
+	state, err := s.pollStates.Load(context.Background(), "repolled:poll")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, state.Seen, "items of a rejected run are not marked seen")
+
+	// The next poll that starts a run delivers them
+	s.pool = nil
+	s.executeScheduledWorkflow(schedule, runAt.Add(2*time.Hour))
+	assert.Eventually(t, func() bool { return testAction.count("repolledWork") == 1 }, time.Second, 10*time.Millisecond)
+	state, err = s.pollStates.Load(context.Background(), "repolled:poll")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, state.Seen)
+}
+
+func TestSubscriptions_StartExecutionsForPlatformEvents(t *testing.T) {
+	bus := events.NewBus()
+	eng := NewEngine().WithEventBus(bus)
+	workflow := &WorkflowDefinition{
+		ID:     "subscribed",
+		UserID: "owner",
+		Nodes: []NodeDefinition{
+			{ID: "onFailure", Type: "event_trigger", Config: map[string]interface{}{"events": "execution.failed"}},
+			{ID: "subscribedWork", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "onFailure", TargetNodeID: "subscribedWork"},
+		},
+	}
+
+	subscriptions := NewSubscriptions(eng, nil)
+	defer subscriptions.Stop()
+	require.NoError(t, subscriptions.SyncWorkflow(context.Background(), workflow))
+	time.Sleep(50 * time.Millisecond)
+
+	publish := func(eventType events.EventType, userID string) {
+		event, err := events.NewEvent(eventType, "exec-1", "execution", events.ExecutionFailedData{ExecutionID: "exec-1"})
+		require.NoError(t, err)
+		require.NoError(t, bus.Publish(context.Background(), event.WithUser(userID)))
+	}
+
+	// Only events of the subscribed types and of the owner start executions;
+	// events of no user need the trigger to opt in to them
+	publish(events.ExecutionFailed, "owner")
+	publish(events.ExecutionCompleted, "owner")
+	publish(events.ExecutionFailed, "someone-else")
+	publish(events.ExecutionFailed, "")
+	assert.Eventually(t, func() bool { return testAction.count("subscribedWork") == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, testAction.count("subscribedWork"))
+
+	workflow.Nodes[0].Config = map[string]interface{}{"events": "execution.failed", "platformEvents": true}
+	require.NoError(t, subscriptions.SyncWorkflow(context.Background(), workflow))
+	time.Sleep(50 * time.Millisecond)
+	publish(events.ExecutionFailed, "")
+	assert.Eventually(t, func() bool { return testAction.count("subscribedWork") == 2 }, time.Second, 10*time.Millisecond)
+
+	subscriptions.RemoveWorkflow("subscribed")
+	publish(events.ExecutionFailed, "owner")
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, testAction.count("subscribedWork"))
+}
+
+// pausingHook holds each node until released, then replaces its input
+type pausingHook struct {
+	entered chan string
+	release chan struct{}
+	mu      sync.Mutex
+	exited  []string
+}
+
+func (h *pausingHook) BeforeNode(ctx context.Context, input *NodeHookInput) error {
+	h.entered <- input.NodeID
+	select {
+	case <-h.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	input.Data = map[string]interface{}{"modified": input.NodeID}
+	return nil
+}
+
+func (h *pausingHook) AfterNode(ctx context.Context, input *NodeHookInput, output map[string]interface{}, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.exited = append(h.exited, input.NodeID)
+}
+
+func TestEngine_PausesAtNodeHookWithModifiedInput(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "hooked",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "hooked-action", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "hooked-action"},
+		},
+	}
+	hook := &pausingHook{entered: make(chan string), release: make(chan struct{})}
+
+	type result struct {
+		state *ExecutionState
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		state, err := NewEngine().Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual", Hook: hook})
+		done <- result{state, err}
+	}()
+
+	for _, nodeID := range []string{"trigger", "hooked-action"} {
+		assert.Equal(t, nodeID, <-hook.entered)
+		select {
+		case <-done:
+			t.Fatal("execution went on while paused")
+		case <-time.After(20 * time.Millisecond):
+		}
+		hook.release <- struct{}{}
+	}
+
+	res := <-done
+	require.NoError(t, res.err)
+	assert.Equal(t, "completed", res.state.Status)
+	assert.Equal(t, map[string]interface{}{"modified": "hooked-action"}, res.state.NodeResults["hooked-action"].Input)
+	assert.Equal(t, []string{"trigger", "hooked-action"}, hook.exited)
+}
+
+func TestEngine_RerunsFromNodeWithRecordedUpstreamOutputs(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "rerun",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "rerun-check", Type: "engine_test_action", Config: map[string]interface{}{"port": "true"}},
+			{ID: "rerun-flaky", Type: "engine_test_action", Config: map[string]interface{}{"failTimes": 1}},
+			{ID: "rerun-false", Type: "engine_test_action"},
+			{ID: "rerun-after", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "rerun-check"},
+			{SourceNodeID: "rerun-check", SourcePort: "true", TargetNodeID: "rerun-flaky"},
+			{SourceNodeID: "rerun-check", SourcePort: "false", TargetNodeID: "rerun-false"},
+			{SourceNodeID: "rerun-flaky", TargetNodeID: "rerun-after"},
+		},
+	}
+	eng := NewEngine()
+
+	original, err := eng.Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.Error(t, err)
+	assert.Equal(t, ExecutionStatusFailed, original.NodeResults["rerun-flaky"].Status)
+
+	rerun := RerunNodes(workflow, "rerun-flaky")
+	assert.Equal(t, map[string]bool{"rerun-flaky": true, "rerun-after": true}, rerun)
+	recorded := make(map[string]*RecordedNode)
+	for nodeID, result := range original.NodeResults {
+		if !rerun[nodeID] && result.Status == ExecutionStatusCompleted {
+			recorded[nodeID] = &RecordedNode{Output: result.Output, Items: original.NodeItems[nodeID]}
+		}
+	}
+
+	state, err := eng.Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual", Recorded: recorded})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", state.Status)
+	assert.Equal(t, 1, testAction.count("rerun-check"), "recorded node ran again")
+	assert.Equal(t, 2, testAction.count("rerun-flaky"))
+	assert.Equal(t, 1, testAction.count("rerun-after"))
+	assert.NotContains(t, state.NodeResults, "rerun-false", "recorded branch not followed")
+	assert.Equal(t, map[string]interface{}{"from": "rerun-flaky"}, state.NodeOutputs["rerun-flaky"])
+}
+
+func TestEngine_OutputsPinnedDataInManualExecutionsOnly(t *testing.T) {
+	workflow := &WorkflowDefinition{
+		ID: "pinned",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "pinned-fetch", Type: "engine_test_action", PinnedData: []map[string]interface{}{{"n": 1}, {"n": 2}}},
+			{ID: "pinned-after", Type: "engine_test_action", Config: map[string]interface{}{"batch": true}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "pinned-fetch"},
+			{SourceNodeID: "pinned-fetch", TargetNodeID: "pinned-after"},
+		},
+	}
+	eng := NewEngine()
+
+	state, err := eng.Execute(context.Background(), workflow, &ExecutionOptions{Mode: "manual"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, testAction.count("pinned-fetch"), "pinned node ran")
+	assert.Equal(t, 1, testAction.count("pinned-after"))
+	assert.Equal(t, []interface{}{map[string]interface{}{"n": 1}, map[string]interface{}{"n": 2}}, state.NodeOutputs["pinned-fetch"]["items"])
+	assert.Len(t, state.NodeItems["pinned-fetch"][""], 2)
+
+	state, err = eng.Execute(context.Background(), workflow, &ExecutionOptions{Mode: "schedule", TriggerNodeID: "trigger"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, testAction.count("pinned-fetch"), "pins used outside manual executions")
+	assert.Equal(t, map[string]interface{}{"from": "pinned-fetch"}, state.NodeOutputs["pinned-fetch"])
+
+	// Retries and re-runs of an execution run the real nodes
+	for i, mode := range []string{"retry", "rerun"} {
+		state, err = eng.Execute(context.Background(), workflow, &ExecutionOptions{Mode: mode})
+		require.NoError(t, err)
+		assert.Equal(t, 2+i, testAction.count("pinned-fetch"), "pins used in %s executions", mode)
+		assert.Equal(t, map[string]interface{}{"from": "pinned-fetch"}, state.NodeOutputs["pinned-fetch"])
+	}
+}
+
+// testWorkflowRunner runs the workflows called by nodes on the engine, as
+// the sub-workflow executor does
+type testWorkflowRunner struct {
+	engine    *Engine
+	workflows map[string]*WorkflowDefinition
+	children  []*ExecutionState
+	options   []*ExecutionOptions
+}
+
+func (r *testWorkflowRunner) RunWorkflow(ctx context.Context, call *runtime.WorkflowCall) (*runtime.WorkflowCallResult, error) {
+	options := &ExecutionOptions{
+		Mode:        subWorkflowMode,
+		TriggerData: call.Input,
+		Depth:       call.Caller.Depth + 1,
+		ParentID:    call.Caller.ExecutionID,
+	}
+	state, err := r.engine.Execute(ctx, r.workflows[call.WorkflowID], options)
+	if err != nil {
+		return nil, err
+	}
+	r.children, r.options = append(r.children, state), append(r.options, options)
+	return &runtime.WorkflowCallResult{ExecutionID: state.ID, Status: state.Status, Output: state.Result.LastOutput()}, nil
+}
+
+func TestEngine_ExecuteWorkflowNodeRunsCalledWorkflow(t *testing.T) {
+	child := &WorkflowDefinition{
+		ID:      "called",
+		Version: 3,
+		Nodes: []NodeDefinition{
+			{ID: "manual", Type: "engine_test_trigger"},
+			{ID: "called-trigger", Type: "execute_workflow_trigger"},
+			{ID: "called-action", Type: "engine_test_action"},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "manual", TargetNodeID: "called-action"},
+			{SourceNodeID: "called-trigger", TargetNodeID: "called-action"},
+		},
+	}
+	parent := &WorkflowDefinition{
+		ID: "calling",
+		Nodes: []NodeDefinition{
+			{ID: "trigger", Type: "engine_test_trigger"},
+			{ID: "calling-call", Type: "execute_workflow", Config: map[string]interface{}{"workflowId": "called"}},
+		},
+		Connections: []Connection{
+			{SourceNodeID: "trigger", TargetNodeID: "calling-call"},
+		},
+	}
+	eng := NewEngine()
+	runner := &testWorkflowRunner{engine: eng, workflows: map[string]*WorkflowDefinition{"called": child}}
+	eng.WithWorkflowRunner(runner)
+
+	state, err := eng.Execute(context.Background(), parent, &ExecutionOptions{Mode: "manual", TriggerData: map[string]interface{}{"n": 1}})
+	require.NoError(t, err)
+	require.Len(t, runner.children, 1)
+	called := runner.children[0]
+	assert.Equal(t, "completed", called.Status)
+	assert.Contains(t, called.NodeResults, "called-trigger", "started from the execute workflow trigger")
+	assert.NotContains(t, called.NodeResults, "manual")
+	assert.Equal(t, map[string]interface{}{"from": "called-action"}, state.NodeOutputs["calling-call"])
+
+	options := runner.options[0]
+	assert.Equal(t, state.ID, options.ParentID)
+	assert.Equal(t, 1, options.Depth)
+	assert.Equal(t, map[string]interface{}{"mode": subWorkflowMode, "workflowVersion": 3, "parentExecutionId": state.ID, "depth": 1}, executionEventData(child, options))
+}
+
+func TestExecutionEventData_NamesTheTriggerOfTheExecution(t *testing.T) {
+	workflow := &WorkflowDefinition{ID: "wf", Version: 2}
+	data := executionEventData(workflow, &ExecutionOptions{Mode: "webhook", TriggerNodeID: "orders"})
+	assert.Equal(t, map[string]interface{}{"mode": "webhook", "triggerNodeId": "orders", "workflowVersion": 2}, data)
+}
@@ -0,0 +1,137 @@
+// Package engine provides error workflows for failed executions
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errorMode is the execution mode of error workflows. Failures of executions
+// in this mode never start another error workflow.
+const errorMode = "error"
+
+// errorTriggerType is the node type that error workflows start from
+const errorTriggerType = "error_trigger"
+
+// WorkflowLoader loads the definition of a workflow by ID, used to start the
+// error workflow of a failed execution
+type WorkflowLoader func(ctx context.Context, workflowID string) (*WorkflowDefinition, error)
+
+// WithWorkflowLoader enables error workflows by letting the engine load them
+func (e *Engine) WithWorkflowLoader(loader WorkflowLoader) *Engine {
+	e.workflows = loader
+	return e
+}
+
+// WithRetryURL sets the base URL that retry links of failed executions are
+// built from; the execution ID and "retry" are appended as path segments
+func (e *Engine) WithRetryURL(baseURL string) *Engine {
+	e.retryBase = baseURL
+	return e
+}
+
+func (e *Engine) retryURL(executionID string) string {
+	return fmt.Sprintf("%s/%s/retry", e.retryBase, executionID)
+}
+
+// startErrorWorkflow starts the error workflow of a failed execution in the
+// background. The workflow is started from its first error trigger whose
+// filter matches the failing node; if none matches, nothing is started.
+func (e *Engine) startErrorWorkflow(run *executionRun, err error) {
+	errorWorkflowID := run.workflow.Settings.ErrorWorkflowID
+	if errorWorkflowID == "" || e.workflows == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	if run.options.Mode == errorMode || errorWorkflowID == run.workflow.ID {
+		run.warn(fmt.Sprintf("Not starting error workflow %s for a failed error workflow", errorWorkflowID))
+		return
+	}
+
+	payload := e.errorPayload(run, err)
+	options := &ExecutionOptions{
+		Mode:        errorMode,
+		TriggerData: payload,
+		Variables:   run.options.Variables,
+		Environment: run.options.Environment,
+		UserID:      run.options.UserID,
+		WorkspaceID: run.options.WorkspaceID,
+	}
+
+	failedNode := run.graph.nodes[run.failedNode]
+
+	go func() {
+		ctx := context.Background()
+		workflow, err := e.workflows(ctx, errorWorkflowID)
+		if err != nil {
+			// The failed execution has already finished; there is nothing
+			// left to report the missing error workflow to
+			return
+		}
+
+		for _, node := range workflow.Nodes {
+			if node.Type == errorTriggerType && errorTriggerMatches(node.Config, failedNode) {
+				options.TriggerNodeID = node.ID
+				e.Execute(ctx, workflow, options)
+				return
+			}
+		}
+	}()
+}
+
+// errorPayload describes a failed execution to its error workflow
+func (e *Engine) errorPayload(run *executionRun, err error) map[string]interface{} {
+	state := run.state
+	payload := map[string]interface{}{
+		"executionId":  state.ID,
+		"workflowId":   run.workflow.ID,
+		"workflowName": run.workflow.Name,
+		"mode":         run.options.Mode,
+		"message":      err.Error(),
+		"stack":        errorStack(err),
+		"retryUrl":     e.retryURL(state.ID),
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}
+
+	if nodeDef := run.graph.nodes[run.failedNode]; nodeDef != nil {
+		payload["nodeId"] = nodeDef.ID
+		payload["nodeName"] = nodeDef.Name
+		payload["nodeType"] = nodeDef.Type
+		if result := state.NodeResults[nodeDef.ID]; result != nil {
+			payload["lastNodeInput"] = result.Input
+		}
+	}
+	return payload
+}
+
+// errorStack lists the chain of wrapped errors, outermost first
+func errorStack(err error) string {
+	var lines []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// errorTriggerMatches applies the errorMode and nodeNames filter of an error
+// trigger to the node that failed. In specific mode the node is matched by
+// name or ID.
+func errorTriggerMatches(config map[string]interface{}, failedNode *NodeDefinition) bool {
+	if mode, _ := config["errorMode"].(string); mode != "specific" {
+		return true
+	}
+	if failedNode == nil {
+		return false
+	}
+
+	names, _ := config["nodeNames"].(string)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && (name == failedNode.Name || name == failedNode.ID) {
+			return true
+		}
+	}
+	return false
+}
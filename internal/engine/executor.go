@@ -1,4 +1,4 @@
-// Package engine provides advanced workflow execution
+// Package engine provides execution results, events and queued execution
 package engine
 
 import (
@@ -9,88 +9,47 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
-	"github.com/linkflow-ai/linkflow-ai/pkg/expression"
 )
 
-// AdvancedExecutor provides advanced workflow execution capabilities
+// AdvancedExecutor adds queued node execution and event subscription on top
+// of an Engine, which it runs every workflow on
 type AdvancedExecutor struct {
 	engine       *Engine
-	parser       *expression.Parser
 	pool         *WorkerPool
 	queue        TaskQueue
 	eventEmitter *EventEmitter
-	mu           sync.RWMutex
 }
 
 // NewAdvancedExecutor creates a new advanced executor
 func NewAdvancedExecutor(engine *Engine, pool *WorkerPool, queue TaskQueue) *AdvancedExecutor {
 	return &AdvancedExecutor{
 		engine:       engine,
-		parser:       expression.NewParser(),
 		pool:         pool,
 		queue:        queue,
-		eventEmitter: NewEventEmitter(),
+		eventEmitter: engine.Events(),
 	}
 }
 
-// ExecuteWorkflow executes a workflow with advanced features
-func (e *AdvancedExecutor) ExecuteWorkflow(ctx context.Context, workflow *WorkflowDefinition, options *ExecutionOptions) (*ExecutionResult, error) {
-	executionID := uuid.New().String()
-	startTime := time.Now()
-
-	result := &ExecutionResult{
-		ExecutionID: executionID,
-		WorkflowID:  workflow.ID,
-		Status:      ExecutionStatusRunning,
-		StartedAt:   startTime,
-		NodeResults: make(map[string]*NodeResult),
-		Logs:        []ExecutionLog{},
-	}
-
-	// Emit execution started event
-	e.eventEmitter.Emit(ExecutionEvent{
-		Type:        EventTypeExecutionStarted,
-		ExecutionID: executionID,
-		WorkflowID:  workflow.ID,
-		Timestamp:   startTime,
-	})
-
-	// Build execution plan
-	plan, err := e.buildExecutionPlan(workflow)
-	if err != nil {
-		result.Status = ExecutionStatusFailed
-		result.Error = err.Error()
-		return result, err
-	}
-
-	// Execute plan
-	err = e.executePlan(ctx, plan, workflow, options, result)
-	
-	endTime := time.Now()
-	result.CompletedAt = &endTime
-	result.DurationMs = endTime.Sub(startTime).Milliseconds()
-
-	if err != nil {
-		result.Status = ExecutionStatusFailed
-		result.Error = err.Error()
-	} else {
-		result.Status = ExecutionStatusCompleted
-	}
-
-	// Emit execution completed event
-	e.eventEmitter.Emit(ExecutionEvent{
-		Type:        EventTypeExecutionCompleted,
-		ExecutionID: executionID,
-		WorkflowID:  workflow.ID,
-		Timestamp:   endTime,
-		Data: map[string]interface{}{
-			"status":     result.Status,
-			"durationMs": result.DurationMs,
-		},
-	})
+// Events returns the emitter that execution and node events are sent to
+func (e *AdvancedExecutor) Events() *EventEmitter {
+	return e.eventEmitter
+}
 
-	return result, err
+// ExecuteWorkflow executes a workflow on the engine and returns its result
+func (e *AdvancedExecutor) ExecuteWorkflow(ctx context.Context, workflow *WorkflowDefinition, options *ExecutionOptions) (*ExecutionResult, error) {
+	state, err := e.engine.Execute(ctx, workflow, options)
+	if state.Result == nil {
+		// The execution failed before any node ran
+		return &ExecutionResult{
+			ExecutionID: state.ID,
+			WorkflowID:  workflow.ID,
+			Status:      ExecutionStatusFailed,
+			StartedAt:   state.StartedAt,
+			NodeResults: state.NodeResults,
+			Error:       err.Error(),
+		}, err
+	}
+	return state.Result, err
 }
 
 // ExecutionResult holds the complete result of a workflow execution
@@ -140,316 +99,66 @@ const (
 	ExecutionStatusFailed    ExecutionStatus = "failed"
 	ExecutionStatusCancelled ExecutionStatus = "cancelled"
 	ExecutionStatusPaused    ExecutionStatus = "paused"
+	ExecutionStatusWaiting   ExecutionStatus = "waiting"
+	ExecutionStatusQueued    ExecutionStatus = "queued"  // Waiting for a concurrency limit
+	ExecutionStatusSkipped   ExecutionStatus = "skipped" // Not started because an earlier run was still active
 )
 
-// ExecutionPlan represents an execution plan
-type ExecutionPlan struct {
-	Stages     [][]*PlanNode // Nodes grouped by stage (parallel execution within stage)
-	TriggerID  string
-	TotalNodes int
-}
-
-// PlanNode represents a node in the execution plan
-type PlanNode struct {
-	ID           string
-	Type         string
-	Name         string
-	Config       map[string]interface{}
-	Dependencies []string
-	Outputs      []string
-	Stage        int
-}
-
-func (e *AdvancedExecutor) buildExecutionPlan(workflow *WorkflowDefinition) (*ExecutionPlan, error) {
-	// Build dependency graph
-	nodeMap := make(map[string]*NodeDefinition)
-	for i := range workflow.Nodes {
-		nodeMap[workflow.Nodes[i].ID] = &workflow.Nodes[i]
-	}
-
-	// Build adjacency list and reverse adjacency (dependencies)
-	outputs := make(map[string][]string)    // node -> nodes it outputs to
-	inputs := make(map[string][]string)     // node -> nodes it receives from
-	inDegree := make(map[string]int)        // node -> number of inputs
-
-	for _, node := range workflow.Nodes {
-		outputs[node.ID] = []string{}
-		inputs[node.ID] = []string{}
-		inDegree[node.ID] = 0
-	}
-
-	for _, conn := range workflow.Connections {
-		outputs[conn.SourceNodeID] = append(outputs[conn.SourceNodeID], conn.TargetNodeID)
-		inputs[conn.TargetNodeID] = append(inputs[conn.TargetNodeID], conn.SourceNodeID)
-		inDegree[conn.TargetNodeID]++
-	}
-
-	// Find trigger node (in-degree 0 and is trigger type)
-	var triggerID string
-	for _, node := range workflow.Nodes {
-		if inDegree[node.ID] == 0 {
-			meta, err := e.engine.getNodeMetadata(node.Type)
-			if err == nil && meta.IsTrigger {
-				triggerID = node.ID
-				break
-			}
-		}
+// result builds the result of a run from its state. Outputs are taken from
+// the nodes that ran and have no outgoing connections.
+func (run *executionRun) result() *ExecutionResult {
+	state := run.state
+	result := &ExecutionResult{
+		ExecutionID: state.ID,
+		WorkflowID:  state.WorkflowID,
+		Status:      ExecutionStatus(state.Status),
+		StartedAt:   state.StartedAt,
+		CompletedAt: state.CompletedAt,
+		NodeResults: state.NodeResults,
+		Outputs:     make(map[string]interface{}),
+		Logs:        make([]ExecutionLog, 0, len(state.Logs)),
 	}
-
-	if triggerID == "" {
-		return nil, fmt.Errorf("no trigger node found")
+	if state.CompletedAt != nil {
+		result.DurationMs = state.CompletedAt.Sub(state.StartedAt).Milliseconds()
 	}
-
-	// Topological sort with stages (Kahn's algorithm with levels)
-	stages := make([][]*PlanNode, 0)
-	queue := []string{triggerID}
-	remaining := make(map[string]int)
-	for k, v := range inDegree {
-		remaining[k] = v
+	if state.Error != nil {
+		result.Error = state.Error.Error()
 	}
 
-	processed := make(map[string]bool)
-
-	for len(queue) > 0 {
-		stage := make([]*PlanNode, 0)
-		nextQueue := []string{}
-
-		for _, nodeID := range queue {
-			if processed[nodeID] {
-				continue
-			}
-
-			node := nodeMap[nodeID]
-			if node == nil {
-				continue
-			}
-
-			stage = append(stage, &PlanNode{
-				ID:           node.ID,
-				Type:         node.Type,
-				Name:         node.Name,
-				Config:       node.Config,
-				Dependencies: inputs[node.ID],
-				Outputs:      outputs[node.ID],
-				Stage:        len(stages),
-			})
-			processed[nodeID] = true
-
-			// Decrease in-degree of dependents
-			for _, outID := range outputs[nodeID] {
-				remaining[outID]--
-				if remaining[outID] == 0 {
-					nextQueue = append(nextQueue, outID)
-				}
-			}
+	for nodeID, output := range state.NodeOutputs {
+		if len(run.graph.outbound[nodeID]) == 0 {
+			result.Outputs[nodeID] = output
 		}
-
-		if len(stage) > 0 {
-			stages = append(stages, stage)
-		}
-		queue = nextQueue
-	}
-
-	// Check if all nodes were processed
-	if len(processed) != len(workflow.Nodes) {
-		return nil, fmt.Errorf("workflow contains cycles or disconnected nodes")
 	}
 
-	return &ExecutionPlan{
-		Stages:     stages,
-		TriggerID:  triggerID,
-		TotalNodes: len(workflow.Nodes),
-	}, nil
-}
-
-func (e *AdvancedExecutor) executePlan(
-	ctx context.Context,
-	plan *ExecutionPlan,
-	workflow *WorkflowDefinition,
-	options *ExecutionOptions,
-	result *ExecutionResult,
-) error {
-	nodeOutputs := make(map[string]map[string]interface{})
-
-	// Set trigger data
-	if options.TriggerData != nil {
-		nodeOutputs[plan.TriggerID] = options.TriggerData
-	}
-
-	// Execute stages sequentially, nodes within stage in parallel
-	for stageIdx, stage := range plan.Stages {
+	for _, entry := range state.Logs {
 		result.Logs = append(result.Logs, ExecutionLog{
-			Timestamp: time.Now(),
-			Level:     "info",
-			Message:   fmt.Sprintf("Starting stage %d with %d nodes", stageIdx, len(stage)),
+			Timestamp: time.UnixMilli(entry.Timestamp),
+			Level:     entry.Level,
+			NodeID:    entry.NodeID,
+			Message:   entry.Message,
 		})
-
-		// Execute nodes in parallel
-		var wg sync.WaitGroup
-		errChan := make(chan error, len(stage))
-		resultChan := make(chan *NodeResult, len(stage))
-
-		for _, planNode := range stage {
-			wg.Add(1)
-			go func(pn *PlanNode) {
-				defer wg.Done()
-
-				nodeResult := e.executeNode(ctx, pn, workflow, options, nodeOutputs)
-				resultChan <- nodeResult
-
-				if nodeResult.Error != "" {
-					errChan <- fmt.Errorf("node %s failed: %s", pn.ID, nodeResult.Error)
-				}
-			}(planNode)
-		}
-
-		// Wait for all nodes in stage to complete
-		wg.Wait()
-		close(errChan)
-		close(resultChan)
-
-		// Collect results
-		for nodeResult := range resultChan {
-			result.NodeResults[nodeResult.NodeID] = nodeResult
-			if nodeResult.Output != nil {
-				nodeOutputs[nodeResult.NodeID] = nodeResult.Output
-			}
-		}
-
-		// Check for errors
-		for err := range errChan {
-			if workflow.Settings.ErrorHandling == "stop" {
-				return err
-			}
-			// Log error and continue
-			result.Logs = append(result.Logs, ExecutionLog{
-				Timestamp: time.Now(),
-				Level:     "error",
-				Message:   err.Error(),
-			})
-		}
 	}
 
-	// Collect final outputs from nodes with no outgoing connections
-	result.Outputs = make(map[string]interface{})
-	for _, stage := range plan.Stages {
-		for _, node := range stage {
-			if len(node.Outputs) == 0 {
-				// This is an output node
-				if output, ok := nodeOutputs[node.ID]; ok {
-					result.Outputs[node.ID] = output
-				}
-			}
-		}
-	}
-
-	return nil
+	return result
 }
 
-func (e *AdvancedExecutor) executeNode(
-	ctx context.Context,
-	planNode *PlanNode,
-	workflow *WorkflowDefinition,
-	options *ExecutionOptions,
-	nodeOutputs map[string]map[string]interface{},
-) *NodeResult {
-	startTime := time.Now()
-	
-	result := &NodeResult{
-		NodeID:    planNode.ID,
-		NodeType:  planNode.Type,
-		Status:    ExecutionStatusRunning,
-		StartedAt: startTime,
-	}
-
-	// Get executor
-	executor, err := runtime.Get(planNode.Type)
-	if err != nil {
-		result.Status = ExecutionStatusFailed
-		result.Error = fmt.Sprintf("executor not found: %v", err)
-		return result
-	}
-
-	// Build input from dependencies
-	inputData := make(map[string]interface{})
-	for _, depID := range planNode.Dependencies {
-		if output, ok := nodeOutputs[depID]; ok {
-			for k, v := range output {
-				if k != "_output" && k != "_loopState" {
-					inputData[k] = v
-				}
-			}
+// LastOutput returns the output of the node that finished last among the
+// nodes with no outgoing connections, nil when none of them ran
+func (r *ExecutionResult) LastOutput() map[string]interface{} {
+	var last map[string]interface{}
+	var lastAt time.Time
+	for nodeID, output := range r.Outputs {
+		node := r.NodeResults[nodeID]
+		data, ok := output.(map[string]interface{})
+		if node == nil || node.CompletedAt == nil || !ok {
+			continue
 		}
-	}
-	result.Input = inputData
-
-	// Evaluate expressions in config
-	exprCtx := expression.NewContext()
-	exprCtx.SetInput(inputData)
-	exprCtx.Env = options.Environment
-	exprCtx.Variables = options.Variables
-	for nodeID, output := range nodeOutputs {
-		exprCtx.SetNodeOutput(nodeID, output)
-	}
-
-	evaluatedConfig, err := e.parser.EvaluateTemplate(planNode.Config, exprCtx)
-	if err != nil {
-		result.Status = ExecutionStatusFailed
-		result.Error = fmt.Sprintf("config evaluation failed: %v", err)
-		return result
-	}
-
-	// Get credentials
-	var credentials map[string]interface{}
-	for _, node := range workflow.Nodes {
-		if node.ID == planNode.ID && node.Credential != "" && options.Credentials != nil {
-			credentials = options.Credentials[node.Credential]
-			break
+		if last == nil || node.CompletedAt.After(lastAt) {
+			last, lastAt = data, *node.CompletedAt
 		}
 	}
-
-	// Build execution context
-	execCtx := &runtime.ExecutionContext{
-		ExecutionID: uuid.New().String(),
-		WorkflowID:  workflow.ID,
-		UserID:      options.UserID,
-		WorkspaceID: options.WorkspaceID,
-		Variables:   options.Variables,
-		Env:         options.Environment,
-		Mode:        options.Mode,
-	}
-
-	// Execute node
-	input := &runtime.ExecutionInput{
-		NodeID:      planNode.ID,
-		NodeConfig:  evaluatedConfig,
-		InputData:   inputData,
-		Credentials: credentials,
-		Context:     execCtx,
-	}
-
-	output, err := executor.Execute(ctx, input)
-	
-	endTime := time.Now()
-	result.CompletedAt = &endTime
-	result.DurationMs = endTime.Sub(startTime).Milliseconds()
-
-	if err != nil {
-		result.Status = ExecutionStatusFailed
-		result.Error = err.Error()
-		return result
-	}
-
-	if output.Error != nil {
-		result.Status = ExecutionStatusFailed
-		result.Error = output.Error.Error()
-		return result
-	}
-
-	result.Status = ExecutionStatusCompleted
-	result.Output = output.Data
-
-	return result
+	return last
 }
 
 // ExecuteNodeAsync executes a single node asynchronously
@@ -479,10 +188,17 @@ func (e *AdvancedExecutor) ExecuteNodeAsync(ctx context.Context, nodeID string,
 	return taskID, fmt.Errorf("no queue configured")
 }
 
-// GetExecutionStatus returns the status of an execution
+// GetExecutionStatus returns the result of an execution that has completed,
+// failed or parked
 func (e *AdvancedExecutor) GetExecutionStatus(executionID string) (*ExecutionResult, error) {
-	// Would need to track executions in a map or database
-	return nil, fmt.Errorf("execution %s not found", executionID)
+	state, err := e.engine.GetExecution(executionID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Result == nil {
+		return nil, fmt.Errorf("execution %s is still running", executionID)
+	}
+	return state.Result, nil
 }
 
 // EventEmitter handles execution events
@@ -498,9 +214,12 @@ const (
 	EventTypeExecutionStarted   EventType = "execution.started"
 	EventTypeExecutionCompleted EventType = "execution.completed"
 	EventTypeExecutionFailed    EventType = "execution.failed"
+	EventTypeExecutionQueued    EventType = "execution.queued"
+	EventTypeExecutionSkipped   EventType = "execution.skipped"
 	EventTypeNodeStarted        EventType = "node.started"
 	EventTypeNodeCompleted      EventType = "node.completed"
 	EventTypeNodeFailed         EventType = "node.failed"
+	EventTypeTriggerFailed      EventType = "trigger.failed" // A polling or subscribing trigger failed
 )
 
 // ExecutionEvent represents an execution event
@@ -513,6 +232,16 @@ type ExecutionEvent struct {
 	Data        map[string]interface{}
 }
 
+// ErrorMessage returns why the execution of an event failed, or was
+// skipped, empty for executions that did neither
+func (e ExecutionEvent) ErrorMessage() string {
+	if message, _ := e.Data["error"].(string); message != "" {
+		return message
+	}
+	reason, _ := e.Data["reason"].(string)
+	return reason
+}
+
 // EventHandler handles an event
 type EventHandler func(event ExecutionEvent)
 
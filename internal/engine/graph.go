@@ -0,0 +1,204 @@
+// Package engine provides execution graph and join tracking
+package engine
+
+// connectionState tracks whether an inbound connection has delivered data
+type connectionState int
+
+const (
+	connectionPending connectionState = iota
+	connectionFired
+	connectionPruned
+)
+
+// executionGraph is the adjacency view of a workflow used during execution
+type executionGraph struct {
+	nodes    map[string]*NodeDefinition
+	outbound map[string][]int // node -> indexes into connections
+	inbound  map[string][]int // node -> indexes into connections, without loop-back edges
+	conns    []Connection
+	loopBody map[string]map[string]bool // loop node -> nodes of its body
+	loopBack map[string][]int           // loop node -> loop-back edges into it
+}
+
+func newExecutionGraph(workflow *WorkflowDefinition) *executionGraph {
+	g := &executionGraph{
+		nodes:    make(map[string]*NodeDefinition),
+		outbound: make(map[string][]int),
+		inbound:  make(map[string][]int),
+		conns:    workflow.Connections,
+		loopBody: make(map[string]map[string]bool),
+		loopBack: make(map[string][]int),
+	}
+
+	for i := range workflow.Nodes {
+		g.nodes[workflow.Nodes[i].ID] = &workflow.Nodes[i]
+	}
+
+	for i, conn := range workflow.Connections {
+		g.outbound[conn.SourceNodeID] = append(g.outbound[conn.SourceNodeID], i)
+	}
+	g.findLoops()
+
+	for i, conn := range workflow.Connections {
+		if !g.isLoopBack(i) {
+			g.inbound[conn.TargetNodeID] = append(g.inbound[conn.TargetNodeID], i)
+		}
+	}
+
+	return g
+}
+
+// reachableFrom returns the set of nodes reachable from the given start node
+func (g *executionGraph) reachableFrom(startID string) map[string]bool {
+	reachable := map[string]bool{startID: true}
+	stack := []string{startID}
+
+	for len(stack) > 0 {
+		nodeID := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, idx := range g.outbound[nodeID] {
+			target := g.conns[idx].TargetNodeID
+			if !reachable[target] {
+				reachable[target] = true
+				stack = append(stack, target)
+			}
+		}
+	}
+
+	return reachable
+}
+
+// outboundPorts returns the distinct output ports a node is connected from
+func (g *executionGraph) outboundPorts(nodeID string) []string {
+	var ports []string
+	for _, idx := range g.outbound[nodeID] {
+		port := g.conns[idx].SourcePort
+		if !containsPort(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// portsExcept returns the connected output ports of a node other than port
+func (g *executionGraph) portsExcept(nodeID, port string) []string {
+	ports := []string{}
+	for _, p := range g.outboundPorts(nodeID) {
+		if p != port {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+// joinTracker records which inbound connections of each node have fired or
+// been pruned, so a node with several parents runs once all of them settle
+type joinTracker struct {
+	graph      *executionGraph
+	reachable  map[string]bool
+	states     []connectionState
+	queued     map[string]bool
+	done       map[string]bool
+	looping    map[string]bool // loop nodes whose body is running
+	iterations map[string]int  // loop node -> times it was re-entered
+}
+
+func newJoinTracker(graph *executionGraph, startID string) *joinTracker {
+	return &joinTracker{
+		graph:      graph,
+		reachable:  graph.reachableFrom(startID),
+		states:     make([]connectionState, len(graph.conns)),
+		queued:     map[string]bool{startID: true},
+		done:       make(map[string]bool),
+		looping:    make(map[string]bool),
+		iterations: make(map[string]int),
+	}
+}
+
+// complete marks a node as finished and resolves its outbound connections.
+// activePorts are the branches that received data (nil means every port
+// fires). It returns the nodes that became ready to execute, including loop
+// nodes whose body just finished an iteration.
+func (t *joinTracker) complete(nodeID string, activePorts []string) []string {
+	t.done[nodeID] = true
+
+	// A loop node sending a batch into its body leaves its other outputs
+	// pending until it emits on them in a later iteration
+	iterating := false
+	if t.graph.loopBody[nodeID] != nil {
+		if t.looping[nodeID] {
+			t.resetBody(nodeID)
+		}
+		iterating = containsPort(activePorts, loopPort)
+		t.looping[nodeID] = iterating
+	}
+
+	var ready []string
+	for _, idx := range t.graph.outbound[nodeID] {
+		conn := t.graph.conns[idx]
+		switch {
+		case activePorts == nil || containsPort(activePorts, conn.SourcePort):
+			t.states[idx] = connectionFired
+		case iterating:
+			continue
+		default:
+			t.states[idx] = connectionPruned
+		}
+		ready = append(ready, t.settle(conn.TargetNodeID)...)
+	}
+
+	return append(ready, t.finishedLoops(nodeID)...)
+}
+
+// settle checks whether every inbound connection of a node is resolved. A node
+// with at least one fired connection is ready; a node whose connections were
+// all pruned is skipped and its own outbound connections are pruned in turn.
+func (t *joinTracker) settle(nodeID string) []string {
+	if t.done[nodeID] || t.queued[nodeID] {
+		return nil
+	}
+
+	fired := false
+	for _, idx := range t.graph.inbound[nodeID] {
+		conn := t.graph.conns[idx]
+		if !t.reachable[conn.SourceNodeID] {
+			continue
+		}
+		switch t.states[idx] {
+		case connectionPending:
+			return nil
+		case connectionFired:
+			fired = true
+		}
+	}
+
+	if fired {
+		t.queued[nodeID] = true
+		return []string{nodeID}
+	}
+
+	// Every branch into this node was pruned, so skip it and prune downstream
+	t.done[nodeID] = true
+	var ready []string
+	for _, idx := range t.graph.outbound[nodeID] {
+		t.states[idx] = connectionPruned
+		ready = append(ready, t.settle(t.graph.conns[idx].TargetNodeID)...)
+	}
+
+	return ready
+}
+
+// isFired reports whether the connection at idx delivered data
+func (t *joinTracker) isFired(idx int) bool {
+	return t.states[idx] == connectionFired
+}
+
+func containsPort(ports []string, port string) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// Package engine provides node hooks
+package engine
+
+import (
+	"context"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// NodeHook is called around each node of an execution, such as by a
+// debugger pausing at breakpoints
+type NodeHook interface {
+	// BeforeNode is called before a node runs and may block, e.g. while the
+	// execution is paused. The node runs with the Data and Items of the input
+	// as the hook left them; an error fails the node.
+	BeforeNode(ctx context.Context, input *NodeHookInput) error
+
+	// AfterNode is called once the node finished, with its output or error
+	AfterNode(ctx context.Context, input *NodeHookInput, output map[string]interface{}, err error)
+}
+
+// NodeHookInput describes a node about to run
+type NodeHookInput struct {
+	ExecutionID string
+	WorkflowID  string
+	NodeID      string
+	NodeName    string
+	NodeType    string
+	Depth       int // Of the execution below the one that started it
+	Data        map[string]interface{}
+	Items       []runtime.Item
+	NodeOutputs map[string]map[string]interface{} // Of the nodes that ran before
+	Variables   map[string]interface{}
+	Environment map[string]string
+}
+
+// beforeNode passes a node to the hook of its run, if any, returning the
+// function to call once the node finished
+func (e *Engine) beforeNode(ctx context.Context, run *executionRun, task *nodeTask) (after func(), err error) {
+	hook := run.options.Hook
+	if hook == nil {
+		return func() {}, nil
+	}
+
+	input := &NodeHookInput{
+		ExecutionID: run.state.ID,
+		WorkflowID:  run.workflow.ID,
+		NodeID:      task.nodeID,
+		NodeName:    task.nodeDef.Name,
+		NodeType:    task.nodeDef.Type,
+		Depth:       run.options.Depth,
+		Data:        task.inputData,
+		Items:       task.items,
+		NodeOutputs: task.outputs,
+		Variables:   run.options.Variables,
+		Environment: run.options.Environment,
+	}
+	if err := hook.BeforeNode(ctx, input); err != nil {
+		return nil, err
+	}
+	task.inputData, task.items = input.Data, input.Items
+
+	return func() {
+		hook.AfterNode(ctx, input, task.output, task.err)
+	}, nil
+}
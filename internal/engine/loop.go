@@ -0,0 +1,142 @@
+// Package engine provides loop-back execution for loop nodes
+package engine
+
+import (
+	"sort"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// Output ports of loop nodes
+const (
+	loopPort     = "loop" // Sends the current batch into the loop body
+	loopDonePort = "done" // Emits the aggregated results once exhausted
+)
+
+// defaultMaxLoopIterations guards against loops that never emit on done.
+// A loop node can raise or lower it with its maxIterations setting.
+const defaultMaxLoopIterations = 10000
+
+// findLoops records the body and loop-back edges of every loop node. The body
+// is everything reachable from the loop output without passing through the
+// loop node again; connections from the body into the loop node are its
+// loop-back edges.
+func (g *executionGraph) findLoops() {
+	for nodeID, nodeDef := range g.nodes {
+		executor, err := runtime.Get(nodeDef.Type)
+		if err != nil || !executor.GetMetadata().Loop {
+			continue
+		}
+
+		body := make(map[string]bool)
+		var stack []string
+		for _, idx := range g.outbound[nodeID] {
+			if g.conns[idx].SourcePort == loopPort {
+				stack = append(stack, g.conns[idx].TargetNodeID)
+			}
+		}
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if current == nodeID || body[current] {
+				continue
+			}
+			body[current] = true
+			for _, idx := range g.outbound[current] {
+				stack = append(stack, g.conns[idx].TargetNodeID)
+			}
+		}
+
+		g.loopBody[nodeID] = body
+		for i, conn := range g.conns {
+			if conn.TargetNodeID == nodeID && body[conn.SourceNodeID] {
+				g.loopBack[nodeID] = append(g.loopBack[nodeID], i)
+			}
+		}
+	}
+}
+
+// isLoopBack reports whether the connection at idx leads back into a loop node
+func (g *executionGraph) isLoopBack(idx int) bool {
+	for _, edges := range g.loopBack {
+		for _, edge := range edges {
+			if edge == idx {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// finishedLoops returns the loop nodes whose body settled after nodeID
+// completed. The body is reset once the loop node has read what came back.
+func (t *joinTracker) finishedLoops(nodeID string) []string {
+	var ready []string
+	for loopID := range t.looping {
+		if !t.looping[loopID] {
+			continue
+		}
+		body := t.graph.loopBody[loopID]
+		if loopID != nodeID && !body[nodeID] {
+			continue
+		}
+		if !t.bodySettled(loopID) {
+			continue
+		}
+
+		t.iterations[loopID]++
+		t.done[loopID] = false
+		t.queued[loopID] = true
+		ready = append(ready, loopID)
+	}
+	sort.Strings(ready)
+	return ready
+}
+
+// bodySettled reports whether every node of a loop body ran or was skipped
+func (t *joinTracker) bodySettled(loopID string) bool {
+	for nodeID := range t.graph.loopBody[loopID] {
+		if !t.done[nodeID] {
+			return false
+		}
+	}
+	return true
+}
+
+// resetBody returns a loop body to its state before the loop node first ran,
+// including any loops nested inside it
+func (t *joinTracker) resetBody(loopID string) {
+	body := t.graph.loopBody[loopID]
+	for nodeID := range body {
+		delete(t.done, nodeID)
+		delete(t.queued, nodeID)
+		delete(t.looping, nodeID)
+		delete(t.iterations, nodeID)
+	}
+	for i, conn := range t.graph.conns {
+		if conn.SourceNodeID == loopID || body[conn.SourceNodeID] {
+			t.states[i] = connectionPending
+		}
+	}
+}
+
+// reentering reports whether a loop node is being run again for its next
+// iteration rather than for the first time
+func (t *joinTracker) reentering(nodeID string) bool {
+	return t.looping[nodeID]
+}
+
+// maxLoopIterations returns the iteration limit configured on a loop node
+func maxLoopIterations(nodeDef *NodeDefinition) int {
+	switch v := nodeDef.Config["maxIterations"].(type) {
+	case int:
+		if v > 0 {
+			return v
+		}
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	}
+	return defaultMaxLoopIterations
+}
@@ -0,0 +1,157 @@
+// Package engine provides per-node retry, timeout and error routing
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// On-error modes of a node
+const (
+	NodeOnErrorStop     = "stop"        // Fail the execution
+	NodeOnErrorContinue = "continue"    // Pass the input on with the error attached
+	NodeOnErrorOutput   = "errorOutput" // Route the input to the error port
+)
+
+// Backoff strategies between node retries
+const (
+	NodeBackoffFixed       = "fixed"
+	NodeBackoffExponential = "exponential"
+	NodeBackoffJitter      = "jitter"
+)
+
+// errorOutputPort is the output that failed items are routed to
+const errorOutputPort = "error"
+
+// maxNodeRetryDelay caps the backoff between node retries
+const maxNodeRetryDelay = 5 * time.Minute
+
+// ErrNodeTimeout is returned when a node attempt exceeds its timeout
+var ErrNodeTimeout = errors.New("node timed out")
+
+// nodeSettings returns the effective settings of a node. Nodes without their
+// own retry settings fall back to the workflow-wide ones.
+func nodeSettings(workflow *WorkflowDefinition, nodeDef *NodeDefinition) NodeSettings {
+	settings := nodeDef.Settings
+	if !settings.RetryOnFail && workflow.Settings.RetryOnFail {
+		settings.RetryOnFail = true
+		settings.MaxTries = workflow.Settings.MaxRetries + 1
+	}
+	return settings
+}
+
+// retryConfig converts the retry settings of a node into a RetryConfig
+func (s NodeSettings) retryConfig() *RetryConfig {
+	config := &RetryConfig{
+		MaxAttempts:   s.MaxTries,
+		InitialDelay:  s.WaitBetweenTries,
+		MaxDelay:      maxNodeRetryDelay,
+		BackoffFactor: 1,
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.InitialDelay <= 0 {
+		config.InitialDelay = time.Second
+	}
+
+	switch s.Backoff {
+	case NodeBackoffExponential:
+		config.BackoffFactor = 2
+	case NodeBackoffJitter:
+		config.BackoffFactor = 2
+		config.JitterFactor = 0.5
+	}
+	return config
+}
+
+// executeWithSettings runs a node, retrying failed attempts and enforcing the
+// timeout of each. A node that keeps reporting a soft error through its output
+// returns the output of its last attempt.
+func (e *Engine) executeWithSettings(
+	ctx context.Context,
+	task *nodeTask,
+	executor runtime.NodeExecutor,
+	input *runtime.ExecutionInput,
+	settings NodeSettings,
+) (*runtime.ExecutionOutput, error) {
+	if !settings.RetryOnFail {
+		return executeWithTimeout(ctx, executor, input, settings.Timeout)
+	}
+
+	var output *runtime.ExecutionOutput
+	err := Retry(ctx, settings.retryConfig(), func(ctx context.Context, attempt int) error {
+		var err error
+		output, err = executeWithTimeout(ctx, executor, input, settings.Timeout)
+		if err == nil && output.Error != nil {
+			err = output.Error
+		} else if err != nil {
+			output = nil
+		}
+		if attempt > 1 {
+			task.retries++
+		}
+		if err != nil {
+			task.log("warn", fmt.Sprintf("Node %s attempt %d failed: %v", task.nodeID, attempt, err))
+		}
+		return err
+	})
+
+	if err != nil && output != nil && output.Error != nil {
+		return output, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// executeWithTimeout runs a single attempt of a node. The attempt is abandoned
+// once the timeout passes, even if the node ignores its context.
+func executeWithTimeout(ctx context.Context, executor runtime.NodeExecutor, input *runtime.ExecutionInput, timeout time.Duration) (*runtime.ExecutionOutput, error) {
+	if timeout <= 0 {
+		return executor.Execute(ctx, input)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		output *runtime.ExecutionOutput
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := executor.Execute(attemptCtx, input)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-attemptCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w after %v", ErrNodeTimeout, timeout)
+	}
+}
+
+// failedNodeOutput builds the output of a node that failed but lets the
+// execution go on: its input with the error attached, on the error port when
+// the node routes errors there
+func failedNodeOutput(task *nodeTask, inputData map[string]interface{}, err error, onError string) *runtime.ExecutionOutput {
+	data := copyData(inputData)
+	data["error"] = err.Error()
+	if onError == NodeOnErrorOutput {
+		data["_output"] = errorOutputPort
+	}
+
+	task.log("warn", fmt.Sprintf("Node %s failed (%s): %v", task.nodeID, onError, err))
+
+	return &runtime.ExecutionOutput{Data: data}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -16,52 +17,70 @@ import (
 type ExecutionRepository interface {
 	// Create creates a new execution record
 	Create(ctx context.Context, execution *ExecutionRecord) error
-	
+
 	// FindByID finds an execution by ID
 	FindByID(ctx context.Context, id string) (*ExecutionRecord, error)
-	
+
 	// Update updates an execution
 	Update(ctx context.Context, execution *ExecutionRecord) error
-	
+
 	// Delete deletes an execution
 	Delete(ctx context.Context, id string) error
-	
+
 	// ListByWorkflow lists executions by workflow ID
 	ListByWorkflow(ctx context.Context, workflowID string, limit, offset int) ([]*ExecutionRecord, error)
-	
+
 	// ListByStatus lists executions by status
 	ListByStatus(ctx context.Context, status ExecutionStatus, limit int) ([]*ExecutionRecord, error)
-	
+
 	// ListRecent lists recent executions
 	ListRecent(ctx context.Context, limit int) ([]*ExecutionRecord, error)
-	
+
 	// CountByWorkflow counts executions for a workflow
 	CountByWorkflow(ctx context.Context, workflowID string) (int64, error)
-	
+
 	// GetStats returns execution statistics
 	GetStats(ctx context.Context, workflowID string, period time.Duration) (*ExecutionStats, error)
+
+	// Claim atomically takes over an execution that is waiting, or running
+	// on a lease that expired, marking it running for owner for the length
+	// of lease. It returns ErrExecutionClaimed if the execution cannot be
+	// claimed.
+	Claim(ctx context.Context, id, owner string, lease time.Duration) (*ExecutionRecord, error)
+
+	// RenewLease extends the lease owner holds on a running execution,
+	// returning ErrExecutionClaimed if it no longer holds it
+	RenewLease(ctx context.Context, id, owner string, lease time.Duration) error
 }
 
+// ErrExecutionClaimed is returned when an execution is held by another
+// owner, or is not in a state to be claimed
+var ErrExecutionClaimed = errors.New("execution is claimed or cannot be resumed")
+
 // ExecutionRecord represents a persisted execution
 type ExecutionRecord struct {
-	ID            string
-	WorkflowID    string
-	WorkflowName  string
-	Status        ExecutionStatus
-	Mode          string
-	StartedAt     time.Time
-	CompletedAt   *time.Time
-	DurationMs    int64
-	TriggerData   map[string]interface{}
-	NodeOutputs   map[string]interface{}
-	Error         string
-	RetryCount    int
-	ParentID      *string
-	UserID        string
-	WorkspaceID   string
-	Metadata      map[string]interface{}
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	ID           string
+	WorkflowID   string
+	WorkflowName string
+	Status       ExecutionStatus
+	Mode         string
+	StartedAt    time.Time
+	CompletedAt  *time.Time
+	DurationMs   int64
+	TriggerData  map[string]interface{}
+	NodeOutputs  map[string]interface{}
+	Error        string
+	RetryCount   int
+	ParentID     *string
+	UserID       string
+	WorkspaceID  string
+	Metadata     map[string]interface{}
+	Checkpoint   *Checkpoint // Progress after the last completed node, nil once finished
+	WaitUntil    *time.Time  // When a parked execution resumes
+	Owner        string      // Engine running the execution
+	LeaseUntil   *time.Time  // Until when the owner is known to be running it
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 // ExecutionStats holds execution statistics
@@ -100,7 +119,9 @@ func (r *InMemoryExecutionRepository) Create(ctx context.Context, execution *Exe
 	execution.CreatedAt = time.Now()
 	execution.UpdatedAt = time.Now()
 
-	r.executions[execution.ID] = execution
+	// Stored records are copies, which are replaced rather than changed
+	stored := *execution
+	r.executions[execution.ID] = &stored
 	return nil
 }
 
@@ -114,7 +135,8 @@ func (r *InMemoryExecutionRepository) FindByID(ctx context.Context, id string) (
 		return nil, fmt.Errorf("execution %s not found", id)
 	}
 
-	return execution, nil
+	found := *execution
+	return &found, nil
 }
 
 // Update updates an execution
@@ -123,7 +145,8 @@ func (r *InMemoryExecutionRepository) Update(ctx context.Context, execution *Exe
 	defer r.mu.Unlock()
 
 	execution.UpdatedAt = time.Now()
-	r.executions[execution.ID] = execution
+	stored := *execution
+	r.executions[execution.ID] = &stored
 	return nil
 }
 
@@ -256,14 +279,61 @@ func (r *InMemoryExecutionRepository) GetStats(ctx context.Context, workflowID s
 	return stats, nil
 }
 
+// Claim takes over a waiting execution or one whose lease expired
+func (r *InMemoryExecutionRepository) Claim(ctx context.Context, id, owner string, lease time.Duration) (*ExecutionRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	execution, ok := r.executions[id]
+	if !ok {
+		return nil, fmt.Errorf("execution %s not found", id)
+	}
+	now := time.Now()
+	expired := execution.Status == ExecutionStatusRunning && (execution.LeaseUntil == nil || execution.LeaseUntil.Before(now))
+	if execution.Status != ExecutionStatusWaiting && !expired {
+		return nil, fmt.Errorf("execution %s: %w", id, ErrExecutionClaimed)
+	}
+
+	claimed := *execution
+	leaseUntil := now.Add(lease)
+	claimed.Status, claimed.Owner, claimed.LeaseUntil, claimed.UpdatedAt = ExecutionStatusRunning, owner, &leaseUntil, now
+	r.executions[id] = &claimed
+	result := claimed
+	return &result, nil
+}
+
+// RenewLease extends the lease of a running execution held by owner
+func (r *InMemoryExecutionRepository) RenewLease(ctx context.Context, id, owner string, lease time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	execution, ok := r.executions[id]
+	if !ok || execution.Status != ExecutionStatusRunning || execution.Owner != owner {
+		return fmt.Errorf("execution %s: %w", id, ErrExecutionClaimed)
+	}
+	renewed := *execution
+	leaseUntil := time.Now().Add(lease)
+	renewed.LeaseUntil = &leaseUntil
+	r.executions[id] = &renewed
+	return nil
+}
+
 // PostgresExecutionRepository implements ExecutionRepository with PostgreSQL
 type PostgresExecutionRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	table string
 }
 
 // NewPostgresExecutionRepository creates a new PostgreSQL repository
 func NewPostgresExecutionRepository(db *sql.DB) *PostgresExecutionRepository {
-	return &PostgresExecutionRepository{db: db}
+	return &PostgresExecutionRepository{db: db, table: "executions"}
+}
+
+// WithTable stores the executions in table, which may be schema-qualified,
+// instead of executions
+func (r *PostgresExecutionRepository) WithTable(table string) *PostgresExecutionRepository {
+	r.table = table
+	return r
 }
 
 // Create creates a new execution record
@@ -277,22 +347,23 @@ func (r *PostgresExecutionRepository) Create(ctx context.Context, execution *Exe
 	triggerData, _ := json.Marshal(execution.TriggerData)
 	nodeOutputs, _ := json.Marshal(execution.NodeOutputs)
 	metadata, _ := json.Marshal(execution.Metadata)
+	checkpoint, _ := json.Marshal(execution.Checkpoint)
 
 	query := `
-		INSERT INTO executions (
+		INSERT INTO ` + r.table + ` (
 			id, workflow_id, workflow_name, status, mode,
 			started_at, completed_at, duration_ms,
 			trigger_data, node_outputs, error,
 			retry_count, parent_id, user_id, workspace_id,
-			metadata, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
+			metadata, checkpoint, wait_until, owner, lease_until, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		execution.ID, execution.WorkflowID, execution.WorkflowName, execution.Status, execution.Mode,
 		execution.StartedAt, execution.CompletedAt, execution.DurationMs,
 		triggerData, nodeOutputs, execution.Error,
 		execution.RetryCount, execution.ParentID, execution.UserID, execution.WorkspaceID,
-		metadata, execution.CreatedAt, execution.UpdatedAt,
+		metadata, checkpoint, execution.WaitUntil, execution.Owner, execution.LeaseUntil, execution.CreatedAt, execution.UpdatedAt,
 	)
 
 	return err
@@ -305,22 +376,28 @@ func (r *PostgresExecutionRepository) FindByID(ctx context.Context, id string) (
 			started_at, completed_at, duration_ms,
 			trigger_data, node_outputs, error,
 			retry_count, parent_id, user_id, workspace_id,
-			metadata, created_at, updated_at
-		FROM executions WHERE id = $1`
+			metadata, checkpoint, wait_until, owner, lease_until, created_at, updated_at
+		FROM ` + r.table + ` WHERE id = $1`
+
+	execution, err := r.queryRecord(ctx, query, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("execution %s not found", id)
+	}
+	return execution, err
+}
 
+// queryRecord reads the execution a query returns the columns of
+func (r *PostgresExecutionRepository) queryRecord(ctx context.Context, query string, args ...interface{}) (*ExecutionRecord, error) {
 	var execution ExecutionRecord
-	var triggerData, nodeOutputs, metadata []byte
+	var triggerData, nodeOutputs, metadata, checkpoint []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
 		&execution.ID, &execution.WorkflowID, &execution.WorkflowName, &execution.Status, &execution.Mode,
 		&execution.StartedAt, &execution.CompletedAt, &execution.DurationMs,
 		&triggerData, &nodeOutputs, &execution.Error,
 		&execution.RetryCount, &execution.ParentID, &execution.UserID, &execution.WorkspaceID,
-		&metadata, &execution.CreatedAt, &execution.UpdatedAt,
+		&metadata, &checkpoint, &execution.WaitUntil, &execution.Owner, &execution.LeaseUntil, &execution.CreatedAt, &execution.UpdatedAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("execution %s not found", id)
-	}
 	if err != nil {
 		return nil, err
 	}
@@ -328,6 +405,9 @@ func (r *PostgresExecutionRepository) FindByID(ctx context.Context, id string) (
 	json.Unmarshal(triggerData, &execution.TriggerData)
 	json.Unmarshal(nodeOutputs, &execution.NodeOutputs)
 	json.Unmarshal(metadata, &execution.Metadata)
+	if len(checkpoint) > 0 {
+		json.Unmarshal(checkpoint, &execution.Checkpoint)
+	}
 
 	return &execution, nil
 }
@@ -339,18 +419,21 @@ func (r *PostgresExecutionRepository) Update(ctx context.Context, execution *Exe
 	triggerData, _ := json.Marshal(execution.TriggerData)
 	nodeOutputs, _ := json.Marshal(execution.NodeOutputs)
 	metadata, _ := json.Marshal(execution.Metadata)
+	checkpoint, _ := json.Marshal(execution.Checkpoint)
 
 	query := `
-		UPDATE executions SET
+		UPDATE ` + r.table + ` SET
 			status = $2, completed_at = $3, duration_ms = $4,
 			trigger_data = $5, node_outputs = $6, error = $7,
-			retry_count = $8, metadata = $9, updated_at = $10
+			retry_count = $8, metadata = $9, checkpoint = $10,
+			wait_until = $11, updated_at = $12
 		WHERE id = $1`
 
 	_, err := r.db.ExecContext(ctx, query,
 		execution.ID, execution.Status, execution.CompletedAt, execution.DurationMs,
 		triggerData, nodeOutputs, execution.Error,
-		execution.RetryCount, metadata, execution.UpdatedAt,
+		execution.RetryCount, metadata, checkpoint,
+		execution.WaitUntil, execution.UpdatedAt,
 	)
 
 	return err
@@ -358,10 +441,47 @@ func (r *PostgresExecutionRepository) Update(ctx context.Context, execution *Exe
 
 // Delete deletes an execution
 func (r *PostgresExecutionRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM executions WHERE id = $1", id)
+	_, err := r.db.ExecContext(ctx, "DELETE FROM "+r.table+" WHERE id = $1", id)
 	return err
 }
 
+// Claim takes over a waiting execution or one whose lease expired. Leases
+// are timed by the database clock, which every replica shares.
+func (r *PostgresExecutionRepository) Claim(ctx context.Context, id, owner string, lease time.Duration) (*ExecutionRecord, error) {
+	query := `
+		UPDATE ` + r.table + ` SET
+			status = 'running', owner = $2,
+			lease_until = NOW() + $3 * INTERVAL '1 millisecond', updated_at = NOW()
+		WHERE id = $1 AND (status = 'waiting' OR
+			(status = 'running' AND (lease_until IS NULL OR lease_until < NOW())))
+		RETURNING id, workflow_id, workflow_name, status, mode,
+			started_at, completed_at, duration_ms,
+			trigger_data, node_outputs, error,
+			retry_count, parent_id, user_id, workspace_id,
+			metadata, checkpoint, wait_until, owner, lease_until, created_at, updated_at`
+
+	execution, err := r.queryRecord(ctx, query, id, owner, lease.Milliseconds())
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("execution %s: %w", id, ErrExecutionClaimed)
+	}
+	return execution, err
+}
+
+// RenewLease extends the lease of a running execution held by owner
+func (r *PostgresExecutionRepository) RenewLease(ctx context.Context, id, owner string, lease time.Duration) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE `+r.table+` SET lease_until = NOW() + $3 * INTERVAL '1 millisecond'
+		WHERE id = $1 AND owner = $2 AND status = 'running'
+	`, id, owner, lease.Milliseconds())
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("execution %s: %w", id, ErrExecutionClaimed)
+	}
+	return nil
+}
+
 // ListByWorkflow lists executions by workflow ID
 func (r *PostgresExecutionRepository) ListByWorkflow(ctx context.Context, workflowID string, limit, offset int) ([]*ExecutionRecord, error) {
 	query := `
@@ -369,8 +489,8 @@ func (r *PostgresExecutionRepository) ListByWorkflow(ctx context.Context, workfl
 			started_at, completed_at, duration_ms,
 			trigger_data, node_outputs, error,
 			retry_count, parent_id, user_id, workspace_id,
-			metadata, created_at, updated_at
-		FROM executions 
+			metadata, checkpoint, wait_until, owner, lease_until, created_at, updated_at
+		FROM ` + r.table + `
 		WHERE workflow_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3`
@@ -391,8 +511,8 @@ func (r *PostgresExecutionRepository) ListByStatus(ctx context.Context, status E
 			started_at, completed_at, duration_ms,
 			trigger_data, node_outputs, error,
 			retry_count, parent_id, user_id, workspace_id,
-			metadata, created_at, updated_at
-		FROM executions 
+			metadata, checkpoint, wait_until, owner, lease_until, created_at, updated_at
+		FROM ` + r.table + `
 		WHERE status = $1
 		ORDER BY created_at DESC
 		LIMIT $2`
@@ -413,8 +533,8 @@ func (r *PostgresExecutionRepository) ListRecent(ctx context.Context, limit int)
 			started_at, completed_at, duration_ms,
 			trigger_data, node_outputs, error,
 			retry_count, parent_id, user_id, workspace_id,
-			metadata, created_at, updated_at
-		FROM executions 
+			metadata, checkpoint, wait_until, owner, lease_until, created_at, updated_at
+		FROM ` + r.table + `
 		ORDER BY created_at DESC
 		LIMIT $1`
 
@@ -432,14 +552,14 @@ func (r *PostgresExecutionRepository) scanRows(rows *sql.Rows) ([]*ExecutionReco
 
 	for rows.Next() {
 		var execution ExecutionRecord
-		var triggerData, nodeOutputs, metadata []byte
+		var triggerData, nodeOutputs, metadata, checkpoint []byte
 
 		err := rows.Scan(
 			&execution.ID, &execution.WorkflowID, &execution.WorkflowName, &execution.Status, &execution.Mode,
 			&execution.StartedAt, &execution.CompletedAt, &execution.DurationMs,
 			&triggerData, &nodeOutputs, &execution.Error,
 			&execution.RetryCount, &execution.ParentID, &execution.UserID, &execution.WorkspaceID,
-			&metadata, &execution.CreatedAt, &execution.UpdatedAt,
+			&metadata, &checkpoint, &execution.WaitUntil, &execution.Owner, &execution.LeaseUntil, &execution.CreatedAt, &execution.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -448,6 +568,9 @@ func (r *PostgresExecutionRepository) scanRows(rows *sql.Rows) ([]*ExecutionReco
 		json.Unmarshal(triggerData, &execution.TriggerData)
 		json.Unmarshal(nodeOutputs, &execution.NodeOutputs)
 		json.Unmarshal(metadata, &execution.Metadata)
+		if len(checkpoint) > 0 {
+			json.Unmarshal(checkpoint, &execution.Checkpoint)
+		}
 
 		executions = append(executions, &execution)
 	}
@@ -458,7 +581,7 @@ func (r *PostgresExecutionRepository) scanRows(rows *sql.Rows) ([]*ExecutionReco
 // CountByWorkflow counts executions for a workflow
 func (r *PostgresExecutionRepository) CountByWorkflow(ctx context.Context, workflowID string) (int64, error) {
 	var count int64
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM executions WHERE workflow_id = $1", workflowID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+r.table+" WHERE workflow_id = $1", workflowID).Scan(&count)
 	return count, err
 }
 
@@ -474,7 +597,7 @@ func (r *PostgresExecutionRepository) GetStats(ctx context.Context, workflowID s
 			COALESCE(AVG(duration_ms), 0) as avg_duration,
 			COALESCE(MIN(duration_ms), 0) as min_duration,
 			COALESCE(MAX(duration_ms), 0) as max_duration
-		FROM executions 
+		FROM ` + r.table + `
 		WHERE ($1 = '' OR workflow_id = $1)
 		AND started_at >= $2`
 
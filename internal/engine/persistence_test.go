@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// executionTestDB connects to the database named by TEST_DATABASE_URL and
+// creates the engine execution table of its migrations
+func executionTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	statements := []string{`CREATE SCHEMA IF NOT EXISTS execution_service`}
+	for _, name := range []string{"000026_engine_executions.up.sql", "000030_engine_execution_leases.up.sql"} {
+		migration, err := os.ReadFile(filepath.Join("..", "..", "migrations", name))
+		require.NoError(t, err)
+		statements = append(statements, string(migration))
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func TestPostgresExecutionRepository_ClaimsAndRenewsLeases(t *testing.T) {
+	db := executionTestDB(t)
+	ctx := context.Background()
+	repo := NewPostgresExecutionRepository(db).WithTable("execution_service.engine_executions")
+
+	record := &ExecutionRecord{
+		ID:         uuid.New().String(),
+		WorkflowID: "wf-1",
+		Status:     ExecutionStatusWaiting,
+		StartedAt:  time.Now(),
+		Checkpoint: &Checkpoint{Workflow: &WorkflowDefinition{ID: "wf-1"}},
+	}
+	require.NoError(t, repo.Create(ctx, record))
+	t.Cleanup(func() { repo.Delete(ctx, record.ID) })
+
+	// A waiting execution is claimed once
+	claimed, err := repo.Claim(ctx, record.ID, "replica-1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionStatusRunning, claimed.Status)
+	assert.Equal(t, "replica-1", claimed.Owner)
+	require.NotNil(t, claimed.LeaseUntil)
+	assert.NotNil(t, claimed.Checkpoint)
+	_, err = repo.Claim(ctx, record.ID, "replica-2", time.Minute)
+	assert.ErrorIs(t, err, ErrExecutionClaimed)
+
+	// Only the owner renews its lease
+	require.NoError(t, repo.RenewLease(ctx, record.ID, "replica-1", time.Millisecond))
+	assert.ErrorIs(t, repo.RenewLease(ctx, record.ID, "replica-2", time.Minute), ErrExecutionClaimed)
+
+	// Once the lease expires another replica takes the execution over
+	time.Sleep(10 * time.Millisecond)
+	claimed, err = repo.Claim(ctx, record.ID, "replica-2", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-2", claimed.Owner)
+	assert.ErrorIs(t, repo.RenewLease(ctx, record.ID, "replica-1", time.Minute), ErrExecutionClaimed)
+
+	found, err := repo.FindByID(ctx, record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-2", found.Owner)
+}
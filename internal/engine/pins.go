@@ -0,0 +1,28 @@
+// Package engine provides pinned data of nodes
+package engine
+
+import (
+	"fmt"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// pinNode outputs the data pinned on a node instead of running it, reporting
+// whether it did. Pins are test data, so that building a workflow does not
+// call external systems on every run; only manual executions use them. The
+// pinned items go to every output port of the node.
+func (e *Engine) pinNode(run *executionRun, task *nodeTask) bool {
+	pinned := task.nodeDef.PinnedData
+	if run.options.Mode != "manual" || len(pinned) == 0 {
+		return false
+	}
+	task.log("info", fmt.Sprintf("Using pinned data of node: %s (%s)", task.nodeDef.Name, task.nodeDef.Type))
+
+	items := make([]runtime.Item, len(pinned))
+	for i, data := range pinned {
+		items[i] = runtime.NewItem(copyData(data))
+	}
+	task.output = runtime.DataFromItems(items)
+	task.portItems = map[string][]runtime.Item{"": items}
+	return true
+}
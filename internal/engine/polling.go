@@ -0,0 +1,252 @@
+// Package engine provides polling triggers
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// maxPollSeenKeys bounds how many keys of items already seen are kept per
+// trigger; items older than that are left to the trigger's cursor
+const maxPollSeenKeys = 1000
+
+// pollTimeout bounds a single poll of a trigger
+const pollTimeout = 2 * time.Minute
+
+// PollState is where a polling trigger of a workflow left off
+type PollState struct {
+	Cursor   string
+	Seen     []string // Keys of the items already seen, oldest first
+	PolledAt time.Time
+}
+
+// PollStateStore keeps the state of polling triggers by trigger ID
+type PollStateStore interface {
+	// Load returns the state of a trigger, nil if it never polled
+	Load(ctx context.Context, triggerID string) (*PollState, error)
+	Save(ctx context.Context, triggerID string, state *PollState) error
+}
+
+// InMemoryPollStateStore keeps poll states within a single process
+type InMemoryPollStateStore struct {
+	states map[string]PollState
+	mu     sync.Mutex
+}
+
+// NewInMemoryPollStateStore creates a new in-memory poll state store
+func NewInMemoryPollStateStore() *InMemoryPollStateStore {
+	return &InMemoryPollStateStore{states: make(map[string]PollState)}
+}
+
+// Load returns a copy of the state of a trigger
+func (s *InMemoryPollStateStore) Load(ctx context.Context, triggerID string) (*PollState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[triggerID]
+	if !ok {
+		return nil, nil
+	}
+	state.Seen = append([]string(nil), state.Seen...)
+	return &state, nil
+}
+
+// Save stores a copy of the state of a trigger
+func (s *InMemoryPollStateStore) Save(ctx context.Context, triggerID string, state *PollState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *state
+	saved.Seen = append([]string(nil), state.Seen...)
+	s.states[triggerID] = saved
+	return nil
+}
+
+// PostgresPollStateStore keeps poll states in the poll_states table, so that
+// every replica resumes a trigger where the last poll left off
+type PostgresPollStateStore struct {
+	db *sql.DB
+}
+
+// NewPostgresPollStateStore creates a poll state store backed by Postgres
+func NewPostgresPollStateStore(db *sql.DB) *PostgresPollStateStore {
+	return &PostgresPollStateStore{db: db}
+}
+
+// Load reads the state of a trigger
+func (s *PostgresPollStateStore) Load(ctx context.Context, triggerID string) (*PollState, error) {
+	var state PollState
+	var seenJSON []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT poll_cursor, seen, polled_at FROM poll_states WHERE trigger_id = $1`, triggerID,
+	).Scan(&state.Cursor, &seenJSON, &state.PolledAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load poll state: %w", err)
+	}
+	if err := json.Unmarshal(seenJSON, &state.Seen); err != nil {
+		return nil, fmt.Errorf("failed to decode poll state: %w", err)
+	}
+	return &state, nil
+}
+
+// Save writes the state of a trigger
+func (s *PostgresPollStateStore) Save(ctx context.Context, triggerID string, state *PollState) error {
+	seenJSON, err := json.Marshal(state.Seen)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO poll_states (trigger_id, poll_cursor, seen, polled_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (trigger_id) DO UPDATE
+		SET poll_cursor = EXCLUDED.poll_cursor, seen = EXCLUDED.seen, polled_at = EXCLUDED.polled_at`,
+		triggerID, state.Cursor, seenJSON, state.PolledAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save poll state: %w", err)
+	}
+	return nil
+}
+
+// WithPollStateStore sets where polling triggers keep their cursors. The
+// default in-memory store forgets them on restart, so replicas must share
+// a Postgres one.
+func (s *Scheduler) WithPollStateStore(store PollStateStore) *Scheduler {
+	s.pollStates = store
+	return s
+}
+
+// pollingExecutor returns the executor of a node type if it is a polling
+// trigger
+func pollingExecutor(nodeType string) (runtime.PollingExecutor, bool) {
+	executor, err := runtime.Get(nodeType)
+	if err != nil || !executor.GetMetadata().IsTrigger {
+		return nil, false
+	}
+	poller, ok := executor.(runtime.PollingExecutor)
+	return poller, ok
+}
+
+// poll polls the trigger of a schedule and returns the items it had not
+// seen before, with the state that marks them seen. The state is left to
+// the caller to save once the items are handed on, so that items of a run
+// that never started are polled again. The first poll of a trigger only
+// records where it starts, so that activating a workflow does not replay
+// what is already there.
+func (s *Scheduler) poll(ctx context.Context, schedule *ScheduleEntry, workflow *WorkflowDefinition) ([]runtime.Item, *PollState, error) {
+	options := schedule.executionOptions()
+	var node *NodeDefinition
+	for i := range workflow.Nodes {
+		if workflow.Nodes[i].ID == options.TriggerNodeID {
+			node = &workflow.Nodes[i]
+		}
+	}
+	if node == nil {
+		return nil, nil, fmt.Errorf("trigger node %s not found", options.TriggerNodeID)
+	}
+	poller, ok := pollingExecutor(node.Type)
+	if !ok {
+		return nil, nil, fmt.Errorf("node %s is not a polling trigger", node.ID)
+	}
+
+	state, err := s.pollStates.Load(ctx, schedule.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	first := state == nil
+	if first {
+		state = &PollState{}
+	}
+
+	var credentials map[string]interface{}
+	if node.Credential != "" && s.engine.credentials != nil {
+		all, err := s.engine.credentials(ctx, workflow, options)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load credentials: %w", err)
+		}
+		credentials = all[node.Credential]
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+	result, err := poller.Poll(pollCtx, &runtime.ExecutionInput{
+		NodeID:      node.ID,
+		NodeConfig:  node.Config,
+		Credentials: credentials,
+		Context: &runtime.ExecutionContext{
+			WorkflowID:  workflow.ID,
+			UserID:      options.UserID,
+			WorkspaceID: options.WorkspaceID,
+			Variables:   options.Variables,
+			Env:         options.Environment,
+			Mode:        "polling",
+			Binary:      s.engine.binary,
+			Egress:      s.engine.egress,
+		},
+	}, state.Cursor)
+	if err != nil {
+		return nil, nil, fmt.Errorf("poll of trigger %s failed: %w", node.ID, err)
+	}
+
+	seen := make(map[string]bool, len(state.Seen))
+	for _, key := range state.Seen {
+		seen[key] = true
+	}
+	var fresh []runtime.Item
+	for i, item := range result.Items {
+		key := pollItemKey(result, i)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		state.Seen = append(state.Seen, key)
+		fresh = append(fresh, item)
+	}
+	if len(state.Seen) > maxPollSeenKeys {
+		state.Seen = state.Seen[len(state.Seen)-maxPollSeenKeys:]
+	}
+	if result.Cursor != "" {
+		state.Cursor = result.Cursor
+	}
+	state.PolledAt = time.Now()
+
+	if first {
+		return nil, state, nil
+	}
+	return fresh, state, nil
+}
+
+// savePollState saves the state of a poll, reporting a failure to save it
+// as a failed trigger since its items will be polled again
+func (s *Scheduler) savePollState(ctx context.Context, schedule *ScheduleEntry, workflow *WorkflowDefinition, state *PollState) {
+	if err := s.pollStates.Save(ctx, schedule.ID, state); err != nil {
+		s.engine.events.Emit(ExecutionEvent{
+			Type:       EventTypeTriggerFailed,
+			WorkflowID: workflow.ID,
+			NodeID:     schedule.executionOptions().TriggerNodeID,
+			Timestamp:  time.Now(),
+			Data:       map[string]interface{}{"error": fmt.Sprintf("failed to save poll state: %v", err)},
+		})
+	}
+}
+
+// pollItemKey returns the key the i-th item of a poll is de-duplicated by
+func pollItemKey(result *runtime.PollResult, i int) string {
+	if i < len(result.Keys) && result.Keys[i] != "" {
+		return result.Keys[i]
+	}
+	data, _ := json.Marshal(result.Items[i].JSON)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
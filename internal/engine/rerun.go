@@ -0,0 +1,47 @@
+// Package engine provides re-runs of executions from one of their nodes
+package engine
+
+import (
+	"fmt"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// RecordedNode is the output a node had in an earlier execution
+type RecordedNode struct {
+	Output map[string]interface{}    `json:"output"`
+	Items  map[string][]runtime.Item `json:"items"` // By output port
+}
+
+// RerunNodes returns the nodes a re-run of a workflow from nodeID runs
+// again: the node itself and every node downstream of it. The other nodes
+// can pass on what they recorded, so that they do not call external
+// systems a second time.
+func RerunNodes(workflow *WorkflowDefinition, nodeID string) map[string]bool {
+	return newExecutionGraph(workflow).reachableFrom(nodeID)
+}
+
+// replayNode passes on the recorded output of a node instead of running it,
+// reporting whether the run had one. The node fires the ports it fired when
+// it was recorded.
+func (e *Engine) replayNode(run *executionRun, task *nodeTask) bool {
+	recorded, ok := run.options.Recorded[task.nodeID]
+	if !ok {
+		return false
+	}
+	task.log("info", fmt.Sprintf("Reusing recorded output of node: %s (%s)", task.nodeDef.Name, task.nodeDef.Type))
+
+	task.output = recorded.Output
+	task.portItems = recorded.Items
+	if task.portItems == nil {
+		task.portItems = map[string][]runtime.Item{"": runtime.ItemsFromData(recorded.Output)}
+	}
+
+	for port := range task.portItems {
+		if port != "" {
+			task.activePorts = activePorts(run.graph, task.nodeID, task.portItems)
+			break
+		}
+	}
+	return true
+}
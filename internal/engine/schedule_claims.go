@@ -0,0 +1,225 @@
+// Package engine provides cluster-wide claiming of scheduled runs
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/cache"
+)
+
+// Policies for runs missed while no scheduler was running
+const (
+	MissedRunSkip    = "skip"    // Resume with the next run
+	MissedRunCatchup = "catchup" // Execute every missed run, oldest first
+)
+
+// maxCatchupRuns bounds how many missed runs of a schedule are caught up;
+// older runs are dropped
+const maxCatchupRuns = 100
+
+// runClaimTTL is how long a Redis claim on a run is kept, long enough for
+// every replica to have passed the run
+const runClaimTTL = 24 * time.Hour
+
+// RunClaimer claims the runs of schedules. Every replica fires every
+// schedule, and only the replica whose claim succeeds executes the run, so
+// each run is executed once however many schedulers are running.
+type RunClaimer interface {
+	// Claim claims the run of a schedule due at runAt, reporting false if
+	// another scheduler already claimed it
+	Claim(ctx context.Context, scheduleID string, runAt time.Time) (bool, error)
+
+	// LastClaimed returns the due time of the latest claimed run, nil if the
+	// schedule has never run
+	LastClaimed(ctx context.Context, scheduleID string) (*time.Time, error)
+}
+
+// InMemoryRunClaimer claims runs within a single process
+type InMemoryRunClaimer struct {
+	claimed map[string]map[int64]bool
+	last    map[string]time.Time
+	mu      sync.Mutex
+}
+
+// NewInMemoryRunClaimer creates a new in-memory claimer
+func NewInMemoryRunClaimer() *InMemoryRunClaimer {
+	return &InMemoryRunClaimer{
+		claimed: make(map[string]map[int64]bool),
+		last:    make(map[string]time.Time),
+	}
+}
+
+// Claim claims a run unless it was claimed before
+func (c *InMemoryRunClaimer) Claim(ctx context.Context, scheduleID string, runAt time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	runs := c.claimed[scheduleID]
+	if runs == nil {
+		runs = make(map[int64]bool)
+		c.claimed[scheduleID] = runs
+	}
+	if runs[runAt.Unix()] {
+		return false, nil
+	}
+	runs[runAt.Unix()] = true
+
+	if runAt.After(c.last[scheduleID]) {
+		c.last[scheduleID] = runAt
+	}
+	return true, nil
+}
+
+// LastClaimed returns the latest claimed run of a schedule
+func (c *InMemoryRunClaimer) LastClaimed(ctx context.Context, scheduleID string) (*time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last, ok := c.last[scheduleID]
+	if !ok {
+		return nil, nil
+	}
+	return &last, nil
+}
+
+// PostgresRunClaimer claims runs by inserting them into the schedule_runs
+// table, whose primary key lets exactly one insert of a run succeed
+type PostgresRunClaimer struct {
+	db       *sql.DB
+	instance string
+}
+
+// NewPostgresRunClaimer creates a claimer recording runs as claimed by instance
+func NewPostgresRunClaimer(db *sql.DB, instance string) *PostgresRunClaimer {
+	return &PostgresRunClaimer{db: db, instance: instance}
+}
+
+// Claim inserts the run, succeeding only if no other scheduler inserted it
+func (c *PostgresRunClaimer) Claim(ctx context.Context, scheduleID string, runAt time.Time) (bool, error) {
+	result, err := c.db.ExecContext(ctx, `
+		INSERT INTO schedule_runs (schedule_id, run_at, claimed_by, claimed_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (schedule_id, run_at) DO NOTHING`,
+		scheduleID, runAt.UTC(), c.instance,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim run: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return inserted == 1, nil
+}
+
+// LastClaimed returns the latest run recorded for a schedule
+func (c *PostgresRunClaimer) LastClaimed(ctx context.Context, scheduleID string) (*time.Time, error) {
+	var last sql.NullTime
+	err := c.db.QueryRowContext(ctx,
+		`SELECT MAX(run_at) FROM schedule_runs WHERE schedule_id = $1`, scheduleID,
+	).Scan(&last)
+	if err != nil {
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+// RedisRunClaimer claims runs with Redis locks that are left to expire
+type RedisRunClaimer struct {
+	cache *cache.RedisCache
+}
+
+// NewRedisRunClaimer creates a claimer backed by Redis
+func NewRedisRunClaimer(c *cache.RedisCache) *RedisRunClaimer {
+	return &RedisRunClaimer{cache: c}
+}
+
+// Claim acquires the lock of the run and records it as the latest run
+func (c *RedisRunClaimer) Claim(ctx context.Context, scheduleID string, runAt time.Time) (bool, error) {
+	lock := c.cache.NewLock(fmt.Sprintf("schedule-run:%s:%d", scheduleID, runAt.Unix()), runClaimTTL)
+	claimed, err := lock.Acquire(ctx)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	// Runs are claimed in order, so the latest claim is the last run
+	if err := c.cache.Set(ctx, "schedule-last-run:"+scheduleID, runAt.Unix(), 0); err != nil {
+		return true, fmt.Errorf("failed to record last run: %w", err)
+	}
+	return true, nil
+}
+
+// LastClaimed returns the latest run recorded for a schedule
+func (c *RedisRunClaimer) LastClaimed(ctx context.Context, scheduleID string) (*time.Time, error) {
+	var unix int64
+	if err := c.cache.Get(ctx, "schedule-last-run:"+scheduleID, &unix); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	last := time.Unix(unix, 0)
+	return &last, nil
+}
+
+// MissedRuns returns the runs of a schedule due after since and up to now
+// that the policy wants executed: none when skipping, otherwise at most
+// maxCatchupRuns of the latest ones
+func MissedRuns(schedule cron.Schedule, since, now time.Time, policy string) []time.Time {
+	if policy != MissedRunCatchup {
+		return nil
+	}
+
+	var runs []time.Time
+	for next := schedule.Next(since); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		runs = append(runs, next)
+		if len(runs) > maxCatchupRuns {
+			runs = runs[1:]
+		}
+	}
+	return runs
+}
+
+// intervalSchedule fires every interval, aligned to the Unix epoch rather
+// than to when the scheduler started, so that replicas agree on run times
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Next returns the first aligned time after t
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.interval).Add(s.interval)
+}
+
+// parseSchedule parses a six-field cron expression, evaluated in the
+// location of the times it is given, or builds an interval schedule of at
+// least a second when no expression is given
+func parseSchedule(cronExpr string, interval time.Duration) (cron.Schedule, error) {
+	if cronExpr != "" {
+		parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+		schedule, err := parser.Parse(cronExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression: %w", err)
+		}
+		return schedule, nil
+	}
+
+	if interval <= 0 {
+		return nil, fmt.Errorf("schedule must have cron expression or interval")
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return intervalSchedule{interval: interval.Truncate(time.Second)}, nil
+}
@@ -9,18 +9,37 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
 )
 
-// Scheduler manages scheduled workflow executions
+// Scheduler manages scheduled workflow executions. Schedulers on several
+// replicas coordinate through a RunClaimer, so that each run of a schedule
+// is executed by exactly one of them.
 type Scheduler struct {
-	cron       *cron.Cron
-	engine     *Engine
-	pool       *WorkerPool
-	schedules  map[string]*ScheduleEntry
-	mu         sync.RWMutex
-	repository ScheduleRepository
+	cron            *cron.Cron
+	engine          *Engine
+	pool            *WorkerPool
+	schedules       map[string]*ScheduleEntry
+	mu              sync.RWMutex
+	repository      ScheduleRepository
+	claimer         RunClaimer
+	location        *time.Location
+	missedRunPolicy string
+	maxConcurrent   int
+	slotKey         string // Concurrency limit shared by the runs of this scheduler
+	pollStates      PollStateStore
 }
 
+// Policies for a run that falls due while an earlier run of the same
+// schedule is still active
+const (
+	OverlapAllow  = "allow"  // Run both at once
+	OverlapSkip   = "skip"   // Record the new run as skipped
+	OverlapQueue  = "queue"  // Start the new run once the earlier one finishes
+	OverlapCancel = "cancel" // Cancel the earlier run
+)
+
 // ScheduleEntry represents a scheduled workflow
 type ScheduleEntry struct {
 	ID          string
@@ -36,6 +55,11 @@ type ScheduleEntry struct {
 	EntryID     cron.EntryID
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	Overlap     string // allow, skip, queue, cancel
+	schedule    cron.Schedule
+	trigger     bool     // Created from a schedule trigger node by SyncWorkflow
+	poll        bool     // Polls its trigger node, running only when it finds new items
+	executions  []string // Runs started that may still be active
 }
 
 // ScheduleRepository defines schedule persistence
@@ -51,7 +75,7 @@ type ScheduleRepository interface {
 // SchedulerConfig holds scheduler configuration
 type SchedulerConfig struct {
 	Timezone        string
-	MaxConcurrent   int
+	MaxConcurrent   int    // Scheduled executions running at once, zero for no limit; others queue
 	MissedRunPolicy string // skip, catchup
 }
 
@@ -72,15 +96,38 @@ func NewScheduler(engine *Engine, pool *WorkerPool, repo ScheduleRepository, con
 		),
 	)
 
+	missedRunPolicy := MissedRunSkip
+	if config != nil && config.MissedRunPolicy != "" {
+		missedRunPolicy = config.MissedRunPolicy
+	}
+
+	maxConcurrent := 0
+	if config != nil {
+		maxConcurrent = config.MaxConcurrent
+	}
+
 	return &Scheduler{
-		cron:       c,
-		engine:     engine,
-		pool:       pool,
-		schedules:  make(map[string]*ScheduleEntry),
-		repository: repo,
+		cron:            c,
+		engine:          engine,
+		pool:            pool,
+		schedules:       make(map[string]*ScheduleEntry),
+		repository:      repo,
+		claimer:         NewInMemoryRunClaimer(),
+		location:        location,
+		missedRunPolicy: missedRunPolicy,
+		maxConcurrent:   maxConcurrent,
+		slotKey:         "scheduler:" + uuid.New().String(),
+		pollStates:      NewInMemoryPollStateStore(),
 	}
 }
 
+// WithRunClaimer sets how runs are claimed. The default in-memory claimer
+// only suits a single replica; replicas must share a Postgres or Redis one.
+func (s *Scheduler) WithRunClaimer(claimer RunClaimer) *Scheduler {
+	s.claimer = claimer
+	return s
+}
+
 // Start starts the scheduler
 func (s *Scheduler) Start(ctx context.Context) error {
 	// Load existing schedules
@@ -95,6 +142,7 @@ func (s *Scheduler) Start(ctx context.Context) error {
 				// Log error but continue
 				continue
 			}
+			s.catchUp(ctx, schedule)
 		}
 	}
 
@@ -102,6 +150,26 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	return nil
 }
 
+// catchUp applies the missed run policy to the runs of a schedule that fell
+// due while no scheduler was running. Every replica catches up on start;
+// claims keep each missed run from executing more than once.
+func (s *Scheduler) catchUp(ctx context.Context, schedule *ScheduleEntry) {
+	since := schedule.LastRun
+	if last, err := s.claimer.LastClaimed(ctx, schedule.ID); err == nil && last != nil {
+		if since == nil || last.After(*since) {
+			since = last
+		}
+	}
+	if since == nil {
+		return
+	}
+
+	now := time.Now().In(s.location)
+	for _, runAt := range MissedRuns(schedule.schedule, since.In(s.location), now, s.missedRunPolicy) {
+		s.executeScheduledWorkflow(schedule, runAt)
+	}
+}
+
 // Stop stops the scheduler
 func (s *Scheduler) Stop() context.Context {
 	return s.cron.Stop()
@@ -116,11 +184,8 @@ func (s *Scheduler) CreateSchedule(ctx context.Context, schedule *ScheduleEntry)
 	schedule.UpdatedAt = time.Now()
 
 	// Validate cron expression
-	if schedule.CronExpr != "" {
-		parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-		if _, err := parser.Parse(schedule.CronExpr); err != nil {
-			return fmt.Errorf("invalid cron expression: %w", err)
-		}
+	if _, err := parseSchedule(schedule.CronExpr, schedule.Interval); err != nil {
+		return err
 	}
 
 	// Save to repository
@@ -142,14 +207,13 @@ func (s *Scheduler) CreateSchedule(ctx context.Context, schedule *ScheduleEntry)
 
 // UpdateSchedule updates an existing schedule
 func (s *Scheduler) UpdateSchedule(ctx context.Context, schedule *ScheduleEntry) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	// Remove old schedule
+	s.mu.Lock()
 	if existing, ok := s.schedules[schedule.ID]; ok {
 		s.cron.Remove(existing.EntryID)
 		delete(s.schedules, schedule.ID)
 	}
+	s.mu.Unlock()
 
 	schedule.UpdatedAt = time.Now()
 
@@ -238,6 +302,85 @@ func (s *Scheduler) DisableSchedule(ctx context.Context, id string) error {
 	return nil
 }
 
+// SyncWorkflow schedules the schedule, interval and polling triggers of a
+// workflow, replacing those scheduled for it before. A trigger's schedule ID
+// is derived from the workflow and node, so every replica claims its runs
+// and polls under the same ID.
+func (s *Scheduler) SyncWorkflow(ctx context.Context, workflow *WorkflowDefinition) error {
+	s.RemoveWorkflow(workflow.ID)
+
+	for _, node := range workflow.Nodes {
+		_, polling := pollingExecutor(node.Type)
+		if triggerModes[node.Type] != "schedule" && !polling {
+			continue
+		}
+
+		now := time.Now()
+		schedule := &ScheduleEntry{
+			ID:         workflow.ID + ":" + node.ID,
+			WorkflowID: workflow.ID,
+			Enabled:    true,
+			Options:    &ExecutionOptions{TriggerNodeID: node.ID},
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			trigger:    true,
+			poll:       polling,
+		}
+		schedule.Overlap, _ = node.Config["overlap"].(string)
+		if mode, _ := node.Config["mode"].(string); mode == "cron" {
+			schedule.CronExpr, _ = node.Config["cronExpression"].(string)
+		} else {
+			schedule.Interval = triggerInterval(node.Config)
+		}
+
+		if err := s.addSchedule(schedule); err != nil {
+			return fmt.Errorf("failed to schedule trigger %s: %w", node.ID, err)
+		}
+		// Items that appeared while no scheduler ran are found by the next
+		// poll, so only schedule triggers catch up
+		if !polling {
+			s.catchUp(ctx, schedule)
+		}
+	}
+
+	return nil
+}
+
+// RemoveWorkflow unschedules the triggers of a workflow scheduled by
+// SyncWorkflow
+func (s *Scheduler) RemoveWorkflow(workflowID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, schedule := range s.schedules {
+		if schedule.trigger && schedule.WorkflowID == workflowID {
+			s.cron.Remove(schedule.EntryID)
+			delete(s.schedules, id)
+		}
+	}
+}
+
+// triggerInterval reads the interval of a schedule trigger node, given in
+// the node's unit and defaulting to a minute
+func triggerInterval(config map[string]interface{}) time.Duration {
+	interval := 60.0
+	switch v := config["interval"].(type) {
+	case int:
+		interval = float64(v)
+	case float64:
+		interval = v
+	}
+
+	unit := time.Second
+	switch config["unit"] {
+	case "minutes":
+		unit = time.Minute
+	case "hours":
+		unit = time.Hour
+	}
+	return time.Duration(interval * float64(unit))
+}
+
 // GetSchedule returns a schedule by ID
 func (s *Scheduler) GetSchedule(id string) (*ScheduleEntry, error) {
 	s.mu.RLock()
@@ -280,73 +423,178 @@ func (s *Scheduler) ListByWorkflow(workflowID string) []*ScheduleEntry {
 }
 
 func (s *Scheduler) addSchedule(schedule *ScheduleEntry) error {
-	var spec string
-	if schedule.CronExpr != "" {
-		spec = schedule.CronExpr
-	} else if schedule.Interval > 0 {
-		spec = fmt.Sprintf("@every %s", schedule.Interval)
-	} else {
-		return fmt.Errorf("schedule must have cron expression or interval")
-	}
-
-	entryID, err := s.cron.AddFunc(spec, func() {
-		s.executeScheduledWorkflow(schedule)
-	})
+	cronSchedule, err := parseSchedule(schedule.CronExpr, schedule.Interval)
 	if err != nil {
-		return fmt.Errorf("failed to add cron job: %w", err)
+		return err
 	}
+	schedule.schedule = cronSchedule
 
-	schedule.EntryID = entryID
-
-	// Calculate next run
-	entry := s.cron.Entry(entryID)
-	schedule.NextRun = entry.Next
-
+	// Runs are claimed by the time they were due, which cron records as the
+	// entry's previous activation before starting the job
 	s.mu.Lock()
+	defer s.mu.Unlock()
+	entryID := s.cron.Schedule(cronSchedule, cron.FuncJob(func() {
+		s.mu.RLock()
+		id := schedule.EntryID
+		s.mu.RUnlock()
+		s.executeScheduledWorkflow(schedule, s.cron.Entry(id).Prev)
+	}))
+
+	schedule.EntryID = entryID
+	schedule.NextRun = s.cron.Entry(entryID).Next
 	s.schedules[schedule.ID] = schedule
-	s.mu.Unlock()
 
 	return nil
 }
 
-func (s *Scheduler) executeScheduledWorkflow(schedule *ScheduleEntry) {
+// executeScheduledWorkflow executes the run of a schedule due at runAt,
+// unless another scheduler claimed it first. Overlap with earlier runs of
+// the schedule is resolved by the schedule's overlap policy; earlier runs
+// are only known to the scheduler that started them.
+func (s *Scheduler) executeScheduledWorkflow(schedule *ScheduleEntry, runAt time.Time) {
 	ctx := context.Background()
 
-	// Update last run
-	now := time.Now()
-	schedule.LastRun = &now
-	schedule.RunCount++
+	claimed, err := s.claimer.Claim(ctx, schedule.ID, runAt)
+	if err != nil || !claimed {
+		return
+	}
 
-	// Update next run
-	entry := s.cron.Entry(schedule.EntryID)
-	schedule.NextRun = entry.Next
+	// Update last and next run
+	s.mu.Lock()
+	schedule.LastRun = &runAt
+	schedule.RunCount++
+	schedule.NextRun = s.cron.Entry(schedule.EntryID).Next
+	runCount := schedule.RunCount
+	s.mu.Unlock()
 
 	// Set trigger mode
-	options := schedule.Options
-	if options == nil {
-		options = &ExecutionOptions{}
-	}
+	options := schedule.executionOptions()
 	options.Mode = "schedule"
 	options.TriggerData = map[string]interface{}{
-		"scheduleId":   schedule.ID,
-		"scheduledAt":  now.Format(time.RFC3339),
-		"runCount":     schedule.RunCount,
-		"cronExpr":     schedule.CronExpr,
+		"scheduleId":  schedule.ID,
+		"scheduledAt": runAt.Format(time.RFC3339),
+		"runCount":    runCount,
+		"cronExpr":    schedule.CronExpr,
 	}
 
-	// Submit to worker pool if available
-	if s.pool != nil {
-		// Need to load workflow - in production, would use repository
-		// For now, skip actual execution
+	workflow, err := s.loadWorkflow(ctx, schedule.WorkflowID)
+	if err != nil {
 		return
 	}
 
+	// A polling trigger starts one execution with the new items it found
+	var polled *PollState
+	if schedule.poll {
+		// New items are left to a later poll rather than skipped
+		if schedule.Overlap == OverlapSkip && len(s.activeRuns(schedule)) > 0 {
+			return
+		}
+		items, state, err := s.poll(ctx, schedule, workflow)
+		if err != nil {
+			s.engine.events.Emit(ExecutionEvent{
+				Type:       EventTypeTriggerFailed,
+				WorkflowID: workflow.ID,
+				NodeID:     options.TriggerNodeID,
+				Timestamp:  time.Now(),
+				Data:       map[string]interface{}{"error": err.Error()},
+			})
+			return
+		}
+		if len(items) == 0 {
+			s.savePollState(ctx, schedule, workflow, state)
+			return
+		}
+		polled = state
+		options.Mode = "polling"
+		options.TriggerData = map[string]interface{}{"items": runtime.ItemsJSON(items)}
+	}
+
+	active := s.activeRuns(schedule)
+	switch {
+	case len(active) == 0:
+	case schedule.Overlap == OverlapSkip:
+		s.engine.skipExecution(workflow, options, fmt.Sprintf("previous run %s is still active", active[0]))
+		return
+	case schedule.Overlap == OverlapCancel:
+		for _, id := range active {
+			s.engine.CancelExecution(id)
+		}
+	}
+
+	if schedule.Overlap == OverlapQueue {
+		options.slots = append(options.slots, executionSlot{key: "schedule:" + schedule.ID, limit: 1})
+	}
+	if s.maxConcurrent > 0 {
+		options.slots = append(options.slots, executionSlot{key: s.slotKey, limit: s.maxConcurrent})
+	}
+
+	executionID, err := s.submitWorkflow(workflow, options)
+	if err != nil {
+		return
+	}
+	if polled != nil {
+		s.savePollState(ctx, schedule, workflow, polled)
+	}
+
+	s.mu.Lock()
+	schedule.executions = append(schedule.executions, executionID)
+	s.mu.Unlock()
+
 	// Update repository
 	if s.repository != nil {
 		s.repository.Update(ctx, schedule)
 	}
 }
 
+// activeRuns returns the runs of a schedule that may still be active,
+// forgetting those that finished
+func (s *Scheduler) activeRuns(schedule *ScheduleEntry) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := schedule.executions[:0]
+	for _, id := range schedule.executions {
+		if s.engine.executionActive(id) {
+			active = append(active, id)
+		}
+	}
+	schedule.executions = active
+	return append([]string(nil), active...)
+}
+
+// runWorkflow loads a workflow through the engine's workflow loader and
+// executes it
+func (s *Scheduler) runWorkflow(ctx context.Context, workflowID string, options *ExecutionOptions) (string, error) {
+	workflow, err := s.loadWorkflow(ctx, workflowID)
+	if err != nil {
+		return "", err
+	}
+	return s.submitWorkflow(workflow, options)
+}
+
+func (s *Scheduler) loadWorkflow(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+	if s.engine == nil || s.engine.workflows == nil {
+		return nil, fmt.Errorf("workflow %s not loaded: engine has no workflow loader", workflowID)
+	}
+	return s.engine.workflows(ctx, workflowID)
+}
+
+// submitWorkflow executes a workflow in the background, on the worker pool
+// when there is one. The execution is pending until it starts.
+func (s *Scheduler) submitWorkflow(workflow *WorkflowDefinition, options *ExecutionOptions) (string, error) {
+	return s.engine.submit(s.pool, workflow, options)
+}
+
+// executionOptions returns a copy of the options of a schedule, so runs
+// never share trigger data
+func (e *ScheduleEntry) executionOptions() *ExecutionOptions {
+	if e.Options == nil {
+		return &ExecutionOptions{}
+	}
+	options := *e.Options
+	return &options
+}
+
 // TriggerNow manually triggers a scheduled workflow
 func (s *Scheduler) TriggerNow(ctx context.Context, id string) (string, error) {
 	s.mu.RLock()
@@ -358,10 +606,7 @@ func (s *Scheduler) TriggerNow(ctx context.Context, id string) (string, error) {
 	}
 
 	// Set trigger mode
-	options := schedule.Options
-	if options == nil {
-		options = &ExecutionOptions{}
-	}
+	options := schedule.executionOptions()
 	options.Mode = "manual"
 	options.TriggerData = map[string]interface{}{
 		"scheduleId":  schedule.ID,
@@ -369,13 +614,7 @@ func (s *Scheduler) TriggerNow(ctx context.Context, id string) (string, error) {
 		"manual":      true,
 	}
 
-	// Submit to worker pool
-	if s.pool != nil {
-		// Would need to load workflow from repository
-		return "", fmt.Errorf("workflow not loaded")
-	}
-
-	return "", nil
+	return s.runWorkflow(ctx, schedule.WorkflowID, options)
 }
 
 // GetNextRuns returns the next N scheduled runs
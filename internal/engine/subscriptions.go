@@ -0,0 +1,197 @@
+// Package engine provides subscribing triggers
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// Bounds of the delay before a failed subscription subscribes again
+const (
+	minResubscribeDelay = time.Second
+	maxResubscribeDelay = time.Minute
+)
+
+// Subscriptions runs the subscribing triggers of active workflows, such as
+// Kafka and platform event triggers, starting an execution for each message
+// or batch of messages they deliver. Every replica runs them: message
+// sources such as Kafka consumer groups share the messages out among them.
+type Subscriptions struct {
+	engine  *Engine
+	pool    *WorkerPool
+	mu      sync.Mutex
+	running map[string]*subscription // By workflow and trigger node
+}
+
+type subscription struct {
+	workflowID string
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// NewSubscriptions creates the runner of subscribing triggers, executing
+// on pool when there is one
+func NewSubscriptions(engine *Engine, pool *WorkerPool) *Subscriptions {
+	return &Subscriptions{
+		engine:  engine,
+		pool:    pool,
+		running: make(map[string]*subscription),
+	}
+}
+
+// subscribingExecutor returns the executor of a node type if it is a
+// subscribing trigger
+func subscribingExecutor(nodeType string) (runtime.SubscribingExecutor, bool) {
+	executor, err := runtime.Get(nodeType)
+	if err != nil || !executor.GetMetadata().IsTrigger {
+		return nil, false
+	}
+	subscriber, ok := executor.(runtime.SubscribingExecutor)
+	return subscriber, ok
+}
+
+// SyncWorkflow subscribes the subscribing triggers of a workflow, replacing
+// those subscribed for it before
+func (s *Subscriptions) SyncWorkflow(ctx context.Context, workflow *WorkflowDefinition) error {
+	s.RemoveWorkflow(workflow.ID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range workflow.Nodes {
+		node := &workflow.Nodes[i]
+		subscriber, ok := subscribingExecutor(node.Type)
+		if !ok {
+			continue
+		}
+
+		runCtx, cancel := context.WithCancel(context.Background())
+		sub := &subscription{workflowID: workflow.ID, cancel: cancel, done: make(chan struct{})}
+		s.running[workflow.ID+":"+node.ID] = sub
+		go func() {
+			defer close(sub.done)
+			s.run(runCtx, workflow, node, subscriber)
+		}()
+	}
+	return nil
+}
+
+// RemoveWorkflow unsubscribes the triggers of a workflow and waits for them
+// to stop
+func (s *Subscriptions) RemoveWorkflow(workflowID string) {
+	s.mu.Lock()
+	var stopped []*subscription
+	for key, sub := range s.running {
+		if sub.workflowID == workflowID {
+			sub.cancel()
+			stopped = append(stopped, sub)
+			delete(s.running, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sub := range stopped {
+		<-sub.done
+	}
+}
+
+// Stop unsubscribes every trigger
+func (s *Subscriptions) Stop() {
+	s.mu.Lock()
+	var workflowIDs []string
+	for _, sub := range s.running {
+		workflowIDs = append(workflowIDs, sub.workflowID)
+	}
+	s.mu.Unlock()
+
+	for _, id := range workflowIDs {
+		s.RemoveWorkflow(id)
+	}
+}
+
+// run keeps a trigger subscribed until ctx is done, subscribing again with
+// a growing delay when its subscription fails
+func (s *Subscriptions) run(ctx context.Context, workflow *WorkflowDefinition, node *NodeDefinition, subscriber runtime.SubscribingExecutor) {
+	delay := minResubscribeDelay
+	for ctx.Err() == nil {
+		started := time.Now()
+		err := s.subscribe(ctx, workflow, node, subscriber)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("subscription ended")
+		}
+		s.engine.events.Emit(ExecutionEvent{
+			Type:       EventTypeTriggerFailed,
+			WorkflowID: workflow.ID,
+			NodeID:     node.ID,
+			Timestamp:  time.Now(),
+			Data:       map[string]interface{}{"error": err.Error()},
+		})
+
+		if time.Since(started) > maxResubscribeDelay {
+			delay = minResubscribeDelay
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxResubscribeDelay {
+			delay = maxResubscribeDelay
+		}
+	}
+}
+
+func (s *Subscriptions) subscribe(ctx context.Context, workflow *WorkflowDefinition, node *NodeDefinition, subscriber runtime.SubscribingExecutor) error {
+	options := &ExecutionOptions{TriggerNodeID: node.ID, UserID: workflow.UserID}
+
+	var credentials map[string]interface{}
+	if node.Credential != "" && s.engine.credentials != nil {
+		all, err := s.engine.credentials(ctx, workflow, options)
+		if err != nil {
+			return fmt.Errorf("failed to load credentials: %w", err)
+		}
+		credentials = all[node.Credential]
+	}
+
+	return subscriber.Subscribe(ctx, &runtime.ExecutionInput{
+		NodeID:      node.ID,
+		NodeConfig:  node.Config,
+		Credentials: credentials,
+		Context: &runtime.ExecutionContext{
+			WorkflowID: workflow.ID,
+			UserID:     workflow.UserID,
+			Mode:       "event",
+			Binary:     s.engine.binary,
+			Egress:     s.engine.egress,
+			Events:     s.engine.platform,
+		},
+	}, func(ctx context.Context, items []runtime.Item) error {
+		return s.deliver(ctx, workflow, node, items)
+	})
+}
+
+// deliver starts an execution of the latest version of a workflow with the
+// items of a message
+func (s *Subscriptions) deliver(ctx context.Context, workflow *WorkflowDefinition, node *NodeDefinition, items []runtime.Item) error {
+	if s.engine.workflows != nil {
+		latest, err := s.engine.workflows(ctx, workflow.ID)
+		if err != nil {
+			return err
+		}
+		workflow = latest
+	}
+
+	_, err := s.engine.submit(s.pool, workflow, &ExecutionOptions{
+		Mode:          "event",
+		TriggerNodeID: node.ID,
+		TriggerData:   map[string]interface{}{"items": runtime.ItemsJSON(items)},
+		UserID:        workflow.UserID,
+	})
+	return err
+}
@@ -0,0 +1,6 @@
+// Package engine provides sub-workflow executions
+package engine
+
+// subWorkflowMode is the execution mode of workflows called by a node of
+// another workflow
+const subWorkflowMode = "subworkflow"
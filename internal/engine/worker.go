@@ -24,6 +24,7 @@ type WorkerPool struct {
 	cancel        context.CancelFunc
 	engine        *Engine
 	metrics       *PoolMetrics
+	staleAfter    time.Duration
 }
 
 // Worker represents a single worker in the pool
@@ -71,6 +72,7 @@ const (
 	TaskTypeNodeExecution     TaskType = "node_execution"
 	TaskTypeWebhookTrigger    TaskType = "webhook_trigger"
 	TaskTypeScheduleTrigger   TaskType = "schedule_trigger"
+	TaskTypeResumeExecution   TaskType = "resume_execution"
 )
 
 // TaskResult represents the result of a task execution
@@ -128,6 +130,7 @@ type PoolConfig struct {
 	IdleTimeout    time.Duration
 	ScaleUpDelay   time.Duration
 	ScaleDownDelay time.Duration
+	StaleAfter     time.Duration // Running executions persisted without a lease are resumed on start once not checkpointed for this long
 }
 
 // DefaultPoolConfig returns default pool configuration
@@ -139,6 +142,7 @@ func DefaultPoolConfig() *PoolConfig {
 		IdleTimeout:    30 * time.Second,
 		ScaleUpDelay:   5 * time.Second,
 		ScaleDownDelay: 30 * time.Second,
+		StaleAfter:     2 * time.Minute,
 	}
 }
 
@@ -159,6 +163,7 @@ func NewWorkerPool(engine *Engine, config *PoolConfig) *WorkerPool {
 		cancel:      cancel,
 		engine:      engine,
 		metrics:     &PoolMetrics{},
+		staleAfter:  config.StaleAfter,
 	}
 
 	return pool
@@ -180,6 +185,9 @@ func (p *WorkerPool) Start(numWorkers int) {
 
 	// Start metrics collector
 	go p.collectMetrics()
+
+	// Pick up executions interrupted by a previous shutdown
+	go p.recoverExecutions()
 }
 
 // Stop stops the worker pool gracefully
@@ -223,7 +231,10 @@ func (p *WorkerPool) Submit(task *Task) error {
 
 // SubmitWorkflow submits a workflow for execution
 func (p *WorkerPool) SubmitWorkflow(workflow *WorkflowDefinition, options *ExecutionOptions) (string, error) {
-	executionID := uuid.New().String()
+	executionID := options.ExecutionID
+	if executionID == "" {
+		executionID = uuid.New().String()
+	}
 
 	task := &Task{
 		ID:          uuid.New().String(),
@@ -347,6 +358,8 @@ func (p *WorkerPool) executeTask(worker *Worker, task *Task) *TaskResult {
 		output, err = p.executeWorkflowTask(ctx, task)
 	case TaskTypeNodeExecution:
 		output, err = p.executeNodeTask(ctx, task)
+	case TaskTypeResumeExecution:
+		output, err = p.executeResumeTask(ctx, task)
 	default:
 		err = fmt.Errorf("unknown task type: %s", task.Type)
 	}
@@ -387,7 +400,28 @@ func (p *WorkerPool) executeTask(worker *Worker, task *Task) *TaskResult {
 }
 
 func (p *WorkerPool) executeWorkflowTask(ctx context.Context, task *Task) (map[string]interface{}, error) {
-	state, err := p.engine.Execute(ctx, task.Workflow, task.Options)
+	options := task.Options
+	if options == nil {
+		options = &ExecutionOptions{}
+	}
+	if options.ExecutionID == "" {
+		options.ExecutionID = task.ExecutionID
+	}
+
+	state, err := p.engine.Execute(ctx, task.Workflow, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"executionId": state.ID,
+		"status":      state.Status,
+		"outputs":     state.NodeOutputs,
+	}, nil
+}
+
+func (p *WorkerPool) executeResumeTask(ctx context.Context, task *Task) (map[string]interface{}, error) {
+	state, err := p.engine.Resume(ctx, task.ExecutionID)
 	if err != nil {
 		return nil, err
 	}
@@ -399,6 +433,24 @@ func (p *WorkerPool) executeWorkflowTask(ctx context.Context, task *Task) (map[s
 	}, nil
 }
 
+// recoverExecutions re-arms parked executions and queues interrupted ones
+func (p *WorkerPool) recoverExecutions() {
+	ids, err := p.engine.RecoverExecutions(p.ctx, p.staleAfter)
+	if err != nil {
+		return
+	}
+
+	for _, id := range ids {
+		p.Submit(&Task{
+			Type:        TaskTypeResumeExecution,
+			ExecutionID: id,
+			Priority:    5,
+			Timeout:     5 * time.Minute,
+			Metadata:    make(map[string]interface{}),
+		})
+	}
+}
+
 func (p *WorkerPool) executeNodeTask(ctx context.Context, task *Task) (map[string]interface{}, error) {
 	// For individual node execution (used in parallel execution)
 	// This would be implemented for parallel node execution
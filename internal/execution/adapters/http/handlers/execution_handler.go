@@ -35,6 +35,7 @@ func (h *ExecutionHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/executions/{id}/cancel", h.CancelExecution).Methods("POST")
 	router.HandleFunc("/executions/{id}/pause", h.PauseExecution).Methods("POST")
 	router.HandleFunc("/executions/{id}/resume", h.ResumeExecution).Methods("POST")
+	router.HandleFunc("/executions/resume/{id}/{token}", h.ResumeWaitingExecution).Methods("POST")
 	router.HandleFunc("/workflows/{workflowId}/execute", h.ExecuteWorkflow).Methods("POST")
 	router.HandleFunc("/executions/{id}/logs", h.GetExecutionLogs).Methods("GET")
 }
@@ -266,6 +267,30 @@ func (h *ExecutionHandler) ResumeExecution(w http.ResponseWriter, r *http.Reques
 	h.respondJSON(w, http.StatusOK, map[string]string{"message": "execution resumed"})
 }
 
+// ResumeWaitingExecution resumes an execution waiting for a webhook
+func (h *ExecutionHandler) ResumeWaitingExecution(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	
+	// Get execution ID and resume token from path
+	vars := mux.Vars(r)
+	executionID := vars["id"]
+
+	var payload map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		payload = make(map[string]interface{})
+	}
+
+	// Resume execution
+	err := h.service.ResumeWaitingExecution(ctx, model.ExecutionID(executionID), vars["token"], payload)
+	if err != nil {
+		h.logger.Error("Failed to resume waiting execution", "error", err, "execution_id", executionID)
+		h.respondError(w, http.StatusNotFound, "execution not found or not waiting")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "execution resumed"})
+}
+
 // GetExecutionLogs gets execution logs
 func (h *ExecutionHandler) GetExecutionLogs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
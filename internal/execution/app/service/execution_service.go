@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/linkflow-ai/linkflow-ai/internal/engine"
 	"github.com/linkflow-ai/linkflow-ai/internal/execution/domain/model"
 	"github.com/linkflow-ai/linkflow-ai/internal/execution/domain/repository"
 	executorService "github.com/linkflow-ai/linkflow-ai/internal/execution/domain/service"
@@ -28,6 +29,7 @@ type ExecutionService struct {
 	eventPublisher   *kafka.EventPublisher
 	cache            *cache.RedisCache
 	logger           logger.Logger
+	engine           *engine.Engine
 }
 
 // NewExecutionService creates a new execution service
@@ -47,6 +49,22 @@ func NewExecutionService(
 	}
 }
 
+// WithEngine resumes executions on the workflow engine and pauses them while
+// the engine is parked at a Wait node
+func (s *ExecutionService) WithEngine(eng *engine.Engine) *ExecutionService {
+	s.engine = eng
+	eng.WithWaitHandler(s.handleWait)
+	return s
+}
+
+// handleWait pauses an execution the engine parked at a Wait node
+func (s *ExecutionService) handleWait(ctx context.Context, state *engine.ExecutionState, resumeAt *time.Time) {
+	err := s.PauseExecution(ctx, model.ExecutionID(state.ID))
+	if err != nil && !errors.Is(err, ErrExecutionNotFound) {
+		s.logger.Error("Failed to pause waiting execution", "error", err, "execution_id", state.ID)
+	}
+}
+
 // StartExecutionCommand represents a command to start an execution
 type StartExecutionCommand struct {
 	WorkflowID  string
@@ -293,7 +311,11 @@ func (s *ExecutionService) ResumeExecution(ctx context.Context, executionID mode
 	}
 
 	// Resume async execution
-	go s.executeAsync(context.Background(), execution)
+	if s.engine != nil {
+		go s.resumeOnEngine(executionID)
+	} else {
+		go s.executeAsync(context.Background(), execution)
+	}
 
 	// Invalidate cache
 	if s.cache != nil {
@@ -305,6 +327,52 @@ func (s *ExecutionService) ResumeExecution(ctx context.Context, executionID mode
 	return nil
 }
 
+// ResumeWaitingExecution resumes an execution parked at a Wait node that
+// waits for a webhook. The payload is merged into the Wait node's output and
+// the rest of the workflow runs before this returns.
+func (s *ExecutionService) ResumeWaitingExecution(ctx context.Context, executionID model.ExecutionID, token string, payload map[string]interface{}) error {
+	if s.engine == nil {
+		return fmt.Errorf("workflow engine not configured")
+	}
+
+	// Executions started directly on the engine have no record here
+	execution, err := s.executionRepo.FindByID(ctx, executionID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to get execution: %w", err)
+	}
+
+	state, err := s.engine.ResumeWebhook(context.WithoutCancel(ctx), executionID.String(), token, payload)
+	if err != nil {
+		return fmt.Errorf("failed to resume execution: %w", err)
+	}
+
+	if execution != nil && execution.Resume() == nil {
+		if err := s.executionRepo.Update(ctx, execution); err != nil {
+			s.logger.Error("Failed to update resumed execution", "error", err, "execution_id", executionID)
+		}
+	}
+
+	// Invalidate cache
+	if s.cache != nil {
+		cacheKey := fmt.Sprintf("execution:%s", executionID)
+		_ = s.cache.Delete(ctx, cacheKey)
+	}
+
+	s.logger.Info("Execution resumed by webhook", "execution_id", executionID, "status", state.Status)
+	return nil
+}
+
+// resumeOnEngine continues an execution from its engine checkpoint
+func (s *ExecutionService) resumeOnEngine(executionID model.ExecutionID) {
+	state, err := s.engine.Resume(context.Background(), executionID.String())
+	if err != nil {
+		s.logger.Error("Failed to resume execution on engine", "error", err, "execution_id", executionID)
+		return
+	}
+
+	s.logger.Info("Engine execution resumed", "execution_id", executionID, "status", state.Status)
+}
+
 // executeAsync executes a workflow asynchronously
 func (s *ExecutionService) executeAsync(ctx context.Context, execution *model.Execution) {
 	// TODO: Implement actual workflow execution
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/linkflow-ai/linkflow-ai/internal/engine"
 	"github.com/linkflow-ai/linkflow-ai/internal/execution/adapters/http/handlers"
 	"github.com/linkflow-ai/linkflow-ai/internal/execution/adapters/repository/postgres"
 	"github.com/linkflow-ai/linkflow-ai/internal/execution/app/service"
@@ -122,6 +123,12 @@ func (s *Server) initialize() error {
 		s.logger,
 	)
 
+	// Park Wait nodes on the engine so executions can be resumed by webhook
+	eng := engine.NewEngine().
+		WithRepository(engine.NewPostgresExecutionRepository(db.DB).WithTable("execution_service.engine_executions")).
+		WithResumeURL("/api/v1/executions/resume")
+	s.executionService.WithEngine(eng)
+
 	// Setup HTTP server
 	s.setupHTTPServer()
 
@@ -59,6 +59,7 @@ type Hub struct {
 	broadcast  chan *BroadcastMessage
 	register   chan *Client
 	unregister chan *Client
+	authorize  func(client *Client, channel string) bool
 	mu         sync.RWMutex
 }
 
@@ -80,6 +81,13 @@ func NewHub() *Hub {
 	}
 }
 
+// WithAuthorizer sets the check a client must pass to subscribe to a
+// channel; by default every channel may be subscribed to
+func (h *Hub) WithAuthorizer(authorize func(client *Client, channel string) bool) *Hub {
+	h.authorize = authorize
+	return h
+}
+
 // Run starts the hub
 func (h *Hub) Run() {
 	for {
@@ -90,22 +98,7 @@ func (h *Hub) Run() {
 			h.mu.Unlock()
 
 		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.Send)
-
-				// Remove from all channels
-				for channel := range client.Channels {
-					if clients, ok := h.channels[channel]; ok {
-						delete(clients, client)
-						if len(clients) == 0 {
-							delete(h.channels, channel)
-						}
-					}
-				}
-			}
-			h.mu.Unlock()
+			h.removeClient(client)
 
 		case message := <-h.broadcast:
 			h.broadcastToChannel(message)
@@ -113,6 +106,28 @@ func (h *Hub) Run() {
 	}
 }
 
+func (h *Hub) removeClient(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	close(client.Send)
+
+	// Remove from all channels
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	for channel := range client.Channels {
+		if clients, ok := h.channels[channel]; ok {
+			delete(clients, client)
+			if len(clients) == 0 {
+				delete(h.channels, channel)
+			}
+		}
+	}
+}
+
 func (h *Hub) broadcastToChannel(message *BroadcastMessage) {
 	h.mu.RLock()
 	clients, ok := h.channels[message.Channel]
@@ -140,17 +155,22 @@ func (h *Hub) broadcastToChannel(message *BroadcastMessage) {
 		return
 	}
 
+	// Clients too slow to keep up are dropped; Run cannot hand them to
+	// itself through the unregister channel
+	var slow []*Client
 	h.mu.RLock()
 	for client := range clients {
 		select {
 		case client.Send <- msgBytes:
 		default:
-			h.mu.RUnlock()
-			h.unregister <- client
-			h.mu.RLock()
+			slow = append(slow, client)
 		}
 	}
 	h.mu.RUnlock()
+
+	for _, client := range slow {
+		h.removeClient(client)
+	}
 }
 
 // Subscribe adds a client to a channel
@@ -196,7 +216,8 @@ func (h *Hub) Broadcast(channel, event string, data interface{}) {
 
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
-	hub *Hub
+	hub         *Hub
+	userFromReq func(r *http.Request) string
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
@@ -204,6 +225,14 @@ func NewWebSocketHandler(hub *Hub) *WebSocketHandler {
 	return &WebSocketHandler{hub: hub}
 }
 
+// WithUserResolver sets how the user of a connection is told, e.g. from the
+// context set by the auth middleware; by default the userId query parameter
+// is trusted
+func (h *WebSocketHandler) WithUserResolver(resolve func(r *http.Request) string) *WebSocketHandler {
+	h.userFromReq = resolve
+	return h
+}
+
 // ServeHTTP handles WebSocket upgrade requests
 func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -214,6 +243,9 @@ func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Get user info from context (set by auth middleware)
 	userID := r.URL.Query().Get("userId")
+	if h.userFromReq != nil {
+		userID = h.userFromReq(r)
+	}
 	tenantID := r.URL.Query().Get("tenantId")
 
 	client := &Client{
@@ -313,6 +345,18 @@ func (c *Client) writePump() {
 func (c *Client) handleMessage(msg *Message) {
 	switch msg.Type {
 	case MessageTypeSubscribe:
+		if msg.Channel != "" && c.hub.authorize != nil && !c.hub.authorize(c, msg.Channel) {
+			response := Message{
+				Type:      MessageTypeError,
+				Channel:   msg.Channel,
+				Data:      json.RawMessage(`{"message":"Not allowed to subscribe to this channel"}`),
+				Timestamp: time.Now(),
+			}
+			if data, err := json.Marshal(response); err == nil {
+				c.Send <- data
+			}
+			return
+		}
 		if msg.Channel != "" {
 			c.hub.Subscribe(c, msg.Channel)
 			response := Message{
@@ -361,6 +405,7 @@ const (
 	ChannelWorkflows     = "workflows"
 	ChannelNotifications = "notifications"
 	ChannelSystem        = "system"
+	ChannelDebug         = "debug" // Followed by the ID of a debug session
 )
 
 // Helper functions for broadcasting events
@@ -387,6 +432,12 @@ func (h *Hub) BroadcastNotification(userID string, notification interface{}) {
 	h.Broadcast(ChannelNotifications+"."+userID, "notification", notification)
 }
 
+// BroadcastDebugEvent broadcasts an event of a debug session to the client
+// debugging
+func (h *Hub) BroadcastDebugEvent(sessionID string, event string, data interface{}) {
+	h.Broadcast(ChannelDebug+"."+sessionID, "debug."+event, data)
+}
+
 // BroadcastSystemEvent broadcasts a system event
 func (h *Hub) BroadcastSystemEvent(event string, data interface{}) {
 	h.Broadcast(ChannelSystem, event, data)
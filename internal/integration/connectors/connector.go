@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
 )
 
 // Connector defines the interface for all integration connectors
@@ -51,11 +53,17 @@ type BaseConnector struct {
 	httpClient *http.Client
 }
 
-// NewBaseConnector creates a new base connector
+// NewBaseConnector creates a new base connector whose requests cannot reach
+// internal addresses
 func NewBaseConnector() *BaseConnector {
-	return &BaseConnector{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
-	}
+	return (&BaseConnector{}).WithEgressGuard(egress.Default())
+}
+
+// WithEgressGuard holds the connector's requests to the egress policy of
+// the workspace set on their context with egress.WithWorkspace
+func (c *BaseConnector) WithEgressGuard(guard *egress.Guard) *BaseConnector {
+	c.httpClient = guard.Client("", "connector", 30*time.Second)
+	return c
 }
 
 // DoRequest performs an HTTP request
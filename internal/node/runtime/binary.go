@@ -0,0 +1,79 @@
+// Package runtime provides binary data passed between nodes
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrBinaryNotFound is returned when a binary reference points to no data
+var ErrBinaryNotFound = errors.New("binary data not found")
+
+// BinaryData references binary content kept in a BinaryStore. Items carry the
+// reference rather than the bytes, so that files are neither held in memory
+// nor copied into node outputs and checkpoints.
+type BinaryData struct {
+	ID       string `json:"id"`
+	FileName string `json:"fileName,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size"`
+}
+
+// BinaryStore keeps the binary data of executions
+type BinaryStore interface {
+	// Put stores the content read from r, which may fail part way through,
+	// e.g. when it exceeds a size limit
+	Put(ctx context.Context, fileName, mimeType string, r io.Reader) (*BinaryData, error)
+
+	// Open returns a reader of stored content
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+}
+
+// MemoryBinaryStore keeps binary data in memory, for tests and single
+// process setups
+type MemoryBinaryStore struct {
+	blobs map[string][]byte
+	mu    sync.RWMutex
+}
+
+// NewMemoryBinaryStore creates a new in-memory binary store
+func NewMemoryBinaryStore() *MemoryBinaryStore {
+	return &MemoryBinaryStore{blobs: make(map[string][]byte)}
+}
+
+// Put reads r to the end and keeps its content
+func (s *MemoryBinaryStore) Put(ctx context.Context, fileName, mimeType string, r io.Reader) (*BinaryData, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	s.mu.Lock()
+	s.blobs[id] = content
+	s.mu.Unlock()
+
+	return &BinaryData{
+		ID:       id,
+		FileName: fileName,
+		MimeType: mimeType,
+		Size:     int64(len(content)),
+	}, nil
+}
+
+// Open returns a reader of kept content
+func (s *MemoryBinaryStore) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	content, ok := s.blobs[id]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrBinaryNotFound
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
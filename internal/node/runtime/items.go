@@ -0,0 +1,111 @@
+// Package runtime provides the item data model shared by nodes
+package runtime
+
+// Item is a single unit of data flowing between nodes. Nodes receive a list
+// of items and emit a list of items; by default the engine runs a node once
+// per input item.
+type Item struct {
+	JSON       map[string]interface{}
+	Binary     map[string]*BinaryData
+	PairedItem *PairedItem
+}
+
+// PairedItem links an output item back to the input item it was derived from
+type PairedItem struct {
+	Item  int // Index of the source item in the node's input
+	Input int // Index of the inbound connection the source item arrived on
+}
+
+// internal keys used by the engine that never become part of item JSON
+var internalDataKeys = map[string]bool{
+	"_output":    true,
+	"_loopState": true,
+}
+
+// NewItem creates an item from JSON data
+func NewItem(json map[string]interface{}) Item {
+	if json == nil {
+		json = make(map[string]interface{})
+	}
+	return Item{JSON: json}
+}
+
+// ItemsFromMaps creates one item per map, e.g. one per database row
+func ItemsFromMaps(rows []map[string]interface{}) []Item {
+	items := make([]Item, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, NewItem(row))
+	}
+	return items
+}
+
+// MetaKey is the key of the item JSON that holds the rest of an output
+// whose "items" array became the items, e.g. the page count of a paginated
+// request, read as $json.$meta.pages
+const MetaKey = "$meta"
+
+// ItemsFromData converts legacy map output into items. An "items" array of
+// objects becomes one item per element, each carrying the output's other
+// keys under MetaKey; anything else becomes a single item.
+func ItemsFromData(data map[string]interface{}) []Item {
+	if data == nil {
+		return nil
+	}
+
+	if arr, ok := data["items"].([]interface{}); ok {
+		var meta map[string]interface{}
+		for k, v := range data {
+			if k != "items" && !internalDataKeys[k] {
+				if meta == nil {
+					meta = make(map[string]interface{})
+				}
+				meta[k] = v
+			}
+		}
+
+		items := make([]Item, 0, len(arr))
+		for _, v := range arr {
+			json, ok := v.(map[string]interface{})
+			if !ok {
+				json = map[string]interface{}{"value": v}
+			}
+			if meta != nil {
+				// Copied, so that the output the items came from is unchanged
+				withMeta := make(map[string]interface{}, len(json)+1)
+				for k, v := range json {
+					withMeta[k] = v
+				}
+				withMeta[MetaKey] = meta
+				json = withMeta
+			}
+			items = append(items, NewItem(json))
+		}
+		return items
+	}
+
+	json := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if !internalDataKeys[k] {
+			json[k] = v
+		}
+	}
+	return []Item{NewItem(json)}
+}
+
+// DataFromItems converts items back into the legacy map shape. A single item
+// is returned as-is; several items are wrapped in an "items" array.
+func DataFromItems(items []Item) map[string]interface{} {
+	if len(items) == 1 {
+		return items[0].JSON
+	}
+	return map[string]interface{}{"items": ItemsJSON(items)}
+}
+
+// ItemsJSON returns the JSON payload of every item
+func ItemsJSON(items []Item) []interface{} {
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[i] = item.JSON
+	}
+	return result
+}
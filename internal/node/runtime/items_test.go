@@ -0,0 +1,37 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemsFromData_KeepsMetadataOfItemsOutput(t *testing.T) {
+	first := map[string]interface{}{"id": 1}
+	data := map[string]interface{}{
+		"items":      []interface{}{first, "plain"},
+		"pages":      2,
+		"nextCursor": "abc",
+		"_output":    "main",
+	}
+
+	items := ItemsFromData(data)
+	require.Len(t, items, 2)
+	meta := map[string]interface{}{"pages": 2, "nextCursor": "abc"}
+	assert.Equal(t, map[string]interface{}{"id": 1, MetaKey: meta}, items[0].JSON)
+	assert.Equal(t, map[string]interface{}{"value": "plain", MetaKey: meta}, items[1].JSON)
+
+	// The output the items came from is left as it was
+	assert.Equal(t, map[string]interface{}{"id": 1}, first)
+}
+
+func TestItemsFromData_ItemsOutputWithoutMetadata(t *testing.T) {
+	items := ItemsFromData(map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": 1}}})
+	require.Len(t, items, 1)
+	assert.Equal(t, map[string]interface{}{"id": 1}, items[0].JSON)
+
+	items = ItemsFromData(map[string]interface{}{"status": "ok", "_loopState": 1})
+	require.Len(t, items, 1)
+	assert.Equal(t, map[string]interface{}{"status": "ok"}, items[0].JSON)
+}
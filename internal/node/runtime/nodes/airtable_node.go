@@ -16,6 +16,7 @@ import (
 
 func init() {
 	runtime.Register(&AirtableNode{})
+	runtime.Register(&AirtableTriggerNode{})
 }
 
 // AirtableNode implements Airtable operations
@@ -53,10 +54,12 @@ func (n *AirtableNode) GetMetadata() runtime.NodeMetadata {
 }
 
 func (n *AirtableNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
-	if n.client == nil {
-		n.client = &http.Client{Timeout: 30 * time.Second}
-	}
+	// Requests go through a client held to the egress policy of the execution
+	node := &AirtableNode{client: egressClient(input, 30*time.Second)}
+	return node.execute(ctx, input)
+}
 
+func (n *AirtableNode) execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	operation, _ := input.NodeConfig["operation"].(string)
 	accessToken, _ := input.Credentials["access_token"].(string)
 	if accessToken == "" {
@@ -191,3 +194,87 @@ func (n *AirtableNode) doRequest(ctx context.Context, method, urlStr string, bod
 	json.Unmarshal(data, &result)
 	return result, nil
 }
+
+// AirtableTriggerNode polls an Airtable table for new and updated records
+type AirtableTriggerNode struct{}
+
+func (n *AirtableTriggerNode) GetType() string                     { return "airtable_trigger" }
+func (n *AirtableTriggerNode) GetTriggerType() runtime.TriggerType { return runtime.TriggerTypePolling }
+
+func (n *AirtableTriggerNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "airtable_trigger",
+		Name:        "Airtable Trigger",
+		Description: "Trigger workflow on new or updated records of an Airtable table",
+		Category:    "trigger",
+		Version:     "1.0.0",
+		Icon:        "airtable",
+		Outputs:     []runtime.PortDefinition{{Name: "main", Type: "main"}},
+		Properties: append([]runtime.PropertyDefinition{
+			{Name: "event", Type: "select", Required: true, Default: "newRecords", Options: []runtime.PropertyOption{
+				{Label: "New Record", Value: "newRecords"}, {Label: "Updated Record", Value: "updatedRecords"},
+			}},
+			{Name: "baseId", Type: "string", Required: true},
+			{Name: "tableId", Type: "string", Required: true},
+			{Name: "triggerField", Type: "string", Description: "Last modified time field of the table (updated records)"},
+		}, pollIntervalProperties...),
+		IsTrigger: true,
+	}
+}
+
+func (n *AirtableTriggerNode) Validate(config map[string]interface{}) error {
+	if getStringConfig(config, "baseId", "") == "" || getStringConfig(config, "tableId", "") == "" {
+		return fmt.Errorf("baseId and tableId are required")
+	}
+	switch getStringConfig(config, "event", "newRecords") {
+	case "newRecords":
+	case "updatedRecords":
+		if getStringConfig(config, "triggerField", "") == "" {
+			return fmt.Errorf("triggerField is required for updated records")
+		}
+	default:
+		return fmt.Errorf("unknown event: %v", config["event"])
+	}
+	return validatePollInterval(config)
+}
+
+func (n *AirtableTriggerNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	return triggerItemsOutput(input, "Airtable poll"), nil
+}
+
+// Poll lists the records created, or modified as told by triggerField,
+// since the cursor, which is the time of the latest one seen
+func (n *AirtableTriggerNode) Poll(ctx context.Context, input *runtime.ExecutionInput, cursor string) (*runtime.PollResult, error) {
+	token, err := accessToken(input.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	config := input.NodeConfig
+	timeExpr, timeField, version := "CREATED_TIME()", "createdTime", ""
+	if getStringConfig(config, "event", "newRecords") == "updatedRecords" {
+		field := getStringConfig(config, "triggerField", "")
+		timeExpr, timeField = "{"+field+"}", "fields."+field
+		version = timeField
+	}
+
+	params := url.Values{"pageSize": {"100"}}
+	if cursor != "" {
+		params.Set("filterByFormula", fmt.Sprintf("NOT(IS_BEFORE(%s, DATETIME_PARSE('%s')))",
+			timeExpr, strings.ReplaceAll(cursor, "'", "")))
+	}
+	endpoint := fmt.Sprintf("https://api.airtable.com/v0/%s/%s?%s",
+		getStringConfig(config, "baseId", ""), url.PathEscape(getStringConfig(config, "tableId", "")), params.Encode())
+
+	response, err := pollRequest(ctx, egressClient(input, pollTimeout), "GET", endpoint, nil,
+		map[string]string{"Authorization": "Bearer " + token})
+	if err != nil {
+		return nil, err
+	}
+	return pollResult(itemsOfPage(response, "records"), cursor, "id", version, timeField), nil
+}
+
+func (n *AirtableTriggerNode) Start(ctx context.Context, config map[string]interface{}, callback runtime.TriggerCallback) error {
+	return errPolledByScheduler
+}
+
+func (n *AirtableTriggerNode) Stop(ctx context.Context) error { return nil }
@@ -55,7 +55,8 @@ func (n *CodeNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Run Once for Each Item", Value: "runOnceForEachItem"},
 			}},
 		},
-		IsTrigger: false,
+		IsTrigger:          false,
+		RunOnceForAllItems: true,
 	}
 }
 
@@ -81,6 +82,7 @@ func (n *CodeNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (
 	
 	// Create expression context
 	exprCtx := expression.NewContext()
+	exprCtx.SetItems(getInputItems(input), 0)
 	exprCtx.SetInput(input.InputData)
 	if input.Context != nil {
 		exprCtx.Execution.ID = input.Context.ExecutionID
@@ -91,7 +93,7 @@ func (n *CodeNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (
 	
 	switch language {
 	case "expression":
-		result, err := n.executeExpression(code, exprCtx, input.InputData, mode)
+		result, err := n.executeExpression(code, exprCtx, getInputItems(input), mode)
 		if err != nil {
 			output.Error = err
 			return output, nil
@@ -127,17 +129,12 @@ func (n *CodeNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (
 	return output, nil
 }
 
-func (n *CodeNode) executeExpression(code string, ctx *expression.Context, inputData map[string]interface{}, mode string) (map[string]interface{}, error) {
+func (n *CodeNode) executeExpression(code string, ctx *expression.Context, items []interface{}, mode string) (map[string]interface{}, error) {
 	if mode == "runOnceForEachItem" {
 		// Process each item separately
-		items, ok := inputData["items"].([]interface{})
-		if !ok {
-			items = []interface{}{inputData}
-		}
-		
 		results := make([]interface{}, 0, len(items))
-		for _, item := range items {
-			ctx.SetInput(item)
+		for i := range items {
+			ctx.SetItems(items, i)
 			result, err := n.parser.Evaluate(code, ctx)
 			if err != nil {
 				return nil, fmt.Errorf("error processing item: %w", err)
@@ -49,13 +49,17 @@ func (n *PostgresNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Insert", Value: "insert"},
 				{Label: "Update", Value: "update"},
 				{Label: "Delete", Value: "delete"},
+				{Label: "Insert Items (Batch)", Value: operationInsertItems},
+				{Label: "Upsert Items (Batch)", Value: operationUpsertItems},
 			}},
 			{Name: "table", Type: "string", Description: "Table name (for CRUD operations)"},
-			{Name: "query", Type: "code", Description: "SQL query (for query operation)"},
-			{Name: "columns", Type: "string", Description: "Columns to select, comma-separated (default: *)"},
+			{Name: "query", Type: "code", Description: "SQL query (for query operation), with :name parameters"},
+			{Name: "params", Type: "json", Description: "Values of the query's :name parameters, or a list of $n parameters"},
+			{Name: "columns", Type: "string", Description: "Columns to select or write, comma-separated (default: all)"},
+			{Name: "conflictColumns", Type: "string", Description: "Unique columns identifying the row to update on upsert, comma-separated"},
 			{Name: "values", Type: "json", Description: "Values for insert/update"},
 			{Name: "where", Type: "json", Description: "WHERE conditions as key-value pairs"},
-			{Name: "orderBy", Type: "string", Description: "ORDER BY clause"},
+			{Name: "orderBy", Type: "string", Description: "Columns to order by, e.g. created_at DESC, id"},
 			{Name: "limit", Type: "number", Description: "LIMIT clause"},
 			{Name: "returnData", Type: "boolean", Default: true, Description: "Return inserted/updated data"},
 		},
@@ -72,15 +76,29 @@ func (n *PostgresNode) Validate(config map[string]interface{}) error {
 		if getStringConfig(config, "query", "") == "" {
 			return fmt.Errorf("query is required for query operation")
 		}
-	case "select", "insert", "update", "delete":
+	case "select", "insert", "update", "delete", operationInsertItems:
 		if getStringConfig(config, "table", "") == "" {
 			return fmt.Errorf("table is required for %s operation", operation)
 		}
+	case operationUpsertItems:
+		if getStringConfig(config, "table", "") == "" {
+			return fmt.Errorf("table is required for %s operation", operation)
+		}
+		if len(stringList(config["conflictColumns"])) == 0 {
+			return fmt.Errorf("conflictColumns is required for %s operation", operation)
+		}
+	default:
+		return fmt.Errorf("unknown operation: %s", operation)
 	}
 	
 	return nil
 }
 
+// RunsOnceForAllItems makes the batch operations write every input item at once
+func (n *PostgresNode) RunsOnceForAllItems(config map[string]interface{}) bool {
+	return isBatchOperation(config)
+}
+
 // Execute executes the PostgreSQL node
 func (n *PostgresNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	startTime := time.Now()
@@ -113,13 +131,13 @@ func (n *PostgresNode) Execute(ctx context.Context, input *runtime.ExecutionInpu
 		return output, nil
 	}
 	
-	// Connect to database
-	db, err := sql.Open("postgres", connStr)
+	// Get a pooled connection
+	db, release, err := openSQL("postgres", connStr)
 	if err != nil {
 		output.Error = fmt.Errorf("failed to connect: %w", err)
 		return output, nil
 	}
-	defer db.Close()
+	defer release()
 	
 	// Execute operation
 	operation := getStringConfig(input.NodeConfig, "operation", "select")
@@ -136,6 +154,8 @@ func (n *PostgresNode) Execute(ctx context.Context, input *runtime.ExecutionInpu
 		result, err = n.executeUpdate(ctx, db, input.NodeConfig)
 	case "delete":
 		result, err = n.executeDelete(ctx, db, input.NodeConfig)
+	case operationInsertItems, operationUpsertItems:
+		result, err = postgresDialect.writeItems(ctx, db, input.NodeConfig, input.Items, operation == operationUpsertItems)
 	default:
 		err = fmt.Errorf("unknown operation: %s", operation)
 	}
@@ -155,6 +175,7 @@ func (n *PostgresNode) Execute(ctx context.Context, input *runtime.ExecutionInpu
 	if rows, ok := result.([]map[string]interface{}); ok {
 		output.Data["rows"] = rows
 		output.Data["rowCount"] = len(rows)
+		output.Items = runtime.ItemsFromMaps(rows)
 	} else if m, ok := result.(map[string]interface{}); ok {
 		output.Data = m
 	}
@@ -176,9 +197,12 @@ func (n *PostgresNode) Execute(ctx context.Context, input *runtime.ExecutionInpu
 }
 
 func (n *PostgresNode) executeQuery(ctx context.Context, db *sql.DB, config map[string]interface{}) (interface{}, error) {
-	query := getStringConfig(config, "query", "")
+	query, args, err := postgresDialect.queryArgs(getStringConfig(config, "query", ""), config["params"])
+	if err != nil {
+		return nil, err
+	}
 	
-	rows, err := db.QueryContext(ctx, query)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -188,8 +212,16 @@ func (n *PostgresNode) executeQuery(ctx context.Context, db *sql.DB, config map[
 }
 
 func (n *PostgresNode) executeSelect(ctx context.Context, db *sql.DB, config map[string]interface{}) (interface{}, error) {
-	table := getStringConfig(config, "table", "")
-	columns := getStringConfig(config, "columns", "*")
+	table, err := postgresDialect.identifier(getStringConfig(config, "table", ""))
+	if err != nil {
+		return nil, err
+	}
+	columns := "*"
+	if names := stringList(config["columns"]); len(names) > 0 && names[0] != "*" {
+		if columns, err = postgresDialect.identifiers(names); err != nil {
+			return nil, err
+		}
+	}
 	orderBy := getStringConfig(config, "orderBy", "")
 	limit := getIntConfig(config, "limit", 0)
 	where := getMapConfig(config, "where")
@@ -199,13 +231,20 @@ func (n *PostgresNode) executeSelect(ctx context.Context, db *sql.DB, config map
 	
 	var args []interface{}
 	if len(where) > 0 {
-		conditions, whereArgs := buildWhereClause(where)
+		conditions, whereArgs, err := postgresDialect.where(where, 1)
+		if err != nil {
+			return nil, err
+		}
 		query += " WHERE " + conditions
 		args = whereArgs
 	}
 	
 	if orderBy != "" {
-		query += " ORDER BY " + orderBy
+		order, err := postgresDialect.orderBy(orderBy)
+		if err != nil {
+			return nil, err
+		}
+		query += " ORDER BY " + order
 	}
 	
 	if limit > 0 {
@@ -222,7 +261,10 @@ func (n *PostgresNode) executeSelect(ctx context.Context, db *sql.DB, config map
 }
 
 func (n *PostgresNode) executeInsert(ctx context.Context, db *sql.DB, config map[string]interface{}) (interface{}, error) {
-	table := getStringConfig(config, "table", "")
+	table, err := postgresDialect.identifier(getStringConfig(config, "table", ""))
+	if err != nil {
+		return nil, err
+	}
 	values := getMapConfig(config, "values")
 	returnData := getBoolConfig(config, "returnData", true)
 	
@@ -231,45 +273,29 @@ func (n *PostgresNode) executeInsert(ctx context.Context, db *sql.DB, config map
 	}
 	
 	// Build query
-	var columns []string
-	var placeholders []string
-	var args []interface{}
-	i := 1
-	
-	for col, val := range values {
-		columns = append(columns, col)
-		placeholders = append(placeholders, fmt.Sprintf("$%d", i))
-		args = append(args, val)
-		i++
-	}
-	
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
-	
-	if returnData {
-		query += " RETURNING *"
-		rows, err := db.QueryContext(ctx, query, args...)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-		return scanRows(rows)
-	}
-	
-	result, err := db.ExecContext(ctx, query, args...)
+	names := sortedKeys(values)
+	columns, err := postgresDialect.identifiers(names)
 	if err != nil {
 		return nil, err
 	}
+	placeholders := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, col := range names {
+		placeholders[i] = postgresDialect.placeholder(i + 1)
+		args[i] = sqlArg(values[col])
+	}
 	
-	affected, _ := result.RowsAffected()
-	return map[string]interface{}{
-		"success":      true,
-		"rowsAffected": affected,
-	}, nil
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, columns, strings.Join(placeholders, ", "))
+	
+	return n.write(ctx, db, query, args, returnData)
 }
 
 func (n *PostgresNode) executeUpdate(ctx context.Context, db *sql.DB, config map[string]interface{}) (interface{}, error) {
-	table := getStringConfig(config, "table", "")
+	table, err := postgresDialect.identifier(getStringConfig(config, "table", ""))
+	if err != nil {
+		return nil, err
+	}
 	values := getMapConfig(config, "values")
 	where := getMapConfig(config, "where")
 	returnData := getBoolConfig(config, "returnData", true)
@@ -279,53 +305,31 @@ func (n *PostgresNode) executeUpdate(ctx context.Context, db *sql.DB, config map
 	}
 	
 	// Build SET clause
-	var setClauses []string
-	var args []interface{}
-	i := 1
-	
-	for col, val := range values {
-		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", col, i))
-		args = append(args, val)
-		i++
+	setClauses, args, err := postgresDialect.assignments(values, 1)
+	if err != nil {
+		return nil, err
 	}
 	
 	query := fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(setClauses, ", "))
 	
 	// Add WHERE clause
 	if len(where) > 0 {
-		var whereClauses []string
-		for col, val := range where {
-			whereClauses = append(whereClauses, fmt.Sprintf("%s = $%d", col, i))
-			args = append(args, val)
-			i++
-		}
-		query += " WHERE " + strings.Join(whereClauses, " AND ")
-	}
-	
-	if returnData {
-		query += " RETURNING *"
-		rows, err := db.QueryContext(ctx, query, args...)
+		conditions, whereArgs, err := postgresDialect.where(where, len(args)+1)
 		if err != nil {
 			return nil, err
 		}
-		defer rows.Close()
-		return scanRows(rows)
-	}
-	
-	result, err := db.ExecContext(ctx, query, args...)
-	if err != nil {
-		return nil, err
+		query += " WHERE " + conditions
+		args = append(args, whereArgs...)
 	}
 	
-	affected, _ := result.RowsAffected()
-	return map[string]interface{}{
-		"success":      true,
-		"rowsAffected": affected,
-	}, nil
+	return n.write(ctx, db, query, args, returnData)
 }
 
 func (n *PostgresNode) executeDelete(ctx context.Context, db *sql.DB, config map[string]interface{}) (interface{}, error) {
-	table := getStringConfig(config, "table", "")
+	table, err := postgresDialect.identifier(getStringConfig(config, "table", ""))
+	if err != nil {
+		return nil, err
+	}
 	where := getMapConfig(config, "where")
 	returnData := getBoolConfig(config, "returnData", true)
 	
@@ -333,11 +337,20 @@ func (n *PostgresNode) executeDelete(ctx context.Context, db *sql.DB, config map
 	
 	var args []interface{}
 	if len(where) > 0 {
-		conditions, whereArgs := buildWhereClause(where)
+		conditions, whereArgs, err := postgresDialect.where(where, 1)
+		if err != nil {
+			return nil, err
+		}
 		query += " WHERE " + conditions
 		args = whereArgs
 	}
 	
+	return n.write(ctx, db, query, args, returnData)
+}
+
+// write runs an insert, update or delete, returning the rows it wrote when
+// returnData is set and the number of rows otherwise
+func (n *PostgresNode) write(ctx context.Context, db *sql.DB, query string, args []interface{}, returnData bool) (interface{}, error) {
 	if returnData {
 		query += " RETURNING *"
 		rows, err := db.QueryContext(ctx, query, args...)
@@ -403,20 +416,6 @@ func scanRows(rows *sql.Rows) ([]map[string]interface{}, error) {
 	return results, rows.Err()
 }
 
-func buildWhereClause(where map[string]interface{}) (string, []interface{}) {
-	var conditions []string
-	var args []interface{}
-	i := 1
-	
-	for col, val := range where {
-		conditions = append(conditions, fmt.Sprintf("%s = $%d", col, i))
-		args = append(args, val)
-		i++
-	}
-	
-	return strings.Join(conditions, " AND "), args
-}
-
 func getCredString(creds map[string]interface{}, key, defaultVal string) string {
 	if v, ok := creds[key]; ok {
 		if s, ok := v.(string); ok {
@@ -0,0 +1,138 @@
+// Package nodes provides pooled connections for the database nodes
+package nodes
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Connection pool defaults of the database nodes
+const (
+	defaultPoolIdleTimeout = 5 * time.Minute // Pools unused for this long are closed
+	defaultPoolMaxOpen     = 10              // Open connections per credential
+)
+
+// dbPool holds the connections of every database node
+var dbPool = newConnPool(defaultPoolIdleTimeout)
+
+// connPool keeps one connection pool per database credential, so that nodes
+// reuse connections across runs instead of dialing on every execution, and
+// closes those left idle for longer than idleTimeout
+type connPool struct {
+	mu          sync.Mutex
+	conns       map[string]*pooledConn
+	idleTimeout time.Duration
+	sweeping    bool
+}
+
+// pooledConn is a cached connection and the number of runs using it
+type pooledConn struct {
+	conn     interface{}
+	close    func()
+	users    int
+	lastUsed time.Time
+}
+
+func newConnPool(idleTimeout time.Duration) *connPool {
+	return &connPool{
+		conns:       make(map[string]*pooledConn),
+		idleTimeout: idleTimeout,
+	}
+}
+
+// poolKey identifies a credential without keeping its secrets in the key
+func poolKey(driver, dsn string) string {
+	sum := sha256.Sum256([]byte(driver + "\x00" + dsn))
+	return hex.EncodeToString(sum[:])
+}
+
+// acquire returns the connection cached under key, opening it with open the
+// first time. The returned release must be called once the run is done with
+// the connection; connections in use are never evicted.
+func (p *connPool) acquire(key string, open func() (interface{}, func(), error)) (interface{}, func(), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.conns[key]
+	if !ok {
+		conn, closeConn, err := open()
+		if err != nil {
+			return nil, nil, err
+		}
+		c = &pooledConn{conn: conn, close: closeConn}
+		p.conns[key] = c
+
+		if !p.sweeping {
+			p.sweeping = true
+			go p.sweep()
+		}
+	}
+	c.users++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			p.mu.Lock()
+			c.users--
+			c.lastUsed = time.Now()
+			p.mu.Unlock()
+		})
+	}
+	return c.conn, release, nil
+}
+
+// sweep evicts idle connections until the pool is empty
+func (p *connPool) sweep() {
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if !p.evictIdle(now) {
+			return
+		}
+	}
+}
+
+// evictIdle closes the connections idle since before now minus idleTimeout
+// and reports whether any connections remain
+func (p *connPool) evictIdle(now time.Time) bool {
+	p.mu.Lock()
+	var closers []func()
+	for key, c := range p.conns {
+		if c.users == 0 && now.Sub(c.lastUsed) >= p.idleTimeout {
+			delete(p.conns, key)
+			closers = append(closers, c.close)
+		}
+	}
+	remaining := len(p.conns) > 0
+	if !remaining {
+		p.sweeping = false
+	}
+	p.mu.Unlock()
+
+	for _, closeConn := range closers {
+		closeConn()
+	}
+	return remaining
+}
+
+// openSQL returns the pooled database of driver for dsn and its release
+func openSQL(driver, dsn string) (*sql.DB, func(), error) {
+	conn, release, err := dbPool.acquire(poolKey(driver, dsn), func() (interface{}, func(), error) {
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		db.SetMaxOpenConns(defaultPoolMaxOpen)
+		db.SetMaxIdleConns(defaultPoolMaxOpen)
+		db.SetConnMaxIdleTime(defaultPoolIdleTimeout)
+		return db, func() { db.Close() }, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn.(*sql.DB), release, nil
+}
@@ -51,10 +51,12 @@ func (n *DiscordNode) GetMetadata() runtime.NodeMetadata {
 }
 
 func (n *DiscordNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
-	if n.client == nil {
-		n.client = &http.Client{Timeout: 30 * time.Second}
-	}
+	// Requests go through a client held to the egress policy of the execution
+	node := &DiscordNode{client: egressClient(input, 30*time.Second)}
+	return node.execute(ctx, input)
+}
 
+func (n *DiscordNode) execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	operation, _ := input.NodeConfig["operation"].(string)
 
 	// Handle webhook separately (no auth required)
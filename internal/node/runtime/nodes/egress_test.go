@@ -0,0 +1,55 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
+)
+
+func TestIntegrationNodes_WebhooksAreHeldToEgressPolicy(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	nodes := []struct {
+		node   runtime.NodeExecutor
+		config map[string]interface{}
+	}{
+		{NewSlackNode(), map[string]interface{}{"operation": "sendWebhook", "webhookUrl": server.URL, "text": "hi"}},
+		{&DiscordNode{}, map[string]interface{}{"operation": "sendWebhook", "webhookUrl": server.URL, "content": "hi"}},
+	}
+
+	run := func(node runtime.NodeExecutor, config map[string]interface{}, guard *egress.Guard) error {
+		output, err := node.Execute(context.Background(), &runtime.ExecutionInput{
+			NodeID:     "notify",
+			NodeConfig: config,
+			Context:    &runtime.ExecutionContext{Egress: guard},
+		})
+		require.NoError(t, err)
+		return output.Error
+	}
+
+	for _, n := range nodes {
+		// The loopback server is an internal address the base policy blocks
+		err := run(n.node, n.config, egress.NewGuard(egress.Policy{}))
+		var blocked *egress.BlockedError
+		require.True(t, errors.As(err, &blocked), "%s: %v", n.node.GetType(), err)
+
+		err = run(n.node, n.config, egress.NewGuard(egress.Policy{AllowPrivate: true}))
+		assert.NoError(t, err, n.node.GetType())
+	}
+	assert.Equal(t, int32(len(nodes)), atomic.LoadInt32(&calls))
+}
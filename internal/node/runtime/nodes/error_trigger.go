@@ -61,13 +61,18 @@ func (n *ErrorTriggerNode) Execute(ctx context.Context, input *runtime.Execution
 	
 	// Extract error information from input
 	errorData := map[string]interface{}{
-		"message":     input.InputData["message"],
-		"nodeId":      input.InputData["nodeId"],
-		"nodeName":    input.InputData["nodeName"],
-		"nodeType":    input.InputData["nodeType"],
-		"executionId": input.InputData["executionId"],
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"stack":       input.InputData["stack"],
+		"message":       input.InputData["message"],
+		"nodeId":        input.InputData["nodeId"],
+		"nodeName":      input.InputData["nodeName"],
+		"nodeType":      input.InputData["nodeType"],
+		"executionId":   input.InputData["executionId"],
+		"workflowId":    input.InputData["workflowId"],
+		"workflowName":  input.InputData["workflowName"],
+		"mode":          input.InputData["mode"],
+		"lastNodeInput": input.InputData["lastNodeInput"],
+		"retryUrl":      input.InputData["retryUrl"],
+		"timestamp":     time.Now().Format(time.RFC3339),
+		"stack":         input.InputData["stack"],
 	}
 	
 	output.Data = errorData
@@ -0,0 +1,144 @@
+// Package nodes provides the platform event trigger node
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/internal/shared/events"
+)
+
+// EventTriggerNode starts executions when events of the platform occur,
+// such as a failed execution or a new user
+type EventTriggerNode struct{}
+
+// NewEventTriggerNode creates a new platform event trigger node
+func NewEventTriggerNode() *EventTriggerNode {
+	return &EventTriggerNode{}
+}
+
+// GetType returns the node type
+func (n *EventTriggerNode) GetType() string {
+	return "event_trigger"
+}
+
+// GetTriggerType returns the trigger type
+func (n *EventTriggerNode) GetTriggerType() runtime.TriggerType {
+	return runtime.TriggerTypeEvent
+}
+
+// GetMetadata returns node metadata
+func (n *EventTriggerNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "event_trigger",
+		Name:        "Platform Event Trigger",
+		Description: "Trigger workflow on events of the platform, such as failed executions or new users",
+		Category:    "trigger",
+		Icon:        "radio",
+		Color:       "#607D8B",
+		Version:     "1.0.0",
+		Outputs: []runtime.PortDefinition{
+			{Name: "main", Type: "any", Description: "Event"},
+		},
+		Properties: []runtime.PropertyDefinition{
+			{Name: "events", Type: "string", Required: true, Description: "Comma-separated event types, e.g. execution.failed, user.created"},
+			{Name: "platformEvents", Type: "boolean", Default: false, Description: "Also receive events that belong to no user, which every workflow opting in receives"},
+		},
+		IsTrigger: true,
+	}
+}
+
+// Validate validates the node configuration
+func (n *EventTriggerNode) Validate(config map[string]interface{}) error {
+	if len(eventTypes(config)) == 0 {
+		return fmt.Errorf("events are required")
+	}
+	return nil
+}
+
+// Execute outputs the event the execution was started with
+func (n *EventTriggerNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	return triggerItemsOutput(input, "Platform event"), nil
+}
+
+// Subscribe delivers the events of the configured types. Events of a user
+// only reach the workflows of that user; events of no user are platform
+// wide and only reach the workflows that opt in to them.
+func (n *EventTriggerNode) Subscribe(ctx context.Context, input *runtime.ExecutionInput, deliver runtime.DeliverFunc) error {
+	bus := input.Context.Events
+	if bus == nil {
+		return fmt.Errorf("platform events are not available")
+	}
+	owner := input.Context.UserID
+	platformWide := getBoolConfig(input.NodeConfig, "platformEvents", false)
+
+	unsubscribe := bus.Subscribe(eventTypes(input.NodeConfig), func(ctx context.Context, event *events.Event) error {
+		if event.UserID == "" && !platformWide {
+			return nil
+		}
+		if event.UserID != "" && event.UserID != owner {
+			return nil
+		}
+		return deliver(ctx, []runtime.Item{runtime.NewItem(eventJSON(event))})
+	})
+	defer unsubscribe()
+
+	<-ctx.Done()
+	return nil
+}
+
+// eventTypes reads the event types a trigger subscribes to
+func eventTypes(config map[string]interface{}) []events.EventType {
+	var names []string
+	switch v := config["events"].(type) {
+	case string:
+		names = splitList(v)
+	case []interface{}:
+		for _, name := range v {
+			if s, ok := name.(string); ok && s != "" {
+				names = append(names, s)
+			}
+		}
+	}
+
+	types := make([]events.EventType, len(names))
+	for i, name := range names {
+		types[i] = events.EventType(name)
+	}
+	return types
+}
+
+// eventJSON is the item of an event
+func eventJSON(event *events.Event) map[string]interface{} {
+	var data interface{}
+	if len(event.Data) > 0 {
+		json.Unmarshal(event.Data, &data)
+	}
+	return map[string]interface{}{
+		"id":            event.ID,
+		"type":          string(event.Type),
+		"aggregateId":   event.AggregateID,
+		"aggregateType": event.AggregateType,
+		"userId":        event.UserID,
+		"tenantId":      event.TenantID,
+		"timestamp":     event.Timestamp.Format(time.RFC3339Nano),
+		"data":          data,
+	}
+}
+
+// Start is not supported: subscribing triggers are run by the engine
+func (n *EventTriggerNode) Start(ctx context.Context, config map[string]interface{}, callback runtime.TriggerCallback) error {
+	return errSubscribedByEngine
+}
+
+// Stop stops the trigger
+func (n *EventTriggerNode) Stop(ctx context.Context) error {
+	return nil
+}
+
+func init() {
+	runtime.Register(NewEventTriggerNode())
+}
@@ -0,0 +1,193 @@
+// Package nodes provides the nodes that call other workflows
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// ExecuteWorkflowNode calls another workflow, either waiting for its output
+// or only starting it
+type ExecuteWorkflowNode struct{}
+
+// NewExecuteWorkflowNode creates a new Execute Workflow node
+func NewExecuteWorkflowNode() *ExecuteWorkflowNode {
+	return &ExecuteWorkflowNode{}
+}
+
+// GetType returns the node type
+func (n *ExecuteWorkflowNode) GetType() string {
+	return "execute_workflow"
+}
+
+// GetMetadata returns node metadata
+func (n *ExecuteWorkflowNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "execute_workflow",
+		Name:        "Execute Workflow",
+		Description: "Run another workflow with the input data and return its output",
+		Category:    "core",
+		Icon:        "git-pull-request",
+		Color:       "#FF6D5A",
+		Version:     "1.0.0",
+		Inputs: []runtime.PortDefinition{
+			{Name: "main", Type: "any", Required: true, Description: "Input data"},
+		},
+		Outputs: []runtime.PortDefinition{
+			{Name: "main", Type: "any", Description: "Output of the workflow, or its execution when not waiting"},
+		},
+		Properties: []runtime.PropertyDefinition{
+			{Name: "workflowId", Type: "string", Required: true, Description: "ID of the workflow to run"},
+			{Name: "waitForOutput", Type: "boolean", Default: true, Description: "Wait for the workflow to finish and output its result; otherwise only start it"},
+			{Name: "inputMapping", Type: "json", Description: "Input fields of the workflow by the input field they are taken from; the whole input when empty"},
+			{Name: "outputMapping", Type: "json", Description: "Output fields by the field of the workflow's output they are taken from; the whole output when empty"},
+			{Name: "timeout", Type: "number", Default: 0, Description: "Seconds to wait for the workflow; 0 waits until it finishes"},
+			{Name: "onError", Type: "select", Default: "stop", Description: "When the workflow fails", Options: []runtime.PropertyOption{
+				{Label: "Stop", Value: "stop"},
+				{Label: "Continue", Value: "continue"},
+				{Label: "Output Fallback Value", Value: "fallback"},
+			}},
+			{Name: "fallbackValue", Type: "json", Description: "Output when the workflow fails (continue, fallback)"},
+		},
+		IsTrigger: false,
+	}
+}
+
+// Validate validates the node configuration
+func (n *ExecuteWorkflowNode) Validate(config map[string]interface{}) error {
+	if getStringConfig(config, "workflowId", "") == "" {
+		return fmt.Errorf("workflowId is required")
+	}
+	if getIntConfig(config, "timeout", 0) < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	switch getStringConfig(config, "onError", "stop") {
+	case "stop", "continue", "fallback":
+		return nil
+	}
+	return fmt.Errorf("unknown onError: %v", config["onError"])
+}
+
+// Execute runs the workflow with the input data. A waiting call outputs the
+// workflow's final output; otherwise the node outputs the started execution.
+func (n *ExecuteWorkflowNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	startTime := time.Now()
+	if input.Context == nil || input.Context.Workflows == nil {
+		return nil, fmt.Errorf("calling workflows is not available")
+	}
+
+	config := input.NodeConfig
+	call := &runtime.WorkflowCall{
+		WorkflowID:    getStringConfig(config, "workflowId", ""),
+		Input:         input.InputData,
+		InputMapping:  stringMapConfig(config, "inputMapping"),
+		OutputMapping: stringMapConfig(config, "outputMapping"),
+		Wait:          getBoolConfig(config, "waitForOutput", true),
+		Timeout:       time.Duration(getIntConfig(config, "timeout", 0)) * time.Second,
+		OnError:       getStringConfig(config, "onError", "stop"),
+		Caller:        input.Context,
+	}
+	if fallback, ok := config["fallbackValue"].(map[string]interface{}); ok {
+		call.FallbackValue = fallback
+	}
+
+	result, err := input.Context.Workflows.RunWorkflow(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+
+	data := result.Output
+	if !call.Wait || data == nil {
+		data = map[string]interface{}{
+			"executionId": result.ExecutionID,
+			"status":      result.Status,
+		}
+		if result.Error != "" {
+			data["error"] = result.Error
+		}
+	}
+
+	level, message := "info", fmt.Sprintf("Workflow %s ran as execution %s (%s)", call.WorkflowID, result.ExecutionID, result.Status)
+	if result.Error != "" {
+		level, message = "warn", fmt.Sprintf("%s: %s", message, result.Error)
+	}
+	return &runtime.ExecutionOutput{
+		Data: data,
+		Logs: []runtime.LogEntry{{
+			Level:     level,
+			Message:   message,
+			Timestamp: time.Now().UnixMilli(),
+			NodeID:    input.NodeID,
+		}},
+		Metrics: runtime.ExecutionMetrics{
+			StartTime:  startTime.UnixMilli(),
+			EndTime:    time.Now().UnixMilli(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		},
+	}, nil
+}
+
+// stringMapConfig reads a mapping of field names
+func stringMapConfig(config map[string]interface{}, key string) map[string]string {
+	values, ok := config[key].(map[string]interface{})
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	mapping := make(map[string]string, len(values))
+	for target, source := range values {
+		if s, ok := source.(string); ok && s != "" {
+			mapping[target] = s
+		}
+	}
+	return mapping
+}
+
+// ExecuteWorkflowTriggerNode starts the executions of a workflow called by
+// an Execute Workflow node
+type ExecuteWorkflowTriggerNode struct{}
+
+// NewExecuteWorkflowTriggerNode creates a new Execute Workflow Trigger node
+func NewExecuteWorkflowTriggerNode() *ExecuteWorkflowTriggerNode {
+	return &ExecuteWorkflowTriggerNode{}
+}
+
+// GetType returns the node type
+func (n *ExecuteWorkflowTriggerNode) GetType() string {
+	return "execute_workflow_trigger"
+}
+
+// GetMetadata returns node metadata
+func (n *ExecuteWorkflowTriggerNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "execute_workflow_trigger",
+		Name:        "Execute Workflow Trigger",
+		Description: "Triggers when another workflow calls this one through an Execute Workflow node",
+		Category:    "trigger",
+		Icon:        "log-in",
+		Color:       "#FF6D5A",
+		Version:     "1.0.0",
+		Outputs: []runtime.PortDefinition{
+			{Name: "main", Type: "any", Description: "Input passed by the calling workflow"},
+		},
+		Properties: []runtime.PropertyDefinition{},
+		IsTrigger:  true,
+	}
+}
+
+// Validate validates the node configuration
+func (n *ExecuteWorkflowTriggerNode) Validate(config map[string]interface{}) error {
+	return nil
+}
+
+// Execute outputs the input the calling workflow passed
+func (n *ExecuteWorkflowTriggerNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	return triggerItemsOutput(input, "Called by workflow"), nil
+}
+
+func init() {
+	runtime.Register(NewExecuteWorkflowNode())
+	runtime.Register(NewExecuteWorkflowTriggerNode())
+}
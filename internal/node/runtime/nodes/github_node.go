@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 
 func init() {
 	runtime.Register(&GitHubNode{})
+	runtime.Register(&GitHubTriggerNode{})
 }
 
 // GitHubNode implements GitHub operations
@@ -56,10 +58,12 @@ func (n *GitHubNode) GetMetadata() runtime.NodeMetadata {
 }
 
 func (n *GitHubNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
-	if n.client == nil {
-		n.client = &http.Client{Timeout: 30 * time.Second}
-	}
+	// Requests go through a client held to the egress policy of the execution
+	node := &GitHubNode{client: egressClient(input, 30*time.Second)}
+	return node.execute(ctx, input)
+}
 
+func (n *GitHubNode) execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	operation, _ := input.NodeConfig["operation"].(string)
 	accessToken, _ := input.Credentials["access_token"].(string)
 	if accessToken == "" {
@@ -228,3 +232,114 @@ func (n *GitHubNode) doRequest(ctx context.Context, method, url string, body int
 	json.Unmarshal(data, &result)
 	return result, nil
 }
+
+// GitHubTriggerNode polls a GitHub repository for new issues and pull
+// requests
+type GitHubTriggerNode struct{}
+
+func (n *GitHubTriggerNode) GetType() string                     { return "github_trigger" }
+func (n *GitHubTriggerNode) GetTriggerType() runtime.TriggerType { return runtime.TriggerTypePolling }
+
+func (n *GitHubTriggerNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "github_trigger",
+		Name:        "GitHub Trigger",
+		Description: "Trigger workflow on new GitHub issues and pull requests",
+		Category:    "trigger",
+		Version:     "1.0.0",
+		Icon:        "github",
+		Outputs:     []runtime.PortDefinition{{Name: "main", Type: "main"}},
+		Properties: append([]runtime.PropertyDefinition{
+			{Name: "event", Type: "select", Required: true, Default: "newIssues", Options: []runtime.PropertyOption{
+				{Label: "New Issue", Value: "newIssues"}, {Label: "Updated Issue", Value: "updatedIssues"},
+				{Label: "New Pull Request", Value: "newPullRequests"},
+			}},
+			{Name: "owner", Type: "string", Required: true},
+			{Name: "repo", Type: "string", Required: true},
+		}, pollIntervalProperties...),
+		IsTrigger: true,
+	}
+}
+
+func (n *GitHubTriggerNode) Validate(config map[string]interface{}) error {
+	if getStringConfig(config, "owner", "") == "" || getStringConfig(config, "repo", "") == "" {
+		return fmt.Errorf("owner and repo are required")
+	}
+	switch getStringConfig(config, "event", "newIssues") {
+	case "newIssues", "updatedIssues", "newPullRequests":
+	default:
+		return fmt.Errorf("unknown event: %v", config["event"])
+	}
+	return validatePollInterval(config)
+}
+
+func (n *GitHubTriggerNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	return triggerItemsOutput(input, "GitHub poll"), nil
+}
+
+// Poll lists the latest issues or pull requests of the repository. The
+// cursor is the creation or update time of the latest one seen.
+func (n *GitHubTriggerNode) Poll(ctx context.Context, input *runtime.ExecutionInput, cursor string) (*runtime.PollResult, error) {
+	token, err := accessToken(input.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	config := input.NodeConfig
+	repo := fmt.Sprintf("https://api.github.com/repos/%s/%s",
+		url.PathEscape(getStringConfig(config, "owner", "")), url.PathEscape(getStringConfig(config, "repo", "")))
+	headers := map[string]string{
+		"Authorization": "Bearer " + token,
+		"Accept":        "application/vnd.github+json",
+	}
+
+	event := getStringConfig(config, "event", "newIssues")
+	query := url.Values{"state": {"all"}, "direction": {"desc"}, "per_page": {"50"}}
+	endpoint, timeField := repo+"/issues", "created_at"
+	switch event {
+	case "updatedIssues":
+		query.Set("sort", "updated")
+		timeField = "updated_at"
+		if cursor != "" {
+			query.Set("since", cursor)
+		}
+	case "newPullRequests":
+		endpoint = repo + "/pulls"
+		query.Set("sort", "created")
+	default:
+		query.Set("sort", "created")
+	}
+
+	response, err := pollRequest(ctx, egressClient(input, pollTimeout), "GET", endpoint+"?"+query.Encode(), nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	list, _ := response.([]interface{})
+
+	var found []interface{}
+	for _, v := range list {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// The issues endpoint lists pull requests as well
+		if event != "newPullRequests" && object["pull_request"] != nil {
+			continue
+		}
+		if at, _ := object[timeField].(string); cursor != "" && at < cursor {
+			continue
+		}
+		found = append(found, object)
+	}
+
+	version := ""
+	if event == "updatedIssues" {
+		version = "updated_at"
+	}
+	return pollResult(found, cursor, "id", version, timeField), nil
+}
+
+func (n *GitHubTriggerNode) Start(ctx context.Context, config map[string]interface{}, callback runtime.TriggerCallback) error {
+	return errPolledByScheduler
+}
+
+func (n *GitHubTriggerNode) Stop(ctx context.Context) error { return nil }
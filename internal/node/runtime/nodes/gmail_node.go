@@ -53,10 +53,12 @@ func (n *GmailNode) GetMetadata() runtime.NodeMetadata {
 }
 
 func (n *GmailNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
-	if n.client == nil {
-		n.client = &http.Client{Timeout: 30 * time.Second}
-	}
+	// Requests go through a client held to the egress policy of the execution
+	node := &GmailNode{client: egressClient(input, 30*time.Second)}
+	return node.execute(ctx, input)
+}
 
+func (n *GmailNode) execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	operation, _ := input.NodeConfig["operation"].(string)
 	accessToken, _ := input.Credentials["access_token"].(string)
 
@@ -64,10 +64,12 @@ func (n *GoogleSheetsNode) GetMetadata() runtime.NodeMetadata {
 }
 
 func (n *GoogleSheetsNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
-	if n.client == nil {
-		n.client = &http.Client{Timeout: 30 * time.Second}
-	}
+	// Requests go through a client held to the egress policy of the execution
+	node := &GoogleSheetsNode{client: egressClient(input, 30*time.Second)}
+	return node.execute(ctx, input)
+}
 
+func (n *GoogleSheetsNode) execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	operation, _ := input.NodeConfig["operation"].(string)
 	spreadsheetID, _ := input.NodeConfig["spreadsheetId"].(string)
 	rangeStr, _ := input.NodeConfig["range"].(string)
@@ -0,0 +1,220 @@
+// Package nodes provides binary uploads and downloads for the HTTP request node
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// defaultMaxBodySize bounds response bodies when the node sets no limit
+const defaultMaxBodySize = 100 << 20
+
+// defaultBinaryProperty is the binary property uploaded from and downloaded to
+const defaultBinaryProperty = "data"
+
+// errBodyTooLarge is returned when a response body exceeds the maximum size
+var errBodyTooLarge = errors.New("response body exceeds the maximum size")
+
+// quoteEscaper escapes names quoted in Content-Disposition headers
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// sizedBody is a request body whose length is known up front
+type sizedBody struct {
+	io.ReadCloser
+	size int64
+}
+
+// limitedBody fails reads once more than max bytes have been read
+type limitedBody struct {
+	r    io.Reader
+	read int64
+	max  int64
+}
+
+func limitBody(r io.Reader, max int64) io.Reader {
+	return &limitedBody{r: r, max: max}
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		return 0, fmt.Errorf("%w of %d bytes", errBodyTooLarge, l.max)
+	}
+	return n, err
+}
+
+// binaryStore returns the store holding the execution's binary data
+func binaryStore(input *runtime.ExecutionInput) (runtime.BinaryStore, error) {
+	if input.Context == nil || input.Context.Binary == nil {
+		return nil, fmt.Errorf("no binary store available")
+	}
+	return input.Context.Binary, nil
+}
+
+// inputBinary returns the binary data of the input item under property
+func inputBinary(input *runtime.ExecutionInput, property string) (*runtime.BinaryData, error) {
+	for _, item := range input.Items {
+		if data := item.Binary[property]; data != nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("input has no binary data %q", property)
+}
+
+// binaryBody sends the binary data of the input item as the body
+func binaryBody(ctx context.Context, input *runtime.ExecutionInput) (io.Reader, string, error) {
+	data, err := inputBinary(input, getStringConfig(input.NodeConfig, "binaryProperty", defaultBinaryProperty))
+	if err != nil {
+		return nil, "", err
+	}
+	store, err := binaryStore(input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, err := store.Open(ctx, data.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open binary data: %w", err)
+	}
+
+	mimeType := data.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return &sizedBody{ReadCloser: content, size: data.Size}, mimeType, nil
+}
+
+// multipartBody builds a multipart/form-data body from the fields of body
+// and the binary data of the input item named by binaryFields. Files are
+// streamed from the binary store while the request is sent.
+func multipartBody(ctx context.Context, input *runtime.ExecutionInput, body interface{}) (io.Reader, string, error) {
+	fields, _ := body.(map[string]interface{})
+
+	// Resolve the files before sending, so that a missing one fails the node
+	// rather than the upload
+	files := make(map[string]*runtime.BinaryData)
+	for field, property := range getMapConfig(input.NodeConfig, "binaryFields") {
+		data, err := inputBinary(input, fmt.Sprintf("%v", property))
+		if err != nil {
+			return nil, "", fmt.Errorf("form field %s: %w", field, err)
+		}
+		files[field] = data
+	}
+
+	var store runtime.BinaryStore
+	if len(files) > 0 {
+		var err error
+		if store, err = binaryStore(input); err != nil {
+			return nil, "", err
+		}
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipart(ctx, writer, store, fields, files))
+	}()
+	return pr, writer.FormDataContentType(), nil
+}
+
+// writeMultipart writes text fields followed by files, in name order
+func writeMultipart(ctx context.Context, writer *multipart.Writer, store runtime.BinaryStore, fields map[string]interface{}, files map[string]*runtime.BinaryData) error {
+	for _, name := range sortedKeys(fields) {
+		if err := writer.WriteField(name, fmt.Sprintf("%v", fields[name])); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := files[name]
+		fileName := data.FileName
+		if fileName == "" {
+			fileName = name
+		}
+		mimeType := data.MimeType
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(name), quoteEscaper.Replace(fileName)))
+		header.Set("Content-Type", mimeType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+
+		content, err := store.Open(ctx, data.ID)
+		if err != nil {
+			return fmt.Errorf("failed to open binary data for %s: %w", name, err)
+		}
+		_, err = io.Copy(part, content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// storeResponse streams a response body into the binary store, failing once
+// it exceeds maxBodySize
+func storeResponse(ctx context.Context, input *runtime.ExecutionInput, resp *http.Response, maxBodySize int64) (*runtime.BinaryData, error) {
+	store, err := binaryStore(input)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+
+	data, err := store.Put(ctx, responseFileName(resp), mimeType, limitBody(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store response: %w", err)
+	}
+	return data, nil
+}
+
+// responseFileName names a downloaded file after its Content-Disposition
+// header or, failing that, the last segment of the request path
+func responseFileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+	if resp.Request != nil {
+		if name := path.Base(resp.Request.URL.Path); name != "/" && name != "." {
+			return name
+		}
+	}
+	return "response"
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,259 @@
+// Package nodes provides pagination for the HTTP request node
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/pkg/expression"
+)
+
+// Pagination modes of the HTTP request node
+const (
+	paginationOff        = "off"
+	paginationNextURL    = "nextUrl"    // The next URL is given by an expression
+	paginationLinkHeader = "linkHeader" // The next URL is the Link header's rel="next"
+	paginationParams     = "params"     // Query parameters of the next page are given by expressions
+)
+
+// defaultMaxPages bounds pagination when the node sets no limit
+const defaultMaxPages = 100
+
+// paginationParser evaluates the pagination expressions of HTTP request nodes
+var paginationParser = expression.NewParser()
+
+// validatePagination checks the pagination mode and the syntax of the
+// pagination expressions
+func validatePagination(config map[string]interface{}) error {
+	exprs := map[string]string{"completeWhen": getStringConfig(config, "completeWhen", "")}
+
+	switch mode := getStringConfig(config, "pagination", paginationOff); mode {
+	case paginationOff, paginationLinkHeader:
+	case paginationNextURL:
+		exprs["nextUrl"] = getStringConfig(config, "nextUrl", "")
+		if exprs["nextUrl"] == "" {
+			return fmt.Errorf("nextUrl is required for next URL pagination")
+		}
+	case paginationParams:
+		params := getMapConfig(config, "paginationParams")
+		if len(params) == 0 {
+			return fmt.Errorf("paginationParams is required for query parameter pagination")
+		}
+		for name, expr := range params {
+			exprs["paginationParams."+name] = fmt.Sprintf("%v", expr)
+		}
+	default:
+		return fmt.Errorf("unknown pagination mode: %s", mode)
+	}
+
+	for name, expr := range exprs {
+		if expr == "" {
+			continue
+		}
+		if err := paginationParser.Validate("{{ " + expr + " }}"); err != nil {
+			return fmt.Errorf("invalid %s expression: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// paginate fetches pages until the completion expression holds, a page is
+// empty, there is no next page or maxPages is reached, and returns the items
+// of every page. Pagination expressions are bare expressions evaluated with
+// $json set to the page just fetched: its statusCode, headers, body, url and
+// 1-based page number.
+func (n *HTTPRequestNode) paginate(ctx context.Context, client *http.Client, input *runtime.ExecutionInput, mode string, firstURL *url.URL, output *runtime.ExecutionOutput) error {
+	config := input.NodeConfig
+	maxPages := getIntConfig(config, "maxPages", defaultMaxPages)
+	interval := time.Duration(getIntConfig(config, "pageInterval", 0)) * time.Millisecond
+	itemsPath := getStringConfig(config, "itemsPath", "")
+	completeWhen := getStringConfig(config, "completeWhen", "")
+
+	var items []interface{}
+	var bytesRead, bytesWritten int64
+	pageURL := firstURL
+	pages := 0
+
+	for {
+		resp, err := n.send(ctx, client, input, pageURL, output)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", pages+1, err)
+		}
+		pages++
+		bytesRead += resp.bytesRead
+		bytesWritten += resp.bytesWritten
+		if !resp.ok() {
+			return fmt.Errorf("page %d: request failed with status %s", pages, resp.status)
+		}
+
+		pageItems := itemsOfPage(resp.body, itemsPath)
+		items = append(items, pageItems...)
+
+		page := resp.data()
+		page["url"] = pageURL.String()
+		page["page"] = pages
+		exprCtx := expression.NewContext()
+		exprCtx.SetInput(page)
+		if input.Context != nil {
+			exprCtx.Execution.ID = input.Context.ExecutionID
+			exprCtx.Execution.Mode = input.Context.Mode
+			exprCtx.Env = input.Context.Env
+			exprCtx.Variables = input.Context.Variables
+		}
+
+		if len(pageItems) == 0 || pages >= maxPages {
+			break
+		}
+		if completeWhen != "" {
+			done, err := paginationParser.EvaluateExpression(completeWhen, exprCtx)
+			if err != nil {
+				return fmt.Errorf("invalid completion expression: %w", err)
+			}
+			if toBool(done) {
+				break
+			}
+		}
+
+		next, err := nextPageURL(mode, config, pageURL, resp.header, exprCtx)
+		if err != nil {
+			return err
+		}
+		if next == nil || next.String() == pageURL.String() {
+			break
+		}
+		pageURL = next
+
+		// Rate limit between pages
+		if interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	output.Logs = append(output.Logs, runtime.LogEntry{
+		Level:     "info",
+		Message:   fmt.Sprintf("Fetched %d items from %d pages", len(items), pages),
+		Timestamp: time.Now().UnixMilli(),
+		NodeID:    input.NodeID,
+	})
+
+	if items == nil {
+		items = []interface{}{}
+	}
+	output.Data = map[string]interface{}{
+		"items":   items,
+		"pages":   pages,
+		"lastUrl": pageURL.String(), // Holds the final cursor of parameter pagination
+	}
+	output.Metrics.BytesRead = bytesRead
+	output.Metrics.BytesWritten = bytesWritten
+	return nil
+}
+
+// nextPageURL returns the URL of the page after current, nil if there is none
+func nextPageURL(mode string, config map[string]interface{}, current *url.URL, header http.Header, exprCtx *expression.Context) (*url.URL, error) {
+	switch mode {
+	case paginationNextURL:
+		next, err := paginationParser.EvaluateExpression(getStringConfig(config, "nextUrl", ""), exprCtx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next URL expression: %w", err)
+		}
+		if next == nil || fmt.Sprintf("%v", next) == "" {
+			return nil, nil
+		}
+		nextURL, err := url.Parse(fmt.Sprintf("%v", next))
+		if err != nil {
+			return nil, fmt.Errorf("invalid next page URL: %w", err)
+		}
+		return current.ResolveReference(nextURL), nil
+
+	case paginationLinkHeader:
+		next := linkRelNext(header.Values("Link"))
+		if next == "" {
+			return nil, nil
+		}
+		nextURL, err := url.Parse(next)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next page URL: %w", err)
+		}
+		return current.ResolveReference(nextURL), nil
+
+	case paginationParams:
+		params := getMapConfig(config, "paginationParams")
+		if len(params) == 0 {
+			return nil, fmt.Errorf("query parameter pagination requires paginationParams")
+		}
+		nextURL := *current
+		q := nextURL.Query()
+		for name, expr := range params {
+			value, err := paginationParser.EvaluateExpression(fmt.Sprintf("%v", expr), exprCtx)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expression for parameter %s: %w", name, err)
+			}
+			if value == nil || fmt.Sprintf("%v", value) == "" {
+				return nil, nil
+			}
+			q.Set(name, fmt.Sprintf("%v", value))
+		}
+		nextURL.RawQuery = q.Encode()
+		return &nextURL, nil
+	}
+
+	return nil, fmt.Errorf("unknown pagination mode: %s", mode)
+}
+
+// linkRelNext returns the target of the rel="next" link of Link headers,
+// e.g. <https://api.example.com/items?page=2>; rel="next"
+func linkRelNext(values []string) string {
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(key, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					if rel == "next" {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// itemsOfPage returns the items of a page body: the array at path, or the
+// body itself. A single object counts as one item.
+func itemsOfPage(body interface{}, path string) []interface{} {
+	value := body
+	if path != "" {
+		m, ok := body.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = getFieldValue(m, path)
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case nil:
+		return nil
+	default:
+		return []interface{}{v}
+	}
+}
@@ -0,0 +1,222 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
+)
+
+// pagedServer serves total items, perPage at a time, reading the page from
+// the page or cursor query parameter. Each page links to the next one
+// through nextCursor, nextUrl and a Link header.
+type pagedServer struct {
+	*httptest.Server
+	total, perPage int
+	mu             sync.Mutex
+	requests       []string
+}
+
+func newPagedServer(total, perPage int) *pagedServer {
+	s := &pagedServer{total: total, perPage: perPage}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *pagedServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.RawQuery)
+	s.mu.Unlock()
+
+	page := 1
+	if value := r.URL.Query().Get("page"); value != "" {
+		page, _ = strconv.Atoi(value)
+	}
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, `{"error":"invalid cursor"}`, http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+
+	items := []interface{}{}
+	for i := (page - 1) * s.perPage; i < page*s.perPage && i < s.total; i++ {
+		items = append(items, map[string]interface{}{"id": i})
+	}
+	body := map[string]interface{}{"data": items, "page": page, "nextCursor": "", "nextUrl": ""}
+	if page*s.perPage < s.total {
+		body["nextCursor"] = strconv.Itoa(page + 1)
+		body["nextUrl"] = fmt.Sprintf("/items?page=%d", page+1)
+		w.Header().Set("Link", fmt.Sprintf(`</items?page=%d>; rel="next", </items?page=1>; rel="first"`, page+1))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func (s *pagedServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func paginate(t *testing.T, config map[string]interface{}) *runtime.ExecutionOutput {
+	t.Helper()
+	node := NewHTTPRequestNode()
+	require.NoError(t, node.Validate(config))
+
+	output, err := node.Execute(context.Background(), &runtime.ExecutionInput{
+		NodeID:     "request",
+		NodeConfig: config,
+		Context:    &runtime.ExecutionContext{Egress: egress.NewGuard(egress.Policy{AllowPrivate: true})},
+	})
+	require.NoError(t, err)
+	return output
+}
+
+func TestHTTPRequest_PaginatesWithCursorParameter(t *testing.T) {
+	server := newPagedServer(7, 3)
+	defer server.Close()
+
+	output := paginate(t, map[string]interface{}{
+		"url":              server.URL + "/items",
+		"pagination":       "params",
+		"paginationParams": map[string]interface{}{"cursor": "$json.body.nextCursor"},
+		"itemsPath":        "data",
+	})
+	require.NoError(t, output.Error)
+	assert.Len(t, output.Data["items"], 7)
+	assert.Equal(t, 3, output.Data["pages"])
+	assert.Equal(t, []string{"", "cursor=2", "cursor=3"}, server.requests)
+}
+
+func TestHTTPRequest_PaginatedItemsCarryPageMetadata(t *testing.T) {
+	server := newPagedServer(5, 2)
+	defer server.Close()
+
+	output := paginate(t, map[string]interface{}{
+		"url":              server.URL + "/items",
+		"pagination":       "params",
+		"paginationParams": map[string]interface{}{"cursor": "$json.body.nextCursor"},
+		"itemsPath":        "data",
+	})
+	require.NoError(t, output.Error)
+
+	// The items downstream nodes receive keep the page count and the URL,
+	// with the final cursor, of the last page
+	items := runtime.ItemsFromData(output.Data)
+	require.Len(t, items, 5)
+	for i, item := range items {
+		assert.Equal(t, float64(i), item.JSON["id"])
+		assert.Equal(t, map[string]interface{}{
+			"pages":   3,
+			"lastUrl": server.URL + "/items?cursor=3",
+		}, item.JSON[runtime.MetaKey])
+	}
+}
+
+func TestHTTPRequest_PaginatesWithNextURLAndLinkHeader(t *testing.T) {
+	server := newPagedServer(5, 2)
+	defer server.Close()
+
+	for _, config := range []map[string]interface{}{
+		{"pagination": "nextUrl", "nextUrl": "$json.body.nextUrl"},
+		{"pagination": "linkHeader"},
+	} {
+		config["url"] = server.URL + "/items"
+		config["itemsPath"] = "data"
+		output := paginate(t, config)
+		require.NoError(t, output.Error)
+		assert.Len(t, output.Data["items"], 5)
+		assert.Equal(t, 3, output.Data["pages"])
+	}
+}
+
+func TestHTTPRequest_PaginationStopsAtPageBoundaries(t *testing.T) {
+	server := newPagedServer(100, 10)
+	defer server.Close()
+
+	config := func(extra map[string]interface{}) map[string]interface{} {
+		config := map[string]interface{}{
+			"url":        server.URL + "/items",
+			"pagination": "linkHeader",
+			"itemsPath":  "data",
+		}
+		for k, v := range extra {
+			config[k] = v
+		}
+		return config
+	}
+
+	// maxPages bounds the pages fetched
+	output := paginate(t, config(map[string]interface{}{"maxPages": 3}))
+	require.NoError(t, output.Error)
+	assert.Len(t, output.Data["items"], 30)
+	assert.Equal(t, 3, output.Data["pages"])
+
+	// The completion expression is evaluated against each page
+	output = paginate(t, config(map[string]interface{}{"completeWhen": "$json.body.page >= 2"}))
+	require.NoError(t, output.Error)
+	assert.Len(t, output.Data["items"], 20)
+	assert.Equal(t, 2, output.Data["pages"])
+
+	// A page without items ends pagination, even if it links to another
+	exact := newPagedServer(4, 2)
+	defer exact.Close()
+	output = paginate(t, map[string]interface{}{
+		"url":              exact.URL + "/items",
+		"pagination":       "params",
+		"paginationParams": map[string]interface{}{"page": "$json.page + 1"},
+		"itemsPath":        "data",
+	})
+	require.NoError(t, output.Error)
+	assert.Len(t, output.Data["items"], 4)
+	assert.Equal(t, 3, output.Data["pages"])
+	assert.Equal(t, 3, exact.requestCount())
+}
+
+func TestHTTPRequest_PaginationFailsOnInvalidCursor(t *testing.T) {
+	server := newPagedServer(10, 2)
+	defer server.Close()
+
+	// The API rejects the cursor of the second page
+	output := paginate(t, map[string]interface{}{
+		"url":              server.URL + "/items",
+		"pagination":       "params",
+		"paginationParams": map[string]interface{}{"cursor": `"not-a-cursor"`},
+		"itemsPath":        "data",
+	})
+	require.Error(t, output.Error)
+	assert.Contains(t, output.Error.Error(), "page 2: request failed with status 400")
+
+	// A next URL that does not parse
+	output = paginate(t, map[string]interface{}{
+		"url":        server.URL + "/items",
+		"pagination": "nextUrl",
+		"nextUrl":    `"%zz"`,
+		"itemsPath":  "data",
+	})
+	require.Error(t, output.Error)
+	assert.Contains(t, output.Error.Error(), "invalid next page URL")
+}
+
+func TestHTTPRequest_ValidatesPagination(t *testing.T) {
+	node := NewHTTPRequestNode()
+	assert.Error(t, node.Validate(map[string]interface{}{"url": "https://example.com", "pagination": "pages"}))
+	assert.Error(t, node.Validate(map[string]interface{}{"url": "https://example.com", "pagination": "nextUrl"}))
+	assert.Error(t, node.Validate(map[string]interface{}{"url": "https://example.com", "pagination": "params"}))
+	assert.Error(t, node.Validate(map[string]interface{}{"url": "https://example.com", "pagination": "nextUrl", "nextUrl": "$json.body.("}))
+	assert.NoError(t, node.Validate(map[string]interface{}{"url": "https://example.com", "pagination": "linkHeader", "maxPages": 5}))
+}
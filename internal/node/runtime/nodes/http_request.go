@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
 )
 
 // HTTPRequestNode implements HTTP request functionality
@@ -48,7 +50,7 @@ func (n *HTTPRequestNode) GetMetadata() runtime.NodeMetadata {
 			{Name: "main", Type: "any", Required: false, Description: "Input data"},
 		},
 		Outputs: []runtime.PortDefinition{
-			{Name: "main", Type: "any", Description: "Response data"},
+			{Name: "main", Type: "any", Description: "Response data; paginated requests output an item per fetched item, with the page count and last page URL in $json.$meta.pages and $json.$meta.lastUrl"},
 			{Name: "error", Type: "any", Description: "Error output"},
 		},
 		Properties: []runtime.PropertyDefinition{
@@ -77,7 +79,10 @@ func (n *HTTPRequestNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Form Data", Value: "form"},
 				{Label: "Form URL Encoded", Value: "urlencoded"},
 				{Label: "Raw", Value: "raw"},
+				{Label: "Binary File", Value: "binary"},
 			}},
+			{Name: "binaryFields", Type: "json", Description: "Form fields uploading binary data of the input item, by binary property (for form data)", Placeholder: `{"file": "data"}`},
+			{Name: "binaryProperty", Type: "string", Default: "data", Description: "Binary property of the input item sent as the body, or receiving a binary response"},
 			{Name: "timeout", Type: "number", Default: 30, Description: "Request timeout in seconds"},
 			{Name: "followRedirects", Type: "boolean", Default: true, Description: "Follow redirects"},
 			{Name: "responseType", Type: "select", Default: "auto", Description: "Response type", Options: []runtime.PropertyOption{
@@ -86,6 +91,19 @@ func (n *HTTPRequestNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Text", Value: "text"},
 				{Label: "Binary", Value: "binary"},
 			}},
+			{Name: "maxBodySize", Type: "number", Default: defaultMaxBodySize, Description: "Maximum response body size in bytes"},
+			{Name: "pagination", Type: "select", Default: "off", Description: "Follow further pages and return their items", Options: []runtime.PropertyOption{
+				{Label: "Off", Value: "off"},
+				{Label: "Next URL Expression", Value: "nextUrl"},
+				{Label: "Link Header", Value: "linkHeader"},
+				{Label: "Query Parameters", Value: "params"},
+			}},
+			{Name: "nextUrl", Type: "string", Description: "Expression giving the URL of the next page; empty stops (for next URL pagination)", Placeholder: "$json.body.next"},
+			{Name: "paginationParams", Type: "json", Description: "Query parameters of the next page as expressions; an empty value stops (for query parameter pagination)", Placeholder: `{"cursor": "$json.body.nextCursor"}`},
+			{Name: "completeWhen", Type: "string", Description: "Expression that is true on the last page", Placeholder: "$json.body.hasMore == false"},
+			{Name: "itemsPath", Type: "string", Description: "Dot path to the items of each page, the body itself when empty", Placeholder: "data.items"},
+			{Name: "maxPages", Type: "number", Default: 100, Description: "Maximum number of pages to fetch"},
+			{Name: "pageInterval", Type: "number", Default: 0, Description: "Milliseconds to wait between pages"},
 		},
 		IsTrigger: false,
 	}
@@ -96,7 +114,7 @@ func (n *HTTPRequestNode) Validate(config map[string]interface{}) error {
 	if _, ok := config["url"]; !ok {
 		return fmt.Errorf("url is required")
 	}
-	return nil
+	return validatePagination(config)
 }
 
 // Execute executes the HTTP request
@@ -108,15 +126,9 @@ func (n *HTTPRequestNode) Execute(ctx context.Context, input *runtime.ExecutionI
 	}
 	
 	// Get configuration
-	method := getStringConfig(input.NodeConfig, "method", "GET")
 	urlStr := getStringConfig(input.NodeConfig, "url", "")
-	headers := getMapConfig(input.NodeConfig, "headers")
 	queryParams := getMapConfig(input.NodeConfig, "queryParams")
-	body := input.NodeConfig["body"]
-	bodyType := getStringConfig(input.NodeConfig, "bodyType", "json")
 	timeout := getIntConfig(input.NodeConfig, "timeout", 30)
-	authType := getStringConfig(input.NodeConfig, "authentication", "none")
-	responseType := getStringConfig(input.NodeConfig, "responseType", "auto")
 	
 	// Build URL with query params
 	parsedURL, err := url.Parse(urlStr)
@@ -133,40 +145,110 @@ func (n *HTTPRequestNode) Execute(ctx context.Context, input *runtime.ExecutionI
 		parsedURL.RawQuery = q.Encode()
 	}
 	
+	// Requests are held to the workspace's egress policy
+	client := egressClient(input, time.Duration(timeout)*time.Second)
+	
+	if mode := getStringConfig(input.NodeConfig, "pagination", paginationOff); mode != paginationOff {
+		if err := n.paginate(ctx, client, input, mode, parsedURL, output); err != nil {
+			output.Error = err
+		}
+		output.Metrics.StartTime = startTime.UnixMilli()
+		output.Metrics.EndTime = time.Now().UnixMilli()
+		output.Metrics.DurationMs = time.Since(startTime).Milliseconds()
+		return output, nil
+	}
+	
+	resp, err := n.send(ctx, client, input, parsedURL, output)
+	if err != nil {
+		output.Error = err
+		return output, nil
+	}
+	
+	if resp.binary != nil {
+		property := getStringConfig(input.NodeConfig, "binaryProperty", defaultBinaryProperty)
+		output.Binary = map[string]*runtime.BinaryData{property: resp.binary}
+	}
+	output.Data = resp.data()
+	
+	// Set metrics
+	output.Metrics = runtime.ExecutionMetrics{
+		StartTime:    startTime.UnixMilli(),
+		EndTime:      time.Now().UnixMilli(),
+		DurationMs:   time.Since(startTime).Milliseconds(),
+		BytesRead:    resp.bytesRead,
+		BytesWritten: resp.bytesWritten,
+	}
+	
+	return output, nil
+}
+
+// httpResponse is a response read and parsed by the HTTP request node
+type httpResponse struct {
+	statusCode   int
+	status       string
+	header       http.Header
+	body         interface{}
+	binary       *runtime.BinaryData // Stored body when the response type is binary
+	bytesRead    int64
+	bytesWritten int64
+}
+
+// ok reports whether the response has a 2xx status
+func (r *httpResponse) ok() bool {
+	return r.statusCode >= 200 && r.statusCode < 300
+}
+
+// data returns the response as node output data
+func (r *httpResponse) data() map[string]interface{} {
+	// Build response headers map
+	respHeaders := make(map[string]string)
+	for k := range r.header {
+		respHeaders[k] = r.header.Get(k)
+	}
+	
+	return map[string]interface{}{
+		"statusCode":    r.statusCode,
+		"statusMessage": r.status,
+		"headers":       respHeaders,
+		"body":          r.body,
+		"ok":            r.ok(),
+	}
+}
+
+// send performs a single request to requestURL with the node's method,
+// headers, body and authentication, logging it to output
+func (n *HTTPRequestNode) send(ctx context.Context, client *http.Client, input *runtime.ExecutionInput, requestURL *url.URL, output *runtime.ExecutionOutput) (*httpResponse, error) {
+	method := getStringConfig(input.NodeConfig, "method", "GET")
+	headers := getMapConfig(input.NodeConfig, "headers")
+	body := input.NodeConfig["body"]
+	bodyType := getStringConfig(input.NodeConfig, "bodyType", "json")
+	authType := getStringConfig(input.NodeConfig, "authentication", "none")
+	responseType := getStringConfig(input.NodeConfig, "responseType", "auto")
+	maxBodySize := int64(getIntConfig(input.NodeConfig, "maxBodySize", defaultMaxBodySize))
+	
 	// Prepare request body
 	var bodyReader io.Reader
 	var contentType string
 	
-	if body != nil && (method == "POST" || method == "PUT" || method == "PATCH") {
-		switch bodyType {
-		case "json":
-			jsonBody, err := json.Marshal(body)
-			if err != nil {
-				output.Error = fmt.Errorf("failed to marshal JSON body: %w", err)
-				return output, nil
-			}
-			bodyReader = bytes.NewReader(jsonBody)
-			contentType = "application/json"
-		case "form", "urlencoded":
-			formData := url.Values{}
-			if m, ok := body.(map[string]interface{}); ok {
-				for k, v := range m {
-					formData.Set(k, fmt.Sprintf("%v", v))
-				}
-			}
-			bodyReader = strings.NewReader(formData.Encode())
-			contentType = "application/x-www-form-urlencoded"
-		case "raw":
-			bodyReader = strings.NewReader(fmt.Sprintf("%v", body))
-			contentType = "text/plain"
+	if method == "POST" || method == "PUT" || method == "PATCH" {
+		var err error
+		bodyReader, contentType, err = requestBody(ctx, input, bodyType, body)
+		if err != nil {
+			return nil, err
 		}
 	}
 	
 	// Create request
-	req, err := http.NewRequestWithContext(ctx, method, parsedURL.String(), bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), bodyReader)
 	if err != nil {
-		output.Error = fmt.Errorf("failed to create request: %w", err)
-		return output, nil
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	
+	if sized, ok := bodyReader.(*sizedBody); ok {
+		req.ContentLength = sized.size
+	}
+	if closer, ok := bodyReader.(io.Closer); ok {
+		defer closer.Close()
 	}
 	
 	// Set headers
@@ -180,19 +262,13 @@ func (n *HTTPRequestNode) Execute(ctx context.Context, input *runtime.ExecutionI
 	
 	// Apply authentication
 	if err := n.applyAuthentication(req, authType, input.NodeConfig, input.Credentials); err != nil {
-		output.Error = fmt.Errorf("authentication error: %w", err)
-		return output, nil
-	}
-	
-	// Set timeout
-	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
+		return nil, fmt.Errorf("authentication error: %w", err)
 	}
 	
 	// Log request
 	output.Logs = append(output.Logs, runtime.LogEntry{
 		Level:     "info",
-		Message:   fmt.Sprintf("%s %s", method, parsedURL.String()),
+		Message:   fmt.Sprintf("%s %s", method, requestURL.String()),
 		Timestamp: time.Now().UnixMilli(),
 		NodeID:    input.NodeID,
 	})
@@ -200,20 +276,27 @@ func (n *HTTPRequestNode) Execute(ctx context.Context, input *runtime.ExecutionI
 	// Execute request
 	resp, err := client.Do(req)
 	if err != nil {
-		output.Error = fmt.Errorf("request failed: %w", err)
-		return output, nil
+		var blocked *egress.BlockedError
+		if errors.As(err, &blocked) {
+			return nil, fmt.Errorf("request not allowed: %w", blocked)
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 	
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		output.Error = fmt.Errorf("failed to read response: %w", err)
-		return output, nil
+	result := &httpResponse{
+		statusCode:   resp.StatusCode,
+		status:       resp.Status,
+		header:       resp.Header,
+		bytesWritten: req.ContentLength,
+	}
+	if result.bytesWritten < 0 {
+		result.bytesWritten = 0
 	}
 	
-	// Parse response
-	var responseData interface{}
+	if resp.ContentLength > maxBodySize {
+		return nil, fmt.Errorf("%w of %d bytes", errBodyTooLarge, maxBodySize)
+	}
 	
 	// Determine response type
 	contentTypeHeader := resp.Header.Get("Content-Type")
@@ -227,44 +310,35 @@ func (n *HTTPRequestNode) Execute(ctx context.Context, input *runtime.ExecutionI
 		}
 	}
 	
-	switch responseType {
-	case "json":
-		if err := json.Unmarshal(respBody, &responseData); err != nil {
-			// If JSON parsing fails, return as text
-			responseData = string(respBody)
+	// Binary responses are streamed to the binary store; everything else
+	// is read into memory to be parsed
+	if responseType == "binary" {
+		data, err := storeResponse(ctx, input, resp, maxBodySize)
+		if err != nil {
+			return nil, err
 		}
-	case "text":
-		responseData = string(respBody)
-	case "binary":
-		output.Binary = map[string][]byte{"body": respBody}
-		responseData = map[string]interface{}{
-			"size":     len(respBody),
-			"mimeType": contentTypeHeader,
+		result.binary = data
+		result.bytesRead = data.Size
+		result.body = map[string]interface{}{
+			"size":     data.Size,
+			"mimeType": data.MimeType,
+			"fileName": data.FileName,
+		}
+	} else {
+		respBody, err := io.ReadAll(limitBody(resp.Body, maxBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		result.bytesRead = int64(len(respBody))
+		
+		if responseType == "json" {
+			if err := json.Unmarshal(respBody, &result.body); err != nil {
+				// If JSON parsing fails, return as text
+				result.body = string(respBody)
+			}
+		} else {
+			result.body = string(respBody)
 		}
-	}
-	
-	// Build response headers map
-	respHeaders := make(map[string]string)
-	for k := range resp.Header {
-		respHeaders[k] = resp.Header.Get(k)
-	}
-	
-	// Set output data
-	output.Data = map[string]interface{}{
-		"statusCode":    resp.StatusCode,
-		"statusMessage": resp.Status,
-		"headers":       respHeaders,
-		"body":          responseData,
-		"ok":            resp.StatusCode >= 200 && resp.StatusCode < 300,
-	}
-	
-	// Set metrics
-	output.Metrics = runtime.ExecutionMetrics{
-		StartTime:    startTime.UnixMilli(),
-		EndTime:      time.Now().UnixMilli(),
-		DurationMs:   time.Since(startTime).Milliseconds(),
-		BytesRead:    int64(len(respBody)),
-		BytesWritten: req.ContentLength,
 	}
 	
 	// Log response
@@ -275,7 +349,42 @@ func (n *HTTPRequestNode) Execute(ctx context.Context, input *runtime.ExecutionI
 		NodeID:    input.NodeID,
 	})
 	
-	return output, nil
+	return result, nil
+}
+
+// requestBody builds the request body of the given type, returning it with
+// its content type
+func requestBody(ctx context.Context, input *runtime.ExecutionInput, bodyType string, body interface{}) (io.Reader, string, error) {
+	switch bodyType {
+	case "form":
+		return multipartBody(ctx, input, body)
+	case "binary":
+		return binaryBody(ctx, input)
+	}
+	
+	if body == nil {
+		return nil, "", nil
+	}
+	
+	switch bodyType {
+	case "json":
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to marshal JSON body: %w", err)
+		}
+		return bytes.NewReader(jsonBody), "application/json", nil
+	case "urlencoded":
+		formData := url.Values{}
+		if m, ok := body.(map[string]interface{}); ok {
+			for k, v := range m {
+				formData.Set(k, fmt.Sprintf("%v", v))
+			}
+		}
+		return strings.NewReader(formData.Encode()), "application/x-www-form-urlencoded", nil
+	case "raw":
+		return strings.NewReader(fmt.Sprintf("%v", body)), "text/plain", nil
+	}
+	return nil, "", nil
 }
 
 func (n *HTTPRequestNode) applyAuthentication(req *http.Request, authType string, config, credentials map[string]interface{}) error {
@@ -336,6 +445,20 @@ func (n *HTTPRequestNode) applyAuthentication(req *http.Request, authType string
 	return nil
 }
 
+// egressClient returns a client held to the egress policy of the execution's
+// workspace, or to the default policy outside of an execution
+func egressClient(input *runtime.ExecutionInput, timeout time.Duration) *http.Client {
+	guard := egress.Default()
+	workspaceID := ""
+	if input.Context != nil {
+		if input.Context.Egress != nil {
+			guard = input.Context.Egress
+		}
+		workspaceID = input.Context.WorkspaceID
+	}
+	return guard.Client(workspaceID, "node "+input.NodeID, timeout)
+}
+
 // Helper functions
 
 func getStringConfig(config map[string]interface{}, key, defaultVal string) string {
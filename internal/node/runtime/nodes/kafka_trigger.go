@@ -0,0 +1,185 @@
+// Package nodes provides the Kafka trigger node
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/messaging/kafka"
+)
+
+// errSubscribedByEngine is returned by the Start of subscribing triggers,
+// which the engine subscribes for each active workflow
+var errSubscribedByEngine = fmt.Errorf("subscribing triggers are run by the engine")
+
+// KafkaTriggerNode starts executions with the messages of Kafka topics
+type KafkaTriggerNode struct{}
+
+// NewKafkaTriggerNode creates a new Kafka trigger node
+func NewKafkaTriggerNode() *KafkaTriggerNode {
+	return &KafkaTriggerNode{}
+}
+
+// GetType returns the node type
+func (n *KafkaTriggerNode) GetType() string {
+	return "kafka_trigger"
+}
+
+// GetTriggerType returns the trigger type
+func (n *KafkaTriggerNode) GetTriggerType() runtime.TriggerType {
+	return runtime.TriggerTypeEvent
+}
+
+// GetMetadata returns node metadata
+func (n *KafkaTriggerNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "kafka_trigger",
+		Name:        "Kafka Trigger",
+		Description: "Trigger workflow with the messages of Kafka topics",
+		Category:    "trigger",
+		Icon:        "kafka",
+		Color:       "#231F20",
+		Version:     "1.0.0",
+		Outputs: []runtime.PortDefinition{
+			{Name: "main", Type: "any", Description: "Messages"},
+		},
+		Properties: []runtime.PropertyDefinition{
+			{Name: "brokers", Type: "string", Required: true, Description: "Comma-separated broker addresses, e.g. kafka:9092"},
+			{Name: "topics", Type: "string", Required: true, Description: "Comma-separated topics to consume"},
+			{Name: "groupId", Type: "string", Required: true, Description: "Consumer group; replicas and workflows sharing it share the messages out"},
+			{Name: "deliverAs", Type: "select", Default: "message", Description: "Start an execution per message or per batch", Options: []runtime.PropertyOption{
+				{Label: "One Execution per Message", Value: "message"},
+				{Label: "One Execution per Batch", Value: "batch"},
+			}},
+			{Name: "batchSize", Type: "number", Default: 100, Description: "Most messages in a batch (batch)"},
+			{Name: "batchTimeout", Type: "number", Default: 5, Description: "Seconds to wait to fill a batch (batch)"},
+			{Name: "fromBeginning", Type: "boolean", Default: false, Description: "Start a new consumer group at the oldest message rather than the newest"},
+			{Name: "parseJson", Type: "boolean", Default: true, Description: "Parse message values that are JSON"},
+			{Name: "maxAttempts", Type: "number", Default: 10, Description: "Times a message or batch whose execution cannot be started is retried before it is given up on"},
+			{Name: "deadLetterTopic", Type: "string", Description: "Topic messages given up on are published to; without one they are skipped"},
+			{Name: "useTLS", Type: "boolean", Default: false, Description: "Connect to the brokers over TLS"},
+		},
+		IsTrigger: true,
+	}
+}
+
+// Validate validates the node configuration
+func (n *KafkaTriggerNode) Validate(config map[string]interface{}) error {
+	if len(splitList(getStringConfig(config, "brokers", ""))) == 0 {
+		return fmt.Errorf("brokers are required")
+	}
+	if len(splitList(getStringConfig(config, "topics", ""))) == 0 {
+		return fmt.Errorf("topics are required")
+	}
+	if getStringConfig(config, "groupId", "") == "" {
+		return fmt.Errorf("groupId is required")
+	}
+	switch getStringConfig(config, "deliverAs", "message") {
+	case "message", "batch":
+	default:
+		return fmt.Errorf("unknown deliverAs: %v", config["deliverAs"])
+	}
+	if getIntConfig(config, "batchSize", 100) < 1 {
+		return fmt.Errorf("batchSize must be at least 1")
+	}
+	return nil
+}
+
+// Execute outputs the messages the execution was started with
+func (n *KafkaTriggerNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	return triggerItemsOutput(input, "Kafka"), nil
+}
+
+// Subscribe consumes the topics as a member of the consumer group. Offsets
+// are committed once the execution of their message or batch is accepted.
+func (n *KafkaTriggerNode) Subscribe(ctx context.Context, input *runtime.ExecutionInput, deliver runtime.DeliverFunc) error {
+	config := input.NodeConfig
+	consumerConfig := &kafka.ConsumerConfig{
+		Brokers:     splitList(getStringConfig(config, "brokers", "")),
+		GroupID:     getStringConfig(config, "groupId", ""),
+		Topics:      splitList(getStringConfig(config, "topics", "")),
+		BatchSize:   1,
+		FromOldest:  getBoolConfig(config, "fromBeginning", false),
+		Username:    getCredString(input.Credentials, "username", ""),
+		Password:    getCredString(input.Credentials, "password", ""),
+		TLS:         getBoolConfig(config, "useTLS", false),
+		MaxAttempts: getIntConfig(config, "maxAttempts", 10),
+		DeadLetter:  getStringConfig(config, "deadLetterTopic", ""),
+	}
+	if getStringConfig(config, "deliverAs", "message") == "batch" {
+		consumerConfig.BatchSize = getIntConfig(config, "batchSize", 100)
+		consumerConfig.BatchTimeout = time.Duration(getIntConfig(config, "batchTimeout", 5)) * time.Second
+	}
+
+	consumer, err := kafka.NewConsumer(consumerConfig)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	parseJSON := getBoolConfig(config, "parseJson", true)
+	return consumer.Consume(ctx, func(ctx context.Context, messages []*sarama.ConsumerMessage) error {
+		items := make([]runtime.Item, len(messages))
+		for i, message := range messages {
+			items[i] = runtime.NewItem(kafkaMessageJSON(message, parseJSON))
+		}
+		return deliver(ctx, items)
+	})
+}
+
+// kafkaMessageJSON is the item of a message
+func kafkaMessageJSON(message *sarama.ConsumerMessage, parseJSON bool) map[string]interface{} {
+	var value interface{} = string(message.Value)
+	if parseJSON {
+		var parsed interface{}
+		if err := json.Unmarshal(message.Value, &parsed); err == nil {
+			value = parsed
+		}
+	}
+
+	headers := make(map[string]interface{}, len(message.Headers))
+	for _, header := range message.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+
+	return map[string]interface{}{
+		"topic":     message.Topic,
+		"partition": message.Partition,
+		"offset":    message.Offset,
+		"key":       string(message.Key),
+		"value":     value,
+		"headers":   headers,
+		"timestamp": message.Timestamp.Format(time.RFC3339Nano),
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var list []string
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// Start is not supported: subscribing triggers are run by the engine
+func (n *KafkaTriggerNode) Start(ctx context.Context, config map[string]interface{}, callback runtime.TriggerCallback) error {
+	return errSubscribedByEngine
+}
+
+// Stop stops the trigger
+func (n *KafkaTriggerNode) Stop(ctx context.Context) error {
+	return nil
+}
+
+func init() {
+	runtime.Register(NewKafkaTriggerNode())
+}
@@ -9,7 +9,10 @@ import (
 	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
 )
 
-// LoopNode implements iteration over items
+// LoopNode implements iteration over items. It sends one batch at a time to
+// its "loop" output; when the loop body connects back into the node, the
+// engine runs it again with the body's results until every batch was
+// processed, and the collected results are emitted on "done".
 type LoopNode struct{}
 
 // NewLoopNode creates a new Loop node
@@ -27,7 +30,7 @@ func (n *LoopNode) GetMetadata() runtime.NodeMetadata {
 	return runtime.NodeMetadata{
 		Type:        "loop",
 		Name:        "Loop",
-		Description: "Iterate over items in an array",
+		Description: "Iterate over items in batches, running the loop body once per batch",
 		Category:    "core",
 		Icon:        "repeat",
 		Color:       "#673AB7",
@@ -36,20 +39,26 @@ func (n *LoopNode) GetMetadata() runtime.NodeMetadata {
 			{Name: "main", Type: "any", Required: true, Description: "Array to iterate"},
 		},
 		Outputs: []runtime.PortDefinition{
-			{Name: "loop", Type: "any", Description: "Current item (for loop body)"},
-			{Name: "done", Type: "any", Description: "All items after loop completes"},
+			{Name: "loop", Type: "any", Description: "Current batch (connect the end of the loop body back to this node)"},
+			{Name: "done", Type: "any", Description: "Results of every iteration after the loop completes"},
 		},
 		Properties: []runtime.PropertyDefinition{
 			{Name: "items", Type: "string", Description: "Path to array field (leave empty for root array)"},
 			{Name: "batchSize", Type: "number", Default: 1, Description: "Number of items per batch"},
 			{Name: "pauseBetweenBatches", Type: "number", Default: 0, Description: "Pause in ms between batches"},
+			{Name: "maxIterations", Type: "number", Default: 10000, Description: "Fail the execution after this many iterations"},
 		},
-		IsTrigger: false,
+		IsTrigger:          false,
+		RunOnceForAllItems: true,
+		Loop:               true,
 	}
 }
 
 // Validate validates the node configuration
 func (n *LoopNode) Validate(config map[string]interface{}) error {
+	if getIntConfig(config, "maxIterations", 1) < 1 {
+		return fmt.Errorf("maxIterations must be at least 1")
+	}
 	return nil
 }
 
@@ -61,100 +70,131 @@ func (n *LoopNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (
 		Logs: []runtime.LogEntry{},
 	}
 	
-	itemsPath := getStringConfig(input.NodeConfig, "items", "")
-	batchSize := getIntConfig(input.NodeConfig, "batchSize", 1)
-	if batchSize < 1 {
-		batchSize = 1
-	}
+	// Items sent back by the loop body continue the previous iteration
+	loopState, continuing := input.InputData["_loopState"].(map[string]interface{})
 	
-	// Get array to iterate
-	var items []interface{}
+	var items, results []interface{}
+	var batchSize, batchIndex int
 	
-	if itemsPath == "" {
-		// Check if input is already an array
-		if arr, ok := input.InputData["items"].([]interface{}); ok {
-			items = arr
-		} else {
-			// Wrap single item
-			items = []interface{}{input.InputData}
+	if continuing {
+		items = toArray(loopState["items"])
+		results = append(append([]interface{}{}, toArray(loopState["results"])...), runtime.ItemsJSON(input.Items)...)
+		batchSize = getIntConfig(loopState, "batchSize", 1)
+		batchIndex = getIntConfig(loopState, "nextBatch", 0)
+		
+		if pause := getIntConfig(input.NodeConfig, "pauseBetweenBatches", 0); pause > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(pause) * time.Millisecond):
+			}
 		}
 	} else {
-		// Get from path
-		value := getFieldValue(input.InputData, itemsPath)
-		if arr, ok := value.([]interface{}); ok {
-			items = arr
+		itemsPath := getStringConfig(input.NodeConfig, "items", "")
+		batchSize = getIntConfig(input.NodeConfig, "batchSize", 1)
+		if batchSize < 1 {
+			batchSize = 1
+		}
+		
+		if itemsPath == "" {
+			// Iterate over the input items
+			items = getInputItems(input)
 		} else {
-			output.Error = fmt.Errorf("field '%s' is not an array", itemsPath)
-			return output, nil
+			// Get from path
+			value := getFieldValue(input.InputData, itemsPath)
+			if arr, ok := value.([]interface{}); ok {
+				items = arr
+			} else {
+				output.Error = fmt.Errorf("field '%s' is not an array", itemsPath)
+				return output, nil
+			}
 		}
+		results = []interface{}{}
+		
+		output.Logs = append(output.Logs, runtime.LogEntry{
+			Level:     "info",
+			Message:   fmt.Sprintf("Starting loop with %d items in batches of %d", len(items), batchSize),
+			Timestamp: time.Now().UnixMilli(),
+			NodeID:    input.NodeID,
+		})
 	}
 	
-	if len(items) == 0 {
-		output.Data["done"] = []interface{}{}
+	totalBatches := (len(items) + batchSize - 1) / batchSize
+	
+	if batchIndex >= totalBatches {
+		output.Data["done"] = results
 		output.Data["_output"] = "done"
+		output.Items = loopItems(results)
 		output.Logs = append(output.Logs, runtime.LogEntry{
 			Level:     "info",
-			Message:   "Empty array, nothing to iterate",
+			Message:   fmt.Sprintf("Loop completed after %d batches with %d results", totalBatches, len(results)),
 			Timestamp: time.Now().UnixMilli(),
 			NodeID:    input.NodeID,
 		})
 		return output, nil
 	}
 	
-	// Create batched items
-	var batches [][]interface{}
-	for i := 0; i < len(items); i += batchSize {
-		end := i + batchSize
-		if end > len(items) {
-			end = len(items)
-		}
-		batches = append(batches, items[i:end])
+	start := batchIndex * batchSize
+	end := start + batchSize
+	if end > len(items) {
+		end = len(items)
 	}
+	batch := items[start:end]
 	
-	// Store iteration state
+	// Store iteration state for the next run
 	output.Data["_loopState"] = map[string]interface{}{
-		"items":       items,
-		"batches":     batches,
-		"totalItems":  len(items),
-		"totalBatches": len(batches),
-		"currentBatch": 0,
+		"items":        items,
+		"results":      results,
+		"batchSize":    batchSize,
+		"nextBatch":    batchIndex + 1,
+		"totalItems":   len(items),
+		"totalBatches": totalBatches,
 	}
 	
-	// Output first batch
 	if batchSize == 1 {
 		output.Data["loop"] = map[string]interface{}{
-			"item":  items[0],
-			"index": 0,
-			"first": true,
-			"last":  len(items) == 1,
+			"item":  batch[0],
+			"index": batchIndex,
+			"first": batchIndex == 0,
+			"last":  batchIndex == totalBatches-1,
 		}
 	} else {
 		output.Data["loop"] = map[string]interface{}{
-			"items":      batches[0],
-			"batchIndex": 0,
-			"first":      true,
-			"last":       len(batches) == 1,
+			"items":      batch,
+			"batchIndex": batchIndex,
+			"first":      batchIndex == 0,
+			"last":       batchIndex == totalBatches-1,
 		}
 	}
 	
 	output.Data["_output"] = "loop"
-	output.Logs = append(output.Logs, runtime.LogEntry{
-		Level:     "info",
-		Message:   fmt.Sprintf("Starting loop with %d items in %d batches", len(items), len(batches)),
-		Timestamp: time.Now().UnixMilli(),
-		NodeID:    input.NodeID,
-	})
+	output.Items = loopItems(batch)
 	
 	output.Metrics = runtime.ExecutionMetrics{
 		StartTime:  startTime.UnixMilli(),
 		EndTime:    time.Now().UnixMilli(),
 		DurationMs: time.Since(startTime).Milliseconds(),
-		ItemsIn:    len(items),
+		ItemsIn:    len(input.Items),
+		ItemsOut:   len(output.Items),
 	}
 	
 	return output, nil
 }
 
+// loopItems converts loop values into items; values that are not objects are
+// wrapped as {"value": v}
+func loopItems(values []interface{}) []runtime.Item {
+	items := make([]runtime.Item, 0, len(values))
+	for _, v := range values {
+		if m, ok := v.(map[string]interface{}); ok {
+			items = append(items, runtime.NewItem(m))
+		} else {
+			items = append(items, runtime.NewItem(map[string]interface{}{"value": v}))
+		}
+	}
+	return items
+}
+
 func init() {
 	runtime.Register(NewLoopNode())
 }
@@ -192,7 +232,8 @@ func (n *SplitInBatchesNode) GetMetadata() runtime.NodeMetadata {
 			{Name: "batchSize", Type: "number", Default: 10, Required: true, Description: "Number of items per batch"},
 			{Name: "options", Type: "json", Description: "Additional options"},
 		},
-		IsTrigger: false,
+		IsTrigger:          false,
+		RunOnceForAllItems: true,
 	}
 }
 
@@ -215,12 +256,7 @@ func (n *SplitInBatchesNode) Execute(ctx context.Context, input *runtime.Executi
 	}
 	
 	// Get items
-	var items []interface{}
-	if arr, ok := input.InputData["items"].([]interface{}); ok {
-		items = arr
-	} else {
-		items = []interface{}{input.InputData}
-	}
+	items := getInputItems(input)
 	
 	// Split into batches
 	var batches []interface{}
@@ -61,7 +61,8 @@ func (n *MergeNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Input 2", Value: "input2"},
 			}},
 		},
-		IsTrigger: false,
+		IsTrigger:          false,
+		RunOnceForAllItems: true,
 	}
 }
 
@@ -156,6 +157,18 @@ func getInputData(data map[string]interface{}, key string) interface{} {
 	return data
 }
 
+// getInputItems returns the JSON of every input item, falling back to the
+// legacy "items" array or the input data itself
+func getInputItems(input *runtime.ExecutionInput) []interface{} {
+	if len(input.Items) > 1 {
+		return runtime.ItemsJSON(input.Items)
+	}
+	if arr, ok := input.InputData["items"].([]interface{}); ok {
+		return arr
+	}
+	return []interface{}{input.InputData}
+}
+
 func (n *MergeNode) appendItems(input1, input2 interface{}) []interface{} {
 	arr1 := toArray(input1)
 	arr2 := toArray(input2)
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
@@ -22,7 +23,22 @@ func init() {
 type MongoDBNode struct{}
 
 func (n *MongoDBNode) GetType() string { return "mongodb" }
-func (n *MongoDBNode) Validate(config map[string]interface{}) error { return nil }
+
+func (n *MongoDBNode) Validate(config map[string]interface{}) error {
+	collection, _ := config["collection"].(string)
+	if collection == "" {
+		return fmt.Errorf("collection is required")
+	}
+	if operation, _ := config["operation"].(string); operation == operationUpsertItems && len(stringList(config["upsertKey"])) == 0 {
+		return fmt.Errorf("upsertKey is required for %s operation", operation)
+	}
+	return nil
+}
+
+// RunsOnceForAllItems makes the batch operations write every input item at once
+func (n *MongoDBNode) RunsOnceForAllItems(config map[string]interface{}) bool {
+	return isBatchOperation(config)
+}
 
 func (n *MongoDBNode) GetMetadata() runtime.NodeMetadata {
 	return runtime.NodeMetadata{
@@ -39,6 +55,7 @@ func (n *MongoDBNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Find", Value: "find"}, {Label: "Find One", Value: "findOne"},
 				{Label: "Insert One", Value: "insertOne"}, {Label: "Update One", Value: "updateOne"},
 				{Label: "Delete One", Value: "deleteOne"}, {Label: "Aggregate", Value: "aggregate"},
+				{Label: "Insert Items (Batch)", Value: operationInsertItems}, {Label: "Upsert Items (Batch)", Value: operationUpsertItems},
 			}},
 			{Name: "collection", Type: "string", Required: true},
 			{Name: "filter", Type: "json"},
@@ -46,6 +63,7 @@ func (n *MongoDBNode) GetMetadata() runtime.NodeMetadata {
 			{Name: "update", Type: "json"},
 			{Name: "pipeline", Type: "json"},
 			{Name: "limit", Type: "number"},
+			{Name: "upsertKey", Type: "json", Description: "Fields identifying the document to replace on upsert"},
 		},
 	}
 }
@@ -69,17 +87,18 @@ func (n *MongoDBNode) Execute(ctx context.Context, input *runtime.ExecutionInput
 		}
 	}
 
-	clientOpts := options.Client().ApplyURI(connectionString)
-	clientOpts.SetConnectTimeout(10 * time.Second)
+	database, _ := input.Credentials["database"].(string)
+	collection, _ := input.NodeConfig["collection"].(string)
+	if err := validateCollectionName(collection); err != nil {
+		return &runtime.ExecutionOutput{Error: err}, nil
+	}
 
-	client, err := mongo.Connect(ctx, clientOpts)
+	client, release, err := openMongo(connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
-	defer client.Disconnect(ctx)
+	defer release()
 
-	database, _ := input.Credentials["database"].(string)
-	collection, _ := input.NodeConfig["collection"].(string)
 	coll := client.Database(database).Collection(collection)
 
 	operation, _ := input.NodeConfig["operation"].(string)
@@ -108,6 +127,8 @@ func (n *MongoDBNode) Execute(ctx context.Context, input *runtime.ExecutionInput
 		result, err = n.count(ctx, coll, input.NodeConfig)
 	case "distinct":
 		result, err = n.distinct(ctx, coll, input.NodeConfig)
+	case operationInsertItems, operationUpsertItems:
+		result, err = n.writeItems(ctx, coll, input.NodeConfig, input.Items, operation == operationUpsertItems)
 	default:
 		return nil, fmt.Errorf("unknown operation: %s", operation)
 	}
@@ -116,7 +137,13 @@ func (n *MongoDBNode) Execute(ctx context.Context, input *runtime.ExecutionInput
 		return &runtime.ExecutionOutput{Error: err}, nil
 	}
 
-	return &runtime.ExecutionOutput{Data: result}, nil
+	output := &runtime.ExecutionOutput{Data: result}
+	if docs, ok := result["documents"].([]map[string]interface{}); ok {
+		// Emit one item per document so downstream nodes run per document
+		output.Items = runtime.ItemsFromMaps(docs)
+	}
+
+	return output, nil
 }
 
 func (n *MongoDBNode) find(ctx context.Context, coll *mongo.Collection, config map[string]interface{}) (map[string]interface{}, error) {
@@ -326,11 +353,72 @@ func (n *MongoDBNode) distinct(ctx context.Context, coll *mongo.Collection, conf
 	}, nil
 }
 
+// writeItems writes every item as a document in one ordered bulk write, which
+// stops at the first failure. MongoDB only has transactions on replica sets,
+// so documents written before a failure are kept. With upsert, the document
+// matching the item's upsertKey fields is replaced, or the item is inserted
+// when there is none.
+func (n *MongoDBNode) writeItems(ctx context.Context, coll *mongo.Collection, config map[string]interface{}, items []runtime.Item, upsert bool) (map[string]interface{}, error) {
+	if len(items) == 0 {
+		return map[string]interface{}{"insertedCount": 0, "matchedCount": 0, "modifiedCount": 0, "upsertedCount": 0}, nil
+	}
+
+	keys := stringList(config["upsertKey"])
+	if upsert && len(keys) == 0 {
+		return nil, fmt.Errorf("upsertKey is required for upsert")
+	}
+
+	models := make([]mongo.WriteModel, len(items))
+	for i, item := range items {
+		document := n.bsonValue(item.JSON).(bson.M)
+		if !upsert {
+			models[i] = mongo.NewInsertOneModel().SetDocument(document)
+			continue
+		}
+
+		filter := bson.M{}
+		for _, key := range keys {
+			value, ok := document[key]
+			if !ok {
+				return nil, fmt.Errorf("item %d has no upsert key field %s", i, key)
+			}
+			filter[key] = value
+		}
+		models[i] = mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(document).SetUpsert(true)
+	}
+
+	result, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"insertedCount": result.InsertedCount,
+		"matchedCount":  result.MatchedCount,
+		"modifiedCount": result.ModifiedCount,
+		"upsertedCount": result.UpsertedCount,
+	}, nil
+}
+
+// toBSON converts a document given as an object or as JSON text. Only the
+// document itself is parsed: strings within it stay values, so that item
+// data placed in a filter by an expression cannot add query operators.
 func (n *MongoDBNode) toBSON(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(s), &doc); err != nil {
+			return s
+		}
+		v = doc
+	}
 	if v == nil {
 		return bson.M{}
 	}
+	return n.bsonValue(v)
+}
 
+// bsonValue converts objects to BSON documents and hex _id strings to object IDs
+func (n *MongoDBNode) bsonValue(v interface{}) interface{} {
 	switch val := v.(type) {
 	case map[string]interface{}:
 		result := bson.M{}
@@ -344,23 +432,49 @@ func (n *MongoDBNode) toBSON(v interface{}) interface{} {
 					}
 				}
 			}
-			result[k] = n.toBSON(v)
+			result[k] = n.bsonValue(v)
 		}
 		return result
 	case []interface{}:
 		result := make([]interface{}, len(val))
 		for i, item := range val {
-			result[i] = n.toBSON(item)
+			result[i] = n.bsonValue(item)
 		}
 		return result
-	case string:
-		// Try to convert JSON strings to BSON
-		var jsonVal interface{}
-		if err := json.Unmarshal([]byte(val), &jsonVal); err == nil {
-			return n.toBSON(jsonVal)
-		}
-		return val
 	default:
 		return val
 	}
 }
+
+// validateCollectionName rejects names MongoDB reserves or cannot store
+func validateCollectionName(name string) error {
+	if name == "" || strings.ContainsAny(name, "$\x00") || strings.HasPrefix(name, "system.") {
+		return fmt.Errorf("invalid collection name %q", name)
+	}
+	return nil
+}
+
+// openMongo returns the pooled client for uri and its release
+func openMongo(uri string) (*mongo.Client, func(), error) {
+	conn, release, err := dbPool.acquire(poolKey("mongodb", uri), func() (interface{}, func(), error) {
+		clientOpts := options.Client().ApplyURI(uri)
+		clientOpts.SetConnectTimeout(10 * time.Second)
+		clientOpts.SetMaxPoolSize(defaultPoolMaxOpen)
+		clientOpts.SetMaxConnIdleTime(defaultPoolIdleTimeout)
+
+		client, err := mongo.Connect(context.Background(), clientOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+		disconnect := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			client.Disconnect(ctx)
+		}
+		return client, disconnect, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn.(*mongo.Client), release, nil
+}
@@ -20,7 +20,28 @@ func init() {
 type MySQLNode struct{}
 
 func (n *MySQLNode) GetType() string { return "mysql" }
-func (n *MySQLNode) Validate(config map[string]interface{}) error { return nil }
+
+func (n *MySQLNode) Validate(config map[string]interface{}) error {
+	operation, _ := config["operation"].(string)
+	switch operation {
+	case "query":
+		if query, _ := config["query"].(string); query == "" {
+			return fmt.Errorf("query is required for query operation")
+		}
+	case "select", "insert", "update", "delete", operationInsertItems, operationUpsertItems:
+		if table, _ := config["table"].(string); table == "" {
+			return fmt.Errorf("table is required for %s operation", operation)
+		}
+	default:
+		return fmt.Errorf("unknown operation: %s", operation)
+	}
+	return nil
+}
+
+// RunsOnceForAllItems makes the batch operations write every input item at once
+func (n *MySQLNode) RunsOnceForAllItems(config map[string]interface{}) bool {
+	return isBatchOperation(config)
+}
 
 func (n *MySQLNode) GetMetadata() runtime.NodeMetadata {
 	return runtime.NodeMetadata{
@@ -36,13 +57,17 @@ func (n *MySQLNode) GetMetadata() runtime.NodeMetadata {
 			{Name: "operation", Type: "select", Required: true, Options: []runtime.PropertyOption{
 				{Label: "Query", Value: "query"}, {Label: "Select", Value: "select"},
 				{Label: "Insert", Value: "insert"}, {Label: "Update", Value: "update"}, {Label: "Delete", Value: "delete"},
+				{Label: "Insert Items (Batch)", Value: operationInsertItems}, {Label: "Upsert Items (Batch)", Value: operationUpsertItems},
 			}},
-			{Name: "query", Type: "string"},
+			{Name: "query", Type: "string", Description: "SQL query, with :name parameters"},
+			{Name: "params", Type: "json", Description: "Values of :name parameters, or a list of ? parameters"},
 			{Name: "table", Type: "string"},
 			{Name: "columns", Type: "json"},
 			{Name: "values", Type: "json"},
-			{Name: "where", Type: "string"},
+			{Name: "where", Type: "json", Description: "Conditions as key-value pairs, or a condition with :name parameters"},
+			{Name: "orderBy", Type: "string", Description: "Columns to order by, e.g. created_at DESC, id"},
 			{Name: "limit", Type: "number"},
+			{Name: "conflictColumns", Type: "json", Description: "Unique columns left unchanged on upsert"},
 		},
 	}
 }
@@ -59,20 +84,18 @@ func (n *MySQLNode) Execute(ctx context.Context, input *runtime.ExecutionInput)
 	}
 
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, password, host, port, database)
-	db, err := sql.Open("mysql", dsn)
+	db, release, err := openSQL("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
-	defer db.Close()
+	defer release()
 
 	operation, _ := input.NodeConfig["operation"].(string)
 	var result map[string]interface{}
 
 	switch operation {
 	case "query":
-		query, _ := input.NodeConfig["query"].(string)
-		params, _ := input.NodeConfig["params"].([]interface{})
-		result, err = n.executeQuery(ctx, db, query, params)
+		result, err = n.executeQuery(ctx, db, input.NodeConfig)
 
 	case "select":
 		result, err = n.executeSelect(ctx, db, input.NodeConfig)
@@ -86,6 +109,9 @@ func (n *MySQLNode) Execute(ctx context.Context, input *runtime.ExecutionInput)
 	case "delete":
 		result, err = n.executeDelete(ctx, db, input.NodeConfig)
 
+	case operationInsertItems, operationUpsertItems:
+		result, err = mysqlDialect.writeItems(ctx, db, input.NodeConfig, input.Items, operation == operationUpsertItems)
+
 	default:
 		return nil, fmt.Errorf("unknown operation: %s", operation)
 	}
@@ -94,10 +120,22 @@ func (n *MySQLNode) Execute(ctx context.Context, input *runtime.ExecutionInput)
 		return &runtime.ExecutionOutput{Error: err}, nil
 	}
 
-	return &runtime.ExecutionOutput{Data: result}, nil
+	output := &runtime.ExecutionOutput{Data: result}
+	if rows, ok := result["rows"].([]map[string]interface{}); ok {
+		// Emit one item per row so downstream nodes run per row
+		output.Items = runtime.ItemsFromMaps(rows)
+	}
+
+	return output, nil
 }
 
-func (n *MySQLNode) executeQuery(ctx context.Context, db *sql.DB, query string, params []interface{}) (map[string]interface{}, error) {
+func (n *MySQLNode) executeQuery(ctx context.Context, db *sql.DB, config map[string]interface{}) (map[string]interface{}, error) {
+	query, _ := config["query"].(string)
+	query, params, err := mysqlDialect.queryArgs(query, config["params"])
+	if err != nil {
+		return nil, err
+	}
+
 	// Determine if it's a SELECT or modification query
 	queryUpper := strings.ToUpper(strings.TrimSpace(query))
 	if strings.HasPrefix(queryUpper, "SELECT") {
@@ -125,33 +163,40 @@ func (n *MySQLNode) executeQuery(ctx context.Context, db *sql.DB, query string,
 
 func (n *MySQLNode) executeSelect(ctx context.Context, db *sql.DB, config map[string]interface{}) (map[string]interface{}, error) {
 	table, _ := config["table"].(string)
-	columns, _ := config["columns"].([]interface{})
-	where, _ := config["where"].(string)
 	orderBy, _ := config["orderBy"].(string)
-	limit, _ := config["limit"].(float64)
+	limit := getIntConfig(config, "limit", 0)
+
+	table, err := mysqlDialect.identifier(table)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build column list
 	colList := "*"
-	if len(columns) > 0 {
-		colStrs := make([]string, len(columns))
-		for i, c := range columns {
-			colStrs[i], _ = c.(string)
+	if columns := stringList(config["columns"]); len(columns) > 0 && columns[0] != "*" {
+		if colList, err = mysqlDialect.identifiers(columns); err != nil {
+			return nil, err
 		}
-		colList = strings.Join(colStrs, ", ")
 	}
 
 	query := fmt.Sprintf("SELECT %s FROM %s", colList, table)
-	if where != "" {
-		query += " WHERE " + where
+	where, params, err := n.whereClause(config, nil)
+	if err != nil {
+		return nil, err
 	}
+	query += where
 	if orderBy != "" {
-		query += " ORDER BY " + orderBy
+		order, err := mysqlDialect.orderBy(orderBy)
+		if err != nil {
+			return nil, err
+		}
+		query += " ORDER BY " + order
 	}
 	if limit > 0 {
-		query += fmt.Sprintf(" LIMIT %d", int(limit))
+		query += fmt.Sprintf(" LIMIT %d", limit)
 	}
 
-	rows, err := db.QueryContext(ctx, query)
+	rows, err := db.QueryContext(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -168,18 +213,26 @@ func (n *MySQLNode) executeInsert(ctx context.Context, db *sql.DB, config map[st
 		return nil, fmt.Errorf("values required for insert")
 	}
 
-	columns := make([]string, 0, len(values))
-	placeholders := make([]string, 0, len(values))
-	params := make([]interface{}, 0, len(values))
+	table, err := mysqlDialect.identifier(table)
+	if err != nil {
+		return nil, err
+	}
+
+	names := sortedKeys(values)
+	columns, err := mysqlDialect.identifiers(names)
+	if err != nil {
+		return nil, err
+	}
+	placeholders := make([]string, 0, len(names))
+	params := make([]interface{}, 0, len(names))
 
-	for col, val := range values {
-		columns = append(columns, col)
+	for _, col := range names {
 		placeholders = append(placeholders, "?")
-		params = append(params, val)
+		params = append(params, sqlArg(values[col]))
 	}
 
 	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+		table, columns, strings.Join(placeholders, ", "))
 
 	result, err := db.ExecContext(ctx, query, params...)
 	if err != nil {
@@ -198,24 +251,27 @@ func (n *MySQLNode) executeInsert(ctx context.Context, db *sql.DB, config map[st
 func (n *MySQLNode) executeUpdate(ctx context.Context, db *sql.DB, config map[string]interface{}) (map[string]interface{}, error) {
 	table, _ := config["table"].(string)
 	values, _ := config["values"].(map[string]interface{})
-	where, _ := config["where"].(string)
 
 	if len(values) == 0 {
 		return nil, fmt.Errorf("values required for update")
 	}
 
-	setClauses := make([]string, 0, len(values))
-	params := make([]interface{}, 0, len(values))
+	table, err := mysqlDialect.identifier(table)
+	if err != nil {
+		return nil, err
+	}
 
-	for col, val := range values {
-		setClauses = append(setClauses, fmt.Sprintf("%s = ?", col))
-		params = append(params, val)
+	setClauses, params, err := mysqlDialect.assignments(values, 1)
+	if err != nil {
+		return nil, err
 	}
 
 	query := fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(setClauses, ", "))
-	if where != "" {
-		query += " WHERE " + where
+	where, params, err := n.whereClause(config, params)
+	if err != nil {
+		return nil, err
 	}
+	query += where
 
 	result, err := db.ExecContext(ctx, query, params...)
 	if err != nil {
@@ -230,14 +286,20 @@ func (n *MySQLNode) executeUpdate(ctx context.Context, db *sql.DB, config map[st
 
 func (n *MySQLNode) executeDelete(ctx context.Context, db *sql.DB, config map[string]interface{}) (map[string]interface{}, error) {
 	table, _ := config["table"].(string)
-	where, _ := config["where"].(string)
+
+	table, err := mysqlDialect.identifier(table)
+	if err != nil {
+		return nil, err
+	}
 
 	query := fmt.Sprintf("DELETE FROM %s", table)
-	if where != "" {
-		query += " WHERE " + where
+	where, params, err := n.whereClause(config, nil)
+	if err != nil {
+		return nil, err
 	}
+	query += where
 
-	result, err := db.ExecContext(ctx, query)
+	result, err := db.ExecContext(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -248,6 +310,37 @@ func (n *MySQLNode) executeDelete(ctx context.Context, db *sql.DB, config map[st
 	}, nil
 }
 
+// whereClause builds the WHERE clause of the configured conditions and
+// appends its parameters to params. Conditions are either key-value pairs
+// or a condition whose :name parameters are bound from params.
+func (n *MySQLNode) whereClause(config map[string]interface{}, params []interface{}) (string, []interface{}, error) {
+	switch where := config["where"].(type) {
+	case map[string]interface{}:
+		if len(where) == 0 {
+			return "", params, nil
+		}
+		condition, args, err := mysqlDialect.where(where, len(params)+1)
+		if err != nil {
+			return "", nil, err
+		}
+		return " WHERE " + condition, append(params, args...), nil
+	case string:
+		if strings.TrimSpace(where) == "" {
+			return "", params, nil
+		}
+		named, _ := config["params"].(map[string]interface{})
+		condition, args, err := mysqlDialect.bindNamed(where, named)
+		if err != nil {
+			return "", nil, err
+		}
+		return " WHERE " + condition, append(params, args...), nil
+	case nil:
+		return "", params, nil
+	default:
+		return "", nil, fmt.Errorf("where must be an object or a condition")
+	}
+}
+
 func (n *MySQLNode) scanRows(rows *sql.Rows) (map[string]interface{}, error) {
 	columns, err := rows.Columns()
 	if err != nil {
@@ -15,6 +15,7 @@ import (
 
 func init() {
 	runtime.Register(&NotionNode{})
+	runtime.Register(&NotionTriggerNode{})
 }
 
 // NotionNode implements Notion operations
@@ -52,10 +53,12 @@ func (n *NotionNode) GetMetadata() runtime.NodeMetadata {
 }
 
 func (n *NotionNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
-	if n.client == nil {
-		n.client = &http.Client{Timeout: 30 * time.Second}
-	}
+	// Requests go through a client held to the egress policy of the execution
+	node := &NotionNode{client: egressClient(input, 30*time.Second)}
+	return node.execute(ctx, input)
+}
 
+func (n *NotionNode) execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	operation, _ := input.NodeConfig["operation"].(string)
 	accessToken, _ := input.Credentials["access_token"].(string)
 	if accessToken == "" {
@@ -211,3 +214,86 @@ func (n *NotionNode) doRequest(ctx context.Context, method, url string, body int
 	json.Unmarshal(data, &result)
 	return result, nil
 }
+
+// NotionTriggerNode polls a Notion database for new and updated pages
+type NotionTriggerNode struct{}
+
+func (n *NotionTriggerNode) GetType() string                     { return "notion_trigger" }
+func (n *NotionTriggerNode) GetTriggerType() runtime.TriggerType { return runtime.TriggerTypePolling }
+
+func (n *NotionTriggerNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "notion_trigger",
+		Name:        "Notion Trigger",
+		Description: "Trigger workflow on new or updated pages of a Notion database",
+		Category:    "trigger",
+		Version:     "1.0.0",
+		Icon:        "notion",
+		Outputs:     []runtime.PortDefinition{{Name: "main", Type: "main"}},
+		Properties: append([]runtime.PropertyDefinition{
+			{Name: "event", Type: "select", Required: true, Default: "newPages", Options: []runtime.PropertyOption{
+				{Label: "New Page", Value: "newPages"}, {Label: "Updated Page", Value: "updatedPages"},
+			}},
+			{Name: "databaseId", Type: "string", Required: true},
+		}, pollIntervalProperties...),
+		IsTrigger: true,
+	}
+}
+
+func (n *NotionTriggerNode) Validate(config map[string]interface{}) error {
+	if getStringConfig(config, "databaseId", "") == "" {
+		return fmt.Errorf("databaseId is required")
+	}
+	switch getStringConfig(config, "event", "newPages") {
+	case "newPages", "updatedPages":
+	default:
+		return fmt.Errorf("unknown event: %v", config["event"])
+	}
+	return validatePollInterval(config)
+}
+
+func (n *NotionTriggerNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	return triggerItemsOutput(input, "Notion poll"), nil
+}
+
+// Poll queries the database for the pages created or edited since the
+// cursor, which is the time of the latest one seen
+func (n *NotionTriggerNode) Poll(ctx context.Context, input *runtime.ExecutionInput, cursor string) (*runtime.PollResult, error) {
+	token, err := accessToken(input.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	config := input.NodeConfig
+	timeField, version := "created_time", ""
+	if getStringConfig(config, "event", "newPages") == "updatedPages" {
+		timeField, version = "last_edited_time", "last_edited_time"
+	}
+
+	body := map[string]interface{}{
+		"sorts":     []interface{}{map[string]interface{}{"timestamp": timeField, "direction": "descending"}},
+		"page_size": 100,
+	}
+	if cursor != "" {
+		body["filter"] = map[string]interface{}{
+			"timestamp": timeField,
+			timeField:   map[string]interface{}{"on_or_after": cursor},
+		}
+	}
+	headers := map[string]string{
+		"Authorization":  "Bearer " + token,
+		"Notion-Version": "2022-06-28",
+	}
+	endpoint := fmt.Sprintf("https://api.notion.com/v1/databases/%s/query", getStringConfig(config, "databaseId", ""))
+
+	response, err := pollRequest(ctx, egressClient(input, pollTimeout), "POST", endpoint, body, headers)
+	if err != nil {
+		return nil, err
+	}
+	return pollResult(itemsOfPage(response, "results"), cursor, "id", version, timeField), nil
+}
+
+func (n *NotionTriggerNode) Start(ctx context.Context, config map[string]interface{}, callback runtime.TriggerCallback) error {
+	return errPolledByScheduler
+}
+
+func (n *NotionTriggerNode) Stop(ctx context.Context) error { return nil }
@@ -0,0 +1,309 @@
+// Package nodes provides polling trigger node implementations
+package nodes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// pollTimeout bounds the requests of a single poll
+const pollTimeout = 30 * time.Second
+
+// errPolledByScheduler is returned by the Start of polling triggers, which
+// are polled by the engine scheduler so that replicas do not poll each on
+// their own
+var errPolledByScheduler = fmt.Errorf("polling triggers are run by the engine scheduler")
+
+// pollIntervalProperties are the properties setting how often a polling
+// trigger polls
+var pollIntervalProperties = []runtime.PropertyDefinition{
+	{Name: "interval", Type: "number", Default: 60, Description: "How often to poll"},
+	{Name: "unit", Type: "select", Default: "seconds", Description: "Unit of the interval", Options: []runtime.PropertyOption{
+		{Label: "Seconds", Value: "seconds"},
+		{Label: "Minutes", Value: "minutes"},
+		{Label: "Hours", Value: "hours"},
+	}},
+}
+
+// triggerItemsOutput returns the items a trigger was started with, which
+// the scheduler or the engine's subscriptions pass to it as its input data
+func triggerItemsOutput(input *runtime.ExecutionInput, message string) *runtime.ExecutionOutput {
+	items := runtime.ItemsFromData(input.InputData)
+	return &runtime.ExecutionOutput{
+		Data:  input.InputData,
+		Items: items,
+		Logs: []runtime.LogEntry{{
+			Level:     "info",
+			Message:   fmt.Sprintf("%s: %d new items", message, len(items)),
+			Timestamp: time.Now().UnixMilli(),
+			NodeID:    input.NodeID,
+		}},
+	}
+}
+
+// validatePollInterval checks the interval of a polling trigger
+func validatePollInterval(config map[string]interface{}) error {
+	if getIntConfig(config, "interval", 1) < 1 {
+		return fmt.Errorf("interval must be at least 1")
+	}
+	switch getStringConfig(config, "unit", "seconds") {
+	case "seconds", "minutes", "hours":
+		return nil
+	}
+	return fmt.Errorf("unknown interval unit: %v", config["unit"])
+}
+
+// pollRequest sends a request of a poll and decodes its JSON response
+func pollRequest(ctx context.Context, client *http.Client, method, rawURL string, body interface{}, headers map[string]string) (interface{}, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return result, nil
+}
+
+// pollResult builds the result of a poll from the objects it found. Items
+// are keyed by their idField, and by versionField as well when set, so that
+// an updated object is seen as new; the cursor moves to the latest
+// cursorField value, compared as text.
+func pollResult(values []interface{}, cursor, idField, versionField, cursorField string) *runtime.PollResult {
+	result := &runtime.PollResult{Cursor: cursor}
+	for _, v := range values {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			object = map[string]interface{}{"value": v}
+		}
+
+		key := ""
+		if id := getFieldValue(object, idField); idField != "" && id != nil {
+			key = fmt.Sprintf("%v", id)
+			if versionField != "" {
+				key += "@" + fmt.Sprintf("%v", getFieldValue(object, versionField))
+			}
+		}
+		result.Items = append(result.Items, runtime.NewItem(object))
+		result.Keys = append(result.Keys, key)
+
+		if cursorField != "" {
+			if at := getFieldValue(object, cursorField); at != nil {
+				if s := fmt.Sprintf("%v", at); s > result.Cursor {
+					result.Cursor = s
+				}
+			}
+		}
+	}
+	return result
+}
+
+// accessToken reads the API token of an integration's credentials
+func accessToken(credentials map[string]interface{}) (string, error) {
+	token := getCredString(credentials, "access_token", "")
+	if token == "" {
+		token = getCredString(credentials, "token", "")
+	}
+	if token == "" {
+		return "", fmt.Errorf("access_token or token required")
+	}
+	return token, nil
+}
+
+// HTTPPollTriggerNode starts executions with the new items an HTTP endpoint
+// returns
+type HTTPPollTriggerNode struct{}
+
+// NewHTTPPollTriggerNode creates a new HTTP poll trigger node
+func NewHTTPPollTriggerNode() *HTTPPollTriggerNode {
+	return &HTTPPollTriggerNode{}
+}
+
+// GetType returns the node type
+func (n *HTTPPollTriggerNode) GetType() string {
+	return "http_poll_trigger"
+}
+
+// GetTriggerType returns the trigger type
+func (n *HTTPPollTriggerNode) GetTriggerType() runtime.TriggerType {
+	return runtime.TriggerTypePolling
+}
+
+// GetMetadata returns node metadata
+func (n *HTTPPollTriggerNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "http_poll_trigger",
+		Name:        "HTTP Poll Trigger",
+		Description: "Trigger workflow with the new items an HTTP endpoint returns",
+		Category:    "trigger",
+		Icon:        "refresh",
+		Color:       "#2196F3",
+		Version:     "1.0.0",
+		Outputs: []runtime.PortDefinition{
+			{Name: "main", Type: "any", Description: "New items"},
+		},
+		Properties: append([]runtime.PropertyDefinition{
+			{Name: "url", Type: "string", Required: true, Description: "URL to poll"},
+			{Name: "method", Type: "select", Default: "GET", Options: []runtime.PropertyOption{
+				{Label: "GET", Value: "GET"},
+				{Label: "POST", Value: "POST"},
+			}},
+			{Name: "headers", Type: "json", Description: "Request headers"},
+			{Name: "queryParameters", Type: "json", Description: "Query parameters"},
+			{Name: "body", Type: "json", Description: "Request body (POST)"},
+			{Name: "authentication", Type: "select", Default: "none", Description: "Authentication type", Options: []runtime.PropertyOption{
+				{Label: "None", Value: "none"},
+				{Label: "Basic Auth", Value: "basic"},
+				{Label: "Bearer Token", Value: "bearer"},
+				{Label: "API Key", Value: "apiKey"},
+				{Label: "OAuth2", Value: "oauth2"},
+			}},
+			{Name: "itemsPath", Type: "string", Description: "Path of the items array in the response, e.g. data.items"},
+			{Name: "idField", Type: "string", Default: "id", Description: "Field identifying an item, for de-duplication"},
+			{Name: "cursorField", Type: "string", Description: "Field whose latest value is the cursor, e.g. updated_at"},
+			{Name: "cursorParameter", Type: "string", Description: "Query parameter the cursor is sent in, e.g. since"},
+		}, pollIntervalProperties...),
+		IsTrigger: true,
+	}
+}
+
+// Validate validates the node configuration
+func (n *HTTPPollTriggerNode) Validate(config map[string]interface{}) error {
+	rawURL := getStringConfig(config, "url", "")
+	if rawURL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if _, err := url.Parse(rawURL); err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	switch getStringConfig(config, "method", "GET") {
+	case "GET", "POST":
+	default:
+		return fmt.Errorf("unsupported method: %v", config["method"])
+	}
+	return validatePollInterval(config)
+}
+
+// Execute outputs the items the poll found
+func (n *HTTPPollTriggerNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	return triggerItemsOutput(input, "HTTP poll"), nil
+}
+
+// Poll requests the endpoint, sending the cursor in cursorParameter
+func (n *HTTPPollTriggerNode) Poll(ctx context.Context, input *runtime.ExecutionInput, cursor string) (*runtime.PollResult, error) {
+	config := input.NodeConfig
+	method := getStringConfig(config, "method", "GET")
+
+	u, err := url.Parse(getStringConfig(config, "url", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	query := u.Query()
+	for k, v := range getMapConfig(config, "queryParameters") {
+		query.Set(k, fmt.Sprintf("%v", v))
+	}
+	if param := getStringConfig(config, "cursorParameter", ""); param != "" && cursor != "" {
+		query.Set(param, cursor)
+	}
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if method == "POST" {
+		data, err := json.Marshal(config["body"])
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range getMapConfig(config, "headers") {
+		req.Header.Set(k, fmt.Sprintf("%v", v))
+	}
+	authType := getStringConfig(config, "authentication", "none")
+	if err := NewHTTPRequestNode().applyAuthentication(req, authType, config, input.Credentials); err != nil {
+		return nil, fmt.Errorf("authentication error: %w", err)
+	}
+
+	resp, err := egressClient(input, pollTimeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("poll failed with status %d", resp.StatusCode)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
+	}
+
+	values := itemsOfPage(decoded, getStringConfig(config, "itemsPath", ""))
+	return pollResult(values, cursor,
+		getStringConfig(config, "idField", "id"), "",
+		getStringConfig(config, "cursorField", "")), nil
+}
+
+// Start is not supported: polling triggers are run by the engine scheduler
+func (n *HTTPPollTriggerNode) Start(ctx context.Context, config map[string]interface{}, callback runtime.TriggerCallback) error {
+	return errPolledByScheduler
+}
+
+// Stop stops the trigger
+func (n *HTTPPollTriggerNode) Stop(ctx context.Context) error {
+	return nil
+}
+
+func init() {
+	runtime.Register(NewHTTPPollTriggerNode())
+}
@@ -0,0 +1,202 @@
+// Package nodes provides the respond to webhook node
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// RespondToWebhookNode replies to the webhook call that started the
+// execution, while the execution goes on with the node's input items
+type RespondToWebhookNode struct{}
+
+// NewRespondToWebhookNode creates a new respond to webhook node
+func NewRespondToWebhookNode() *RespondToWebhookNode {
+	return &RespondToWebhookNode{}
+}
+
+// GetType returns the node type
+func (n *RespondToWebhookNode) GetType() string {
+	return "respond_to_webhook"
+}
+
+// GetMetadata returns node metadata
+func (n *RespondToWebhookNode) GetMetadata() runtime.NodeMetadata {
+	return runtime.NodeMetadata{
+		Type:        "respond_to_webhook",
+		Name:        "Respond to Webhook",
+		Description: "Reply to the webhook call that started the workflow",
+		Category:    "core",
+		Icon:        "reply",
+		Color:       "#9C27B0",
+		Version:     "1.0.0",
+		Inputs: []runtime.PortDefinition{
+			{Name: "main", Type: "any", Required: true, Description: "Input data"},
+		},
+		Outputs: []runtime.PortDefinition{
+			{Name: "main", Type: "any", Description: "Input data, passed through"},
+		},
+		Properties: []runtime.PropertyDefinition{
+			{Name: "respondWith", Type: "select", Default: "firstItem", Description: "What to reply with", Options: []runtime.PropertyOption{
+				{Label: "First Item", Value: "firstItem"},
+				{Label: "All Items", Value: "allItems"},
+				{Label: "JSON", Value: "json"},
+				{Label: "Text", Value: "text"},
+				{Label: "Binary File", Value: "binary"},
+				{Label: "No Data", Value: "noData"},
+			}},
+			{Name: "responseBody", Type: "json", Description: "Body to reply with (json, text)"},
+			{Name: "binaryProperty", Type: "string", Default: defaultBinaryProperty, Description: "Binary property of the input item to reply with (binary)"},
+			{Name: "statusCode", Type: "number", Default: 200, Description: "Response status code"},
+			{Name: "headers", Type: "json", Description: "Response headers"},
+		},
+		RunOnceForAllItems: true,
+	}
+}
+
+// Validate validates the node configuration
+func (n *RespondToWebhookNode) Validate(config map[string]interface{}) error {
+	switch getStringConfig(config, "respondWith", "firstItem") {
+	case "firstItem", "allItems", "json", "text", "binary", "noData":
+	default:
+		return fmt.Errorf("unknown respondWith: %v", config["respondWith"])
+	}
+	if code := getIntConfig(config, "statusCode", http.StatusOK); code < 100 || code > 599 {
+		return fmt.Errorf("invalid status code: %d", code)
+	}
+	return nil
+}
+
+// Execute builds the response and passes the input items on
+func (n *RespondToWebhookNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	config := input.NodeConfig
+	response := &runtime.WebhookResponse{
+		StatusCode: getIntConfig(config, "statusCode", http.StatusOK),
+		Headers:    make(map[string]string),
+	}
+	for name, value := range getMapConfig(config, "headers") {
+		response.Headers[name] = fmt.Sprintf("%v", value)
+	}
+
+	switch getStringConfig(config, "respondWith", "firstItem") {
+	case "firstItem":
+		if len(input.Items) > 0 {
+			response.Body = input.Items[0].JSON
+		} else {
+			response.Body = input.InputData
+		}
+	case "allItems":
+		body := make([]interface{}, len(input.Items))
+		for i, item := range input.Items {
+			body[i] = item.JSON
+		}
+		response.Body = body
+	case "json":
+		response.Body = config["responseBody"]
+		if s, ok := response.Body.(string); ok {
+			// JSON typed into the editor rather than built from expressions
+			var body interface{}
+			if err := json.Unmarshal([]byte(s), &body); err != nil {
+				return nil, fmt.Errorf("invalid JSON response body: %w", err)
+			}
+			response.Body = body
+		}
+	case "text":
+		response.Body = getStringConfig(config, "responseBody", "")
+	case "binary":
+		data, err := inputBinary(input, getStringConfig(config, "binaryProperty", defaultBinaryProperty))
+		if err != nil {
+			return nil, err
+		}
+		response.Binary = data
+	}
+
+	output := &runtime.ExecutionOutput{
+		Data:     input.InputData,
+		Items:    input.Items,
+		Response: response,
+		Logs: []runtime.LogEntry{{
+			Level:     "info",
+			Message:   fmt.Sprintf("Responding to webhook with status %d", response.StatusCode),
+			Timestamp: time.Now().UnixMilli(),
+			NodeID:    input.NodeID,
+		}},
+	}
+	return output, nil
+}
+
+// WriteWebhookResponse writes resp, streaming its binary data from store
+func WriteWebhookResponse(ctx context.Context, w http.ResponseWriter, store runtime.BinaryStore, resp *runtime.WebhookResponse) error {
+	status := resp.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := w.Header()
+	for name, value := range resp.Headers {
+		header.Set(name, value)
+	}
+	setDefault := func(name, value string) {
+		if header.Get(name) == "" {
+			header.Set(name, value)
+		}
+	}
+
+	if resp.Binary != nil {
+		if store == nil {
+			return fmt.Errorf("no binary store available")
+		}
+		content, err := store.Open(ctx, resp.Binary.ID)
+		if err != nil {
+			return fmt.Errorf("failed to open binary data: %w", err)
+		}
+		defer content.Close()
+
+		mimeType := resp.Binary.MimeType
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		setDefault("Content-Type", mimeType)
+		if resp.Binary.FileName != "" {
+			setDefault("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, quoteEscaper.Replace(resp.Binary.FileName)))
+		}
+		if resp.Binary.Size > 0 {
+			header.Set("Content-Length", strconv.FormatInt(resp.Binary.Size, 10))
+		}
+		w.WriteHeader(status)
+		_, err = io.Copy(w, content)
+		return err
+	}
+
+	var body []byte
+	switch b := resp.Body.(type) {
+	case nil:
+	case string:
+		setDefault("Content-Type", "text/plain; charset=utf-8")
+		body = []byte(b)
+	case []byte:
+		setDefault("Content-Type", "application/octet-stream")
+		body = b
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return fmt.Errorf("failed to encode response body: %w", err)
+		}
+		setDefault("Content-Type", "application/json")
+		body = encoded
+	}
+
+	w.WriteHeader(status)
+	_, err := w.Write(body)
+	return err
+}
+
+func init() {
+	runtime.Register(NewRespondToWebhookNode())
+}
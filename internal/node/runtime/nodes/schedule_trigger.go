@@ -10,18 +10,22 @@ import (
 	"github.com/robfig/cron/v3"
 )
 
-// ScheduleTriggerNode implements scheduled workflow triggering
-type ScheduleTriggerNode struct {
-	scheduler *cron.Cron
-	entries   map[string]cron.EntryID
+// overlapOptions are the policies of schedule triggers for a run that falls
+// due while the previous one is still active
+var overlapOptions = []runtime.PropertyOption{
+	{Label: "Run both", Value: "allow"},
+	{Label: "Skip the new run", Value: "skip"},
+	{Label: "Queue the new run", Value: "queue"},
+	{Label: "Cancel the previous run", Value: "cancel"},
 }
 
+// ScheduleTriggerNode implements scheduled workflow triggering. Its runs are
+// fired by the engine scheduler, which coordinates them across replicas.
+type ScheduleTriggerNode struct{}
+
 // NewScheduleTriggerNode creates a new Schedule Trigger node
 func NewScheduleTriggerNode() *ScheduleTriggerNode {
-	return &ScheduleTriggerNode{
-		scheduler: cron.New(cron.WithSeconds()),
-		entries:   make(map[string]cron.EntryID),
-	}
+	return &ScheduleTriggerNode{}
 }
 
 // GetType returns the node type
@@ -55,6 +59,7 @@ func (n *ScheduleTriggerNode) GetMetadata() runtime.NodeMetadata {
 			{Name: "interval", Type: "number", Default: 60, Description: "Interval in seconds (for interval mode)"},
 			{Name: "cronExpression", Type: "string", Description: "Cron expression (for cron mode)", Placeholder: "0 0 * * * *"},
 			{Name: "timezone", Type: "string", Default: "UTC", Description: "Timezone for schedule"},
+			{Name: "overlap", Type: "select", Default: "allow", Description: "When a run falls due while the previous one is still active", Options: overlapOptions},
 		},
 		IsTrigger: true,
 	}
@@ -113,55 +118,17 @@ func (n *ScheduleTriggerNode) Execute(ctx context.Context, input *runtime.Execut
 	return output, nil
 }
 
-// Start starts the schedule trigger
+// Start is not supported: schedule triggers are run by the engine
+// scheduler, so that every replica does not fire them on its own
 func (n *ScheduleTriggerNode) Start(ctx context.Context, config map[string]interface{}, callback runtime.TriggerCallback) error {
-	workflowID := getStringConfig(config, "workflowId", "")
-	mode := getStringConfig(config, "mode", "interval")
-	
-	var schedule string
-	if mode == "cron" {
-		schedule = getStringConfig(config, "cronExpression", "0 * * * * *")
-	} else {
-		interval := getIntConfig(config, "interval", 60)
-		schedule = fmt.Sprintf("@every %ds", interval)
-	}
-	
-	// Add cron job
-	entryID, err := n.scheduler.AddFunc(schedule, func() {
-		data := map[string]interface{}{
-			"timestamp":   time.Now().Format(time.RFC3339),
-			"triggerType": "schedule",
-			"mode":        mode,
-		}
-		callback(data)
-	})
-	
-	if err != nil {
-		return fmt.Errorf("failed to add schedule: %w", err)
-	}
-	
-	n.entries[workflowID] = entryID
-	
-	// Start scheduler if not running
-	n.scheduler.Start()
-	
-	return nil
+	return fmt.Errorf("schedule triggers are run by the engine scheduler")
 }
 
 // Stop stops the schedule trigger
 func (n *ScheduleTriggerNode) Stop(ctx context.Context) error {
-	n.scheduler.Stop()
 	return nil
 }
 
-// StopWorkflow stops a specific workflow's schedule
-func (n *ScheduleTriggerNode) StopWorkflow(workflowID string) {
-	if entryID, exists := n.entries[workflowID]; exists {
-		n.scheduler.Remove(entryID)
-		delete(n.entries, workflowID)
-	}
-}
-
 // Global schedule trigger instance
 var scheduleTrigger *ScheduleTriggerNode
 
@@ -212,6 +179,7 @@ func (n *IntervalTriggerNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Minutes", Value: "minutes"},
 				{Label: "Hours", Value: "hours"},
 			}},
+			{Name: "overlap", Type: "select", Default: "allow", Description: "When a run falls due while the previous one is still active", Options: overlapOptions},
 		},
 		IsTrigger: true,
 	}
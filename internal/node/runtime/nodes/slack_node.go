@@ -20,9 +20,7 @@ type SlackNode struct {
 
 // NewSlackNode creates a new Slack node
 func NewSlackNode() *SlackNode {
-	return &SlackNode{
-		client: &http.Client{Timeout: 30 * time.Second},
-	}
+	return &SlackNode{}
 }
 
 // GetType returns the node type
@@ -92,8 +90,14 @@ func (n *SlackNode) Validate(config map[string]interface{}) error {
 	return nil
 }
 
-// Execute executes the Slack node
+// Execute executes the Slack node. Requests go through a client held to
+// the egress policy of the execution.
 func (n *SlackNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
+	node := &SlackNode{client: egressClient(input, 30*time.Second)}
+	return node.execute(ctx, input)
+}
+
+func (n *SlackNode) execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	startTime := time.Now()
 	output := &runtime.ExecutionOutput{
 		Data: make(map[string]interface{}),
@@ -0,0 +1,412 @@
+// Package nodes provides safe SQL building for the SQL database nodes
+package nodes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// Batch operations of the SQL database nodes, which get every input item at
+// once and write them as rows of one transaction
+const (
+	operationInsertItems = "insertItems"
+	operationUpsertItems = "upsertItems"
+)
+
+// identifierPattern matches one part of an unquoted SQL identifier
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]{0,62}$`)
+
+// sqlDialect captures how a database quotes identifiers, numbers parameters
+// and resolves insert conflicts
+type sqlDialect struct {
+	name     string
+	quote    string // Identifier quote character
+	numbered bool   // Parameters are $1, $2 rather than ?
+}
+
+var (
+	postgresDialect = sqlDialect{name: "postgres", quote: `"`, numbered: true}
+	mysqlDialect    = sqlDialect{name: "mysql", quote: "`"}
+)
+
+// placeholder returns the parameter placeholder of the n-th (1-based) argument
+func (d sqlDialect) placeholder(n int) string {
+	if d.numbered {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// identifier validates a table or column name, optionally schema qualified,
+// and quotes each of its parts
+func (d sqlDialect) identifier(name string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(name), ".")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("invalid identifier %q", name)
+	}
+	for i, part := range parts {
+		if !identifierPattern.MatchString(part) {
+			return "", fmt.Errorf("invalid identifier %q", name)
+		}
+		parts[i] = d.quote + part + d.quote
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// identifiers validates and quotes names, returning them comma-separated
+func (d sqlDialect) identifiers(names []string) (string, error) {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		q, err := d.identifier(name)
+		if err != nil {
+			return "", err
+		}
+		quoted[i] = q
+	}
+	return strings.Join(quoted, ", "), nil
+}
+
+// orderBy validates an ORDER BY list of columns, each optionally followed by
+// ASC or DESC, e.g. "created_at DESC, id"
+func (d sqlDialect) orderBy(clause string) (string, error) {
+	var terms []string
+	for _, term := range strings.Split(clause, ",") {
+		fields := strings.Fields(term)
+		if len(fields) == 0 || len(fields) > 2 {
+			return "", fmt.Errorf("invalid order by term %q", strings.TrimSpace(term))
+		}
+		column, err := d.identifier(fields[0])
+		if err != nil {
+			return "", err
+		}
+		if len(fields) == 2 {
+			direction := strings.ToUpper(fields[1])
+			if direction != "ASC" && direction != "DESC" {
+				return "", fmt.Errorf("invalid order by direction %q", fields[1])
+			}
+			column += " " + direction
+		}
+		terms = append(terms, column)
+	}
+	return strings.Join(terms, ", "), nil
+}
+
+// assignments builds "column" = placeholder pairs of values in column order,
+// numbering the placeholders from start
+func (d sqlDialect) assignments(values map[string]interface{}, start int) ([]string, []interface{}, error) {
+	var clauses []string
+	var args []interface{}
+	for i, col := range sortedKeys(values) {
+		column, err := d.identifier(col)
+		if err != nil {
+			return nil, nil, err
+		}
+		clauses = append(clauses, fmt.Sprintf("%s = %s", column, d.placeholder(start+i)))
+		args = append(args, sqlArg(values[col]))
+	}
+	return clauses, args, nil
+}
+
+// where builds a WHERE condition matching every key-value pair of conditions
+func (d sqlDialect) where(conditions map[string]interface{}, start int) (string, []interface{}, error) {
+	clauses, args, err := d.assignments(conditions, start)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// bindNamed replaces the :name parameters of query with placeholders and
+// returns the values of params they bind, so that values taken from
+// expressions are never spliced into the SQL. Parameters are not recognized
+// inside string literals, quoted identifiers and comments, nor in casts such
+// as ::int.
+func (d sqlDialect) bindNamed(query string, params map[string]interface{}) (string, []interface{}, error) {
+	var sb strings.Builder
+	var args []interface{}
+	numbers := make(map[string]int)
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		end := i + 1
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end = quotedEnd(query, i, d.name == "mysql")
+		case strings.HasPrefix(query[i:], "--"):
+			end = len(query)
+			if n := strings.IndexByte(query[i:], '\n'); n >= 0 {
+				end = i + n
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end = len(query)
+			if n := strings.Index(query[i+2:], "*/"); n >= 0 {
+				end = i + 2 + n + 2
+			}
+		case c == '$' && d.name == "postgres" && dollarTag(query[i:]) != "":
+			// Dollar-quoted string, e.g. $$text$$ or $body$text$body$
+			tag := dollarTag(query[i:])
+			end = len(query)
+			if n := strings.Index(query[i+len(tag):], tag); n >= 0 {
+				end = i + len(tag) + n + len(tag)
+			}
+		case strings.HasPrefix(query[i:], "::"):
+			end = i + 2
+		case c == ':' && i+1 < len(query) && isIdentStart(query[i+1]):
+			end = i + 1
+			for end < len(query) && isIdentPart(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("missing value for parameter :%s", name)
+			}
+			if n, seen := numbers[name]; seen && d.numbered {
+				sb.WriteString(d.placeholder(n))
+			} else {
+				args = append(args, sqlArg(value))
+				numbers[name] = len(args)
+				sb.WriteString(d.placeholder(len(args)))
+			}
+			i = end
+			continue
+		}
+		sb.WriteString(query[i:end])
+		i = end
+	}
+	return sb.String(), args, nil
+}
+
+// queryArgs returns the query to run and its arguments: params given as an
+// object bind :name parameters, params given as a list bind placeholders in
+// order
+func (d sqlDialect) queryArgs(query string, params interface{}) (string, []interface{}, error) {
+	switch p := params.(type) {
+	case nil:
+		return query, nil, nil
+	case map[string]interface{}:
+		return d.bindNamed(query, p)
+	case []interface{}:
+		args := make([]interface{}, len(p))
+		for i, v := range p {
+			args[i] = sqlArg(v)
+		}
+		return query, args, nil
+	default:
+		return "", nil, fmt.Errorf("params must be an object or a list")
+	}
+}
+
+// upsertClause resolves conflicts on conflictColumns by updating the other
+// columns with the values of the rejected row
+func (d sqlDialect) upsertClause(columns, conflictColumns []string) (string, error) {
+	conflict := make(map[string]bool)
+	for _, col := range conflictColumns {
+		conflict[col] = true
+	}
+
+	var updates []string
+	for _, col := range columns {
+		if conflict[col] {
+			continue
+		}
+		column, err := d.identifier(col)
+		if err != nil {
+			return "", err
+		}
+		if d.name == "postgres" {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", column, column))
+		}
+	}
+
+	if d.name != "postgres" {
+		// MySQL resolves conflicts on any unique key
+		if len(updates) == 0 {
+			column, _ := d.identifier(columns[0])
+			updates = append(updates, fmt.Sprintf("%s = %s", column, column))
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", "), nil
+	}
+
+	if len(conflictColumns) == 0 {
+		return "", fmt.Errorf("conflictColumns is required for upsert")
+	}
+	target, err := d.identifiers(conflictColumns)
+	if err != nil {
+		return "", err
+	}
+	if len(updates) == 0 {
+		return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", target), nil
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", target, strings.Join(updates, ", ")), nil
+}
+
+// writeItems inserts every item as a row of table in a single transaction,
+// rolling all of them back when one fails. With upsert, rows conflicting
+// with existing ones update them instead. The columns written are the
+// configured columns, or else every field found in the items; fields an
+// item lacks are written as NULL.
+func (d sqlDialect) writeItems(ctx context.Context, db *sql.DB, config map[string]interface{}, items []runtime.Item, upsert bool) (map[string]interface{}, error) {
+	table, err := d.identifier(getStringConfig(config, "table", ""))
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return map[string]interface{}{"rowsAffected": int64(0), "count": 0}, nil
+	}
+
+	columns := stringList(config["columns"])
+	if len(columns) == 0 {
+		fields := make(map[string]interface{})
+		for _, item := range items {
+			for k := range item.JSON {
+				fields[k] = nil
+			}
+		}
+		columns = sortedKeys(fields)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("items have no fields to write")
+	}
+
+	columnList, err := d.identifiers(columns)
+	if err != nil {
+		return nil, err
+	}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = d.placeholder(i + 1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, columnList, strings.Join(placeholders, ", "))
+	if upsert {
+		clause, err := d.upsertClause(columns, stringList(config["conflictColumns"]))
+		if err != nil {
+			return nil, err
+		}
+		query += clause
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var affected int64
+	for i, item := range items {
+		args := make([]interface{}, len(columns))
+		for j, col := range columns {
+			args[j] = sqlArg(item.JSON[col])
+		}
+		result, err := stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		n, _ := result.RowsAffected()
+		affected += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"rowsAffected": affected,
+		"count":        len(items),
+	}, nil
+}
+
+// isBatchOperation reports whether the node configured with config writes
+// every input item at once
+func isBatchOperation(config map[string]interface{}) bool {
+	operation := getStringConfig(config, "operation", "")
+	return operation == operationInsertItems || operation == operationUpsertItems
+}
+
+// sqlArg converts objects and lists, which drivers cannot bind, to JSON
+func sqlArg(v interface{}) interface{} {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		return string(b)
+	}
+	return v
+}
+
+// stringList reads a list given either as a JSON list or comma-separated
+func stringList(v interface{}) []string {
+	var list []string
+	switch val := v.(type) {
+	case string:
+		for _, s := range strings.Split(val, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	case []interface{}:
+		for _, s := range val {
+			if str := strings.TrimSpace(fmt.Sprintf("%v", s)); str != "" {
+				list = append(list, str)
+			}
+		}
+	case []string:
+		list = append(list, val...)
+	}
+	return list
+}
+
+// quotedEnd returns the index after the literal or quoted identifier opened
+// at start. Doubled quotes stay inside, as do backslash escapes when allowed.
+func quotedEnd(query string, start int, backslash bool) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case backslash && query[i] == '\\' && quote != '`':
+			i++
+		case query[i] == quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// dollarTag returns the tag opening a dollar-quoted string at the start of s
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1]
+		case !isIdentStart(s[i]):
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
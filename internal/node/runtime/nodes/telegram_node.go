@@ -53,10 +53,12 @@ func (n *TelegramNode) GetMetadata() runtime.NodeMetadata {
 }
 
 func (n *TelegramNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
-	if n.client == nil {
-		n.client = &http.Client{Timeout: 30 * time.Second}
-	}
+	// Requests go through a client held to the egress policy of the execution
+	node := &TelegramNode{client: egressClient(input, 30*time.Second)}
+	return node.execute(ctx, input)
+}
 
+func (n *TelegramNode) execute(ctx context.Context, input *runtime.ExecutionInput) (*runtime.ExecutionOutput, error) {
 	operation, _ := input.NodeConfig["operation"].(string)
 	botToken, _ := input.Credentials["bot_token"].(string)
 	if botToken == "" {
@@ -9,6 +9,10 @@ import (
 	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
 )
 
+// waitParkThreshold is the shortest wait that is parked by the engine rather
+// than slept through inline
+const waitParkThreshold = 65 * time.Second
+
 // WaitNode implements delay functionality
 type WaitNode struct{}
 
@@ -27,7 +31,7 @@ func (n *WaitNode) GetMetadata() runtime.NodeMetadata {
 	return runtime.NodeMetadata{
 		Type:        "wait",
 		Name:        "Wait",
-		Description: "Wait for a specified amount of time or until a condition",
+		Description: "Wait for an amount of time, until a point in time, or for a resume webhook",
 		Category:    "core",
 		Icon:        "clock",
 		Color:       "#9E9E9E",
@@ -37,8 +41,16 @@ func (n *WaitNode) GetMetadata() runtime.NodeMetadata {
 		},
 		Outputs: []runtime.PortDefinition{
 			{Name: "main", Type: "any", Description: "Data after wait"},
+			{Name: "timeout", Type: "any", Description: "Data when a resume webhook was not called in time"},
 		},
 		Properties: []runtime.PropertyDefinition{
+			{Name: "resume", Type: "select", Default: "timeInterval", Description: "When to resume", Options: []runtime.PropertyOption{
+				{Label: "After Time Interval", Value: "timeInterval"},
+				{Label: "At Specified Time", Value: "specificTime"},
+				{Label: "On Webhook Call", Value: "webhook"},
+			}},
+			{Name: "dateTime", Type: "string", Description: "Time to resume at, as an ISO 8601 timestamp or Unix time (specificTime)"},
+			{Name: "limitWaitTime", Type: "boolean", Default: false, Description: "Give up waiting for the webhook after amount/unit (webhook)"},
 			{Name: "amount", Type: "number", Default: 1, Required: true, Description: "Amount to wait"},
 			{Name: "unit", Type: "select", Default: "seconds", Description: "Time unit", Options: []runtime.PropertyOption{
 				{Label: "Milliseconds", Value: "milliseconds"},
@@ -47,7 +59,8 @@ func (n *WaitNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Hours", Value: "hours"},
 			}},
 		},
-		IsTrigger: false,
+		IsTrigger:          false,
+		RunOnceForAllItems: true,
 	}
 }
 
@@ -57,6 +70,16 @@ func (n *WaitNode) Validate(config map[string]interface{}) error {
 	if amount < 0 {
 		return fmt.Errorf("wait amount cannot be negative")
 	}
+	
+	switch getStringConfig(config, "resume", "timeInterval") {
+	case "timeInterval", "webhook":
+	case "specificTime":
+		if config["dateTime"] == nil || config["dateTime"] == "" {
+			return fmt.Errorf("dateTime is required when resuming at a specified time")
+		}
+	default:
+		return fmt.Errorf("unknown resume mode: %v", config["resume"])
+	}
 	return nil
 }
 
@@ -68,22 +91,39 @@ func (n *WaitNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (
 		Logs: []runtime.LogEntry{},
 	}
 	
-	amount := getIntConfig(input.NodeConfig, "amount", 1)
-	unit := getStringConfig(input.NodeConfig, "unit", "seconds")
+	duration := waitDuration(input.NodeConfig)
 	
-	// Calculate duration
-	var duration time.Duration
-	switch unit {
-	case "milliseconds":
-		duration = time.Duration(amount) * time.Millisecond
-	case "seconds":
-		duration = time.Duration(amount) * time.Second
-	case "minutes":
-		duration = time.Duration(amount) * time.Minute
-	case "hours":
-		duration = time.Duration(amount) * time.Hour
-	default:
-		duration = time.Duration(amount) * time.Second
+	switch getStringConfig(input.NodeConfig, "resume", "timeInterval") {
+	case "webhook":
+		// Always parked: the engine resumes the execution when its resume URL
+		// is called, or on the timeout branch once the limit passes
+		wait := &runtime.WaitRequest{Webhook: true}
+		if getBoolConfig(input.NodeConfig, "limitWaitTime", false) {
+			wait.ResumeAt = startTime.Add(duration)
+		}
+		output.Wait = wait
+		
+		resumeURL := ""
+		if input.Context != nil {
+			resumeURL = input.Context.ResumeURL
+		}
+		output.Logs = append(output.Logs, runtime.LogEntry{
+			Level:     "info",
+			Message:   fmt.Sprintf("Waiting for resume webhook at %s", resumeURL),
+			Timestamp: time.Now().UnixMilli(),
+			NodeID:    input.NodeID,
+		})
+		return output, nil
+		
+	case "specificTime":
+		resumeAt, err := parseWaitTime(input.NodeConfig["dateTime"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid dateTime: %w", err)
+		}
+		duration = time.Until(resumeAt)
+		if duration < 0 {
+			duration = 0
+		}
 	}
 	
 	output.Logs = append(output.Logs, runtime.LogEntry{
@@ -93,6 +133,12 @@ func (n *WaitNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (
 		NodeID:    input.NodeID,
 	})
 	
+	// Long waits are handed back to the engine so no worker is pinned
+	if duration >= waitParkThreshold {
+		output.Wait = &runtime.WaitRequest{ResumeAt: startTime.Add(duration)}
+		return output, nil
+	}
+	
 	// Wait with context
 	select {
 	case <-ctx.Done():
@@ -118,6 +164,53 @@ func (n *WaitNode) Execute(ctx context.Context, input *runtime.ExecutionInput) (
 	return output, nil
 }
 
+// waitDuration converts the amount and unit settings into a duration
+func waitDuration(config map[string]interface{}) time.Duration {
+	amount := getIntConfig(config, "amount", 1)
+	
+	switch getStringConfig(config, "unit", "seconds") {
+	case "milliseconds":
+		return time.Duration(amount) * time.Millisecond
+	case "minutes":
+		return time.Duration(amount) * time.Minute
+	case "hours":
+		return time.Duration(amount) * time.Hour
+	default:
+		return time.Duration(amount) * time.Second
+	}
+}
+
+// parseWaitTime reads an absolute time from a timestamp string or Unix time
+// in seconds (or milliseconds for large values)
+func parseWaitTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case float64:
+		return unixWaitTime(int64(v)), nil
+	case int:
+		return unixWaitTime(int64(v)), nil
+	case int64:
+		return unixWaitTime(v), nil
+	case string:
+		layouts := []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized time format %q", v)
+	}
+	return time.Time{}, fmt.Errorf("unsupported time value %v", value)
+}
+
+func unixWaitTime(v int64) time.Time {
+	if v > 1e12 {
+		return time.UnixMilli(v)
+	}
+	return time.Unix(v, 0)
+}
+
 func init() {
 	runtime.Register(NewWaitNode())
 }
@@ -2,10 +2,8 @@
 package nodes
 
 import (
+	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,21 +14,155 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	webhookmodel "github.com/linkflow-ai/linkflow-ai/internal/webhook/domain/model"
 )
 
+// Webhook response modes
+const (
+	WebhookRespondOnReceived = "onReceived" // Reply as soon as the execution starts
+	WebhookRespondOnFinished = "onFinished" // Reply with the output of the last node
+	WebhookRespondWithNode   = "custom"     // Reply from a respond to webhook node
+)
+
+// defaultWebhookResponseTimeout bounds how long a call waits for the reply
+// of its execution
+const defaultWebhookResponseTimeout = 30 * time.Second
+
+// webhookWriteMargin is the time a held call is given to write its reply
+// once the response timeout is up
+const webhookWriteMargin = 10 * time.Second
+
+// webhookReplayWindow is how long delivery keys are remembered to turn away
+// replayed calls
+const webhookReplayWindow = 24 * time.Hour
+
+// webhookAuthSettings are the webhook trigger properties passed on to the
+// authentication of its calls
+var webhookAuthSettings = []string{
+	"headerName", "token", "username", "password", "secret", "signatureHeader",
+	"timestampHeader", "tolerance", "publicKey", "issuer", "audience", "idempotencyHeader",
+}
+
+// WebhookCallback starts the execution of a webhook call. respond may be
+// called from any goroutine, once, with the reply of the execution, or with
+// nil when it finished without giving one.
+type WebhookCallback func(data map[string]interface{}, respond func(*runtime.WebhookResponse)) error
+
 // WebhookTriggerNode implements webhook trigger functionality
 type WebhookTriggerNode struct {
 	mu        sync.RWMutex
-	callbacks map[string]runtime.TriggerCallback
+	callbacks map[string]WebhookCallback
 	paths     map[string]webhookConfig
+	binary    runtime.BinaryStore
+
+	// Delivery keys seen per path and when, for replay protection
+	deliveries map[string]map[string]time.Time
 }
 
 type webhookConfig struct {
 	workflowID string
 	method     string
 	path       string
-	secret     string
+	authType   webhookmodel.AuthenticationType
+	authConfig map[string]interface{}
 	headers    map[string]string
+	reply      WebhookReply
+}
+
+// WebhookReply is how a webhook trigger replies to the calls it receives
+type WebhookReply struct {
+	Mode    string
+	Timeout time.Duration // How long a synchronous reply is waited for
+	
+	// Response given in onReceived mode, or when the execution finishes
+	// without replying
+	StatusCode  int
+	Body        string
+	ContentType string
+}
+
+// WebhookReplyFromConfig reads the reply settings of a webhook trigger
+func WebhookReplyFromConfig(config map[string]interface{}) WebhookReply {
+	reply := WebhookReply{
+		Mode:        getStringConfig(config, "responseMode", WebhookRespondOnReceived),
+		Timeout:     time.Duration(getIntConfig(config, "responseTimeout", 0)) * time.Second,
+		StatusCode:  getIntConfig(config, "responseCode", http.StatusOK),
+		Body:        getStringConfig(config, "responseData", `{"success": true}`),
+		ContentType: getStringConfig(config, "responseContentType", "application/json"),
+	}
+	if reply.Timeout <= 0 {
+		reply.Timeout = defaultWebhookResponseTimeout
+	}
+	return reply
+}
+
+// Synchronous reports whether calls are held open until the execution replies
+func (r WebhookReply) Synchronous() bool {
+	return r.Mode == WebhookRespondOnFinished || r.Mode == WebhookRespondWithNode
+}
+
+// Hold moves the write deadline of a call held open for a synchronous reply
+// past its response timeout, which may be longer than the write timeout of
+// the server. Writers that cannot set deadlines keep the server's.
+func (r WebhookReply) Hold(w http.ResponseWriter) {
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(r.Timeout + webhookWriteMargin))
+}
+
+// Received returns the configured response
+func (r WebhookReply) Received() *runtime.WebhookResponse {
+	return &runtime.WebhookResponse{
+		StatusCode: r.StatusCode,
+		Headers:    map[string]string{"Content-Type": r.ContentType},
+		Body:       r.Body,
+	}
+}
+
+// Finished returns the reply to a call whose execution finished without a
+// node replying: the output of its last node when it completed in onFinished
+// mode, the configured response otherwise
+func (r WebhookReply) Finished(output map[string]interface{}, err error) *runtime.WebhookResponse {
+	if err != nil {
+		return &runtime.WebhookResponse{
+			StatusCode: http.StatusInternalServerError,
+			Body:       map[string]interface{}{"error": "Workflow execution failed"},
+		}
+	}
+	if r.Mode == WebhookRespondOnFinished {
+		if output == nil {
+			output = map[string]interface{}{}
+		}
+		return &runtime.WebhookResponse{StatusCode: http.StatusOK, Body: output}
+	}
+	return r.Received()
+}
+
+// TimedOut returns the reply to a call whose execution did not reply in time
+func (r WebhookReply) TimedOut(executionID string) *runtime.WebhookResponse {
+	body := map[string]interface{}{"error": "Workflow did not respond in time"}
+	if executionID != "" {
+		body["executionId"] = executionID
+	}
+	return &runtime.WebhookResponse{StatusCode: http.StatusGatewayTimeout, Body: body}
+}
+
+// WebhookAuthFromConfig reads the authentication settings of a webhook
+// trigger. A secret without an authentication method selects hmac.
+func WebhookAuthFromConfig(config map[string]interface{}) (string, map[string]interface{}) {
+	authConfig := make(map[string]interface{})
+	for _, key := range webhookAuthSettings {
+		if v, ok := config[key]; ok && v != nil && v != "" {
+			authConfig[key] = v
+		}
+	}
+
+	authType := getStringConfig(config, "authentication", "")
+	if authType == "" {
+		authType = string(webhookmodel.AuthTypeNone)
+		if authConfig["secret"] != nil {
+			authType = string(webhookmodel.AuthTypeHMAC)
+		}
+	}
+	return authType, authConfig
 }
 
 // Global webhook handler instance
@@ -39,11 +171,20 @@ var webhookHandler *WebhookTriggerNode
 // NewWebhookTriggerNode creates a new webhook trigger node
 func NewWebhookTriggerNode() *WebhookTriggerNode {
 	return &WebhookTriggerNode{
-		callbacks: make(map[string]runtime.TriggerCallback),
-		paths:     make(map[string]webhookConfig),
+		callbacks:  make(map[string]WebhookCallback),
+		paths:      make(map[string]webhookConfig),
+		deliveries: make(map[string]map[string]time.Time),
 	}
 }
 
+// WithBinaryStore sets the store binary replies are streamed from
+func (n *WebhookTriggerNode) WithBinaryStore(store runtime.BinaryStore) *WebhookTriggerNode {
+	n.mu.Lock()
+	n.binary = store
+	n.mu.Unlock()
+	return n
+}
+
 // GetType returns the node type
 func (n *WebhookTriggerNode) GetType() string {
 	return "webhook_trigger"
@@ -82,12 +223,26 @@ func (n *WebhookTriggerNode) GetMetadata() runtime.NodeMetadata {
 				{Label: "Basic Auth", Value: "basic"},
 				{Label: "Header Auth", Value: "header"},
 				{Label: "HMAC Signature", Value: "hmac"},
+				{Label: "JWT", Value: "jwt"},
 			}},
-			{Name: "responseMode", Type: "select", Default: "onReceived", Description: "When to respond", Options: []runtime.PropertyOption{
-				{Label: "When received", Value: "onReceived"},
-				{Label: "When execution finishes", Value: "onFinished"},
-				{Label: "Custom response node", Value: "custom"},
+			{Name: "headerName", Type: "string", Default: webhookmodel.DefaultTokenHeader, Description: "Header carrying the token (header)"},
+			{Name: "token", Type: "string", Description: "Expected token (header)"},
+			{Name: "username", Type: "string", Description: "Expected username (basic)"},
+			{Name: "password", Type: "string", Description: "Expected password (basic)"},
+			{Name: "secret", Type: "string", Description: "Signing secret (hmac, jwt)"},
+			{Name: "signatureHeader", Type: "string", Default: webhookmodel.DefaultSignatureHeader, Description: "Header carrying the HMAC-SHA256 of \"<timestamp>.<body>\" (hmac)"},
+			{Name: "timestampHeader", Type: "string", Default: webhookmodel.DefaultTimestampHeader, Description: "Header carrying the unix time the call was signed at (hmac)"},
+			{Name: "tolerance", Type: "number", Default: 300, Description: "Seconds a signed call stays valid (hmac)"},
+			{Name: "publicKey", Type: "string", Description: "PEM public key verifying RS256 tokens instead of the secret (jwt)"},
+			{Name: "issuer", Type: "string", Description: "Required token issuer (jwt)"},
+			{Name: "audience", Type: "string", Description: "Required token audience (jwt)"},
+			{Name: "idempotencyHeader", Type: "string", Default: webhookmodel.DefaultIdempotencyHeader, Description: "Header carrying the delivery key replayed calls are recognized by (none, header, basic, bearer, api_key); signed calls are recognized by their signature or token ID"},
+			{Name: "responseMode", Type: "select", Default: WebhookRespondOnReceived, Description: "When to respond", Options: []runtime.PropertyOption{
+				{Label: "When received", Value: WebhookRespondOnReceived},
+				{Label: "When last node finishes", Value: WebhookRespondOnFinished},
+				{Label: "Using respond to webhook node", Value: WebhookRespondWithNode},
 			}},
+			{Name: "responseTimeout", Type: "number", Default: 30, Description: "Seconds to wait for the workflow to respond"},
 			{Name: "responseCode", Type: "number", Default: 200, Description: "Response status code"},
 			{Name: "responseData", Type: "string", Default: `{"success": true}`, Description: "Response body"},
 			{Name: "responseContentType", Type: "string", Default: "application/json", Description: "Response content type"},
@@ -98,6 +253,36 @@ func (n *WebhookTriggerNode) GetMetadata() runtime.NodeMetadata {
 
 // Validate validates the node configuration
 func (n *WebhookTriggerNode) Validate(config map[string]interface{}) error {
+	switch mode := getStringConfig(config, "responseMode", WebhookRespondOnReceived); mode {
+	case WebhookRespondOnReceived, WebhookRespondOnFinished, WebhookRespondWithNode:
+	default:
+		return fmt.Errorf("unknown response mode: %s", mode)
+	}
+	if getIntConfig(config, "responseTimeout", 0) < 0 {
+		return fmt.Errorf("response timeout cannot be negative")
+	}
+
+	authType, authConfig := WebhookAuthFromConfig(config)
+	switch webhookmodel.AuthenticationType(authType) {
+	case webhookmodel.AuthTypeNone, webhookmodel.AuthTypeHMAC:
+	case webhookmodel.AuthTypeHeader:
+		if authConfig["token"] == nil {
+			return fmt.Errorf("header authentication requires a token")
+		}
+	case webhookmodel.AuthTypeBasic:
+		if authConfig["username"] == nil || authConfig["password"] == nil {
+			return fmt.Errorf("basic authentication requires a username and password")
+		}
+	case webhookmodel.AuthTypeJWT:
+		if authConfig["secret"] == nil && authConfig["publicKey"] == nil {
+			return fmt.Errorf("JWT authentication requires a secret or public key")
+		}
+	default:
+		return fmt.Errorf("unknown authentication: %s", authType)
+	}
+	if authType == string(webhookmodel.AuthTypeHMAC) && authConfig["secret"] == nil {
+		return fmt.Errorf("HMAC authentication requires a secret")
+	}
 	return nil
 }
 
@@ -119,8 +304,20 @@ func (n *WebhookTriggerNode) Execute(ctx context.Context, input *runtime.Executi
 	return output, nil
 }
 
-// Start starts the webhook trigger
+// Start starts the webhook trigger. Since callback cannot reply, calls in a
+// synchronous response mode get the configured response once it returns.
 func (n *WebhookTriggerNode) Start(ctx context.Context, config map[string]interface{}, callback runtime.TriggerCallback) error {
+	return n.StartWithReply(ctx, config, func(data map[string]interface{}, respond func(*runtime.WebhookResponse)) error {
+		err := callback(data)
+		respond(nil)
+		return err
+	})
+}
+
+// StartWithReply starts the webhook trigger with a callback that can reply
+// to the calls it receives. Paths the workflow was served at before are
+// released.
+func (n *WebhookTriggerNode) StartWithReply(ctx context.Context, config map[string]interface{}, callback WebhookCallback) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	
@@ -137,24 +334,78 @@ func (n *WebhookTriggerNode) Start(ctx context.Context, config map[string]interf
 		path = "/" + path
 	}
 	
+	if existing, ok := n.paths[path]; ok && existing.workflowID != workflowID {
+		return fmt.Errorf("webhook path %s is served by another workflow", path)
+	}
+	n.removeWorkflow(workflowID)
+	
 	// Store callback and config
+	authType, authConfig := WebhookAuthFromConfig(config)
 	n.callbacks[workflowID] = callback
 	n.paths[path] = webhookConfig{
 		workflowID: workflowID,
 		method:     method,
 		path:       path,
-		secret:     getStringConfig(config, "secret", ""),
+		authType:   webhookmodel.AuthenticationType(authType),
+		authConfig: authConfig,
+		reply:      WebhookReplyFromConfig(config),
 	}
 	
 	return nil
 }
 
-// Stop stops the webhook trigger
+// Stop stops the webhook trigger, unregistering every path
 func (n *WebhookTriggerNode) Stop(ctx context.Context) error {
-	// Cleanup would be done in production
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.callbacks = make(map[string]WebhookCallback)
+	n.paths = make(map[string]webhookConfig)
+	n.deliveries = make(map[string]map[string]time.Time)
 	return nil
 }
 
+// StopWorkflow unregisters the paths of a workflow
+func (n *WebhookTriggerNode) StopWorkflow(workflowID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.removeWorkflow(workflowID)
+}
+
+func (n *WebhookTriggerNode) removeWorkflow(workflowID string) {
+	delete(n.callbacks, workflowID)
+	for path, config := range n.paths {
+		if config.workflowID == workflowID {
+			delete(n.paths, path)
+			delete(n.deliveries, path)
+		}
+	}
+}
+
+// claimDelivery records a delivery key seen on path and reports false if it
+// was seen within the replay window
+func (n *WebhookTriggerNode) claimDelivery(path, key string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	seen := n.deliveries[path]
+	if seen == nil {
+		seen = make(map[string]time.Time)
+		n.deliveries[path] = seen
+	}
+	for k, at := range seen {
+		if now.Sub(at) > webhookReplayWindow {
+			delete(seen, k)
+		}
+	}
+	if _, ok := seen[key]; ok {
+		return false
+	}
+	seen[key] = now
+	return true
+}
+
 // HandleWebhook handles incoming webhook requests
 func (n *WebhookTriggerNode) HandleWebhook(w http.ResponseWriter, r *http.Request, path string) {
 	n.mu.RLock()
@@ -166,6 +417,7 @@ func (n *WebhookTriggerNode) HandleWebhook(w http.ResponseWriter, r *http.Reques
 	}
 	
 	callback := n.callbacks[config.workflowID]
+	store := n.binary
 	n.mu.RUnlock()
 	
 	// Check method
@@ -174,29 +426,81 @@ func (n *WebhookTriggerNode) HandleWebhook(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	
-	// Verify signature if configured
-	if config.secret != "" {
-		signature := r.Header.Get("X-Webhook-Signature")
-		if signature == "" {
-			signature = r.Header.Get("X-Hub-Signature-256")
-		}
-		
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, "Failed to read body", http.StatusBadRequest)
-			return
-		}
-		
-		if !verifySignature(body, signature, config.secret) {
-			http.Error(w, "Invalid signature", http.StatusUnauthorized)
-			return
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	
+	now := time.Now()
+	key, err := webhookmodel.Authenticate(config.authType, config.authConfig, "", webhookmodel.Delivery{
+		Header: r.Header,
+		Body:   body,
+		Time:   now,
+	})
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if key != "" && !n.claimDelivery(path, key, now) {
+		http.Error(w, "Delivery already received", http.StatusConflict)
+		return
+	}
+	
+	webhookData, err := ParseWebhookRequest(r)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	
+	if callback == nil {
+		WriteWebhookResponse(r.Context(), w, store, config.reply.Received())
+		return
+	}
+	
+	// Call workflow
+	replies := make(chan *runtime.WebhookResponse, 1)
+	respond := func(resp *runtime.WebhookResponse) {
+		select {
+		case replies <- resp:
+		default:
 		}
-		
-		// Reset body for parsing
-		r.Body = io.NopCloser(strings.NewReader(string(body)))
+	}
+	if err := callback(webhookData, respond); err != nil {
+		http.Error(w, "Execution failed", http.StatusInternalServerError)
+		return
 	}
 	
-	// Parse request data
+	if !config.reply.Synchronous() {
+		WriteWebhookResponse(r.Context(), w, store, config.reply.Received())
+		return
+	}
+	
+	// Hold the call open until the workflow replies
+	config.reply.Hold(w)
+	timer := time.NewTimer(config.reply.Timeout)
+	defer timer.Stop()
+	
+	var resp *runtime.WebhookResponse
+	select {
+	case resp = <-replies:
+		if resp == nil {
+			resp = config.reply.Finished(nil, nil)
+		}
+	case <-timer.C:
+		resp = config.reply.TimedOut("")
+	case <-r.Context().Done():
+		return
+	}
+	if err := WriteWebhookResponse(r.Context(), w, store, resp); err != nil {
+		http.Error(w, "Failed to send response", http.StatusInternalServerError)
+	}
+}
+
+// ParseWebhookRequest reads the method, path, query, headers and body of a
+// webhook call into trigger data. JSON and form bodies are decoded.
+func ParseWebhookRequest(r *http.Request) (map[string]interface{}, error) {
 	webhookData := map[string]interface{}{
 		"method":  r.Method,
 		"path":    r.URL.Path,
@@ -207,7 +511,10 @@ func (n *WebhookTriggerNode) HandleWebhook(w http.ResponseWriter, r *http.Reques
 	// Parse body
 	if r.Method != "GET" && r.Method != "HEAD" {
 		body, err := io.ReadAll(r.Body)
-		if err == nil && len(body) > 0 {
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
 			contentType := r.Header.Get("Content-Type")
 			
 			if strings.Contains(contentType, "application/json") {
@@ -235,21 +542,7 @@ func (n *WebhookTriggerNode) HandleWebhook(w http.ResponseWriter, r *http.Reques
 		}
 	}
 	
-	// Call workflow
-	if callback != nil {
-		if err := callback(webhookData); err != nil {
-			http.Error(w, "Execution failed", http.StatusInternalServerError)
-			return
-		}
-	}
-	
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":   true,
-		"timestamp": time.Now().Format(time.RFC3339),
-	})
+	return webhookData, nil
 }
 
 // GetWebhookURL returns the full webhook URL for a workflow
@@ -282,22 +575,6 @@ func parseHeaders(headers http.Header) map[string]string {
 	return result
 }
 
-func verifySignature(body []byte, signature, secret string) bool {
-	if signature == "" {
-		return false
-	}
-	
-	// Remove prefix if present
-	signature = strings.TrimPrefix(signature, "sha256=")
-	
-	// Calculate expected signature
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(body)
-	expected := hex.EncodeToString(mac.Sum(nil))
-	
-	return hmac.Equal([]byte(signature), []byte(expected))
-}
-
 func init() {
 	webhookHandler = NewWebhookTriggerNode()
 	runtime.Register(webhookHandler)
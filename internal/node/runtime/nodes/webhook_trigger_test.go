@@ -0,0 +1,52 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/pkg/middleware"
+)
+
+func TestWebhookTrigger_HoldsCallsPastServerWriteTimeout(t *testing.T) {
+	trigger := NewWebhookTriggerNode()
+	require.NoError(t, trigger.StartWithReply(context.Background(), map[string]interface{}{
+		"workflowId":      "slow",
+		"path":            "slow",
+		"responseMode":    WebhookRespondWithNode,
+		"responseTimeout": 1,
+	}, func(data map[string]interface{}, respond func(*runtime.WebhookResponse)) error {
+		go func() {
+			time.Sleep(400 * time.Millisecond)
+			respond(&runtime.WebhookResponse{StatusCode: http.StatusCreated, Body: map[string]interface{}{"ok": true}})
+		}()
+		return nil
+	}))
+
+	// The reply comes after the write timeout of the server, behind the
+	// middleware the API serves webhooks with
+	server := httptest.NewUnstartedServer(middleware.Logging(&middleware.LoggingConfig{})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trigger.HandleWebhook(w, r, "/slow")
+		}),
+	))
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, true, body["ok"])
+}
@@ -5,6 +5,10 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
+	"github.com/linkflow-ai/linkflow-ai/internal/shared/events"
 )
 
 // NodeExecutor is the interface that all node executors must implement
@@ -22,22 +26,78 @@ type NodeExecutor interface {
 	GetMetadata() NodeMetadata
 }
 
+// BatchExecutor is implemented by nodes that are called once with every input
+// item in some configurations only, e.g. for a batch write operation
+type BatchExecutor interface {
+	// RunsOnceForAllItems reports whether the node configured with config
+	// takes every input item at once
+	RunsOnceForAllItems(config map[string]interface{}) bool
+}
+
+// PollingExecutor is implemented by trigger nodes that start executions
+// with the new items they find by polling a service on an interval
+type PollingExecutor interface {
+	// Poll returns the items that appeared since cursor, which is empty on
+	// the first poll, and the cursor to poll from next time
+	Poll(ctx context.Context, input *ExecutionInput, cursor string) (*PollResult, error)
+}
+
+// PollResult is what a poll found
+type PollResult struct {
+	Items  []Item
+	Keys   []string // Identify Items when de-duplicating; derived from their content when unset
+	Cursor string   // Kept as is when empty
+}
+
+// SubscribingExecutor is implemented by trigger nodes that start executions
+// for the messages of a source they subscribe to, such as a Kafka topic
+type SubscribingExecutor interface {
+	// Subscribe consumes messages until ctx is done, passing each message,
+	// or each batch of them, to deliver. A message is acknowledged to its
+	// source only once deliver accepted it.
+	Subscribe(ctx context.Context, input *ExecutionInput, deliver DeliverFunc) error
+}
+
+// DeliverFunc starts an execution with the items of a message, returning nil
+// once the execution is accepted
+type DeliverFunc func(ctx context.Context, items []Item) error
+
 // ExecutionInput represents input to a node execution
 type ExecutionInput struct {
 	NodeID      string
 	NodeConfig  map[string]interface{}
 	InputData   map[string]interface{}
+	Items       []Item
 	Credentials map[string]interface{}
 	Context     *ExecutionContext
 }
 
 // ExecutionOutput represents output from a node execution
 type ExecutionOutput struct {
-	Data    map[string]interface{}
-	Binary  map[string][]byte
-	Error   error
-	Logs    []LogEntry
-	Metrics ExecutionMetrics
+	Data     map[string]interface{}
+	Items    []Item                 // Takes precedence over Data when set
+	Binary   map[string]*BinaryData // Attached to the output's item when Items is not set
+	Error    error
+	Logs     []LogEntry
+	Metrics  ExecutionMetrics
+	Wait     *WaitRequest     // Suspends the execution after this node when set
+	Response *WebhookResponse // Replies to the webhook call that started the execution
+}
+
+// WaitRequest asks the engine to park the execution and resume it later
+// instead of blocking a worker while waiting
+type WaitRequest struct {
+	ResumeAt time.Time // Zero waits without a deadline
+	Webhook  bool      // Resume when the execution's resume URL is called
+}
+
+// WebhookResponse is the HTTP response given to the webhook call that started
+// an execution
+type WebhookResponse struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       interface{} // Sent as is when a string or bytes, as JSON otherwise
+	Binary     *BinaryData // Streamed from the binary store instead of Body when set
 }
 
 // ExecutionContext provides context during execution
@@ -48,7 +108,13 @@ type ExecutionContext struct {
 	WorkspaceID string
 	Variables   map[string]interface{}
 	Env         map[string]string
-	Mode        string // manual, webhook, schedule, api
+	Mode        string         // manual, webhook, schedule, api
+	ResumeURL   string         // URL that resumes the execution when it waits for a webhook
+	Binary      BinaryStore    // Holds the content of the items' binary data
+	Egress      *egress.Guard  // Policy outbound requests of the workspace are held to
+	Events      *events.Bus    // Platform events, which event triggers subscribe to
+	Workflows   WorkflowRunner // Runs the workflows nodes call
+	Depth       int            // Of a sub-workflow execution below the one that started it
 }
 
 // LogEntry represents a log entry during execution
@@ -84,6 +150,15 @@ type NodeMetadata struct {
 	Properties  []PropertyDefinition
 	IsTrigger   bool
 	IsPremium   bool
+	
+	// RunOnceForAllItems makes the engine call the node once with every input
+	// item instead of once per item
+	RunOnceForAllItems bool
+	
+	// Loop makes the engine run the node again whenever the body reachable
+	// from its "loop" output finishes, feeding it the items that came back
+	// and its previous "_loopState", until it emits on "done" instead
+	Loop bool
 }
 
 // PortDefinition defines an input or output port
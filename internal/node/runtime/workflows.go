@@ -0,0 +1,36 @@
+// Package runtime provides calls of other workflows from nodes
+package runtime
+
+import (
+	"context"
+	"time"
+)
+
+// WorkflowRunner runs the workflows that nodes call, such as the execute
+// workflow node
+type WorkflowRunner interface {
+	// RunWorkflow starts the called workflow and, if the call waits for it,
+	// returns its final output
+	RunWorkflow(ctx context.Context, call *WorkflowCall) (*WorkflowCallResult, error)
+}
+
+// WorkflowCall is a call of another workflow by a node
+type WorkflowCall struct {
+	WorkflowID    string
+	Input         map[string]interface{}
+	InputMapping  map[string]string // Input field of the called workflow -> field of Input
+	OutputMapping map[string]string // Output field -> field of the called workflow's output
+	Wait          bool              // Return the output rather than once the execution started
+	Timeout       time.Duration     // Of a waiting call; zero waits indefinitely
+	OnError       string            // stop, continue, fallback
+	FallbackValue map[string]interface{}
+	Caller        *ExecutionContext // Execution of the calling node
+}
+
+// WorkflowCallResult is the outcome of a call of a workflow
+type WorkflowCallResult struct {
+	ExecutionID string
+	Status      string
+	Output      map[string]interface{} // Set once the called execution finished
+	Error       string                 // Of a failed execution the call continued after
+}
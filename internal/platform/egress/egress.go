@@ -0,0 +1,519 @@
+// Package egress holds outbound requests made on behalf of workspaces to an
+// allow/deny policy, so that workflows cannot reach internal services
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBlocked is returned when a destination is not allowed by the policy
+var ErrBlocked = errors.New("egress blocked")
+
+// policyTTL is how long a loaded workspace policy is used before it is
+// loaded again
+const policyTTL = time.Minute
+
+// BlockedError describes a destination the policy does not allow
+type BlockedError struct {
+	Host   string
+	IP     string // Empty when the host was blocked before it was resolved
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	if e.IP != "" && e.IP != e.Host {
+		return fmt.Sprintf("egress to %s (%s) blocked: %s", e.Host, e.IP, e.Reason)
+	}
+	return fmt.Sprintf("egress to %s blocked: %s", e.Host, e.Reason)
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
+// Policy lists the destinations requests may reach. Entries are host names,
+// "*.example.com" wildcards matching subdomains, IP addresses or CIDRs.
+type Policy struct {
+	Allow        []string `json:"allow,omitempty"`        // When set, only matching destinations may be reached
+	Deny         []string `json:"deny,omitempty"`         // Never reached, even if allowed
+	AllowPrivate bool     `json:"allowPrivate,omitempty"` // Reach loopback, link-local, private and other internal addresses
+}
+
+// Validate checks that every entry is a host name, wildcard, IP or CIDR
+func (p *Policy) Validate() error {
+	for _, entry := range append(append([]string{}, p.Allow...), p.Deny...) {
+		if _, err := parseRule(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateWithin checks that the policy only narrows base: every entry it
+// allows must be allowed by base's allowlist, if base has one, and it cannot
+// allow internal addresses base does not
+func (p *Policy) ValidateWithin(base Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if p.AllowPrivate && !base.AllowPrivate {
+		return fmt.Errorf("egress to internal addresses is not allowed")
+	}
+	if len(base.Allow) == 0 {
+		return nil
+	}
+
+	baseAllow, err := parseRules(base.Allow)
+	if err != nil {
+		return err
+	}
+	for _, entry := range p.Allow {
+		r, _ := parseRule(entry)
+		if !coversAny(baseAllow, r) {
+			return fmt.Errorf("egress rule %q is outside the allowed destinations", entry)
+		}
+	}
+	return nil
+}
+
+// Violation reports a request the policy blocked
+type Violation struct {
+	WorkspaceID string
+	Source      string // What made the request, e.g. a workflow node
+	Host        string
+	IP          string
+	Reason      string
+	Timestamp   time.Time
+}
+
+// ViolationHandler is notified of blocked requests, e.g. to audit them
+type ViolationHandler func(ctx context.Context, violation Violation)
+
+// PolicyLoader loads the policy of a workspace, nil if it has none
+type PolicyLoader func(ctx context.Context, workspaceID string) (*Policy, error)
+
+// Guard enforces egress policies on outbound HTTP requests. The base policy
+// applies to every request; a workspace policy may only narrow it, so that
+// internal addresses are reachable only when the base policy allows them.
+//
+// Host names are resolved once per connection and the connection is made
+// to the checked address, so a name cannot be rebound to an internal
+// address between the check and the dial.
+type Guard struct {
+	base        Policy
+	loader      PolicyLoader
+	onViolation ViolationHandler
+	resolver    *net.Resolver
+	mu          sync.Mutex
+	workspaces  map[string]*guardEntry
+}
+
+// guardEntry is the cached policy and transport of a workspace
+type guardEntry struct {
+	policy    *effectivePolicy
+	transport *http.Transport
+	loadedAt  time.Time
+}
+
+var defaultGuard = NewGuard(Policy{})
+
+// Default returns a guard that blocks internal addresses and nothing else,
+// used by callers that are given no guard
+func Default() *Guard {
+	return defaultGuard
+}
+
+// NewGuard creates a guard enforcing base on every request
+func NewGuard(base Policy) *Guard {
+	return &Guard{
+		base:       base,
+		resolver:   net.DefaultResolver,
+		workspaces: make(map[string]*guardEntry),
+	}
+}
+
+// WithPolicyLoader sets how the policies of workspaces are loaded
+func (g *Guard) WithPolicyLoader(loader PolicyLoader) *Guard {
+	g.loader = loader
+	return g
+}
+
+// WithViolationHandler sets the handler notified of blocked requests
+func (g *Guard) WithViolationHandler(handler ViolationHandler) *Guard {
+	g.onViolation = handler
+	return g
+}
+
+// ValidatePolicy checks that a workspace policy is valid and only narrows
+// the guard's base policy
+func (g *Guard) ValidatePolicy(workspace *Policy) error {
+	return workspace.ValidateWithin(g.base)
+}
+
+// Invalidate drops the cached policy of a workspace after it changed
+func (g *Guard) Invalidate(workspaceID string) {
+	g.mu.Lock()
+	entry := g.workspaces[workspaceID]
+	delete(g.workspaces, workspaceID)
+	g.mu.Unlock()
+
+	if entry != nil {
+		entry.transport.CloseIdleConnections()
+	}
+}
+
+// Client returns an HTTP client held to the policy of a workspace. source
+// names the caller in violation reports.
+func (g *Guard) Client(workspaceID, source string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: g.RoundTripper(workspaceID, source),
+	}
+}
+
+// RoundTripper returns a transport held to the policy of a workspace. With
+// no workspace given, the workspace is taken from each request's context.
+func (g *Guard) RoundTripper(workspaceID, source string) http.RoundTripper {
+	return &guardedTransport{guard: g, workspaceID: workspaceID, source: source}
+}
+
+// guardedTransport checks requests against a workspace's policy before
+// handing them to the workspace's transport
+type guardedTransport struct {
+	guard       *Guard
+	workspaceID string
+	source      string
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	workspaceID := t.workspaceID
+	if workspaceID == "" {
+		workspaceID = WorkspaceFromContext(ctx)
+	}
+
+	entry, err := t.guard.entry(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := entry.policy.checkURL(req.URL); err != nil {
+		t.guard.report(ctx, workspaceID, t.source, err)
+		return nil, err
+	}
+
+	resp, err := entry.transport.RoundTrip(req)
+	if err != nil {
+		t.guard.report(ctx, workspaceID, t.source, err)
+	}
+	return resp, err
+}
+
+// report notifies the violation handler if err is a blocked destination
+func (g *Guard) report(ctx context.Context, workspaceID, source string, err error) {
+	var blocked *BlockedError
+	if g.onViolation == nil || !errors.As(err, &blocked) {
+		return
+	}
+	g.onViolation(ctx, Violation{
+		WorkspaceID: workspaceID,
+		Source:      source,
+		Host:        blocked.Host,
+		IP:          blocked.IP,
+		Reason:      blocked.Reason,
+		Timestamp:   time.Now(),
+	})
+}
+
+// entry returns the policy and transport of a workspace, loading the policy
+// when it is not cached or has expired
+func (g *Guard) entry(ctx context.Context, workspaceID string) (*guardEntry, error) {
+	g.mu.Lock()
+	entry := g.workspaces[workspaceID]
+	g.mu.Unlock()
+
+	if entry != nil && time.Since(entry.loadedAt) < policyTTL {
+		return entry, nil
+	}
+
+	var workspace *Policy
+	if workspaceID != "" && g.loader != nil {
+		var err error
+		if workspace, err = g.loader(ctx, workspaceID); err != nil {
+			return nil, fmt.Errorf("failed to load egress policy: %w", err)
+		}
+	}
+
+	policy, err := newEffectivePolicy(g.base, workspace)
+	if err != nil {
+		return nil, err
+	}
+	fresh := &guardEntry{policy: policy, loadedAt: time.Now()}
+	fresh.transport = g.transport(policy)
+
+	g.mu.Lock()
+	g.workspaces[workspaceID] = fresh
+	g.mu.Unlock()
+
+	if entry != nil {
+		entry.transport.CloseIdleConnections()
+	}
+	return fresh, nil
+}
+
+// transport dials only addresses the policy allows. Proxies are not used,
+// as they would make the connection on the request's behalf.
+func (g *Guard) transport(policy *effectivePolicy) *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := g.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, ip := range addrs {
+			if err := policy.checkIP(host, ip.IP); err != nil {
+				lastErr = err
+				continue
+			}
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil, lastErr
+	}
+	return transport
+}
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a context whose requests are held to the policy of a
+// workspace by transports that are not bound to one
+func WithWorkspace(ctx context.Context, workspaceID string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+// WorkspaceFromContext returns the workspace set by WithWorkspace
+func WorkspaceFromContext(ctx context.Context) string {
+	workspaceID, _ := ctx.Value(workspaceKey{}).(string)
+	return workspaceID
+}
+
+// rule matches destinations by host name or address
+type rule struct {
+	host    string // Exact host name
+	suffix  string // Matches subdomains, e.g. ".example.com"
+	network *net.IPNet
+}
+
+func parseRule(entry string) (rule, error) {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	switch {
+	case entry == "":
+		return rule{}, fmt.Errorf("empty egress rule")
+	case strings.Contains(entry, "/"):
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return rule{}, fmt.Errorf("invalid egress rule %q: %w", entry, err)
+		}
+		return rule{network: network}, nil
+	case net.ParseIP(entry) != nil:
+		ip := net.ParseIP(entry)
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return rule{network: &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}}, nil
+	case strings.HasPrefix(entry, "*."):
+		return rule{suffix: entry[1:]}, nil
+	case strings.ContainsAny(entry, "*:@ "):
+		return rule{}, fmt.Errorf("invalid egress rule %q", entry)
+	}
+	return rule{host: strings.TrimSuffix(entry, ".")}, nil
+}
+
+func (r rule) matchesHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if r.host != "" {
+		return host == r.host
+	}
+	if r.suffix != "" {
+		return strings.HasSuffix(host, r.suffix)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return r.network.Contains(ip)
+	}
+	return false
+}
+
+func (r rule) matchesIP(ip net.IP) bool {
+	return r.network != nil && r.network.Contains(ip)
+}
+
+// covers reports whether every destination other matches also matches r
+func (r rule) covers(other rule) bool {
+	switch {
+	case other.host != "":
+		return r.matchesHost(other.host)
+	case other.suffix != "":
+		return r.suffix != "" && strings.HasSuffix(other.suffix, r.suffix)
+	case r.network == nil:
+		return false
+	}
+	ones, bits := r.network.Mask.Size()
+	otherOnes, otherBits := other.network.Mask.Size()
+	return bits == otherBits && ones <= otherOnes && r.network.Contains(other.network.IP)
+}
+
+func coversAny(rules []rule, other rule) bool {
+	for _, r := range rules {
+		if r.covers(other) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRules(entries []string) ([]rule, error) {
+	rules := make([]rule, 0, len(entries))
+	for _, entry := range entries {
+		r, err := parseRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// effectivePolicy is the base policy combined with a workspace's own. A
+// destination must be in both allowlists that are set, so a workspace can
+// only narrow the base one.
+type effectivePolicy struct {
+	baseAllow    []rule // Also lets internal addresses through
+	allow        []rule // Workspace allowlist
+	deny         []rule
+	allowPrivate bool
+}
+
+func newEffectivePolicy(base Policy, workspace *Policy) (*effectivePolicy, error) {
+	policy := &effectivePolicy{allowPrivate: base.AllowPrivate}
+
+	var err error
+	if policy.baseAllow, err = parseRules(base.Allow); err != nil {
+		return nil, err
+	}
+	if policy.deny, err = parseRules(base.Deny); err != nil {
+		return nil, err
+	}
+
+	if workspace != nil {
+		deny, err := parseRules(workspace.Deny)
+		if err != nil {
+			return nil, err
+		}
+		policy.deny = append(policy.deny, deny...)
+
+		if len(workspace.Allow) > 0 {
+			if policy.allow, err = parseRules(workspace.Allow); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return policy, nil
+}
+
+// checkURL checks the scheme and host of a request before it is sent
+func (p *effectivePolicy) checkURL(u *url.URL) error {
+	host := u.Hostname()
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &BlockedError{Host: host, Reason: fmt.Sprintf("scheme %q not allowed", u.Scheme)}
+	}
+	for _, r := range p.deny {
+		if r.matchesHost(host) {
+			return &BlockedError{Host: host, Reason: "host denied by policy"}
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(host, ip)
+	}
+	return nil
+}
+
+// checkIP checks an address that host resolved to before connecting
+func (p *effectivePolicy) checkIP(host string, ip net.IP) error {
+	blocked := func(reason string) error {
+		return &BlockedError{Host: host, IP: ip.String(), Reason: reason}
+	}
+
+	for _, r := range p.deny {
+		if r.matchesHost(host) || r.matchesIP(ip) {
+			return blocked("address denied by policy")
+		}
+	}
+	if len(p.baseAllow) > 0 && !matchesAny(p.baseAllow, host, ip) {
+		return blocked("not in allowlist")
+	}
+	if len(p.allow) > 0 && !matchesAny(p.allow, host, ip) {
+		return blocked("not in workspace allowlist")
+	}
+	if reason := internalAddress(ip); reason != "" && !p.allowPrivate && !matchesAny(p.baseAllow, host, ip) {
+		return blocked(reason)
+	}
+	return nil
+}
+
+func matchesAny(rules []rule, host string, ip net.IP) bool {
+	for _, r := range rules {
+		if r.matchesHost(host) || r.matchesIP(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, RFC 6598
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)}
+
+// internalAddress names the kind of internal address ip is, empty for a
+// public address
+func internalAddress(ip net.IP) string {
+	switch {
+	case ip.IsLoopback():
+		return "loopback address"
+	case ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast():
+		return "link-local address"
+	case ip.IsPrivate():
+		return "private address"
+	case ip.IsUnspecified():
+		return "unspecified address"
+	case ip.IsMulticast(), ip.IsInterfaceLocalMulticast(), ip.Equal(net.IPv4bcast):
+		return "multicast address"
+	case sharedAddressSpace.Contains(ip):
+		return "shared address space"
+	case ip.To4() != nil && ip.To4()[0] == 0:
+		return "reserved address"
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_ValidateWithinRejectsEntriesOutsideBase(t *testing.T) {
+	base := Policy{Allow: []string{"*.example.com", "api.partner.io", "10.0.0.0/8"}}
+
+	for _, allow := range [][]string{
+		{"api.example.com"},
+		{"*.eu.example.com"},
+		{"api.partner.io"},
+		{"10.1.0.0/16", "10.2.3.4"},
+	} {
+		workspace := Policy{Allow: allow}
+		assert.NoError(t, workspace.ValidateWithin(base), "%v", allow)
+	}
+
+	for _, allow := range [][]string{
+		{"evil.com"},
+		{"api.example.com", "evil.com"},
+		{"*.com"},
+		{"example.com"},
+		{"10.0.0.0/7"},
+		{"192.168.1.1"},
+	} {
+		workspace := Policy{Allow: allow}
+		assert.Error(t, workspace.ValidateWithin(base), "%v", allow)
+	}
+
+	// Without a base allowlist any destination can be listed, but internal
+	// addresses only when the base policy allows them
+	workspace := Policy{Allow: []string{"evil.com"}}
+	assert.NoError(t, workspace.ValidateWithin(Policy{}))
+	workspace = Policy{AllowPrivate: true}
+	assert.Error(t, workspace.ValidateWithin(Policy{}))
+	assert.NoError(t, workspace.ValidateWithin(Policy{AllowPrivate: true}))
+}
+
+func TestGuard_WorkspaceAllowlistOnlyNarrowsBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// Policies stored before they were validated against the base one
+	policies := map[string]*Policy{
+		"outside": {Allow: []string{"203.0.113.7", "127.0.0.1"}},
+		"narrow":  {Allow: []string{"127.0.0.2"}},
+	}
+	var violations []Violation
+	guard := NewGuard(Policy{Allow: []string{"127.0.0.0/8"}}).
+		WithPolicyLoader(func(ctx context.Context, workspaceID string) (*Policy, error) {
+			return policies[workspaceID], nil
+		}).
+		WithViolationHandler(func(ctx context.Context, v Violation) {
+			violations = append(violations, v)
+		})
+
+	get := func(workspaceID, url string) error {
+		resp, err := guard.Client(workspaceID, "test", time.Second).Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// The base allowlist lets the loopback server through
+	require.NoError(t, get("", server.URL))
+	require.NoError(t, get("outside", server.URL))
+
+	// A workspace cannot reach a host the base allowlist does not have
+	err := get("outside", "http://203.0.113.7/")
+	require.Error(t, err)
+	var blocked *BlockedError
+	require.True(t, errors.As(err, &blocked))
+	assert.Equal(t, "not in allowlist", blocked.Reason)
+
+	// and its own allowlist excludes the rest of the base one
+	err = get("narrow", server.URL)
+	require.True(t, errors.As(err, &blocked))
+	assert.Equal(t, "not in workspace allowlist", blocked.Reason)
+
+	require.Len(t, violations, 2)
+	assert.Equal(t, "outside", violations[0].WorkspaceID)
+	assert.Equal(t, "203.0.113.7", violations[0].Host)
+}
@@ -0,0 +1,278 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/linkflow-ai/linkflow-ai/internal/shared/events"
+)
+
+// Consumer defaults
+const (
+	defaultBatchTimeout = time.Second
+	defaultMaxAttempts  = 10
+	retryDelay          = time.Second
+	maxRetryDelay       = 30 * time.Second
+)
+
+// EventTopics are the topics the EventPublisher publishes events to
+var EventTopics = []string{"workflow-events", "execution-events", "user-events", "auth-events", "default-events"}
+
+// ConsumerConfig holds the configuration of a consumer group
+type ConsumerConfig struct {
+	Brokers      []string
+	GroupID      string
+	Topics       []string
+	BatchSize    int           // Messages handled at once, defaults to 1
+	BatchTimeout time.Duration // Longest wait to fill a batch, defaults to a second
+	FromOldest   bool          // Groups without committed offsets start at the oldest message rather than the newest
+	MaxAttempts  int           // Times a failing batch is handled before it is given up on, defaults to 10
+	DeadLetter   string        // Topic batches given up on are published to; without one they are skipped
+	Username     string        // SASL/PLAIN credentials, when set
+	Password     string
+	TLS          bool
+}
+
+// BatchHandler handles a batch of messages; the batch is committed only
+// once it returns nil
+type BatchHandler func(ctx context.Context, messages []*sarama.ConsumerMessage) error
+
+// Consumer consumes topics as a member of a consumer group
+type Consumer struct {
+	group      sarama.ConsumerGroup
+	deadLetter sarama.SyncProducer
+	config     *ConsumerConfig
+}
+
+// NewConsumer creates a new Kafka consumer
+func NewConsumer(config *ConsumerConfig) (*Consumer, error) {
+	if len(config.Brokers) == 0 || config.GroupID == "" || len(config.Topics) == 0 {
+		return nil, fmt.Errorf("brokers, group ID and topics are required")
+	}
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Version = sarama.V3_3_1_0
+	saramaConfig.Consumer.Offsets.AutoCommit.Enable = false
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	if config.FromOldest {
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+	if config.Username != "" {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		saramaConfig.Net.SASL.User = config.Username
+		saramaConfig.Net.SASL.Password = config.Password
+	}
+	if config.TLS {
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	group, err := sarama.NewConsumerGroup(config.Brokers, config.GroupID, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	consumer := &Consumer{group: group, config: config}
+
+	if config.DeadLetter != "" {
+		saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+		saramaConfig.Producer.Return.Successes = true
+		consumer.deadLetter, err = sarama.NewSyncProducer(config.Brokers, saramaConfig)
+		if err != nil {
+			group.Close()
+			return nil, fmt.Errorf("failed to create dead letter producer: %w", err)
+		}
+	}
+	return consumer, nil
+}
+
+// Consume passes the messages of the topics to handler until ctx is done.
+// A batch the handler fails is retried with a growing delay, holding back
+// the rest of its partition, so that messages are handled at least once
+// and in order. A batch still failing after MaxAttempts is published to the
+// dead letter topic, or skipped without one, so that it cannot hold back
+// its partition forever.
+func (c *Consumer) Consume(ctx context.Context, handler BatchHandler) error {
+	h := &groupHandler{
+		handler:      handler,
+		batchSize:    c.config.BatchSize,
+		batchTimeout: c.config.BatchTimeout,
+		maxAttempts:  c.config.MaxAttempts,
+		retryDelay:   retryDelay,
+	}
+	if h.batchSize < 1 {
+		h.batchSize = 1
+	}
+	if h.batchTimeout <= 0 {
+		h.batchTimeout = defaultBatchTimeout
+	}
+	if h.maxAttempts < 1 {
+		h.maxAttempts = defaultMaxAttempts
+	}
+	if c.deadLetter != nil {
+		h.deadLetter = &deadLetterQueue{producer: c.deadLetter, topic: c.config.DeadLetter}
+	}
+
+	// Consume returns at each rebalance of the group
+	for {
+		if err := c.group.Consume(ctx, c.config.Topics, h); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return nil
+			}
+			return fmt.Errorf("failed to consume: %w", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// Close leaves the consumer group
+func (c *Consumer) Close() error {
+	if c.deadLetter != nil {
+		c.deadLetter.Close()
+	}
+	if err := c.group.Close(); err != nil {
+		return fmt.Errorf("failed to close consumer: %w", err)
+	}
+	return nil
+}
+
+// groupHandler batches the messages of each claimed partition
+type groupHandler struct {
+	handler      BatchHandler
+	batchSize    int
+	batchTimeout time.Duration
+	maxAttempts  int
+	retryDelay   time.Duration
+	deadLetter   *deadLetterQueue // Nil skips the batches given up on
+}
+
+func (h *groupHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (h *groupHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	batch := make([]*sarama.ConsumerMessage, 0, h.batchSize)
+	var flushAt <-chan time.Time
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := h.handle(session, batch); err != nil {
+			return err
+		}
+		batch, flushAt = make([]*sarama.ConsumerMessage, 0, h.batchSize), nil
+		return nil
+	}
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return flush()
+			}
+			batch = append(batch, message)
+			if len(batch) == 1 {
+				flushAt = time.After(h.batchTimeout)
+			}
+			if len(batch) >= h.batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-flushAt:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// handle passes a batch to the handler until it accepts it or the attempts
+// run out, then commits it. A batch given up on is committed only once the
+// dead letter topic took it.
+func (h *groupHandler) handle(session sarama.ConsumerGroupSession, batch []*sarama.ConsumerMessage) error {
+	ctx := session.Context()
+	delay := h.retryDelay
+	for attempt := 1; ; attempt++ {
+		err := h.handler(ctx, batch)
+		if err == nil {
+			break
+		}
+		if attempt >= h.maxAttempts {
+			if h.deadLetter != nil {
+				if err := h.deadLetter.publish(batch, err); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+
+	session.MarkMessage(batch[len(batch)-1], "")
+	session.Commit()
+	return nil
+}
+
+// deadLetterQueue keeps the batches a handler gave up on, with where they
+// were consumed from and why they failed in the headers of each message
+type deadLetterQueue struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func (q *deadLetterQueue) publish(batch []*sarama.ConsumerMessage, cause error) error {
+	messages := make([]*sarama.ProducerMessage, len(batch))
+	for i, message := range batch {
+		messages[i] = &sarama.ProducerMessage{
+			Topic: q.topic,
+			Key:   sarama.ByteEncoder(message.Key),
+			Value: sarama.ByteEncoder(message.Value),
+			Headers: []sarama.RecordHeader{
+				{Key: []byte("x-original-topic"), Value: []byte(message.Topic)},
+				{Key: []byte("x-original-partition"), Value: []byte(strconv.Itoa(int(message.Partition)))},
+				{Key: []byte("x-original-offset"), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+				{Key: []byte("x-error"), Value: []byte(cause.Error())},
+			},
+		}
+	}
+	if err := q.producer.SendMessages(messages); err != nil {
+		return fmt.Errorf("failed to publish to dead letter topic %s: %w", q.topic, err)
+	}
+	return nil
+}
+
+// ForwardEvents publishes the events consumed from the EventPublisher's
+// topics on bus, committing each once the bus's subscribers accepted it
+func ForwardEvents(ctx context.Context, consumer *Consumer, bus *events.Bus) error {
+	return consumer.Consume(ctx, func(ctx context.Context, messages []*sarama.ConsumerMessage) error {
+		for _, message := range messages {
+			var event events.Event
+			if err := json.Unmarshal(message.Value, &event); err != nil {
+				// Not an event; retrying would not make it one
+				continue
+			}
+			if err := bus.Publish(ctx, &event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSession records the messages a handler commits
+type recordingSession struct {
+	ctx    context.Context
+	marked []*sarama.ConsumerMessage
+}
+
+func (s *recordingSession) Claims() map[string][]int32               { return nil }
+func (s *recordingSession) MemberID() string                         { return "member" }
+func (s *recordingSession) GenerationID() int32                      { return 1 }
+func (s *recordingSession) MarkOffset(string, int32, int64, string)  {}
+func (s *recordingSession) Commit()                                  {}
+func (s *recordingSession) ResetOffset(string, int32, int64, string) {}
+func (s *recordingSession) MarkMessage(msg *sarama.ConsumerMessage, meta string) {
+	s.marked = append(s.marked, msg)
+}
+func (s *recordingSession) Context() context.Context { return s.ctx }
+
+func failingHandler(attempts *int) BatchHandler {
+	return func(ctx context.Context, messages []*sarama.ConsumerMessage) error {
+		*attempts++
+		return errors.New("workflow unavailable")
+	}
+}
+
+func TestGroupHandler_SkipsBatchAfterMaxAttempts(t *testing.T) {
+	var attempts int
+	h := &groupHandler{handler: failingHandler(&attempts), maxAttempts: 3, retryDelay: time.Millisecond}
+	session := &recordingSession{ctx: context.Background()}
+	batch := []*sarama.ConsumerMessage{{Topic: "orders", Offset: 7}}
+
+	require.NoError(t, h.handle(session, batch))
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, batch, session.marked)
+}
+
+func TestGroupHandler_PublishesBatchGivenUpOnToDeadLetterTopic(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+	var attempts int
+	h := &groupHandler{
+		handler:     failingHandler(&attempts),
+		maxAttempts: 2,
+		retryDelay:  time.Millisecond,
+		deadLetter:  &deadLetterQueue{producer: producer, topic: "orders-dead"},
+	}
+	batch := []*sarama.ConsumerMessage{
+		{Topic: "orders", Partition: 1, Offset: 7, Value: []byte(`{"id":1}`)},
+		{Topic: "orders", Partition: 1, Offset: 8, Value: []byte(`{"id":2}`)},
+	}
+
+	var published []*sarama.ProducerMessage
+	for range batch {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+			published = append(published, message)
+			return nil
+		})
+	}
+	session := &recordingSession{ctx: context.Background()}
+	require.NoError(t, h.handle(session, batch))
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, batch[1:], session.marked)
+
+	require.Len(t, published, 2)
+	assert.Equal(t, "orders-dead", published[0].Topic)
+	value, _ := published[1].Value.Encode()
+	assert.Equal(t, `{"id":2}`, string(value))
+	headers := map[string]string{}
+	for _, header := range published[1].Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	assert.Equal(t, map[string]string{
+		"x-original-topic":     "orders",
+		"x-original-partition": "1",
+		"x-original-offset":    "8",
+		"x-error":              "workflow unavailable",
+	}, headers)
+
+	// A batch the dead letter topic does not take is not committed
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	session = &recordingSession{ctx: context.Background()}
+	assert.Error(t, h.handle(session, batch[:1]))
+	assert.Empty(t, session.marked)
+}
+
+func TestGroupHandler_RetriesUntilHandled(t *testing.T) {
+	var attempts int
+	h := &groupHandler{
+		handler: func(ctx context.Context, messages []*sarama.ConsumerMessage) error {
+			if attempts++; attempts < 3 {
+				return errors.New("not yet")
+			}
+			return nil
+		},
+		maxAttempts: 5,
+		retryDelay:  time.Millisecond,
+	}
+	session := &recordingSession{ctx: context.Background()}
+	require.NoError(t, h.handle(session, []*sarama.ConsumerMessage{{Topic: "orders"}}))
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, session.marked, 1)
+}
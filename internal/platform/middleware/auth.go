@@ -25,6 +25,7 @@ func NewAuthMiddleware(jwtSecret []byte) *AuthMiddleware {
 			"/api/v1/users/login",
 			"/auth/login",
 			"/auth/register",
+			"/api/v1/executions/resume/", // Authorized by the resume token in the path
 		},
 	}
 }
@@ -7,20 +7,24 @@ import (
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"github.com/linkflow-ai/linkflow-ai/internal/engine"
 	"github.com/linkflow-ai/linkflow-ai/internal/platform/logger"
 	"github.com/linkflow-ai/linkflow-ai/internal/schedule/domain/model"
 	"github.com/linkflow-ai/linkflow-ai/internal/shared/events"
 )
 
-// Scheduler manages cron job scheduling
+// Scheduler manages cron job scheduling. Every replica fires every
+// schedule; a run is only executed by the replica that claims it.
 type Scheduler struct {
-	cron      *cron.Cron
-	service   *ScheduleService
-	logger    logger.Logger
-	jobs      map[model.ScheduleID]cron.EntryID
-	mu        sync.RWMutex
-	running   bool
-	stopChan  chan struct{}
+	cron            *cron.Cron
+	service         *ScheduleService
+	logger          logger.Logger
+	jobs            map[model.ScheduleID]cron.EntryID
+	mu              sync.RWMutex
+	running         bool
+	stopChan        chan struct{}
+	claimer         engine.RunClaimer
+	missedRunPolicy string
 }
 
 // NewScheduler creates a new scheduler
@@ -31,32 +35,49 @@ func NewScheduler(service *ScheduleService, logger logger.Logger) *Scheduler {
 		cron.WithSeconds(),
 	)
 	
+	// Replicas share claims through Redis; without it only a single
+	// replica may run
+	var claimer engine.RunClaimer = engine.NewInMemoryRunClaimer()
+	if service.cache != nil {
+		claimer = engine.NewRedisRunClaimer(service.cache)
+	}
+	
 	return &Scheduler{
-		cron:     c,
-		service:  service,
-		logger:   logger,
-		jobs:     make(map[model.ScheduleID]cron.EntryID),
-		stopChan: make(chan struct{}),
+		cron:            c,
+		service:         service,
+		logger:          logger,
+		jobs:            make(map[model.ScheduleID]cron.EntryID),
+		stopChan:        make(chan struct{}),
+		claimer:         claimer,
+		missedRunPolicy: engine.MissedRunSkip,
 	}
 }
 
+// WithMissedRunPolicy sets whether runs missed while no scheduler was
+// running are skipped or caught up
+func (s *Scheduler) WithMissedRunPolicy(policy string) *Scheduler {
+	s.missedRunPolicy = policy
+	return s
+}
+
 // Start starts the scheduler
 func (s *Scheduler) Start(ctx context.Context) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	
 	if s.running {
+		s.mu.Unlock()
 		return nil
 	}
 	
-	// Load active schedules from database
-	if err := s.loadActiveSchedules(ctx); err != nil {
-		return err
-	}
-	
 	// Start cron scheduler
 	s.cron.Start()
 	s.running = true
+	s.mu.Unlock()
+	
+	// Load active schedules from database, catching up on missed runs;
+	// registering takes the lock itself
+	if err := s.loadActiveSchedules(ctx); err != nil {
+		return err
+	}
 	
 	// Start periodic check for due schedules
 	go s.periodicCheck(ctx)
@@ -106,10 +127,13 @@ func (s *Scheduler) RegisterSchedule(schedule *model.Schedule) {
 		return
 	}
 	
-	// Add new cron job
+	// Add new cron job; its run is claimed by the time it was due
 	entryID, err := s.cron.AddFunc(schedule.CronExpression(), func() {
-		ctx := context.Background()
-		s.ExecuteSchedule(ctx, schedule)
+		s.mu.RLock()
+		entryID := s.jobs[schedule.ID()]
+		s.mu.RUnlock()
+		
+		s.executeRun(context.Background(), schedule, s.cron.Entry(entryID).Prev)
 	})
 	
 	if err != nil {
@@ -210,6 +234,58 @@ func (s *Scheduler) ExecuteSchedule(ctx context.Context, schedule *model.Schedul
 	}
 }
 
+// executeRun executes the run of a schedule due at runAt, unless another
+// replica claimed it first
+func (s *Scheduler) executeRun(ctx context.Context, schedule *model.Schedule, runAt time.Time) {
+	claimed, err := s.claimer.Claim(ctx, schedule.ID().String(), runAt)
+	if err != nil {
+		s.logger.Error("Failed to claim schedule run",
+			"schedule_id", schedule.ID(),
+			"error", err,
+		)
+		return
+	}
+	if !claimed {
+		return
+	}
+	
+	s.ExecuteSchedule(ctx, schedule)
+}
+
+// catchUp applies the missed run policy to the runs of a schedule that fell
+// due while no scheduler was running
+func (s *Scheduler) catchUp(ctx context.Context, schedule *model.Schedule) {
+	since := schedule.LastRunAt()
+	if last, err := s.claimer.LastClaimed(ctx, schedule.ID().String()); err == nil && last != nil {
+		if since == nil || last.After(*since) {
+			since = last
+		}
+	}
+	if since == nil {
+		return
+	}
+	
+	cronSchedule, err := cron.ParseStandard(schedule.CronExpression())
+	if err != nil {
+		return
+	}
+	loc, err := time.LoadLocation(schedule.Timezone())
+	if err != nil {
+		loc = time.UTC
+	}
+	
+	missed := engine.MissedRuns(cronSchedule, since.In(loc), time.Now().In(loc), s.missedRunPolicy)
+	for _, runAt := range missed {
+		s.executeRun(ctx, schedule, runAt)
+	}
+	if len(missed) > 0 {
+		s.logger.Info("Caught up missed schedule runs",
+			"schedule_id", schedule.ID(),
+			"runs", len(missed),
+		)
+	}
+}
+
 // loadActiveSchedules loads all active schedules from the database
 func (s *Scheduler) loadActiveSchedules(ctx context.Context) error {
 	// Get all active schedules
@@ -221,6 +297,7 @@ func (s *Scheduler) loadActiveSchedules(ctx context.Context) error {
 	// Register each schedule
 	for _, schedule := range schedules {
 		s.RegisterSchedule(schedule)
+		s.catchUp(ctx, schedule)
 	}
 	
 	s.logger.Info("Loaded active schedules", "count", len(schedules))
@@ -266,7 +343,7 @@ func (s *Scheduler) checkDueSchedules(ctx context.Context) {
 			if !registered {
 				// Register and execute
 				s.RegisterSchedule(schedule)
-				s.ExecuteSchedule(ctx, schedule)
+				s.executeRun(ctx, schedule, *schedule.NextRunAt())
 			}
 		}
 	}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Handler handles an event; an error tells the publisher the event was not
+// accepted
+type Handler func(ctx context.Context, event *Event) error
+
+// Bus passes the events of the platform to the handlers within a process
+// subscribed to them
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[int]subscriber
+	next        int
+}
+
+type subscriber struct {
+	types   map[EventType]bool // Every type when empty
+	handler Handler
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[int]subscriber)}
+}
+
+// Subscribe calls handler with the events of the given types, or with every
+// event when none are given, until the returned function is called
+func (b *Bus) Subscribe(types []EventType, handler Handler) (unsubscribe func()) {
+	s := subscriber{types: make(map[EventType]bool, len(types)), handler: handler}
+	for _, t := range types {
+		s.types[t] = true
+	}
+
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.subscribers[id] = s
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		delete(b.subscribers, id)
+		b.mu.Unlock()
+	}
+}
+
+// Publish passes event to each of its subscribers in turn and returns the
+// errors of those that did not accept it
+func (b *Bus) Publish(ctx context.Context, event *Event) error {
+	b.mu.RLock()
+	var handlers []Handler
+	for _, s := range b.subscribers {
+		if len(s.types) == 0 || s.types[event.Type] {
+			handlers = append(handlers, s.handler)
+		}
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
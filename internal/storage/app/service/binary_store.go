@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+)
+
+// BinaryStore keeps the binary data of workflow executions as files of the
+// storage service, owned by a single account
+type BinaryStore struct {
+	storage *StorageService
+	owner   string
+}
+
+// NewBinaryStore creates a binary store saving files as owner
+func NewBinaryStore(storage *StorageService, owner string) *BinaryStore {
+	return &BinaryStore{storage: storage, owner: owner}
+}
+
+// Put streams r into a new file
+func (b *BinaryStore) Put(ctx context.Context, fileName, mimeType string, r io.Reader) (*runtime.BinaryData, error) {
+	if fileName == "" {
+		fileName = "data"
+	}
+
+	file, err := b.storage.UploadFile(ctx, UploadFileCommand{
+		UserID:   b.owner,
+		FileName: fileName,
+		Reader:   r,
+		MimeType: mimeType,
+		Tags:     []string{"execution"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &runtime.BinaryData{
+		ID:       string(file.ID()),
+		FileName: file.Name(),
+		MimeType: file.MimeType(),
+		Size:     file.Size(),
+	}, nil
+}
+
+// Open opens a stored file
+func (b *BinaryStore) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	reader, _, err := b.storage.DownloadFile(ctx, id, b.owner)
+	if err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
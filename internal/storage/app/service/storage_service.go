@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/linkflow-ai/linkflow-ai/internal/platform/logger"
@@ -18,6 +19,7 @@ type StorageService struct {
 	baseDir string
 	logger  logger.Logger
 	files   map[string]*model.File // In-memory storage for demo
+	mu      sync.RWMutex
 }
 
 func NewStorageService(baseDir string, logger logger.Logger) *StorageService {
@@ -90,7 +92,9 @@ func (s *StorageService) UploadFile(ctx context.Context, cmd UploadFileCommand)
 	}
 	
 	// Store in memory (would be database in production)
+	s.mu.Lock()
 	s.files[string(fileModel.ID())] = fileModel
+	s.mu.Unlock()
 	
 	s.logger.Info("File uploaded",
 		"file_id", fileModel.ID(),
@@ -104,7 +108,9 @@ func (s *StorageService) UploadFile(ctx context.Context, cmd UploadFileCommand)
 
 func (s *StorageService) DownloadFile(ctx context.Context, fileID string, userID string) (io.ReadCloser, *model.File, error) {
 	// Get file metadata
+	s.mu.RLock()
 	fileModel, exists := s.files[fileID]
+	s.mu.RUnlock()
 	if !exists {
 		return nil, nil, fmt.Errorf("file not found")
 	}
@@ -133,6 +139,8 @@ func (s *StorageService) DownloadFile(ctx context.Context, fileID string, userID
 
 func (s *StorageService) DeleteFile(ctx context.Context, fileID string, userID string) error {
 	// Get file metadata
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	fileModel, exists := s.files[fileID]
 	if !exists {
 		return fmt.Errorf("file not found")
@@ -162,6 +170,8 @@ func (s *StorageService) DeleteFile(ctx context.Context, fileID string, userID s
 func (s *StorageService) ListFiles(ctx context.Context, userID string) ([]*model.File, error) {
 	var files []*model.File
 	
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	for _, file := range s.files {
 		if file.UserID() == userID || file.IsPublic() {
 			files = append(files, file)
@@ -172,6 +182,8 @@ func (s *StorageService) ListFiles(ctx context.Context, userID string) ([]*model
 }
 
 func (s *StorageService) ShareFile(ctx context.Context, fileID string, userID string, public bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	fileModel, exists := s.files[fileID]
 	if !exists {
 		return fmt.Errorf("file not found")
@@ -197,6 +209,8 @@ func (s *StorageService) GetStorageStats(ctx context.Context, userID string) (ma
 	var fileCount int
 	var typeBreakdown = make(map[string]int)
 	
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	for _, file := range s.files {
 		if file.UserID() == userID {
 			totalSize += file.Size()
@@ -218,6 +232,8 @@ func (s *StorageService) CleanupExpiredFiles(ctx context.Context) error {
 	now := time.Now()
 	var deleted int
 	
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for id, file := range s.files {
 		if file.Metadata()["expires_at"] != nil {
 			expiresAt := file.Metadata()["expires_at"].(time.Time)
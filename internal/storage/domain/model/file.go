@@ -73,6 +73,7 @@ func (f *File) UserID() string            { return f.userID }
 func (f *File) Name() string              { return f.name }
 func (f *File) Path() string              { return f.path }
 func (f *File) Size() int64               { return f.size }
+func (f *File) MimeType() string          { return f.mimeType }
 func (f *File) IsPublic() bool            { return f.isPublic }
 func (f *File) UploadedAt() time.Time     { return f.uploadedAt }
 func (f *File) Tags() []string            { return f.tags }
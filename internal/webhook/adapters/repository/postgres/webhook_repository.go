@@ -2,7 +2,12 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/linkflow-ai/linkflow-ai/internal/platform/database"
 	"github.com/linkflow-ai/linkflow-ai/internal/webhook/domain/model"
@@ -18,20 +23,31 @@ func NewWebhookRepository(db *database.DB) repository.WebhookRepository {
 }
 
 func (r *WebhookRepository) Save(ctx context.Context, webhook *model.Webhook) error {
+	headersJSON, authJSON, err := marshalWebhookConfig(webhook)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO webhooks (
-			id, user_id, workflow_id, name, endpoint_url, secret, method, status,
+		INSERT INTO webhook_service.webhooks (
+			id, user_id, workflow_id, trigger_node_id, name, endpoint_url, path, secret,
+			method, headers, authentication_type, authentication_config, status,
 			trigger_count, success_count, failure_count, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		webhook.ID().String(),
 		webhook.UserID(),
-		webhook.WorkflowID(),
+		nullString(webhook.WorkflowID()),
+		nullString(webhook.TriggerNodeID()),
 		webhook.Name(),
 		webhook.EndpointURL(),
+		nullString(webhook.Path()),
 		webhook.Secret(),
 		string(webhook.Method()),
+		headersJSON,
+		string(webhook.AuthenticationType()),
+		authJSON,
 		string(webhook.Status()),
 		webhook.TriggerCount(),
 		webhook.SuccessCount(),
@@ -40,17 +56,24 @@ func (r *WebhookRepository) Save(ctx context.Context, webhook *model.Webhook) er
 		webhook.UpdatedAt(),
 	)
 
-	return err
+	return uniqueViolation(err, "failed to insert webhook")
 }
 
 func (r *WebhookRepository) Update(ctx context.Context, webhook *model.Webhook) error {
+	headersJSON, authJSON, err := marshalWebhookConfig(webhook)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		UPDATE webhooks SET
+		UPDATE webhook_service.webhooks SET
 			endpoint_url = $2, status = $3, trigger_count = $4,
-			success_count = $5, failure_count = $6, updated_at = $7
+			success_count = $5, failure_count = $6, updated_at = $7,
+			path = $8, method = $9, headers = $10, authentication_type = $11,
+			authentication_config = $12, last_triggered_at = $13
 		WHERE id = $1`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		webhook.ID().String(),
 		webhook.EndpointURL(),
 		string(webhook.Status()),
@@ -58,26 +81,187 @@ func (r *WebhookRepository) Update(ctx context.Context, webhook *model.Webhook)
 		webhook.SuccessCount(),
 		webhook.FailureCount(),
 		webhook.UpdatedAt(),
+		nullString(webhook.Path()),
+		string(webhook.Method()),
+		headersJSON,
+		string(webhook.AuthenticationType()),
+		authJSON,
+		webhook.LastTriggeredAt(),
+	)
+
+	return uniqueViolation(err, "failed to update webhook")
+}
+
+// webhookColumns are the columns scanWebhook reads
+const webhookColumns = `
+	id, user_id, COALESCE(workflow_id::text, ''), COALESCE(trigger_node_id, ''), name,
+	endpoint_url, COALESCE(path, ''), COALESCE(secret, ''), method, COALESCE(headers, '{}'),
+	COALESCE(authentication_type, ''), COALESCE(authentication_config, '{}'), status,
+	last_triggered_at, COALESCE(trigger_count, 0), COALESCE(success_count, 0),
+	COALESCE(failure_count, 0), created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhook(row rowScanner) (*model.Webhook, error) {
+	var (
+		id, userID, workflowID, triggerNodeID, name string
+		endpointURL, path, secret, method, authType string
+		status                                      string
+		headersJSON, authJSON                       []byte
+		lastTriggeredAt                             sql.NullTime
+		triggerCount, successCount, failureCount    int64
+		createdAt, updatedAt                        time.Time
+	)
+	err := row.Scan(
+		&id, &userID, &workflowID, &triggerNodeID, &name,
+		&endpointURL, &path, &secret, &method, &headersJSON,
+		&authType, &authJSON, &status,
+		&lastTriggeredAt, &triggerCount, &successCount,
+		&failureCount, &createdAt, &updatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal(headersJSON, &headers); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook headers: %w", err)
+	}
+	var authConfig map[string]interface{}
+	if err := json.Unmarshal(authJSON, &authConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook authentication: %w", err)
+	}
+	var triggeredAt *time.Time
+	if lastTriggeredAt.Valid {
+		triggeredAt = &lastTriggeredAt.Time
+	}
+
+	return model.ReconstructWebhook(
+		model.WebhookID(id), userID, workflowID, triggerNodeID, name,
+		endpointURL, path, secret, model.WebhookMethod(method), headers,
+		model.AuthenticationType(authType), authConfig, model.WebhookStatus(status),
+		triggeredAt, triggerCount, successCount, failureCount, createdAt, updatedAt,
+	), nil
+}
 
-	return err
+func (r *WebhookRepository) findOne(ctx context.Context, where string, args ...interface{}) (*model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhook_service.webhooks WHERE ` + where
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (r *WebhookRepository) findMany(ctx context.Context, where string, args ...interface{}) ([]*model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhook_service.webhooks WHERE ` + where
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*model.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
 }
 
 func (r *WebhookRepository) FindByID(ctx context.Context, id model.WebhookID) (*model.Webhook, error) {
-	// Simplified implementation - would need full implementation
-	return nil, repository.ErrNotFound
+	return r.findOne(ctx, `id = $1`, id.String())
 }
 
 func (r *WebhookRepository) FindByUserID(ctx context.Context, userID string, offset, limit int) ([]*model.Webhook, error) {
-	return nil, nil
+	return r.findMany(ctx, `user_id = $1 ORDER BY created_at DESC OFFSET $2 LIMIT $3`, userID, offset, limit)
 }
 
 func (r *WebhookRepository) FindByWorkflowID(ctx context.Context, workflowID string, offset, limit int) ([]*model.Webhook, error) {
-	return nil, nil
+	return r.findMany(ctx, `workflow_id = $1 ORDER BY created_at DESC OFFSET $2 LIMIT $3`, workflowID, offset, limit)
 }
 
 func (r *WebhookRepository) FindByURL(ctx context.Context, url string) (*model.Webhook, error) {
-	return nil, repository.ErrNotFound
+	return r.findOne(ctx, `endpoint_url = $1`, url)
+}
+
+func (r *WebhookRepository) FindByPath(ctx context.Context, path string) (*model.Webhook, error) {
+	return r.findOne(ctx, `path = $1 AND status = 'active'`, path)
+}
+
+func (r *WebhookRepository) DeactivateByWorkflowID(ctx context.Context, workflowID string) error {
+	query := `
+		UPDATE webhook_service.webhooks SET status = 'inactive', updated_at = NOW()
+		WHERE workflow_id = $1 AND status = 'active'`
+
+	if _, err := r.db.ExecContext(ctx, query, workflowID); err != nil {
+		return fmt.Errorf("failed to deactivate webhooks: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, id model.WebhookID, key string, window time.Duration) (bool, error) {
+	cutoff := time.Now().Add(-window)
+
+	// Keys older than the window are forgotten; deliveries replayed later
+	// than that are turned away by their timestamp or token expiry instead
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM webhook_service.webhook_deliveries WHERE webhook_id = $1 AND received_at < $2`,
+		id.String(), cutoff)
+	if err != nil {
+		return false, fmt.Errorf("failed to expire webhook delivery: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_service.webhook_deliveries (webhook_id, delivery_key, received_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (webhook_id, delivery_key) DO NOTHING`,
+		id.String(), key)
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return claimed == 1, nil
+}
+
+func marshalWebhookConfig(webhook *model.Webhook) ([]byte, []byte, error) {
+	headersJSON, err := json.Marshal(webhook.Headers())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode webhook headers: %w", err)
+	}
+	authJSON, err := json.Marshal(webhook.AuthConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode webhook authentication: %w", err)
+	}
+	return headersJSON, authJSON, nil
+}
+
+func uniqueViolation(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // Unique violation
+		if pqErr.Constraint == "idx_webhooks_active_path" {
+			return repository.ErrPathTaken
+		}
+		return repository.ErrDuplicateURL
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
 
 func (r *WebhookRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
@@ -85,7 +269,7 @@ func (r *WebhookRepository) CountByUserID(ctx context.Context, userID string) (i
 }
 
 func (r *WebhookRepository) Delete(ctx context.Context, id model.WebhookID) error {
-	query := `DELETE FROM webhooks WHERE id = $1`
+	query := `DELETE FROM webhook_service.webhooks WHERE id = $1`
 	
 	result, err := r.db.ExecContext(ctx, query, id.String())
 	if err != nil {
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/webhook/domain/model"
+	"github.com/linkflow-ai/linkflow-ai/internal/webhook/domain/repository"
+)
+
+var (
+	ErrRouteNotFound    = errors.New("webhook route not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrReplayed         = errors.New("webhook delivery already received")
+)
+
+// Route registry defaults
+const (
+	// Routes are cached this long, so a route changed on another replica
+	// is served at most this long in its old state
+	defaultRouteCacheTTL = 10 * time.Second
+	// Delivery keys are remembered this long for replay protection
+	defaultReplayWindow = 24 * time.Hour
+	// Upper bound of the routes a single workflow can serve
+	maxWorkflowRoutes = 100
+)
+
+// RouteSpec is a webhook trigger node of a workflow and the route it serves
+type RouteSpec struct {
+	TriggerNodeID string
+	Path          string
+	Method        string
+	AuthType      string
+	AuthConfig    map[string]interface{}
+}
+
+// RouteRegistry maps inbound webhook calls to the workflows serving them.
+// Routes are kept by the webhook repository, so they survive restarts and
+// every replica resolves them the same way.
+type RouteRegistry struct {
+	repository   repository.WebhookRepository
+	cacheTTL     time.Duration
+	replayWindow time.Duration
+
+	mu     sync.Mutex
+	routes map[string]cachedRoute
+}
+
+// cachedRoute is a resolved path; webhook is nil for paths served by no
+// workflow, so unknown paths do not reach the database on every call
+type cachedRoute struct {
+	webhook *model.Webhook
+	expires time.Time
+}
+
+// NewRouteRegistry creates a route registry backed by repository
+func NewRouteRegistry(repository repository.WebhookRepository) *RouteRegistry {
+	return &RouteRegistry{
+		repository:   repository,
+		cacheTTL:     defaultRouteCacheTTL,
+		replayWindow: defaultReplayWindow,
+		routes:       make(map[string]cachedRoute),
+	}
+}
+
+// WithReplayWindow sets how long delivery keys are remembered
+func (r *RouteRegistry) WithReplayWindow(window time.Duration) *RouteRegistry {
+	r.replayWindow = window
+	return r
+}
+
+// SyncWorkflow makes the routes of a workflow match specs: routes of
+// trigger nodes no longer in specs are deactivated and the others are
+// created or updated and activated. It fails with repository.ErrPathTaken
+// when another workflow serves one of the paths.
+func (r *RouteRegistry) SyncWorkflow(ctx context.Context, userID, workflowID string, specs []RouteSpec) error {
+	existing, err := r.repository.FindByWorkflowID(ctx, workflowID, 0, maxWorkflowRoutes)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook routes: %w", err)
+	}
+	byNode := make(map[string]*model.Webhook, len(existing))
+	for _, webhook := range existing {
+		if webhook.TriggerNodeID() != "" {
+			byNode[webhook.TriggerNodeID()] = webhook
+		}
+	}
+
+	wanted := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		wanted[spec.TriggerNodeID] = true
+	}
+
+	// Free the paths of removed trigger nodes first, so that the remaining
+	// ones can move to them
+	for nodeID, webhook := range byNode {
+		if wanted[nodeID] || webhook.Status() != model.WebhookStatusActive {
+			continue
+		}
+		webhook.Deactivate()
+		if err := r.repository.Update(ctx, webhook); err != nil {
+			return fmt.Errorf("failed to remove webhook route %s: %w", webhook.Path(), err)
+		}
+		r.forget(webhook.Path())
+	}
+
+	for _, spec := range specs {
+		webhook, err := r.saveRoute(ctx, userID, workflowID, spec, byNode[spec.TriggerNodeID])
+		if err != nil {
+			return err
+		}
+		r.forget(webhook.Path())
+	}
+	return nil
+}
+
+func (r *RouteRegistry) saveRoute(ctx context.Context, userID, workflowID string, spec RouteSpec, webhook *model.Webhook) (*model.Webhook, error) {
+	method := model.WebhookMethod(spec.Method)
+	authType := model.AuthenticationType(spec.AuthType)
+	if authType == "" {
+		authType = model.AuthTypeNone
+	}
+
+	if webhook == nil {
+		webhook, err := model.NewTriggerWebhook(userID, workflowID, spec.TriggerNodeID, spec.Path, method)
+		if err != nil {
+			return nil, err
+		}
+		webhook.SetAuthentication(authType, spec.AuthConfig)
+		if err := r.repository.Save(ctx, webhook); err != nil {
+			return nil, fmt.Errorf("failed to add webhook route %s: %w", webhook.Path(), err)
+		}
+		return webhook, nil
+	}
+
+	previous := webhook.Path()
+	if err := webhook.SetRoute(spec.Path, method); err != nil {
+		return nil, err
+	}
+	webhook.SetAuthentication(authType, spec.AuthConfig)
+	webhook.Activate()
+	if err := r.repository.Update(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to update webhook route %s: %w", webhook.Path(), err)
+	}
+	r.forget(previous)
+	return webhook, nil
+}
+
+// UnregisterWorkflow stops serving every route of a workflow
+func (r *RouteRegistry) UnregisterWorkflow(ctx context.Context, workflowID string) error {
+	if err := r.repository.DeactivateByWorkflowID(ctx, workflowID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	for path, route := range r.routes {
+		if route.webhook != nil && route.webhook.WorkflowID() == workflowID {
+			delete(r.routes, path)
+		}
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// Resolve returns the route serving a call with method to path
+func (r *RouteRegistry) Resolve(ctx context.Context, method, path string) (*model.Webhook, error) {
+	path, err := model.NormalizePath(path)
+	if err != nil {
+		return nil, ErrRouteNotFound
+	}
+
+	webhook, err := r.lookup(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil || webhook.Status() != model.WebhookStatusActive {
+		return nil, ErrRouteNotFound
+	}
+	if !webhook.Accepts(method) {
+		return nil, ErrMethodNotAllowed
+	}
+	return webhook, nil
+}
+
+func (r *RouteRegistry) lookup(ctx context.Context, path string) (*model.Webhook, error) {
+	now := time.Now()
+	r.mu.Lock()
+	route, ok := r.routes[path]
+	r.mu.Unlock()
+	if ok && now.Before(route.expires) {
+		return route.webhook, nil
+	}
+
+	webhook, err := r.repository.FindByPath(ctx, path)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.routes[path] = cachedRoute{webhook: webhook, expires: now.Add(r.cacheTTL)}
+	r.mu.Unlock()
+	return webhook, nil
+}
+
+func (r *RouteRegistry) forget(path string) {
+	r.mu.Lock()
+	delete(r.routes, path)
+	r.mu.Unlock()
+}
+
+// Authenticate checks a call against the authentication of its route and
+// turns away deliveries already received on it. Calls failing
+// authentication get ErrUnauthorized, replays ErrReplayed.
+func (r *RouteRegistry) Authenticate(ctx context.Context, webhook *model.Webhook, header http.Header, body []byte) error {
+	key, err := webhook.Authenticate(model.Delivery{Header: header, Body: body, Time: time.Now()})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	if key == "" {
+		return nil
+	}
+
+	// Keys are chosen by senders, so only their digest is stored
+	sum := sha256.Sum256([]byte(key))
+	claimed, err := r.repository.ClaimDelivery(ctx, webhook.ID(), hex.EncodeToString(sum[:]), r.replayWindow)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrReplayed
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/webhook/domain/model"
+	"github.com/linkflow-ai/linkflow-ai/internal/webhook/domain/repository"
+)
+
+// memoryRepository keeps webhooks and claimed deliveries in memory
+type memoryRepository struct {
+	mu         sync.Mutex
+	webhooks   map[model.WebhookID]*model.Webhook
+	deliveries map[string]time.Time
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		webhooks:   make(map[model.WebhookID]*model.Webhook),
+		deliveries: make(map[string]time.Time),
+	}
+}
+
+func (r *memoryRepository) Save(ctx context.Context, webhook *model.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.webhooks {
+		if other.Status() == model.WebhookStatusActive && other.Path() == webhook.Path() && other.WorkflowID() != webhook.WorkflowID() {
+			return repository.ErrPathTaken
+		}
+	}
+	r.webhooks[webhook.ID()] = webhook
+	return nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, webhook *model.Webhook) error {
+	return r.Save(ctx, webhook)
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id model.WebhookID) (*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if webhook, ok := r.webhooks[id]; ok {
+		return webhook, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryRepository) FindByUserID(ctx context.Context, userID string, offset, limit int) ([]*model.Webhook, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) FindByWorkflowID(ctx context.Context, workflowID string, offset, limit int) ([]*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var webhooks []*model.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.WorkflowID() == workflowID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *memoryRepository) FindByURL(ctx context.Context, url string) (*model.Webhook, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id model.WebhookID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *memoryRepository) FindByPath(ctx context.Context, path string) (*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, webhook := range r.webhooks {
+		if webhook.Status() == model.WebhookStatusActive && webhook.Path() == path {
+			return webhook, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryRepository) DeactivateByWorkflowID(ctx context.Context, workflowID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, webhook := range r.webhooks {
+		if webhook.WorkflowID() == workflowID {
+			webhook.Deactivate()
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) ClaimDelivery(ctx context.Context, id model.WebhookID, key string, window time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claimKey := id.String() + "/" + key
+	if seen, ok := r.deliveries[claimKey]; ok && time.Since(seen) < window {
+		return false, nil
+	}
+	r.deliveries[claimKey] = time.Now()
+	return true, nil
+}
+
+func TestRouteRegistry_RejectsReplayedSignedDeliveries(t *testing.T) {
+	ctx := context.Background()
+	registry := NewRouteRegistry(newMemoryRepository())
+	require.NoError(t, registry.SyncWorkflow(ctx, "user-1", "wf-1", []RouteSpec{{
+		TriggerNodeID: "webhook",
+		Path:          "orders",
+		Method:        "POST",
+		AuthType:      "hmac",
+		AuthConfig:    map[string]interface{}{"secret": "s3cret"},
+	}}))
+
+	webhook, err := registry.Resolve(ctx, http.MethodPost, "/orders")
+	require.NoError(t, err)
+
+	body := []byte(`{"order":42}`)
+	now := time.Now().Unix()
+	signed := func(idempotencyKey string) http.Header {
+		header := http.Header{}
+		header.Set(model.DefaultTimestampHeader, strconv.FormatInt(now, 10))
+		header.Set(model.DefaultSignatureHeader, model.TimestampedSignature("s3cret", now, body))
+		header.Set(model.DefaultIdempotencyHeader, idempotencyKey)
+		return header
+	}
+
+	require.NoError(t, registry.Authenticate(ctx, webhook, signed("first"), body))
+
+	// Only the idempotency key of the captured delivery changed
+	assert.ErrorIs(t, registry.Authenticate(ctx, webhook, signed("second"), body), ErrReplayed)
+	assert.ErrorIs(t, registry.Authenticate(ctx, webhook, signed(""), body), ErrReplayed)
+
+	// Unsigned deliveries are turned away before any key is claimed
+	assert.ErrorIs(t, registry.Authenticate(ctx, webhook, http.Header{}, body), ErrUnauthorized)
+}
+
+func TestRouteRegistry_RejectsReplayedIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	registry := NewRouteRegistry(newMemoryRepository())
+	require.NoError(t, registry.SyncWorkflow(ctx, "user-1", "wf-1", []RouteSpec{{
+		TriggerNodeID: "webhook",
+		Path:          "events",
+		Method:        "POST",
+	}}))
+
+	webhook, err := registry.Resolve(ctx, http.MethodPost, "/events")
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set(model.DefaultIdempotencyHeader, "evt-1")
+	require.NoError(t, registry.Authenticate(ctx, webhook, header, nil))
+	assert.ErrorIs(t, registry.Authenticate(ctx, webhook, header, nil), ErrReplayed)
+
+	// Deliveries without a key are not de-duplicated
+	require.NoError(t, registry.Authenticate(ctx, webhook, http.Header{}, nil))
+	require.NoError(t, registry.Authenticate(ctx, webhook, http.Header{}, nil))
+}
+
+func TestRouteRegistry_SyncWorkflowFollowsEditedTriggers(t *testing.T) {
+	ctx := context.Background()
+	registry := NewRouteRegistry(newMemoryRepository())
+	require.NoError(t, registry.SyncWorkflow(ctx, "user-1", "wf-1", []RouteSpec{
+		{TriggerNodeID: "orders", Path: "orders", Method: "POST"},
+		{TriggerNodeID: "refunds", Path: "refunds", Method: "POST"},
+	}))
+	_, err := registry.Resolve(ctx, http.MethodPost, "/orders")
+	require.NoError(t, err)
+
+	// The orders trigger moved and the refunds trigger was removed
+	require.NoError(t, registry.SyncWorkflow(ctx, "user-1", "wf-1", []RouteSpec{
+		{TriggerNodeID: "orders", Path: "orders/v2", Method: "PUT"},
+	}))
+
+	webhook, err := registry.Resolve(ctx, http.MethodPut, "/orders/v2")
+	require.NoError(t, err)
+	assert.Equal(t, "orders", webhook.TriggerNodeID())
+	_, err = registry.Resolve(ctx, http.MethodPost, "/orders")
+	assert.Error(t, err)
+	_, err = registry.Resolve(ctx, http.MethodPost, "/refunds")
+	assert.Error(t, err)
+
+	// The freed path can be served by another workflow
+	require.NoError(t, registry.SyncWorkflow(ctx, "user-2", "wf-2", []RouteSpec{
+		{TriggerNodeID: "webhook", Path: "refunds", Method: "POST"},
+	}))
+	assert.ErrorIs(t, registry.SyncWorkflow(ctx, "user-2", "wf-2", []RouteSpec{
+		{TriggerNodeID: "webhook", Path: "orders/v2", Method: "POST"},
+	}), repository.ErrPathTaken)
+}
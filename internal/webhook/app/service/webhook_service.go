@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/linkflow-ai/linkflow-ai/internal/platform/cache"
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
 	"github.com/linkflow-ai/linkflow-ai/internal/platform/logger"
 	"github.com/linkflow-ai/linkflow-ai/internal/platform/messaging/kafka"
 	"github.com/linkflow-ai/linkflow-ai/internal/shared/events"
@@ -42,12 +43,17 @@ func NewWebhookService(
 		eventPublisher: eventPublisher,
 		cache:          cache,
 		logger:         logger,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient:     egress.Default().Client("", "webhook", 30*time.Second),
 	}
 }
 
+// WithEgressGuard holds outgoing webhook calls to the egress policy of the
+// workspace set on their context with egress.WithWorkspace
+func (s *WebhookService) WithEgressGuard(guard *egress.Guard) *WebhookService {
+	s.httpClient = guard.Client("", "webhook", 30*time.Second)
+	return s
+}
+
 type CreateWebhookCommand struct {
 	UserID           string
 	OrganizationID   string
@@ -0,0 +1,243 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Defaults of the inbound webhook authentication settings
+const (
+	DefaultTokenHeader       = "X-Webhook-Token"
+	DefaultSignatureHeader   = "X-Webhook-Signature"
+	DefaultTimestampHeader   = "X-Webhook-Timestamp"
+	DefaultIdempotencyHeader = "Idempotency-Key"
+	DefaultAPIKeyHeader      = "X-API-Key"
+	DefaultTimestampWindow   = 5 * time.Minute
+)
+
+var (
+	ErrAuthenticationFailed = errors.New("webhook authentication failed")
+	ErrStaleDelivery        = errors.New("webhook delivery timestamp outside the allowed window")
+)
+
+// Delivery is an inbound webhook call to authenticate
+type Delivery struct {
+	Header http.Header
+	Body   []byte
+	Time   time.Time
+}
+
+// Authenticate checks a delivery against the authentication settings of a
+// route and returns the key identifying the delivery for replay protection,
+// or "" when the sender gave none. secret is the route's signing secret,
+// used when config has no secret of its own.
+//
+// Supported settings by type:
+//   - header: token sent in headerName (default X-Webhook-Token)
+//   - basic: username and password
+//   - bearer: token sent as an Authorization bearer token
+//   - api_key: apiKey sent in headerName (default X-API-Key)
+//   - hmac: hex HMAC-SHA256 of "<timestamp>.<body>" sent in signatureHeader,
+//     the unix timestamp in timestampHeader and at most tolerance seconds
+//     (default 300) away from the delivery time
+//   - jwt: bearer JWT signed with secret (HS256/384/512) or publicKey
+//     (RS256/384/512, PEM), optionally checked against issuer and audience
+//
+// Signed deliveries are keyed by what was verified: the HMAC signature, or
+// the JWT ID (the token itself when it has none), so a captured delivery
+// cannot be replayed under a new key. Other deliveries are keyed by
+// idempotencyHeader (default Idempotency-Key), which the sender chooses.
+func Authenticate(authType AuthenticationType, config map[string]interface{}, secret string, d Delivery) (string, error) {
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+	key := d.Header.Get(configString(config, "idempotencyHeader", DefaultIdempotencyHeader))
+
+	switch authType {
+	case "", AuthTypeNone:
+		return key, nil
+
+	case AuthTypeHeader:
+		got := d.Header.Get(configString(config, "headerName", DefaultTokenHeader))
+		if !secureEqual(got, configString(config, "token", secret)) {
+			return "", ErrAuthenticationFailed
+		}
+		return key, nil
+
+	case AuthTypeAPIKey:
+		got := d.Header.Get(configString(config, "headerName", DefaultAPIKeyHeader))
+		if !secureEqual(got, configString(config, "apiKey", secret)) {
+			return "", ErrAuthenticationFailed
+		}
+		return key, nil
+
+	case AuthTypeBearer:
+		if !secureEqual(bearerToken(d.Header), configString(config, "token", secret)) {
+			return "", ErrAuthenticationFailed
+		}
+		return key, nil
+
+	case AuthTypeBasic:
+		user, pass, ok := (&http.Request{Header: d.Header}).BasicAuth()
+		wantUser, wantPass := configString(config, "username", ""), configString(config, "password", "")
+		// Evaluate both comparisons so timing does not reveal which failed
+		userOK, passOK := secureEqual(user, wantUser), secureEqual(pass, wantPass)
+		if !ok || !userOK || !passOK {
+			return "", ErrAuthenticationFailed
+		}
+		return key, nil
+
+	case AuthTypeHMAC:
+		return verifyTimestampedHMAC(config, secret, d)
+
+	case AuthTypeJWT:
+		return verifyJWT(config, secret, d)
+	}
+
+	return "", fmt.Errorf("unsupported authentication type: %s", authType)
+}
+
+// Authenticate checks a delivery against the webhook's authentication
+func (w *Webhook) Authenticate(d Delivery) (string, error) {
+	return Authenticate(w.authenticationType, w.authConfig, w.secret, d)
+}
+
+// TimestampedSignature signs body as sent at timestamp, as the hmac
+// authentication expects it
+func TimestampedSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyTimestampedHMAC(config map[string]interface{}, secret string, d Delivery) (string, error) {
+	secret = configString(config, "secret", secret)
+	if secret == "" {
+		return "", ErrAuthenticationFailed
+	}
+
+	signature := d.Header.Get(configString(config, "signatureHeader", DefaultSignatureHeader))
+	signature = strings.TrimPrefix(signature, "sha256=")
+	stamp := d.Header.Get(configString(config, "timestampHeader", DefaultTimestampHeader))
+	if signature == "" || stamp == "" {
+		return "", ErrAuthenticationFailed
+	}
+	timestamp, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return "", ErrAuthenticationFailed
+	}
+
+	window := DefaultTimestampWindow
+	if seconds := configInt(config, "tolerance"); seconds > 0 {
+		window = time.Duration(seconds) * time.Second
+	}
+	if skew := d.Time.Sub(time.Unix(timestamp, 0)); skew > window || skew < -window {
+		return "", ErrStaleDelivery
+	}
+
+	expected := TimestampedSignature(secret, timestamp, d.Body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return "", ErrAuthenticationFailed
+	}
+	return "hmac:" + expected, nil
+}
+
+func verifyJWT(config map[string]interface{}, secret string, d Delivery) (string, error) {
+	tokenString := bearerToken(d.Header)
+	if tokenString == "" {
+		return "", ErrAuthenticationFailed
+	}
+
+	var (
+		methods []string
+		key     interface{}
+	)
+	if pem := configString(config, "publicKey", ""); pem != "" {
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM([]byte(pem))
+		if err != nil {
+			return "", fmt.Errorf("invalid JWT public key: %w", err)
+		}
+		methods, key = []string{"RS256", "RS384", "RS512"}, publicKey
+	} else {
+		secret = configString(config, "secret", secret)
+		if secret == "" {
+			return "", ErrAuthenticationFailed
+		}
+		methods, key = []string{"HS256", "HS384", "HS512"}, []byte(secret)
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithTimeFunc(func() time.Time { return d.Time }),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if issuer := configString(config, "issuer", ""); issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience := configString(config, "audience", ""); audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return key, nil
+	}, options...)
+	if err != nil || !token.Valid {
+		return "", ErrAuthenticationFailed
+	}
+	if claims.ID == "" {
+		return "jwt:" + tokenString, nil
+	}
+	return "jti:" + claims.ID, nil
+}
+
+func bearerToken(header http.Header) string {
+	auth := header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// secureEqual compares a credential in constant time; an empty expected
+// value never matches
+func secureEqual(got, want string) bool {
+	if want == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+func configString(config map[string]interface{}, key, defaultValue string) string {
+	if v, ok := config[key].(string); ok && v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+func configInt(config map[string]interface{}, key string) int {
+	switch v := config[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
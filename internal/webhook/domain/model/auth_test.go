@@ -0,0 +1,119 @@
+package model
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signedDelivery(secret string, at time.Time, body, idempotencyKey string) Delivery {
+	header := http.Header{}
+	header.Set(DefaultTimestampHeader, strconv.FormatInt(at.Unix(), 10))
+	header.Set(DefaultSignatureHeader, "sha256="+TimestampedSignature(secret, at.Unix(), []byte(body)))
+	if idempotencyKey != "" {
+		header.Set(DefaultIdempotencyHeader, idempotencyKey)
+	}
+	return Delivery{Header: header, Body: []byte(body), Time: at}
+}
+
+func TestAuthenticate_KeysHMACDeliveriesBySignature(t *testing.T) {
+	now := time.Now()
+	config := map[string]interface{}{"secret": "s3cret"}
+
+	key, err := Authenticate(AuthTypeHMAC, config, "", signedDelivery("s3cret", now, `{"id":1}`, "first"))
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+
+	// Resending the captured delivery under another idempotency key, or
+	// none, yields the same key
+	replayed, err := Authenticate(AuthTypeHMAC, config, "", signedDelivery("s3cret", now, `{"id":1}`, "second"))
+	require.NoError(t, err)
+	assert.Equal(t, key, replayed)
+	replayed, err = Authenticate(AuthTypeHMAC, config, "", signedDelivery("s3cret", now, `{"id":1}`, ""))
+	require.NoError(t, err)
+	assert.Equal(t, key, replayed)
+
+	// A different signed body is a different delivery
+	other, err := Authenticate(AuthTypeHMAC, config, "", signedDelivery("s3cret", now, `{"id":2}`, "first"))
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	// Wrong secret, tampered body and stale timestamps are rejected
+	_, err = Authenticate(AuthTypeHMAC, config, "", signedDelivery("other", now, `{"id":1}`, ""))
+	assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	tampered := signedDelivery("s3cret", now, `{"id":1}`, "")
+	tampered.Body = []byte(`{"id":3}`)
+	_, err = Authenticate(AuthTypeHMAC, config, "", tampered)
+	assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	stale := signedDelivery("s3cret", now.Add(-10*time.Minute), `{"id":1}`, "")
+	stale.Time = now
+	_, err = Authenticate(AuthTypeHMAC, config, "", stale)
+	assert.ErrorIs(t, err, ErrStaleDelivery)
+}
+
+func TestAuthenticate_KeysJWTDeliveriesByTokenID(t *testing.T) {
+	config := map[string]interface{}{"secret": "jwt-secret", "issuer": "partner"}
+	token := func(id string) string {
+		claims := jwt.RegisteredClaims{
+			ID:        id,
+			Issuer:    "partner",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("jwt-secret"))
+		require.NoError(t, err)
+		return signed
+	}
+	delivery := func(token, idempotencyKey string) Delivery {
+		header := http.Header{}
+		header.Set("Authorization", "Bearer "+token)
+		header.Set(DefaultIdempotencyHeader, idempotencyKey)
+		return Delivery{Header: header}
+	}
+
+	withID := token("evt-1")
+	key, err := Authenticate(AuthTypeJWT, config, "", delivery(withID, "first"))
+	require.NoError(t, err)
+	replayed, err := Authenticate(AuthTypeJWT, config, "", delivery(withID, "second"))
+	require.NoError(t, err)
+	assert.Equal(t, key, replayed)
+
+	// Tokens without an ID are recognized by the token itself
+	withoutID := token("")
+	key, err = Authenticate(AuthTypeJWT, config, "", delivery(withoutID, "first"))
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+	replayed, err = Authenticate(AuthTypeJWT, config, "", delivery(withoutID, "second"))
+	require.NoError(t, err)
+	assert.Equal(t, key, replayed)
+
+	_, err = Authenticate(AuthTypeJWT, map[string]interface{}{"secret": "jwt-secret", "issuer": "someone-else"}, "", delivery(withID, ""))
+	assert.ErrorIs(t, err, ErrAuthenticationFailed)
+}
+
+func TestAuthenticate_KeysUnsignedDeliveriesByIdempotencyHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set(DefaultIdempotencyHeader, "order-42")
+	header.Set(DefaultTokenHeader, "token")
+	req := &http.Request{Header: header}
+	req.SetBasicAuth("user", "pass")
+
+	key, err := Authenticate(AuthTypeNone, nil, "", Delivery{Header: header})
+	require.NoError(t, err)
+	assert.Equal(t, "order-42", key)
+
+	key, err = Authenticate(AuthTypeBasic, map[string]interface{}{"username": "user", "password": "pass"}, "", Delivery{Header: header})
+	require.NoError(t, err)
+	assert.Equal(t, "order-42", key)
+
+	key, err = Authenticate(AuthTypeHeader, map[string]interface{}{"token": "token"}, "", Delivery{Header: header})
+	require.NoError(t, err)
+	assert.Equal(t, "order-42", key)
+
+	_, err = Authenticate(AuthTypeBasic, map[string]interface{}{"username": "user", "password": "other"}, "", Delivery{Header: header})
+	assert.ErrorIs(t, err, ErrAuthenticationFailed)
+}
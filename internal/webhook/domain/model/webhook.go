@@ -5,6 +5,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -37,6 +39,7 @@ const (
 	WebhookMethodPUT    WebhookMethod = "PUT"
 	WebhookMethodPATCH  WebhookMethod = "PATCH"
 	WebhookMethodDELETE WebhookMethod = "DELETE"
+	WebhookMethodANY    WebhookMethod = "ANY" // Inbound routes accepting every method
 )
 
 type AuthenticationType string
@@ -47,6 +50,8 @@ const (
 	AuthTypeBearer AuthenticationType = "bearer"
 	AuthTypeAPIKey AuthenticationType = "api_key"
 	AuthTypeHMAC   AuthenticationType = "hmac"
+	AuthTypeHeader AuthenticationType = "header"
+	AuthTypeJWT    AuthenticationType = "jwt"
 )
 
 type RetryConfig struct {
@@ -63,6 +68,8 @@ type Webhook struct {
 	name               string
 	description        string
 	endpointURL        string
+	path               string // Path inbound calls are routed on, for workflow triggers
+	triggerNodeID      string // Webhook trigger node the route starts executions from
 	secret             string
 	method             WebhookMethod
 	headers            map[string]string
@@ -126,6 +133,110 @@ func NewWebhook(userID, workflowID, name, endpointURL string, method WebhookMeth
 	return webhook, nil
 }
 
+// NewTriggerWebhook creates the inbound route of a workflow's webhook
+// trigger node, served at path
+func NewTriggerWebhook(userID, workflowID, triggerNodeID, path string, method WebhookMethod) (*Webhook, error) {
+	if workflowID == "" || triggerNodeID == "" {
+		return nil, errors.New("workflow and trigger node are required")
+	}
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if method == "" {
+		method = WebhookMethodPOST
+	}
+
+	// The endpoint identifies the trigger node, so that its row is kept
+	// while the path it is served at changes
+	endpoint := "workflow://" + workflowID + "/" + triggerNodeID
+	webhook, err := NewWebhook(userID, workflowID, "Webhook trigger "+triggerNodeID, endpoint, method)
+	if err != nil {
+		return nil, err
+	}
+	webhook.path = path
+	webhook.triggerNodeID = triggerNodeID
+	return webhook, nil
+}
+
+// ReconstructWebhook reconstructs a webhook from persisted state
+func ReconstructWebhook(
+	id WebhookID,
+	userID string,
+	workflowID string,
+	triggerNodeID string,
+	name string,
+	endpointURL string,
+	path string,
+	secret string,
+	method WebhookMethod,
+	headers map[string]string,
+	authenticationType AuthenticationType,
+	authConfig map[string]interface{},
+	status WebhookStatus,
+	lastTriggeredAt *time.Time,
+	triggerCount, successCount, failureCount int64,
+	createdAt time.Time,
+	updatedAt time.Time,
+) *Webhook {
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	if authConfig == nil {
+		authConfig = make(map[string]interface{})
+	}
+	if authenticationType == "" {
+		authenticationType = AuthTypeNone
+	}
+	return &Webhook{
+		id:                 id,
+		userID:             userID,
+		workflowID:         workflowID,
+		triggerNodeID:      triggerNodeID,
+		name:               name,
+		endpointURL:        endpointURL,
+		path:               path,
+		secret:             secret,
+		method:             method,
+		headers:            headers,
+		queryParams:        make(map[string]string),
+		authenticationType: authenticationType,
+		authConfig:         authConfig,
+		retryConfig: RetryConfig{
+			MaxRetries:    3,
+			RetryDelay:    1 * time.Second,
+			BackoffFactor: 2.0,
+		},
+		timeoutMs:       30000,
+		status:          status,
+		lastTriggeredAt: lastTriggeredAt,
+		triggerCount:    triggerCount,
+		successCount:    successCount,
+		failureCount:    failureCount,
+		metadata:        make(map[string]interface{}),
+		createdAt:       createdAt,
+		updatedAt:       updatedAt,
+	}
+}
+
+// NormalizePath validates the path of an inbound route and gives it a
+// leading slash and no trailing one
+func NormalizePath(path string) (string, error) {
+	path = "/" + strings.Trim(strings.TrimSpace(path), "/")
+	if path == "/" {
+		return "", errors.New("webhook path is required")
+	}
+	if len(path) > 255 {
+		return "", errors.New("webhook path is too long")
+	}
+	for _, segment := range strings.Split(path[1:], "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid webhook path %q", path)
+		}
+	}
+	return path, nil
+}
+
 // Getters
 func (w *Webhook) ID() WebhookID                        { return w.id }
 func (w *Webhook) UserID() string                       { return w.userID }
@@ -144,6 +255,32 @@ func (w *Webhook) CreatedAt() time.Time                 { return w.createdAt }
 func (w *Webhook) UpdatedAt() time.Time                 { return w.updatedAt }
 func (w *Webhook) Version() int                         { return w.version }
 
+func (w *Webhook) Path() string                       { return w.path }
+func (w *Webhook) TriggerNodeID() string              { return w.triggerNodeID }
+func (w *Webhook) AuthConfig() map[string]interface{} { return w.authConfig }
+func (w *Webhook) LastTriggeredAt() *time.Time        { return w.lastTriggeredAt }
+
+// Accepts reports whether the route accepts calls with method
+func (w *Webhook) Accepts(method string) bool {
+	return w.method == WebhookMethodANY || strings.EqualFold(string(w.method), method)
+}
+
+// SetRoute moves the route to another path and method
+func (w *Webhook) SetRoute(path string, method WebhookMethod) error {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return err
+	}
+	if method == "" {
+		method = WebhookMethodPOST
+	}
+	w.path = path
+	w.method = method
+	w.updatedAt = time.Now()
+	w.version++
+	return nil
+}
+
 func (w *Webhook) SetEndpointURL(url string) error {
 	if url == "" {
 		return errors.New("endpoint URL cannot be empty")
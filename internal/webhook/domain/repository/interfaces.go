@@ -3,13 +3,15 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/linkflow-ai/linkflow-ai/internal/webhook/domain/model"
 )
 
 var (
-	ErrNotFound = errors.New("webhook not found")
+	ErrNotFound     = errors.New("webhook not found")
 	ErrDuplicateURL = errors.New("webhook URL already exists")
+	ErrPathTaken    = errors.New("webhook path is served by another workflow")
 )
 
 type WebhookRepository interface {
@@ -21,4 +23,12 @@ type WebhookRepository interface {
 	FindByURL(ctx context.Context, url string) (*model.Webhook, error)
 	CountByUserID(ctx context.Context, userID string) (int64, error)
 	Delete(ctx context.Context, id model.WebhookID) error
+
+	// FindByPath returns the active webhook served at path
+	FindByPath(ctx context.Context, path string) (*model.Webhook, error)
+	// DeactivateByWorkflowID deactivates every webhook of a workflow
+	DeactivateByWorkflowID(ctx context.Context, workflowID string) error
+	// ClaimDelivery records a delivery key seen on a webhook and reports
+	// false if it was already recorded within window
+	ClaimDelivery(ctx context.Context, id model.WebhookID, key string, window time.Duration) (bool, error)
 }
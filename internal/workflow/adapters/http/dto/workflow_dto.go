@@ -69,12 +69,24 @@ type Pagination struct {
 
 // NodeDTO represents a workflow node
 type NodeDTO struct {
-	ID          string                 `json:"id"`
-	Type        string                 `json:"type"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Config      map[string]interface{} `json:"config"`
-	Position    PositionDTO            `json:"position"`
+	ID          string                   `json:"id"`
+	Type        string                   `json:"type"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Config      map[string]interface{}   `json:"config"`
+	Position    PositionDTO              `json:"position"`
+	Settings    NodeSettingsDTO          `json:"settings"`
+	PinnedData  []map[string]interface{} `json:"pinnedData,omitempty"`
+}
+
+// NodeSettingsDTO represents node retry, timeout and error handling settings
+type NodeSettingsDTO struct {
+	RetryOnFail      bool   `json:"retryOnFail"`
+	MaxTries         int    `json:"maxTries,omitempty"`
+	WaitBetweenTries int    `json:"waitBetweenTries,omitempty"`
+	Backoff          string `json:"backoff,omitempty"`
+	Timeout          int    `json:"timeout,omitempty"`
+	OnError          string `json:"onError,omitempty"`
 }
 
 // PositionDTO represents node position
@@ -97,6 +109,8 @@ type SettingsDTO struct {
 	MaxExecutionTime int                    `json:"maxExecutionTime"`
 	RetryPolicy      RetryPolicyDTO         `json:"retryPolicy"`
 	ErrorHandling    string                 `json:"errorHandling"`
+	ErrorWorkflowID  string                 `json:"errorWorkflowId,omitempty"`
+	MaxConcurrency   int                    `json:"maxConcurrency,omitempty"`
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -331,6 +331,8 @@ func (h *WorkflowHandler) convertNodeDTOs(nodes []dto.NodeDTO) []model.Node {
 				X: n.Position.X,
 				Y: n.Position.Y,
 			},
+			Settings:   model.NodeSettings(n.Settings),
+			PinnedData: n.PinnedData,
 		}
 	}
 	return result
@@ -363,6 +365,8 @@ func (h *WorkflowHandler) convertNodesToDTO(nodes []model.Node) []dto.NodeDTO {
 				X: n.Position.X,
 				Y: n.Position.Y,
 			},
+			Settings:   dto.NodeSettingsDTO(n.Settings),
+			PinnedData: n.PinnedData,
 		}
 	}
 	return result
@@ -390,8 +394,10 @@ func (h *WorkflowHandler) convertSettingsToDTO(settings model.Settings) dto.Sett
 			BackoffType: settings.RetryPolicy.BackoffType,
 			Delay:       settings.RetryPolicy.Delay,
 		},
-		ErrorHandling: string(settings.ErrorHandling),
-		Metadata:      settings.Metadata,
+		ErrorHandling:   string(settings.ErrorHandling),
+		ErrorWorkflowID: settings.ErrorWorkflowID,
+		MaxConcurrency:  settings.MaxConcurrency,
+		Metadata:        settings.Metadata,
 	}
 }
 
@@ -49,12 +49,42 @@ const (
 
 // Node represents a workflow node
 type Node struct {
-	ID          string                 `json:"id"`
-	Type        NodeType               `json:"type"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Config      map[string]interface{} `json:"config"`
-	Position    Position               `json:"position"`
+	ID          string                   `json:"id"`
+	Type        NodeType                 `json:"type"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Config      map[string]interface{}   `json:"config"`
+	Position    Position                 `json:"position"`
+	Settings    NodeSettings             `json:"settings"`
+	PinnedData  []map[string]interface{} `json:"pinnedData,omitempty"` // Items output instead of running the node in manual executions
+}
+
+// NodeSettings controls retries, timeouts and error handling of a node
+type NodeSettings struct {
+	RetryOnFail      bool   `json:"retryOnFail"`
+	MaxTries         int    `json:"maxTries,omitempty"`
+	WaitBetweenTries int    `json:"waitBetweenTries,omitempty"` // Milliseconds
+	Backoff          string `json:"backoff,omitempty"`          // fixed, exponential, jitter
+	Timeout          int    `json:"timeout,omitempty"`          // Milliseconds per attempt
+	OnError          string `json:"onError,omitempty"`          // stop, continue, errorOutput
+}
+
+// Validate checks the node settings
+func (s NodeSettings) Validate() error {
+	if s.MaxTries < 0 || s.WaitBetweenTries < 0 || s.Timeout < 0 {
+		return errors.New("node retry and timeout settings cannot be negative")
+	}
+	switch s.Backoff {
+	case "", "fixed", "exponential", "jitter":
+	default:
+		return fmt.Errorf("unknown backoff strategy: %s", s.Backoff)
+	}
+	switch s.OnError {
+	case "", "stop", "continue", "errorOutput":
+	default:
+		return fmt.Errorf("unknown on-error mode: %s", s.OnError)
+	}
+	return nil
 }
 
 // Position represents node position in UI
@@ -77,6 +107,8 @@ type Settings struct {
 	MaxExecutionTime int                    `json:"maxExecutionTime"`
 	RetryPolicy      RetryPolicy            `json:"retryPolicy"`
 	ErrorHandling    ErrorHandlingStrategy  `json:"errorHandling"`
+	ErrorWorkflowID  string                 `json:"errorWorkflowId,omitempty"` // Started when an execution fails
+	MaxConcurrency   int                    `json:"maxConcurrency,omitempty"`  // Executions running at once, zero for no limit
 	Metadata         map[string]interface{} `json:"metadata"`
 }
 
@@ -299,6 +331,10 @@ func (w *Workflow) AddNode(node Node) error {
 		}
 	}
 	
+	if err := node.Settings.Validate(); err != nil {
+		return err
+	}
+	
 	// Validate node ID
 	if node.ID == "" {
 		node.ID = uuid.New().String()
@@ -430,6 +466,12 @@ func (w *Workflow) UpdateSettings(settings Settings) error {
 	if w.status == WorkflowStatusArchived {
 		return errors.New("cannot modify archived workflow")
 	}
+	if settings.ErrorWorkflowID != "" && WorkflowID(settings.ErrorWorkflowID) == w.id {
+		return errors.New("workflow cannot be its own error workflow")
+	}
+	if settings.MaxConcurrency < 0 {
+		return errors.New("max concurrency cannot be negative")
+	}
 	
 	w.settings = settings
 	w.updatedAt = time.Now()
@@ -467,8 +509,16 @@ func (w *Workflow) validateConnections() error {
 	return nil
 }
 
-// hasCycle detects cycles in the workflow
+// hasCycle detects cycles in the workflow. Cycles that lead back into a loop
+// node are its loop body and allowed.
 func (w *Workflow) hasCycle() bool {
+	loops := make(map[string]bool)
+	for _, node := range w.nodes {
+		if node.Type == NodeTypeLoop {
+			loops[node.ID] = true
+		}
+	}
+	
 	// Build adjacency list
 	adj := make(map[string][]string)
 	for _, conn := range w.connections {
@@ -489,7 +539,7 @@ func (w *Workflow) hasCycle() bool {
 				if hasCycleDFS(neighbor) {
 					return true
 				}
-			} else if recStack[neighbor] {
+			} else if recStack[neighbor] && !loops[neighbor] {
 				return true
 			}
 		}
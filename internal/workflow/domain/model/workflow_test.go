@@ -82,6 +82,11 @@ func TestWorkflowAddNode(t *testing.T) {
 	err = workflow.AddNode(node)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "already exists")
+
+	// Test invalid node settings
+	err = workflow.AddNode(Node{ID: "node-2", Type: NodeTypeAction, Settings: NodeSettings{OnError: "ignore"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "on-error mode")
 }
 
 func TestWorkflowAddConnection(t *testing.T) {
@@ -153,6 +158,26 @@ func TestWorkflowConnections(t *testing.T) {
 	assert.Len(t, connections, 2)
 }
 
+func TestWorkflowCycleThroughLoopNode(t *testing.T) {
+	workflow, err := NewWorkflow("user-123", "Test", "Description")
+	require.NoError(t, err)
+
+	workflow.AddNode(Node{ID: "start", Type: NodeTypeTrigger, Name: "Start"})
+	workflow.AddNode(Node{ID: "loop", Type: NodeTypeLoop, Name: "Loop"})
+	workflow.AddNode(Node{ID: "body", Type: NodeTypeAction, Name: "Body"})
+
+	workflow.AddConnection(Connection{SourceNodeID: "start", TargetNodeID: "loop"})
+	workflow.AddConnection(Connection{SourceNodeID: "loop", SourcePort: "loop", TargetNodeID: "body"})
+	workflow.AddConnection(Connection{SourceNodeID: "body", TargetNodeID: "loop"})
+	assert.False(t, workflow.hasCycle())
+
+	// A cycle that does not pass through a loop node is still rejected
+	workflow.AddNode(Node{ID: "other", Type: NodeTypeAction, Name: "Other"})
+	workflow.AddConnection(Connection{SourceNodeID: "body", TargetNodeID: "other"})
+	workflow.AddConnection(Connection{SourceNodeID: "other", TargetNodeID: "body"})
+	assert.True(t, workflow.hasCycle())
+}
+
 func TestWorkflowStatusTransitions(t *testing.T) {
 	workflow, err := NewWorkflow("user-123", "Test", "Description")
 	require.NoError(t, err)
@@ -226,3 +251,35 @@ func TestWorkflowArchive(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "archived")
 }
+
+func TestWorkflowErrorWorkflowSetting(t *testing.T) {
+	workflow, err := NewWorkflow("user-123", "Test", "Description")
+	require.NoError(t, err)
+
+	settings := workflow.Settings()
+	settings.ErrorWorkflowID = "error-handler"
+	err = workflow.UpdateSettings(settings)
+	assert.NoError(t, err)
+	assert.Equal(t, "error-handler", workflow.Settings().ErrorWorkflowID)
+
+	// A workflow can't handle its own failures
+	settings.ErrorWorkflowID = string(workflow.ID())
+	err = workflow.UpdateSettings(settings)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "own error workflow")
+}
+
+func TestWorkflowMaxConcurrencySetting(t *testing.T) {
+	workflow, err := NewWorkflow("user-123", "Test", "Description")
+	require.NoError(t, err)
+
+	settings := workflow.Settings()
+	settings.MaxConcurrency = 2
+	require.NoError(t, workflow.UpdateSettings(settings))
+	assert.Equal(t, 2, workflow.Settings().MaxConcurrency)
+
+	settings.MaxConcurrency = -1
+	err = workflow.UpdateSettings(settings)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "negative")
+}
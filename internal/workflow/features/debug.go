@@ -8,18 +8,24 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/linkflow-ai/linkflow-ai/internal/workflow/domain/model"
+	"github.com/linkflow-ai/linkflow-ai/internal/engine"
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
+	"github.com/linkflow-ai/linkflow-ai/pkg/expression"
 )
 
-// DebugSession represents an active debug session
+// errDebugStopped fails the node a stopped session was paused at
+var errDebugStopped = fmt.Errorf("debug session stopped")
+
+// DebugSession represents an active debug session. It is the node hook of
+// its debug run: nodes pause on entering it at breakpoints and steps.
 type DebugSession struct {
 	ID           string
 	WorkflowID   string
 	UserID       string
 	Status       DebugStatus
 	CurrentNode  string
-	Breakpoints  map[string]bool
-	WatchedVars  []string
+	Breakpoints  map[string]*Breakpoint // By node
+	Watches      []*WatchExpression
 	StepMode     StepMode
 	StartedAt    time.Time
 	LastActivity time.Time
@@ -27,6 +33,17 @@ type DebugSession struct {
 	State        *DebugState
 	History      []*DebugEvent
 	mu           sync.RWMutex
+
+	parser    *expression.Parser
+	onEvent   func(*DebugSession, *DebugEvent)
+	turn      chan struct{}      // Held by the node entering the session, so one node pauses at a time
+	resume    chan struct{}      // Closed to resume the paused node
+	editable  bool               // Whether the input of the paused node may be modified
+	modified  bool               // Whether it was
+	depth     int                // Of the node paused at
+	stepDepth int                // Of the node the last step started from
+	cursor    string             // Node to run to
+	cancel    context.CancelFunc // Cancels the debug run
 }
 
 // DebugStatus represents debug session status
@@ -45,10 +62,10 @@ type StepMode string
 
 const (
 	StepModeNone     StepMode = "none"
-	StepModeInto     StepMode = "step_into"     // Step into sub-workflows
-	StepModeOver     StepMode = "step_over"     // Step over sub-workflows
-	StepModeOut      StepMode = "step_out"      // Step out of current context
-	StepModeToNext   StepMode = "step_to_next"  // Step to next node
+	StepModeInto     StepMode = "step_into"      // Step into sub-workflows
+	StepModeOver     StepMode = "step_over"      // Step over sub-workflows
+	StepModeOut      StepMode = "step_out"       // Step out of current context
+	StepModeToNext   StepMode = "step_to_next"   // Step to next node
 	StepModeToCursor StepMode = "step_to_cursor" // Step to specific node
 )
 
@@ -58,17 +75,18 @@ type DebugState struct {
 	Variables    map[string]interface{}            `json:"variables"`
 	TriggerData  map[string]interface{}            `json:"triggerData"`
 	CurrentInput map[string]interface{}            `json:"currentInput"`
+	CurrentItems []map[string]interface{}          `json:"currentItems"`
 	CallStack    []StackFrame                      `json:"callStack"`
 }
 
 // StackFrame represents a frame in the call stack
 type StackFrame struct {
-	NodeID       string                 `json:"nodeId"`
-	NodeName     string                 `json:"nodeName"`
-	NodeType     string                 `json:"nodeType"`
-	Input        map[string]interface{} `json:"input"`
-	Depth        int                    `json:"depth"`
-	SubWorkflow  string                 `json:"subWorkflow,omitempty"`
+	NodeID      string                 `json:"nodeId"`
+	NodeName    string                 `json:"nodeName"`
+	NodeType    string                 `json:"nodeType"`
+	Input       map[string]interface{} `json:"input"`
+	Depth       int                    `json:"depth"`
+	SubWorkflow string                 `json:"subWorkflow,omitempty"`
 }
 
 // DebugEvent represents a debug event
@@ -91,6 +109,12 @@ const (
 	DebugEventBreakpointHit  DebugEventType = "breakpoint_hit"
 	DebugEventVariableChange DebugEventType = "variable_change"
 	DebugEventStepComplete   DebugEventType = "step_complete"
+	DebugEventResumed        DebugEventType = "resumed"
+	DebugEventInputModified  DebugEventType = "input_modified"
+	DebugEventLog            DebugEventType = "log"
+	DebugEventWatch          DebugEventType = "watch"
+	DebugEventExecutionStart DebugEventType = "execution_start"
+	DebugEventExecutionEnd   DebugEventType = "execution_end"
 	DebugEventSessionStart   DebugEventType = "session_start"
 	DebugEventSessionEnd     DebugEventType = "session_end"
 )
@@ -99,12 +123,12 @@ const (
 type Breakpoint struct {
 	ID         string                 `json:"id"`
 	NodeID     string                 `json:"nodeId"`
-	Condition  string                 `json:"condition,omitempty"`
+	Condition  string                 `json:"condition,omitempty"` // Expression without {{ }}, e.g. $json.amount > 100
 	HitCount   int                    `json:"hitCount"`
 	MaxHits    int                    `json:"maxHits,omitempty"`
 	Enabled    bool                   `json:"enabled"`
-	LogMessage string                 `json:"logMessage,omitempty"`
-	Actions    []BreakpointAction     `json:"actions,omitempty"`
+	LogMessage string                 `json:"logMessage,omitempty"` // Template, e.g. Amount: {{ $json.amount }}
+	Actions    []BreakpointAction     `json:"actions,omitempty"`    // Defaults to pause
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -112,16 +136,16 @@ type Breakpoint struct {
 type BreakpointAction string
 
 const (
-	BreakpointActionPause   BreakpointAction = "pause"
-	BreakpointActionLog     BreakpointAction = "log"
-	BreakpointActionEval    BreakpointAction = "eval"
-	BreakpointActionModify  BreakpointAction = "modify"
+	BreakpointActionPause  BreakpointAction = "pause"
+	BreakpointActionLog    BreakpointAction = "log"    // Record the log message
+	BreakpointActionEval   BreakpointAction = "eval"   // Record the values of the watch expressions
+	BreakpointActionModify BreakpointAction = "modify" // Pause, letting the input of the node be modified
 )
 
 // WatchExpression represents a watch expression
 type WatchExpression struct {
 	ID         string      `json:"id"`
-	Expression string      `json:"expression"`
+	Expression string      `json:"expression"` // Without {{ }}
 	Value      interface{} `json:"value,omitempty"`
 	Error      string      `json:"error,omitempty"`
 	LastEval   time.Time   `json:"lastEval,omitempty"`
@@ -129,9 +153,11 @@ type WatchExpression struct {
 
 // DebugManager manages debug sessions
 type DebugManager struct {
-	sessions   map[string]*DebugSession
-	mu         sync.RWMutex
+	sessions    map[string]*DebugSession
+	mu          sync.RWMutex
 	maxSessions int
+	parser      *expression.Parser
+	onEvent     func(*DebugSession, *DebugEvent)
 }
 
 // NewDebugManager creates a new debug manager
@@ -139,9 +165,18 @@ func NewDebugManager() *DebugManager {
 	return &DebugManager{
 		sessions:    make(map[string]*DebugSession),
 		maxSessions: 100,
+		parser:      expression.NewParser(),
 	}
 }
 
+// WithEventHandler sets the handler the events of every session are passed
+// to as they occur, e.g. to stream them to the client debugging. It is
+// called with the session locked and must not call back into it.
+func (m *DebugManager) WithEventHandler(handler func(*DebugSession, *DebugEvent)) *DebugManager {
+	m.onEvent = handler
+	return m
+}
+
 // CreateSession creates a new debug session
 func (m *DebugManager) CreateSession(workflowID, userID string) (*DebugSession, error) {
 	m.mu.Lock()
@@ -149,7 +184,7 @@ func (m *DebugManager) CreateSession(workflowID, userID string) (*DebugSession,
 
 	// Check if session already exists for this workflow/user
 	for _, session := range m.sessions {
-		if session.WorkflowID == workflowID && session.UserID == userID && session.Status != DebugStatusStopped {
+		if session.WorkflowID == workflowID && session.UserID == userID && session.status() != DebugStatusStopped {
 			return nil, fmt.Errorf("debug session already exists for this workflow")
 		}
 	}
@@ -159,8 +194,8 @@ func (m *DebugManager) CreateSession(workflowID, userID string) (*DebugSession,
 		WorkflowID:   workflowID,
 		UserID:       userID,
 		Status:       DebugStatusIdle,
-		Breakpoints:  make(map[string]bool),
-		WatchedVars:  make([]string, 0),
+		Breakpoints:  make(map[string]*Breakpoint),
+		Watches:      make([]*WatchExpression, 0),
 		StepMode:     StepModeNone,
 		StartedAt:    time.Now(),
 		LastActivity: time.Now(),
@@ -170,11 +205,16 @@ func (m *DebugManager) CreateSession(workflowID, userID string) (*DebugSession,
 			CallStack:   make([]StackFrame, 0),
 		},
 		History: make([]*DebugEvent, 0),
+		parser:  m.parser,
+		onEvent: m.onEvent,
+		turn:    make(chan struct{}, 1),
 	}
 
 	m.sessions[session.ID] = session
 
+	session.mu.Lock()
 	session.addEvent(DebugEventSessionStart, "", "Debug session started", nil)
+	session.mu.Unlock()
 
 	// Cleanup if too many sessions
 	if len(m.sessions) > m.maxSessions {
@@ -192,7 +232,7 @@ func (m *DebugManager) GetSession(sessionID string) (*DebugSession, bool) {
 	return session, exists
 }
 
-// EndSession ends a debug session
+// EndSession ends a debug session, stopping its debug run
 func (m *DebugManager) EndSession(sessionID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -202,8 +242,10 @@ func (m *DebugManager) EndSession(sessionID string) error {
 		return fmt.Errorf("session not found")
 	}
 
-	session.Status = DebugStatusStopped
+	session.Stop()
+	session.mu.Lock()
 	session.addEvent(DebugEventSessionEnd, "", "Debug session ended", nil)
+	session.mu.Unlock()
 
 	return nil
 }
@@ -227,10 +269,13 @@ func (m *DebugManager) cleanupOldestSession() {
 	var oldestTime time.Time
 
 	for id, session := range m.sessions {
-		if session.Status == DebugStatusStopped {
-			if oldest == "" || session.LastActivity.Before(oldestTime) {
+		session.mu.RLock()
+		stopped, lastActivity := session.Status == DebugStatusStopped, session.LastActivity
+		session.mu.RUnlock()
+		if stopped {
+			if oldest == "" || lastActivity.Before(oldestTime) {
 				oldest = id
-				oldestTime = session.LastActivity
+				oldestTime = lastActivity
 			}
 		}
 	}
@@ -242,12 +287,27 @@ func (m *DebugManager) cleanupOldestSession() {
 
 // DebugSession methods
 
-// SetBreakpoint sets a breakpoint on a node
+func (s *DebugSession) status() DebugStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Status
+}
+
+// SetBreakpoint sets a breakpoint pausing at a node
 func (s *DebugSession) SetBreakpoint(nodeID string) {
+	s.AddBreakpoint(&Breakpoint{NodeID: nodeID, Enabled: true})
+}
+
+// AddBreakpoint sets a breakpoint, replacing the one of its node
+func (s *DebugSession) AddBreakpoint(bp *Breakpoint) *Breakpoint {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.Breakpoints[nodeID] = true
+	if bp.ID == "" {
+		bp.ID = uuid.New().String()
+	}
+	s.Breakpoints[bp.NodeID] = bp
 	s.LastActivity = time.Now()
+	return bp
 }
 
 // RemoveBreakpoint removes a breakpoint
@@ -262,137 +322,420 @@ func (s *DebugSession) RemoveBreakpoint(nodeID string) {
 func (s *DebugSession) ClearBreakpoints() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.Breakpoints = make(map[string]bool)
+	s.Breakpoints = make(map[string]*Breakpoint)
 	s.LastActivity = time.Now()
 }
 
-// HasBreakpoint checks if a node has a breakpoint
+// HasBreakpoint checks if a node has an enabled breakpoint
 func (s *DebugSession) HasBreakpoint(nodeID string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.Breakpoints[nodeID]
+	bp := s.Breakpoints[nodeID]
+	return bp != nil && bp.Enabled
 }
 
-// AddWatch adds a variable to watch
-func (s *DebugSession) AddWatch(varName string) {
+// AddWatch adds an expression to evaluate whenever the session pauses
+func (s *DebugSession) AddWatch(expr string) *WatchExpression {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.WatchedVars = append(s.WatchedVars, varName)
+	watch := &WatchExpression{ID: uuid.New().String(), Expression: expr}
+	s.Watches = append(s.Watches, watch)
 	s.LastActivity = time.Now()
+	return watch
 }
 
-// RemoveWatch removes a watched variable
-func (s *DebugSession) RemoveWatch(varName string) {
+// RemoveWatch removes a watch expression by ID or expression
+func (s *DebugSession) RemoveWatch(idOrExpr string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	for i, v := range s.WatchedVars {
-		if v == varName {
-			s.WatchedVars = append(s.WatchedVars[:i], s.WatchedVars[i+1:]...)
+	for i, watch := range s.Watches {
+		if watch.ID == idOrExpr || watch.Expression == idOrExpr {
+			s.Watches = append(s.Watches[:i], s.Watches[i+1:]...)
 			break
 		}
 	}
 	s.LastActivity = time.Now()
 }
 
-// Start starts the debug session
-func (s *DebugSession) Start(triggerData map[string]interface{}) {
+// Start starts the debug run of the session
+func (s *DebugSession) Start(executionID string, triggerData map[string]interface{}, cancel context.CancelFunc) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.Status != DebugStatusIdle {
+		return fmt.Errorf("debug session is %s", s.Status)
+	}
 	s.Status = DebugStatusRunning
 	s.State.TriggerData = triggerData
-	s.ExecutionID = uuid.New().String()
+	s.ExecutionID = executionID
+	s.cancel = cancel
 	s.LastActivity = time.Now()
+	s.addEvent(DebugEventExecutionStart, "", "Debug run started", map[string]interface{}{"executionId": executionID})
+	return nil
 }
 
-// Pause pauses the debug session
+// Pause pauses the debug run at the next node, or at its trigger when it
+// has not started
 func (s *DebugSession) Pause() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.Status = DebugStatusPaused
+	if s.Status != DebugStatusPaused && s.Status != DebugStatusStopped {
+		s.StepMode = StepModeToNext
+	}
 	s.LastActivity = time.Now()
 }
 
-// Resume resumes the debug session
+// Resume resumes the debug run until the next breakpoint
 func (s *DebugSession) Resume() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.Status = DebugStatusRunning
-	s.StepMode = StepModeNone
-	s.LastActivity = time.Now()
+	s.step(StepModeNone)
 }
 
-// StepInto steps into the next node
+// StepInto runs to the next node, entering sub-workflows
 func (s *DebugSession) StepInto() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.Status = DebugStatusStepping
-	s.StepMode = StepModeInto
-	s.LastActivity = time.Now()
+	s.step(StepModeInto)
 }
 
-// StepOver steps over the next node
+// StepOver runs to the next node, running sub-workflows through
 func (s *DebugSession) StepOver() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.Status = DebugStatusStepping
-	s.StepMode = StepModeOver
-	s.LastActivity = time.Now()
+	s.step(StepModeOver)
 }
 
-// StepOut steps out of current context
+// StepOut runs to the next node of the workflow that started the current
+// sub-workflow
 func (s *DebugSession) StepOut() {
+	s.step(StepModeOut)
+}
+
+// RunToNode runs until a node is entered
+func (s *DebugSession) RunToNode(nodeID string) {
+	s.mu.Lock()
+	s.cursor = nodeID
+	s.mu.Unlock()
+	s.step(StepModeToCursor)
+}
+
+// step sets how far the run goes before pausing again and resumes it
+func (s *DebugSession) step(mode StepMode) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.Status == DebugStatusStopped || s.Status == DebugStatusIdle {
+		return
+	}
+
+	s.StepMode = mode
+	s.stepDepth = s.depth
 	s.Status = DebugStatusStepping
-	s.StepMode = StepModeOut
+	if mode == StepModeNone {
+		s.Status = DebugStatusRunning
+	}
+	if s.resume != nil {
+		close(s.resume)
+		s.resume = nil
+		s.addEvent(DebugEventResumed, s.CurrentNode, "Resumed", map[string]interface{}{"stepMode": mode})
+	}
 	s.LastActivity = time.Now()
 }
 
-// Stop stops the debug session
+// Stop stops the debug session, cancelling its debug run
 func (s *DebugSession) Stop() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.Status = DebugStatusStopped
+	if s.resume != nil {
+		close(s.resume)
+		s.resume = nil
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
 	s.LastActivity = time.Now()
 }
 
-// EnterNode records entering a node
-func (s *DebugSession) EnterNode(nodeID, nodeName, nodeType string, input map[string]interface{}) bool {
+// BeforeNode enters a node, blocking while the session pauses at it. The
+// node runs with its input as modified while paused.
+func (s *DebugSession) BeforeNode(ctx context.Context, node *engine.NodeHookInput) error {
+	select {
+	case s.turn <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-s.turn }()
+
+	resume, err := s.enterNode(node)
+	if err != nil || resume == nil {
+		return err
+	}
+
+	select {
+	case <-resume:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Status == DebugStatusStopped {
+		return errDebugStopped
+	}
+	if s.modified {
+		node.Data = s.State.CurrentInput
+		node.Items = withItemsJSON(node.Items, s.State.CurrentItems)
+	}
+	s.editable, s.modified = false, false
+	return nil
+}
+
+// AfterNode exits a node
+func (s *DebugSession) AfterNode(ctx context.Context, node *engine.NodeHookInput, output map[string]interface{}, err error) {
+	if err != nil {
+		s.NodeError(node.NodeID, err)
+		return
+	}
+	s.ExitNode(node.NodeID, output)
+}
+
+// EnterNode records entering a node and reports whether the session paused
+// at it
+func (s *DebugSession) EnterNode(node *engine.NodeHookInput) bool {
+	resume, _ := s.enterNode(node)
+	return resume != nil
+}
+
+// enterNode records entering a node, returning the channel closed once the
+// session resumes when it paused at the node
+func (s *DebugSession) enterNode(node *engine.NodeHookInput) (chan struct{}, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.CurrentNode = nodeID
-	s.State.CurrentInput = input
+	if s.Status == DebugStatusStopped {
+		return nil, errDebugStopped
+	}
+
+	items := make([]map[string]interface{}, len(node.Items))
+	for i, item := range node.Items {
+		items[i] = item.JSON
+	}
+
+	s.CurrentNode = node.NodeID
+	s.State.CurrentInput = node.Data
+	s.State.CurrentItems = items
 	s.State.CallStack = append(s.State.CallStack, StackFrame{
-		NodeID:   nodeID,
-		NodeName: nodeName,
-		NodeType: nodeType,
-		Input:    input,
-		Depth:    len(s.State.CallStack),
+		NodeID:   node.NodeID,
+		NodeName: node.NodeName,
+		NodeType: node.NodeType,
+		Input:    node.Data,
+		Depth:    node.Depth,
 	})
+	s.LastActivity = time.Now()
 
-	s.addEvent(DebugEventNodeEnter, nodeID, fmt.Sprintf("Entering node: %s", nodeName), map[string]interface{}{
-		"input": input,
+	s.addEvent(DebugEventNodeEnter, node.NodeID, fmt.Sprintf("Entering node: %s", node.NodeName), map[string]interface{}{
+		"input": node.Data,
+		"items": items,
 	})
 
-	// Check breakpoint
-	if s.Breakpoints[nodeID] {
-		s.Status = DebugStatusPaused
-		s.addEvent(DebugEventBreakpointHit, nodeID, fmt.Sprintf("Breakpoint hit at: %s", nodeName), nil)
-		return true // Should pause
+	s.editable = false
+	eventType, message, data := s.breakpointHit(node)
+	if eventType == "" && s.stepReached(node) {
+		eventType, message, data = DebugEventStepComplete, fmt.Sprintf("Step complete at: %s", node.NodeName), map[string]interface{}{}
+	}
+	if eventType == "" {
+		return nil, nil
 	}
 
-	// Check step mode
-	if s.StepMode != StepModeNone {
-		s.Status = DebugStatusPaused
-		s.addEvent(DebugEventStepComplete, nodeID, fmt.Sprintf("Step complete at: %s", nodeName), nil)
-		return true // Should pause
+	s.Status = DebugStatusPaused
+	s.StepMode = StepModeNone
+	s.cursor = ""
+	s.depth = node.Depth
+	s.resume = make(chan struct{})
+
+	data["inputEditable"] = s.editable
+	data["watches"] = s.evaluateWatches(s.expressionContext(node, 0))
+	s.addEvent(eventType, node.NodeID, message, data)
+	return s.resume, nil
+}
+
+// breakpointHit runs the actions of the breakpoint of a node when its
+// condition holds, returning the event to pause with if one pauses
+func (s *DebugSession) breakpointHit(node *engine.NodeHookInput) (DebugEventType, string, map[string]interface{}) {
+	bp := s.Breakpoints[node.NodeID]
+	if bp == nil || !bp.Enabled || (bp.MaxHits > 0 && bp.HitCount >= bp.MaxHits) {
+		return "", "", nil
 	}
 
-	s.LastActivity = time.Now()
+	index, hit, condErr := s.evaluateCondition(bp.Condition, node)
+	if !hit {
+		return "", "", nil
+	}
+	bp.HitCount++
+	ctx := s.expressionContext(node, index)
+
+	actions := bp.Actions
+	if len(actions) == 0 {
+		actions = []BreakpointAction{BreakpointActionPause}
+	}
+
+	pause := false
+	for _, action := range actions {
+		switch action {
+		case BreakpointActionPause:
+			pause = true
+		case BreakpointActionModify:
+			pause, s.editable = true, true
+		case BreakpointActionLog:
+			s.addEvent(DebugEventLog, node.NodeID, s.logMessage(bp, ctx), map[string]interface{}{"breakpointId": bp.ID})
+		case BreakpointActionEval:
+			s.addEvent(DebugEventWatch, node.NodeID, "Watch expressions evaluated", map[string]interface{}{
+				"breakpointId": bp.ID,
+				"watches":      s.evaluateWatches(ctx),
+			})
+		}
+	}
+	if !pause {
+		return "", "", nil
+	}
+
+	data := map[string]interface{}{"breakpointId": bp.ID, "hitCount": bp.HitCount, "item": index}
+	if condErr != nil {
+		data["conditionError"] = condErr.Error()
+	}
+	return DebugEventBreakpointHit, fmt.Sprintf("Breakpoint hit at: %s", node.NodeName), data
+}
+
+// evaluateCondition evaluates the condition of a breakpoint against each
+// input item of a node, returning the first it holds for. A condition that
+// fails to evaluate counts as holding, so that the failure is seen.
+func (s *DebugSession) evaluateCondition(condition string, node *engine.NodeHookInput) (int, bool, error) {
+	if condition == "" {
+		return 0, true, nil
+	}
+
+	count := len(node.Items)
+	if count == 0 {
+		count = 1
+	}
+	for i := 0; i < count; i++ {
+		value, err := s.parser.EvaluateExpression(condition, s.expressionContext(node, i))
+		if err != nil {
+			return i, true, err
+		}
+		if isTruthy(value) {
+			return i, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// stepReached reports whether the step the session is taking ends at a node
+func (s *DebugSession) stepReached(node *engine.NodeHookInput) bool {
+	switch s.StepMode {
+	case StepModeInto, StepModeToNext:
+		return true
+	case StepModeOver:
+		return node.Depth <= s.stepDepth
+	case StepModeOut:
+		return node.Depth < s.stepDepth
+	case StepModeToCursor:
+		return node.NodeID == s.cursor
+	}
 	return false
 }
 
+// expressionContext is the context expressions are evaluated in at a node,
+// as its configuration would be for the item at index: nodes with several
+// items run once per item, others with their input data
+func (s *DebugSession) expressionContext(node *engine.NodeHookInput, index int) *expression.Context {
+	ctx := expression.NewContext()
+	if len(node.Items) > 0 {
+		ctx.SetItems(runtime.ItemsJSON(node.Items), index)
+	}
+	if len(node.Items) <= 1 {
+		ctx.SetInput(node.Data)
+	}
+	for nodeID, output := range node.NodeOutputs {
+		ctx.SetNodeOutput(nodeID, output)
+	}
+	if node.Environment != nil {
+		ctx.Env = node.Environment
+	}
+	for name, value := range node.Variables {
+		ctx.Variables[name] = value
+	}
+	for name, value := range s.State.Variables {
+		ctx.Variables[name] = value
+	}
+	ctx.Execution.ID = node.ExecutionID
+	ctx.Workflow.ID = node.WorkflowID
+	return ctx
+}
+
+// evaluateWatches evaluates the watch expressions, returning their values
+func (s *DebugSession) evaluateWatches(ctx *expression.Context) []WatchExpression {
+	values := make([]WatchExpression, len(s.Watches))
+	for i, watch := range s.Watches {
+		value, err := s.parser.EvaluateExpression(watch.Expression, ctx)
+		watch.Value, watch.Error, watch.LastEval = value, "", time.Now()
+		if err != nil {
+			watch.Value, watch.Error = nil, err.Error()
+		}
+		values[i] = *watch
+	}
+	return values
+}
+
+// logMessage evaluates the log message of a breakpoint
+func (s *DebugSession) logMessage(bp *Breakpoint, ctx *expression.Context) string {
+	if bp.LogMessage == "" {
+		return fmt.Sprintf("Breakpoint hit at: %s", bp.NodeID)
+	}
+	value, err := s.parser.Evaluate(bp.LogMessage, ctx)
+	if err != nil {
+		return fmt.Sprintf("%s (error: %v)", bp.LogMessage, err)
+	}
+	return fmt.Sprint(value)
+}
+
+// ModifyInput replaces the input data and items of the node the session is
+// paused at, which it runs with once resumed. Either may be nil to keep it;
+// a single item also becomes the input data unless that is given.
+func (s *DebugSession) ModifyInput(data map[string]interface{}, items []map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Status != DebugStatusPaused || !s.editable {
+		return fmt.Errorf("input can only be modified while paused at a modify breakpoint")
+	}
+	if data == nil && len(items) == 1 {
+		data = items[0]
+	}
+	if data != nil {
+		s.State.CurrentInput = data
+	}
+	if items != nil {
+		s.State.CurrentItems = items
+	}
+	s.modified = true
+
+	s.addEvent(DebugEventInputModified, s.CurrentNode, "Input modified", map[string]interface{}{
+		"input": s.State.CurrentInput,
+		"items": s.State.CurrentItems,
+	})
+	s.LastActivity = time.Now()
+	return nil
+}
+
+// withItemsJSON replaces the JSON of items, keeping the binary data and
+// pairing of those still there
+func withItemsJSON(items []runtime.Item, values []map[string]interface{}) []runtime.Item {
+	result := make([]runtime.Item, len(values))
+	for i, value := range values {
+		result[i] = runtime.NewItem(value)
+		if i < len(items) {
+			result[i].Binary = items[i].Binary
+			result[i].PairedItem = items[i].PairedItem
+		}
+	}
+	return result
+}
+
 // ExitNode records exiting a node
 func (s *DebugSession) ExitNode(nodeID string, output map[string]interface{}) {
 	s.mu.Lock()
@@ -400,11 +743,7 @@ func (s *DebugSession) ExitNode(nodeID string, output map[string]interface{}) {
 
 	// Store output
 	s.State.NodeOutputs[nodeID] = output
-
-	// Pop from call stack
-	if len(s.State.CallStack) > 0 {
-		s.State.CallStack = s.State.CallStack[:len(s.State.CallStack)-1]
-	}
+	s.popFrame(nodeID)
 
 	s.addEvent(DebugEventNodeExit, nodeID, "Exiting node", map[string]interface{}{
 		"output": output,
@@ -418,11 +757,24 @@ func (s *DebugSession) NodeError(nodeID string, err error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.addEvent(DebugEventNodeError, nodeID, fmt.Sprintf("Node error: %v", err), nil)
-	s.Status = DebugStatusPaused
+	s.popFrame(nodeID)
+	s.addEvent(DebugEventNodeError, nodeID, fmt.Sprintf("Node error: %v", err), map[string]interface{}{
+		"error": err.Error(),
+	})
 	s.LastActivity = time.Now()
 }
 
+// popFrame removes the latest frame of a node from the call stack; nodes
+// running in parallel may exit in any order
+func (s *DebugSession) popFrame(nodeID string) {
+	for i := len(s.State.CallStack) - 1; i >= 0; i-- {
+		if s.State.CallStack[i].NodeID == nodeID {
+			s.State.CallStack = append(s.State.CallStack[:i], s.State.CallStack[i+1:]...)
+			return
+		}
+	}
+}
+
 // SetVariable sets a variable value (for debugging)
 func (s *DebugSession) SetVariable(name string, value interface{}) {
 	s.mu.Lock()
@@ -447,11 +799,54 @@ func (s *DebugSession) GetVariable(name string) (interface{}, bool) {
 	return value, exists
 }
 
-// GetState returns the current debug state
+// GetState returns a copy of the current debug state
 func (s *DebugSession) GetState() *DebugState {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.State
+
+	state := *s.State
+	state.NodeOutputs = make(map[string]map[string]interface{}, len(s.State.NodeOutputs))
+	for nodeID, output := range s.State.NodeOutputs {
+		state.NodeOutputs[nodeID] = output
+	}
+	state.Variables = make(map[string]interface{}, len(s.State.Variables))
+	for name, value := range s.State.Variables {
+		state.Variables[name] = value
+	}
+	state.CallStack = append([]StackFrame(nil), s.State.CallStack...)
+	return &state
+}
+
+// Snapshot returns the status, breakpoints, watches and state of the session
+func (s *DebugSession) Snapshot() map[string]interface{} {
+	state := s.GetState()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	breakpoints := make([]Breakpoint, 0, len(s.Breakpoints))
+	for _, bp := range s.Breakpoints {
+		breakpoints = append(breakpoints, *bp)
+	}
+	watches := make([]WatchExpression, len(s.Watches))
+	for i, watch := range s.Watches {
+		watches[i] = *watch
+	}
+
+	return map[string]interface{}{
+		"id":            s.ID,
+		"workflowId":    s.WorkflowID,
+		"executionId":   s.ExecutionID,
+		"status":        s.Status,
+		"currentNode":   s.CurrentNode,
+		"stepMode":      s.StepMode,
+		"inputEditable": s.Status == DebugStatusPaused && s.editable,
+		"breakpoints":   breakpoints,
+		"watches":       watches,
+		"state":         state,
+		"startedAt":     s.StartedAt,
+		"lastActivity":  s.LastActivity,
+	}
 }
 
 // GetHistory returns debug event history
@@ -465,6 +860,8 @@ func (s *DebugSession) GetHistory(limit int) []*DebugEvent {
 	return s.History[len(s.History)-limit:]
 }
 
+// addEvent records an event and passes it to the event handler; the session
+// must be locked
 func (s *DebugSession) addEvent(eventType DebugEventType, nodeID, message string, data map[string]interface{}) {
 	event := &DebugEvent{
 		ID:        uuid.New().String(),
@@ -475,59 +872,80 @@ func (s *DebugSession) addEvent(eventType DebugEventType, nodeID, message string
 		Timestamp: time.Now(),
 	}
 	s.History = append(s.History, event)
+	if s.onEvent != nil {
+		s.onEvent(s, event)
+	}
+}
+
+// isTruthy reports whether the value of a condition holds
+func isTruthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != "" && val != "false"
+	case float64:
+		return val != 0
+	case int:
+		return val != 0
+	case int64:
+		return val != 0
+	}
+	return true
 }
 
-// DebugExecutor wraps workflow execution with debug support
+// DebugExecutor runs workflows on the engine with a debug session attached
 type DebugExecutor struct {
-	session  *DebugSession
-	executor WorkflowExecutor
+	session *DebugSession
+	engine  *engine.Engine
 }
 
 // NewDebugExecutor creates a new debug executor
-func NewDebugExecutor(session *DebugSession, executor WorkflowExecutor) *DebugExecutor {
+func NewDebugExecutor(session *DebugSession, eng *engine.Engine) *DebugExecutor {
 	return &DebugExecutor{
-		session:  session,
-		executor: executor,
+		session: session,
+		engine:  eng,
 	}
 }
 
-// Execute executes a workflow in debug mode
-func (d *DebugExecutor) Execute(ctx context.Context, workflow *model.Workflow, input map[string]interface{}, options *ExecutionOptions) (*ExecutionResult, error) {
-	d.session.Start(input)
-
-	// Execute with debug hooks
-	// In a real implementation, this would integrate with the actual executor
-	// and call d.session.EnterNode/ExitNode at appropriate times
+// Execute runs a workflow in debug mode, pausing at the breakpoints and
+// steps of the session. The session is stopped once the run ends.
+func (d *DebugExecutor) Execute(ctx context.Context, workflow *engine.WorkflowDefinition, options *engine.ExecutionOptions) (*engine.ExecutionState, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	// Flatten node outputs for result
-	output := make(map[string]interface{})
-	for nodeID, nodeOutput := range d.session.State.NodeOutputs {
-		output[nodeID] = nodeOutput
+	opts := *options
+	if opts.ExecutionID == "" {
+		opts.ExecutionID = uuid.New().String()
 	}
-
-	result := &ExecutionResult{
-		ExecutionID: d.session.ExecutionID,
-		Status:      "completed",
-		Output:      output,
+	opts.Hook = d.session
+	if err := d.session.Start(opts.ExecutionID, opts.TriggerData, cancel); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	state, err := d.engine.Execute(ctx, workflow, &opts)
+	d.session.finish(state, err)
+	return state, err
 }
 
-// WaitForResume waits for the debug session to resume
-func (d *DebugExecutor) WaitForResume(ctx context.Context) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if d.session.Status == DebugStatusRunning || d.session.Status == DebugStatusStepping {
-				return nil
-			}
-			if d.session.Status == DebugStatusStopped {
-				return fmt.Errorf("debug session stopped")
-			}
-			time.Sleep(100 * time.Millisecond)
-		}
+// finish records the end of the debug run and stops the session
+func (s *DebugSession) finish(state *engine.ExecutionState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := map[string]interface{}{"executionId": s.ExecutionID}
+	if state != nil {
+		data["status"] = state.Status
 	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	s.addEvent(DebugEventExecutionEnd, "", "Debug run ended", data)
+
+	s.Status = DebugStatusStopped
+	s.CurrentNode = ""
+	s.cancel = nil
+	s.LastActivity = time.Now()
 }
@@ -3,60 +3,99 @@ package features
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/linkflow-ai/linkflow-ai/internal/engine"
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
 )
 
 // ExecutionSnapshot represents a snapshot of execution state at a point in time
 type ExecutionSnapshot struct {
-	ID            string                 `json:"id"`
-	ExecutionID   string                 `json:"executionId"`
-	WorkflowID    string                 `json:"workflowId"`
-	NodeID        string                 `json:"nodeId"`
-	NodeName      string                 `json:"nodeName"`
-	NodeType      string                 `json:"nodeType"`
-	Sequence      int                    `json:"sequence"`
-	Timestamp     time.Time              `json:"timestamp"`
-	Status        string                 `json:"status"`
-	Input         map[string]interface{} `json:"input"`
-	Output        map[string]interface{} `json:"output,omitempty"`
-	Error         string                 `json:"error,omitempty"`
-	DurationMs    int64                  `json:"durationMs"`
-	Variables     map[string]interface{} `json:"variables"`
-	ContextData   map[string]interface{} `json:"contextData"`
+	ID          string                    `json:"id"`
+	ExecutionID string                    `json:"executionId"`
+	WorkflowID  string                    `json:"workflowId"`
+	NodeID      string                    `json:"nodeId"`
+	NodeName    string                    `json:"nodeName"`
+	NodeType    string                    `json:"nodeType"`
+	Sequence    int                       `json:"sequence"`
+	Timestamp   time.Time                 `json:"timestamp"`
+	Status      string                    `json:"status"`
+	Input       map[string]interface{}    `json:"input"`
+	Output      map[string]interface{}    `json:"output,omitempty"`
+	Items       map[string][]runtime.Item `json:"items,omitempty"` // Output items by port
+	Error       string                    `json:"error,omitempty"`
+	DurationMs  int64                     `json:"durationMs"`
+	Variables   map[string]interface{}    `json:"variables"`
+	ContextData map[string]interface{}    `json:"contextData"`
 }
 
 // ExecutionRecording represents a full execution recording
 type ExecutionRecording struct {
-	ID            string                 `json:"id"`
-	ExecutionID   string                 `json:"executionId"`
-	WorkflowID    string                 `json:"workflowId"`
-	WorkflowName  string                 `json:"workflowName"`
-	Mode          string                 `json:"mode"`
-	Status        string                 `json:"status"`
-	StartedAt     time.Time              `json:"startedAt"`
-	CompletedAt   *time.Time             `json:"completedAt,omitempty"`
-	TotalDurationMs int64                `json:"totalDurationMs"`
-	TriggerData   map[string]interface{} `json:"triggerData"`
-	FinalOutput   map[string]interface{} `json:"finalOutput,omitempty"`
-	Error         string                 `json:"error,omitempty"`
-	Snapshots     []*ExecutionSnapshot   `json:"snapshots"`
-	Metadata      map[string]interface{} `json:"metadata"`
+	ID              string                 `json:"id"`
+	ExecutionID     string                 `json:"executionId"`
+	WorkflowID      string                 `json:"workflowId"`
+	WorkflowName    string                 `json:"workflowName"`
+	Mode            string                 `json:"mode"`
+	Status          string                 `json:"status"`
+	StartedAt       time.Time              `json:"startedAt"`
+	CompletedAt     *time.Time             `json:"completedAt,omitempty"`
+	TotalDurationMs int64                  `json:"totalDurationMs"`
+	TriggerNodeID   string                 `json:"triggerNodeId,omitempty"`
+	TriggerData     map[string]interface{} `json:"triggerData"`
+	FinalOutput     map[string]interface{} `json:"finalOutput,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+	Snapshots       []*ExecutionSnapshot   `json:"snapshots"`
+	Metadata        map[string]interface{} `json:"metadata"`
+}
+
+// FailedNode returns the first node that failed in the recorded execution,
+// empty if none did
+func (r *ExecutionRecording) FailedNode() string {
+	for _, snap := range r.Snapshots {
+		if snap.Status == string(engine.ExecutionStatusFailed) {
+			return snap.NodeID
+		}
+	}
+	return ""
+}
+
+// OutputItems returns the items a node output in the recorded execution,
+// from every port, reporting whether the node completed in it
+func (r *ExecutionRecording) OutputItems(nodeID string) ([]runtime.Item, bool) {
+	for _, snap := range r.Snapshots {
+		if snap.NodeID != nodeID || snap.Status != string(engine.ExecutionStatusCompleted) {
+			continue
+		}
+		if snap.Items == nil {
+			return runtime.ItemsFromData(snap.Output), true
+		}
+		ports := make([]string, 0, len(snap.Items))
+		for port := range snap.Items {
+			ports = append(ports, port)
+		}
+		sort.Strings(ports)
+
+		var items []runtime.Item
+		for _, port := range ports {
+			items = append(items, snap.Items[port]...)
+		}
+		return items, true
+	}
+	return nil, false
 }
 
 // ReplayOptions holds replay configuration
 type ReplayOptions struct {
-	StartFromNode    string                 // Start from specific node
-	StopAtNode       string                 // Stop at specific node
-	OverrideInput    map[string]interface{} // Override input data
-	OverrideVariables map[string]interface{} // Override variables
-	Speed            float64                // Replay speed (1.0 = real-time, 0 = instant)
-	BreakpointNodes  []string               // Nodes to pause at
-	SkipNodes        []string               // Nodes to skip
+	StartFromNode     string                 `json:"startFromNode,omitempty"`     // Nodes upstream of it pass on their recorded output
+	OverrideInput     map[string]interface{} `json:"overrideInput,omitempty"`     // Input of the start node, or trigger data without one
+	OverrideVariables map[string]interface{} `json:"overrideVariables,omitempty"` // Merged into the variables of the run
+	SkipNodes         []string               `json:"skipNodes,omitempty"`         // Pass on their recorded output rather than run again
 }
 
 // ReplayResult represents the result of a replay
@@ -64,9 +103,10 @@ type ReplayResult struct {
 	ExecutionID   string                 `json:"executionId"`
 	OriginalID    string                 `json:"originalId"`
 	Status        string                 `json:"status"`
-	NodesReplayed int                    `json:"nodesReplayed"`
-	NodesSkipped  int                    `json:"nodesSkipped"`
+	NodesReplayed int                    `json:"nodesReplayed"` // Ran again
+	NodesSkipped  int                    `json:"nodesSkipped"`  // Passed on their recorded output
 	Output        map[string]interface{} `json:"output"`
+	Error         string                 `json:"error,omitempty"`
 	Diffs         []ReplayDiff           `json:"diffs"`
 	StartedAt     time.Time              `json:"startedAt"`
 	CompletedAt   time.Time              `json:"completedAt"`
@@ -76,12 +116,20 @@ type ReplayResult struct {
 
 // ReplayDiff represents a difference between original and replay
 type ReplayDiff struct {
-	NodeID       string      `json:"nodeId"`
-	NodeName     string      `json:"nodeName"`
-	Field        string      `json:"field"`
+	NodeID        string      `json:"nodeId"`
+	NodeName      string      `json:"nodeName"`
+	Field         string      `json:"field"`
 	OriginalValue interface{} `json:"originalValue"`
-	ReplayValue  interface{} `json:"replayValue"`
-	Type         string      `json:"type"` // output, duration, status
+	ReplayValue   interface{} `json:"replayValue"`
+	Type          string      `json:"type"` // input, output, status
+}
+
+// RecordingStore keeps execution recordings by execution ID
+type RecordingStore interface {
+	Save(ctx context.Context, recording *ExecutionRecording) error
+
+	// Load returns the recording of an execution, nil if there is none
+	Load(ctx context.Context, executionID string) (*ExecutionRecording, error)
 }
 
 // ExecutionRecorder records execution for replay
@@ -89,6 +137,7 @@ type ExecutionRecorder struct {
 	recordings map[string]*ExecutionRecording
 	mu         sync.RWMutex
 	maxSize    int
+	store      RecordingStore
 }
 
 // NewExecutionRecorder creates a new execution recorder
@@ -99,12 +148,16 @@ func NewExecutionRecorder(maxSize int) *ExecutionRecorder {
 	}
 }
 
+// WithStore sets where recordings are kept durably. The recorder itself only
+// keeps the most recent ones in memory.
+func (r *ExecutionRecorder) WithStore(store RecordingStore) *ExecutionRecorder {
+	r.store = store
+	return r
+}
+
 // StartRecording starts recording an execution
 func (r *ExecutionRecorder) StartRecording(executionID, workflowID, workflowName, mode string, triggerData map[string]interface{}) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	r.recordings[executionID] = &ExecutionRecording{
+	r.keep(&ExecutionRecording{
 		ID:           uuid.New().String(),
 		ExecutionID:  executionID,
 		WorkflowID:   workflowID,
@@ -115,12 +168,7 @@ func (r *ExecutionRecorder) StartRecording(executionID, workflowID, workflowName
 		TriggerData:  triggerData,
 		Snapshots:    make([]*ExecutionSnapshot, 0),
 		Metadata:     make(map[string]interface{}),
-	}
-
-	// Cleanup old recordings if exceeded max size
-	if len(r.recordings) > r.maxSize {
-		r.cleanupOldest()
-	}
+	})
 }
 
 // RecordNodeStart records a node starting execution
@@ -212,6 +260,109 @@ func (r *ExecutionRecorder) CompleteRecording(executionID string, status string,
 	}
 }
 
+// Record records a finished execution of workflow from its state, with the
+// input, output and items of every node that ran, and saves it to the store
+func (r *ExecutionRecorder) Record(ctx context.Context, workflow *engine.WorkflowDefinition, state *engine.ExecutionState, mode string) (*ExecutionRecording, error) {
+	names := make(map[string]string, len(workflow.Nodes))
+	for _, node := range workflow.Nodes {
+		names[node.ID] = node.Name
+	}
+
+	results := make([]*engine.NodeResult, 0, len(state.NodeResults))
+	for _, result := range state.NodeResults {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].StartedAt.Before(results[j].StartedAt)
+	})
+
+	recording := &ExecutionRecording{
+		ID:           uuid.New().String(),
+		ExecutionID:  state.ID,
+		WorkflowID:   state.WorkflowID,
+		WorkflowName: workflow.Name,
+		Mode:         mode,
+		Status:       state.Status,
+		StartedAt:    state.StartedAt,
+		CompletedAt:  state.CompletedAt,
+		Snapshots:    make([]*ExecutionSnapshot, len(results)),
+		Metadata:     make(map[string]interface{}),
+	}
+	if state.CompletedAt != nil {
+		recording.TotalDurationMs = state.CompletedAt.Sub(state.StartedAt).Milliseconds()
+	}
+	if state.Result != nil {
+		recording.FinalOutput = state.Result.Outputs
+	}
+	if state.Error != nil {
+		recording.Error = state.Error.Error()
+	}
+
+	for i, result := range results {
+		recording.Snapshots[i] = &ExecutionSnapshot{
+			ID:          uuid.New().String(),
+			ExecutionID: state.ID,
+			WorkflowID:  state.WorkflowID,
+			NodeID:      result.NodeID,
+			NodeName:    names[result.NodeID],
+			NodeType:    result.NodeType,
+			Sequence:    i + 1,
+			Timestamp:   result.StartedAt,
+			Status:      string(result.Status),
+			Input:       result.Input,
+			Output:      result.Output,
+			Items:       state.NodeItems[result.NodeID],
+			Error:       result.Error,
+			DurationMs:  result.DurationMs,
+		}
+	}
+
+	// The trigger runs first, with the data the execution started with
+	if len(results) > 0 {
+		recording.TriggerNodeID = results[0].NodeID
+		recording.TriggerData = results[0].Input
+	}
+
+	r.keep(recording)
+	if r.store != nil {
+		if err := r.store.Save(ctx, recording); err != nil {
+			return recording, err
+		}
+	}
+	return recording, nil
+}
+
+// Load returns the recording of an execution, from the store unless it is
+// still in memory
+func (r *ExecutionRecorder) Load(ctx context.Context, executionID string) (*ExecutionRecording, error) {
+	if recording, exists := r.GetRecording(executionID); exists {
+		return recording, nil
+	}
+	if r.store != nil {
+		recording, err := r.store.Load(ctx, executionID)
+		if err != nil {
+			return nil, err
+		}
+		if recording != nil {
+			r.keep(recording)
+			return recording, nil
+		}
+	}
+	return nil, fmt.Errorf("recording not found: %s", executionID)
+}
+
+// keep adds a recording to those in memory, dropping the oldest beyond the
+// maximum size
+func (r *ExecutionRecorder) keep(recording *ExecutionRecording) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recordings[recording.ExecutionID] = recording
+	if len(r.recordings) > r.maxSize {
+		r.cleanupOldest()
+	}
+}
+
 // GetRecording retrieves a recording
 func (r *ExecutionRecorder) GetRecording(executionID string) (*ExecutionRecording, bool) {
 	r.mu.RLock()
@@ -262,147 +413,256 @@ func (r *ExecutionRecorder) cleanupOldest() {
 	}
 }
 
-// ExecutionReplayer replays executions
-type ExecutionReplayer struct {
-	recorder *ExecutionRecorder
-	executor WorkflowExecutor
+// PostgresRecordingStore keeps recordings in Postgres, a row per recorded
+// node alongside a row per execution
+type PostgresRecordingStore struct {
+	db *sql.DB
 }
 
-// NewExecutionReplayer creates a new execution replayer
-func NewExecutionReplayer(recorder *ExecutionRecorder, executor WorkflowExecutor) *ExecutionReplayer {
-	return &ExecutionReplayer{
-		recorder: recorder,
-		executor: executor,
-	}
+// NewPostgresRecordingStore creates a recording store backed by Postgres
+func NewPostgresRecordingStore(db *sql.DB) *PostgresRecordingStore {
+	return &PostgresRecordingStore{db: db}
 }
 
-// Replay replays an execution
-func (p *ExecutionReplayer) Replay(ctx context.Context, executionID string, options *ReplayOptions) (*ReplayResult, error) {
-	recording, exists := p.recorder.GetRecording(executionID)
-	if !exists {
-		return nil, fmt.Errorf("recording not found: %s", executionID)
+// Save writes a recording, replacing an earlier one of the same execution
+func (s *PostgresRecordingStore) Save(ctx context.Context, recording *ExecutionRecording) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save recording: %w", err)
+	}
+	defer tx.Rollback()
+
+	triggerJSON, _ := json.Marshal(recording.TriggerData)
+	outputJSON, _ := json.Marshal(recording.FinalOutput)
+	metadataJSON, _ := json.Marshal(recording.Metadata)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO execution_recordings (execution_id, id, workflow_id, workflow_name, mode, status, trigger_node_id, trigger_data, final_output, error, metadata, started_at, completed_at, total_duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (execution_id) DO UPDATE
+		SET id = EXCLUDED.id, workflow_name = EXCLUDED.workflow_name, mode = EXCLUDED.mode, status = EXCLUDED.status,
+			trigger_node_id = EXCLUDED.trigger_node_id, trigger_data = EXCLUDED.trigger_data, final_output = EXCLUDED.final_output,
+			error = EXCLUDED.error, metadata = EXCLUDED.metadata, completed_at = EXCLUDED.completed_at, total_duration_ms = EXCLUDED.total_duration_ms`,
+		recording.ExecutionID, recording.ID, recording.WorkflowID, recording.WorkflowName, recording.Mode, recording.Status,
+		recording.TriggerNodeID, triggerJSON, outputJSON, recording.Error, metadataJSON,
+		recording.StartedAt.UTC(), recording.CompletedAt, recording.TotalDurationMs,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save recording: %w", err)
 	}
 
-	result := &ReplayResult{
-		ExecutionID: uuid.New().String(),
-		OriginalID:  executionID,
-		Status:      "running",
-		Diffs:       make([]ReplayDiff, 0),
-		StartedAt:   time.Now(),
+	if _, err := tx.ExecContext(ctx, `DELETE FROM execution_node_recordings WHERE execution_id = $1`, recording.ExecutionID); err != nil {
+		return fmt.Errorf("failed to save recording: %w", err)
 	}
-
-	// Determine starting point
-	startIndex := 0
-	if options != nil && options.StartFromNode != "" {
-		for i, snap := range recording.Snapshots {
-			if snap.NodeID == options.StartFromNode {
-				startIndex = i
-				break
-			}
+	for _, snap := range recording.Snapshots {
+		inputJSON, _ := json.Marshal(snap.Input)
+		nodeOutputJSON, _ := json.Marshal(snap.Output)
+		itemsJSON, err := json.Marshal(snap.Items)
+		if err != nil {
+			return fmt.Errorf("failed to encode items of node %s: %w", snap.NodeID, err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO execution_node_recordings (execution_id, sequence, node_id, node_name, node_type, status, input, output, items, error, started_at, duration_ms)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			recording.ExecutionID, snap.Sequence, snap.NodeID, snap.NodeName, snap.NodeType, snap.Status,
+			inputJSON, nodeOutputJSON, itemsJSON, snap.Error, snap.Timestamp.UTC(), snap.DurationMs,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save recording of node %s: %w", snap.NodeID, err)
 		}
 	}
 
-	// Build skip set
-	skipSet := make(map[string]bool)
-	if options != nil {
-		for _, nodeID := range options.SkipNodes {
-			skipSet[nodeID] = true
-		}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save recording: %w", err)
 	}
+	return nil
+}
 
-	// Replay each snapshot
-	for i := startIndex; i < len(recording.Snapshots); i++ {
-		snap := recording.Snapshots[i]
+// Load reads the recording of an execution
+func (s *PostgresRecordingStore) Load(ctx context.Context, executionID string) (*ExecutionRecording, error) {
+	recording := &ExecutionRecording{ExecutionID: executionID}
+	var triggerJSON, outputJSON, metadataJSON []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, workflow_id, workflow_name, mode, status, trigger_node_id, trigger_data, final_output, error, metadata, started_at, completed_at, total_duration_ms
+		FROM execution_recordings WHERE execution_id = $1`, executionID,
+	).Scan(&recording.ID, &recording.WorkflowID, &recording.WorkflowName, &recording.Mode, &recording.Status,
+		&recording.TriggerNodeID, &triggerJSON, &outputJSON, &recording.Error, &metadataJSON,
+		&recording.StartedAt, &recording.CompletedAt, &recording.TotalDurationMs)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recording: %w", err)
+	}
+	json.Unmarshal(triggerJSON, &recording.TriggerData)
+	json.Unmarshal(outputJSON, &recording.FinalOutput)
+	json.Unmarshal(metadataJSON, &recording.Metadata)
 
-		// Check stop condition
-		if options != nil && options.StopAtNode != "" && snap.NodeID == options.StopAtNode {
-			break
-		}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sequence, node_id, node_name, node_type, status, input, output, items, error, started_at, duration_ms
+		FROM execution_node_recordings WHERE execution_id = $1 ORDER BY sequence`, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recording: %w", err)
+	}
+	defer rows.Close()
 
-		// Check skip
-		if skipSet[snap.NodeID] {
-			result.NodesSkipped++
-			continue
+	recording.Snapshots = make([]*ExecutionSnapshot, 0)
+	for rows.Next() {
+		snap := &ExecutionSnapshot{ExecutionID: executionID, WorkflowID: recording.WorkflowID}
+		var inputJSON, nodeOutputJSON, itemsJSON []byte
+		if err := rows.Scan(&snap.Sequence, &snap.NodeID, &snap.NodeName, &snap.NodeType, &snap.Status,
+			&inputJSON, &nodeOutputJSON, &itemsJSON, &snap.Error, &snap.Timestamp, &snap.DurationMs); err != nil {
+			return nil, fmt.Errorf("failed to load recording: %w", err)
 		}
-
-		// Check breakpoint
-		if options != nil && containsString(options.BreakpointNodes, snap.NodeID) {
-			result.Status = "paused"
-			result.Metadata = map[string]interface{}{
-				"pausedAt":     snap.NodeID,
-				"pausedAtName": snap.NodeName,
-			}
-			break
+		json.Unmarshal(inputJSON, &snap.Input)
+		json.Unmarshal(nodeOutputJSON, &snap.Output)
+		if err := json.Unmarshal(itemsJSON, &snap.Items); err != nil {
+			return nil, fmt.Errorf("failed to decode items of node %s: %w", snap.NodeID, err)
 		}
+		snap.ID = fmt.Sprintf("%s-%d", executionID, snap.Sequence)
+		recording.Snapshots = append(recording.Snapshots, snap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load recording: %w", err)
+	}
+	return recording, nil
+}
 
-		// Apply speed delay
-		if options != nil && options.Speed > 0 && snap.DurationMs > 0 {
-			delay := time.Duration(float64(snap.DurationMs) / options.Speed) * time.Millisecond
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(delay):
-			}
-		}
+// ExecutionReplayer re-runs recorded executions on the engine
+type ExecutionReplayer struct {
+	recorder *ExecutionRecorder
+	engine   *engine.Engine
+}
 
-		result.NodesReplayed++
+// NewExecutionReplayer creates a new execution replayer
+func NewExecutionReplayer(recorder *ExecutionRecorder, eng *engine.Engine) *ExecutionReplayer {
+	return &ExecutionReplayer{
+		recorder: recorder,
+		engine:   eng,
 	}
+}
 
-	result.CompletedAt = time.Now()
-	result.DurationMs = result.CompletedAt.Sub(result.StartedAt).Milliseconds()
-	if result.Status == "running" {
-		result.Status = "completed"
+// Replay re-runs a recorded execution of workflow. Nodes upstream of
+// StartFromNode and those in SkipNodes pass on the output they recorded,
+// provided they completed, rather than run again; the nodes that do run
+// again are compared with the original execution. The error of a failed
+// re-run is returned along with its result.
+func (p *ExecutionReplayer) Replay(ctx context.Context, workflow *engine.WorkflowDefinition, executionID string, options *ReplayOptions, runOptions *engine.ExecutionOptions) (*ReplayResult, error) {
+	recording, err := p.recorder.Load(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &ReplayOptions{}
 	}
-	result.Output = recording.FinalOutput
 
-	return result, nil
-}
+	var rerun map[string]bool
+	if options.StartFromNode != "" {
+		if !hasNode(workflow, options.StartFromNode) {
+			return nil, fmt.Errorf("node %s not found in workflow %s", options.StartFromNode, workflow.ID)
+		}
+		rerun = engine.RerunNodes(workflow, options.StartFromNode)
+	}
 
-// ReplayWithComparison replays and compares with original
-func (p *ExecutionReplayer) ReplayWithComparison(ctx context.Context, executionID string, newInput map[string]interface{}) (*ReplayResult, error) {
-	recording, exists := p.recorder.GetRecording(executionID)
-	if !exists {
-		return nil, fmt.Errorf("recording not found: %s", executionID)
+	recorded := make(map[string]*engine.RecordedNode)
+	for _, snap := range recording.Snapshots {
+		upstream := rerun != nil && !rerun[snap.NodeID]
+		if (upstream || containsString(options.SkipNodes, snap.NodeID)) && snap.Status == string(engine.ExecutionStatusCompleted) {
+			recorded[snap.NodeID] = &engine.RecordedNode{Output: snap.Output, Items: snap.Items}
+		}
+	}
+
+	opts := *runOptions
+	if opts.ExecutionID == "" {
+		opts.ExecutionID = uuid.New().String()
+	}
+	opts.TriggerNodeID = recording.TriggerNodeID
+	opts.TriggerData = recording.TriggerData
+	opts.Recorded = recorded
+	if options.OverrideVariables != nil {
+		variables := copyMap(opts.Variables)
+		if variables == nil {
+			variables = make(map[string]interface{})
+		}
+		for key, value := range options.OverrideVariables {
+			variables[key] = value
+		}
+		opts.Variables = variables
+	}
+	if options.OverrideInput != nil {
+		if options.StartFromNode == "" {
+			opts.TriggerData = options.OverrideInput
+		} else {
+			opts.Hook = &inputOverride{nodeID: options.StartFromNode, data: options.OverrideInput}
+		}
 	}
 
 	result := &ReplayResult{
-		ExecutionID: uuid.New().String(),
+		ExecutionID: opts.ExecutionID,
 		OriginalID:  executionID,
-		Status:      "completed",
 		Diffs:       make([]ReplayDiff, 0),
 		StartedAt:   time.Now(),
 	}
 
-	// Use new input or original trigger data
-	input := recording.TriggerData
-	if newInput != nil {
-		input = newInput
-		// Compare inputs
-		for key, origVal := range recording.TriggerData {
-			if newVal, ok := newInput[key]; ok && !equalValues(origVal, newVal) {
-				result.Diffs = append(result.Diffs, ReplayDiff{
-					Field:        fmt.Sprintf("input.%s", key),
-					OriginalValue: origVal,
-					ReplayValue:  newVal,
-					Type:         "input",
-				})
-			}
+	state, runErr := p.engine.Execute(ctx, workflow, &opts)
+	result.CompletedAt = time.Now()
+	result.DurationMs = result.CompletedAt.Sub(result.StartedAt).Milliseconds()
+	result.Status = state.Status
+	if state.Result != nil {
+		result.Output = state.Result.Outputs
+	}
+	if runErr != nil {
+		result.Error = runErr.Error()
+	}
+
+	for nodeID := range state.NodeResults {
+		if recorded[nodeID] != nil {
+			result.NodesSkipped++
+		} else {
+			result.NodesReplayed++
 		}
 	}
+	result.Diffs = append(result.Diffs, diffRecording(workflow, recording, state, recorded)...)
 
-	result.Output = input // Would contain actual replay output
-	result.CompletedAt = time.Now()
-	result.DurationMs = result.CompletedAt.Sub(result.StartedAt).Milliseconds()
-	result.NodesReplayed = len(recording.Snapshots)
+	return result, runErr
+}
 
-	return result, nil
+// ReplayWithComparison re-runs an execution with new trigger data, comparing
+// the input as well as the nodes with the original
+func (p *ExecutionReplayer) ReplayWithComparison(ctx context.Context, workflow *engine.WorkflowDefinition, executionID string, newInput map[string]interface{}, runOptions *engine.ExecutionOptions) (*ReplayResult, error) {
+	recording, err := p.recorder.Load(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.Replay(ctx, workflow, executionID, &ReplayOptions{OverrideInput: newInput}, runOptions)
+	if result == nil || newInput == nil {
+		return result, err
+	}
+
+	// Triggers add keys of their own to the data, so only the new input's
+	// keys are compared
+	var inputDiffs []ReplayDiff
+	for _, key := range sortedKeys(newInput, nil) {
+		origVal, newVal := recording.TriggerData[key], newInput[key]
+		if !equalValues(origVal, newVal) {
+			inputDiffs = append(inputDiffs, ReplayDiff{
+				Field:         fmt.Sprintf("input.%s", key),
+				OriginalValue: origVal,
+				ReplayValue:   newVal,
+				Type:          "input",
+			})
+		}
+	}
+	result.Diffs = append(inputDiffs, result.Diffs...)
+
+	return result, err
 }
 
 // GetSnapshotAt returns the execution state at a specific snapshot
-func (p *ExecutionReplayer) GetSnapshotAt(executionID string, sequence int) (*ExecutionSnapshot, error) {
-	recording, exists := p.recorder.GetRecording(executionID)
-	if !exists {
-		return nil, fmt.Errorf("recording not found: %s", executionID)
+func (p *ExecutionReplayer) GetSnapshotAt(ctx context.Context, executionID string, sequence int) (*ExecutionSnapshot, error) {
+	recording, err := p.recorder.Load(ctx, executionID)
+	if err != nil {
+		return nil, err
 	}
 
 	if sequence < 1 || sequence > len(recording.Snapshots) {
@@ -413,10 +673,10 @@ func (p *ExecutionReplayer) GetSnapshotAt(executionID string, sequence int) (*Ex
 }
 
 // GetNodeSnapshots returns all snapshots for a specific node
-func (p *ExecutionReplayer) GetNodeSnapshots(executionID, nodeID string) ([]*ExecutionSnapshot, error) {
-	recording, exists := p.recorder.GetRecording(executionID)
-	if !exists {
-		return nil, fmt.Errorf("recording not found: %s", executionID)
+func (p *ExecutionReplayer) GetNodeSnapshots(ctx context.Context, executionID, nodeID string) ([]*ExecutionSnapshot, error) {
+	recording, err := p.recorder.Load(ctx, executionID)
+	if err != nil {
+		return nil, err
 	}
 
 	var snapshots []*ExecutionSnapshot
@@ -430,29 +690,102 @@ func (p *ExecutionReplayer) GetNodeSnapshots(executionID, nodeID string) ([]*Exe
 }
 
 // ExportRecording exports a recording as JSON
-func (p *ExecutionReplayer) ExportRecording(executionID string) ([]byte, error) {
-	recording, exists := p.recorder.GetRecording(executionID)
-	if !exists {
-		return nil, fmt.Errorf("recording not found: %s", executionID)
+func (p *ExecutionReplayer) ExportRecording(ctx context.Context, executionID string) ([]byte, error) {
+	recording, err := p.recorder.Load(ctx, executionID)
+	if err != nil {
+		return nil, err
 	}
 
 	return json.MarshalIndent(recording, "", "  ")
 }
 
 // ImportRecording imports a recording from JSON
-func (p *ExecutionReplayer) ImportRecording(data []byte) error {
+func (p *ExecutionReplayer) ImportRecording(ctx context.Context, data []byte) error {
 	var recording ExecutionRecording
 	if err := json.Unmarshal(data, &recording); err != nil {
 		return fmt.Errorf("failed to parse recording: %w", err)
 	}
 
-	p.recorder.mu.Lock()
-	defer p.recorder.mu.Unlock()
+	p.recorder.keep(&recording)
+	if p.recorder.store != nil {
+		return p.recorder.store.Save(ctx, &recording)
+	}
+	return nil
+}
 
-	p.recorder.recordings[recording.ExecutionID] = &recording
+// inputOverride replaces the input of a node of a re-run
+type inputOverride struct {
+	nodeID string
+	data   map[string]interface{}
+}
+
+func (o *inputOverride) BeforeNode(ctx context.Context, input *engine.NodeHookInput) error {
+	if input.NodeID == o.nodeID {
+		input.Data = copyMap(o.data)
+		input.Items = []runtime.Item{runtime.NewItem(copyMap(o.data))}
+	}
 	return nil
 }
 
+func (o *inputOverride) AfterNode(ctx context.Context, input *engine.NodeHookInput, output map[string]interface{}, err error) {
+}
+
+// diffRecording compares the nodes a re-run ran again with the same nodes
+// in the original execution, in the order of the workflow
+func diffRecording(workflow *engine.WorkflowDefinition, recording *ExecutionRecording, state *engine.ExecutionState, recorded map[string]*engine.RecordedNode) []ReplayDiff {
+	original := make(map[string]*ExecutionSnapshot, len(recording.Snapshots))
+	for _, snap := range recording.Snapshots {
+		original[snap.NodeID] = snap
+	}
+
+	var diffs []ReplayDiff
+	for _, node := range workflow.Nodes {
+		if recorded[node.ID] != nil {
+			continue
+		}
+		result, ran := state.NodeResults[node.ID]
+		snap, ranBefore := original[node.ID]
+		if !ran && !ranBefore {
+			continue
+		}
+
+		var originalStatus, replayStatus interface{}
+		if ranBefore {
+			originalStatus = snap.Status
+		}
+		if ran {
+			replayStatus = string(result.Status)
+		}
+		// Outputs are only compared between runs of the same outcome
+		if originalStatus != replayStatus {
+			diffs = append(diffs, ReplayDiff{
+				NodeID:        node.ID,
+				NodeName:      node.Name,
+				Field:         "status",
+				OriginalValue: originalStatus,
+				ReplayValue:   replayStatus,
+				Type:          "status",
+			})
+			continue
+		}
+
+		for _, key := range sortedKeys(snap.Output, result.Output) {
+			origVal, replayVal := snap.Output[key], result.Output[key]
+			if !equalValues(origVal, replayVal) {
+				diffs = append(diffs, ReplayDiff{
+					NodeID:        node.ID,
+					NodeName:      node.Name,
+					Field:         fmt.Sprintf("output.%s", key),
+					OriginalValue: origVal,
+					ReplayValue:   replayVal,
+					Type:          "output",
+				})
+			}
+		}
+	}
+	return diffs
+}
+
 // Helper functions
 func copyMap(m map[string]interface{}) map[string]interface{} {
 	if m == nil {
@@ -480,4 +813,26 @@ func equalValues(a, b interface{}) bool {
 	return string(aJSON) == string(bJSON)
 }
 
+// sortedKeys returns the keys of both maps in order
+func sortedKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
 
+func hasNode(workflow *engine.WorkflowDefinition, nodeID string) bool {
+	for _, node := range workflow.Nodes {
+		if node.ID == nodeID {
+			return true
+		}
+	}
+	return false
+}
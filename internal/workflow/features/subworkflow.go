@@ -3,39 +3,40 @@ package features
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/linkflow-ai/linkflow-ai/internal/workflow/domain/model"
+	"github.com/linkflow-ai/linkflow-ai/internal/engine"
+	"github.com/linkflow-ai/linkflow-ai/internal/node/runtime"
 )
 
-// SubWorkflowExecutor handles sub-workflow execution
-type SubWorkflowExecutor struct {
-	workflowRepo WorkflowRepository
-	executor     WorkflowExecutor
-	maxDepth     int
-	mu           sync.RWMutex
-}
+// executeWorkflowType is the type of the nodes that call other workflows
+const executeWorkflowType = "execute_workflow"
 
-// WorkflowRepository interface for fetching workflows
-type WorkflowRepository interface {
-	FindByID(ctx context.Context, id string) (*model.Workflow, error)
-}
+// Sub-workflow chain errors
+var (
+	ErrSubWorkflowCycle    = errors.New("circular sub-workflow reference")
+	ErrSubWorkflowNotFound = errors.New("sub-workflow not found")
+)
 
-// WorkflowExecutor interface for executing workflows
-type WorkflowExecutor interface {
-	Execute(ctx context.Context, workflow *model.Workflow, input map[string]interface{}, options *ExecutionOptions) (*ExecutionResult, error)
+// SubWorkflowExecutor handles sub-workflow execution. It runs the workflows
+// that execute workflow nodes call, on the engine of the calling workflow.
+type SubWorkflowExecutor struct {
+	workflows engine.WorkflowLoader
+	engine    *engine.Engine
+	maxDepth  int
 }
 
 // ExecutionOptions holds execution options
 type ExecutionOptions struct {
-	Mode        string
 	UserID      string
 	WorkspaceID string
-	ParentID    string
-	Depth       int
+	ParentID    string // Calling execution
+	Depth       int    // Of the calling execution
 	Variables   map[string]interface{}
 	Credentials map[string]map[string]interface{}
 }
@@ -50,11 +51,11 @@ type ExecutionResult struct {
 }
 
 // NewSubWorkflowExecutor creates a new sub-workflow executor
-func NewSubWorkflowExecutor(repo WorkflowRepository, executor WorkflowExecutor) *SubWorkflowExecutor {
+func NewSubWorkflowExecutor(workflows engine.WorkflowLoader, eng *engine.Engine) *SubWorkflowExecutor {
 	return &SubWorkflowExecutor{
-		workflowRepo: repo,
-		executor:     executor,
-		maxDepth:     10, // Maximum nesting depth
+		workflows: workflows,
+		engine:    eng,
+		maxDepth:  10, // Maximum nesting depth
 	}
 }
 
@@ -69,7 +70,9 @@ type SubWorkflowNode struct {
 	FallbackValue map[string]interface{} `json:"fallbackValue"`
 }
 
-// ExecuteSubWorkflow executes a sub-workflow
+// ExecuteSubWorkflow executes a sub-workflow. Unless the node waits for its
+// output, the sub-workflow runs in the background and the result only holds
+// its execution ID.
 func (e *SubWorkflowExecutor) ExecuteSubWorkflow(
 	ctx context.Context,
 	config *SubWorkflowNode,
@@ -81,24 +84,34 @@ func (e *SubWorkflowExecutor) ExecuteSubWorkflow(
 		return nil, fmt.Errorf("maximum sub-workflow nesting depth (%d) exceeded", e.maxDepth)
 	}
 
-	// Fetch sub-workflow
-	workflow, err := e.workflowRepo.FindByID(ctx, config.WorkflowID)
+	// Fetch sub-workflow; the workflows of other users are not found
+	workflow, err := e.workflows(ctx, config.WorkflowID)
 	if err != nil {
-		return nil, fmt.Errorf("sub-workflow not found: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrSubWorkflowNotFound, err)
+	}
+	if workflow.UserID != "" && options.UserID != "" && workflow.UserID != options.UserID {
+		return nil, ErrSubWorkflowNotFound
 	}
-
-	// Map input data
-	mappedInput := e.mapInput(input, config.InputMapping)
 
 	// Create execution options for sub-workflow
-	subOptions := &ExecutionOptions{
+	subOptions := &engine.ExecutionOptions{
+		ExecutionID: uuid.New().String(),
 		Mode:        "subworkflow",
+		TriggerData: e.mapInput(input, config.InputMapping),
+		Credentials: options.Credentials,
+		Variables:   options.Variables,
 		UserID:      options.UserID,
 		WorkspaceID: options.WorkspaceID,
-		ParentID:    options.ParentID,
 		Depth:       options.Depth + 1,
-		Variables:   options.Variables,
-		Credentials: options.Credentials,
+		ParentID:    options.ParentID,
+	}
+
+	if !config.WaitForOutput {
+		go e.engine.Execute(context.Background(), workflow, subOptions)
+		return &ExecutionResult{
+			ExecutionID: subOptions.ExecutionID,
+			Status:      string(engine.ExecutionStatusRunning),
+		}, nil
 	}
 
 	// Execute with timeout if specified
@@ -109,36 +122,78 @@ func (e *SubWorkflowExecutor) ExecuteSubWorkflow(
 	}
 
 	// Execute sub-workflow
-	result, err := e.executor.Execute(ctx, workflow, mappedInput, subOptions)
+	state, err := e.engine.Execute(ctx, workflow, subOptions)
+	result := &ExecutionResult{ExecutionID: subOptions.ExecutionID}
+	if state != nil {
+		result.Status = state.Status
+		if state.Result != nil {
+			result.Output = state.Result.LastOutput()
+			result.DurationMs = state.Result.DurationMs
+		}
+	}
 	if err != nil {
 		// Handle error based on config
 		switch config.OnError {
 		case "continue":
-			return &ExecutionResult{
-				Status: "failed",
-				Output: config.FallbackValue,
-				Error:  err,
-			}, nil
+			result.Status = string(engine.ExecutionStatusFailed)
+			result.Output = config.FallbackValue
+			result.Error = err
+			return result, nil
 		case "fallback":
-			return &ExecutionResult{
-				Status: "fallback",
-				Output: config.FallbackValue,
-			}, nil
+			result.Status = "fallback"
+			result.Output = config.FallbackValue
+			return result, nil
 		default:
-			return nil, err
+			return nil, fmt.Errorf("sub-workflow execution %s failed: %w", subOptions.ExecutionID, err)
 		}
 	}
 
 	// Map output data
-	if config.OutputMapping != nil && len(config.OutputMapping) > 0 {
+	if len(config.OutputMapping) > 0 && result.Output != nil {
 		result.Output = e.mapOutput(result.Output, config.OutputMapping)
 	}
 
 	return result, nil
 }
 
+// RunWorkflow runs the workflow called by a node, as the engine's
+// runtime.WorkflowRunner
+func (e *SubWorkflowExecutor) RunWorkflow(ctx context.Context, call *runtime.WorkflowCall) (*runtime.WorkflowCallResult, error) {
+	options := &ExecutionOptions{}
+	if caller := call.Caller; caller != nil {
+		options.UserID = caller.UserID
+		options.WorkspaceID = caller.WorkspaceID
+		options.ParentID = caller.ExecutionID
+		options.Depth = caller.Depth
+		options.Variables = caller.Variables
+	}
+
+	result, err := e.ExecuteSubWorkflow(ctx, &SubWorkflowNode{
+		WorkflowID:    call.WorkflowID,
+		InputMapping:  call.InputMapping,
+		OutputMapping: call.OutputMapping,
+		WaitForOutput: call.Wait,
+		Timeout:       call.Timeout,
+		OnError:       call.OnError,
+		FallbackValue: call.FallbackValue,
+	}, call.Input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	callResult := &runtime.WorkflowCallResult{
+		ExecutionID: result.ExecutionID,
+		Status:      result.Status,
+		Output:      result.Output,
+	}
+	if result.Error != nil {
+		callResult.Error = result.Error.Error()
+	}
+	return callResult, nil
+}
+
 func (e *SubWorkflowExecutor) mapInput(input map[string]interface{}, mapping map[string]string) map[string]interface{} {
-	if mapping == nil || len(mapping) == 0 {
+	if len(mapping) == 0 {
 		return input
 	}
 
@@ -190,7 +245,7 @@ func NewSubWorkflowRegistry() *SubWorkflowRegistry {
 func (r *SubWorkflowRegistry) Register(ref *SubWorkflowReference) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	
+
 	if ref.ID == "" {
 		ref.ID = uuid.New().String()
 	}
@@ -210,7 +265,7 @@ func (r *SubWorkflowRegistry) Get(id string) (*SubWorkflowReference, bool) {
 func (r *SubWorkflowRegistry) ListByParent(parentID string) []*SubWorkflowReference {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	
+
 	var refs []*SubWorkflowReference
 	for _, ref := range r.references {
 		if ref.ParentID == parentID {
@@ -224,7 +279,7 @@ func (r *SubWorkflowRegistry) ListByParent(parentID string) []*SubWorkflowRefere
 func (r *SubWorkflowRegistry) ListAll() []*SubWorkflowReference {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	
+
 	refs := make([]*SubWorkflowReference, 0, len(r.references))
 	for _, ref := range r.references {
 		refs = append(refs, ref)
@@ -239,20 +294,39 @@ func (r *SubWorkflowRegistry) Delete(id string) {
 	delete(r.references, id)
 }
 
-// ValidateSubWorkflowChain validates that a sub-workflow chain doesn't have cycles
-func ValidateSubWorkflowChain(repo WorkflowRepository, workflowID string, visited map[string]bool) error {
-	if visited == nil {
-		visited = make(map[string]bool)
-	}
-	
-	if visited[workflowID] {
-		return fmt.Errorf("circular sub-workflow reference detected: %s", workflowID)
+// ValidateSubWorkflowChain validates that a sub-workflow chain doesn't have
+// cycles: no workflow a workflow calls, directly or through the workflows
+// it calls in turn, may call back into the chain. Calls of a workflow ID
+// given by an expression are only known once they run and are not followed.
+func ValidateSubWorkflowChain(ctx context.Context, load engine.WorkflowLoader, workflow *engine.WorkflowDefinition) error {
+	return validateChain(ctx, load, workflow, []string{workflow.ID}, make(map[string]bool))
+}
+
+// validateChain follows the calls of the last workflow of path. Workflows
+// already validated are not followed again.
+func validateChain(ctx context.Context, load engine.WorkflowLoader, workflow *engine.WorkflowDefinition, path []string, visited map[string]bool) error {
+	for _, node := range workflow.Nodes {
+		calledID, _ := node.Config["workflowId"].(string)
+		if node.Type != executeWorkflowType || calledID == "" || strings.Contains(calledID, "{{") || visited[calledID] {
+			continue
+		}
+
+		chain := append(path[:len(path):len(path)], calledID)
+		if containsString(path, calledID) {
+			return fmt.Errorf("%w: %s", ErrSubWorkflowCycle, strings.Join(chain, " -> "))
+		}
+
+		called, err := load(ctx, calledID)
+		if err != nil {
+			return fmt.Errorf("%w: %s, called by node %s: %v", ErrSubWorkflowNotFound, calledID, node.Name, err)
+		}
+		if called.UserID != "" && workflow.UserID != "" && called.UserID != workflow.UserID {
+			return fmt.Errorf("%w: %s, called by node %s", ErrSubWorkflowNotFound, calledID, node.Name)
+		}
+		if err := validateChain(ctx, load, called, chain, visited); err != nil {
+			return err
+		}
+		visited[calledID] = true
 	}
-	
-	visited[workflowID] = true
-	
-	// In a real implementation, this would fetch the workflow and check its sub-workflow nodes
-	// For now, we just validate the visited map
-	
 	return nil
 }
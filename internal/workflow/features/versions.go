@@ -0,0 +1,258 @@
+// Package features provides the version history of workflows
+package features
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrVersionNotFound is returned for a version a workflow never had
+var ErrVersionNotFound = errors.New("workflow version not found")
+
+// WorkflowVersion is a saved revision of a workflow. Versions are stored
+// once, when the workflow is saved, and never changed afterwards.
+type WorkflowVersion struct {
+	WorkflowID  string                   `json:"workflowId"`
+	Version     int                      `json:"version"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Nodes       []map[string]interface{} `json:"nodes,omitempty"`
+	Connections []map[string]interface{} `json:"connections,omitempty"`
+	Settings    map[string]interface{}   `json:"settings,omitempty"`
+	Author      string                   `json:"author"` // User who saved the version
+	Message     string                   `json:"message"`
+	CreatedAt   time.Time                `json:"createdAt"`
+}
+
+// PostgresVersionStore keeps the versions of workflows in the
+// workflow_service.workflow_versions table, next to the workflows
+// themselves
+type PostgresVersionStore struct {
+	db *sql.DB
+}
+
+// NewPostgresVersionStore creates a version store backed by Postgres
+func NewPostgresVersionStore(db *sql.DB) *PostgresVersionStore {
+	return &PostgresVersionStore{db: db}
+}
+
+// Save stores a version, failing if the workflow already has it. Given a
+// transaction, the version is stored as part of it, along with the change
+// of the workflow it records.
+func (s *PostgresVersionStore) Save(ctx context.Context, tx *sql.Tx, version *WorkflowVersion) error {
+	nodesJSON, err := json.Marshal(version.Nodes)
+	if err != nil {
+		return fmt.Errorf("failed to encode nodes: %w", err)
+	}
+	connectionsJSON, _ := json.Marshal(version.Connections)
+	settingsJSON, _ := json.Marshal(version.Settings)
+
+	query := `
+		INSERT INTO workflow_service.workflow_versions (workflow_id, version, name, description, nodes, connections, settings, user_id, change_description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	args := []interface{}{
+		version.WorkflowID, version.Version, version.Name, version.Description,
+		nodesJSON, connectionsJSON, settingsJSON, version.Author, version.Message, version.CreatedAt.UTC(),
+	}
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save version %d of workflow %s: %w", version.Version, version.WorkflowID, err)
+	}
+	return nil
+}
+
+// List returns the versions of a workflow, newest first, without their
+// nodes, connections and settings
+func (s *PostgresVersionStore) List(ctx context.Context, workflowID string) ([]*WorkflowVersion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT version, name, description, user_id, COALESCE(change_description, ''), created_at
+		FROM workflow_service.workflow_versions WHERE workflow_id = $1
+		ORDER BY version DESC`, workflowID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []*WorkflowVersion{}
+	for rows.Next() {
+		version := &WorkflowVersion{WorkflowID: workflowID}
+		if err := rows.Scan(&version.Version, &version.Name, &version.Description, &version.Author, &version.Message, &version.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to list versions: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// Get returns a version of a workflow
+func (s *PostgresVersionStore) Get(ctx context.Context, workflowID string, number int) (*WorkflowVersion, error) {
+	version := &WorkflowVersion{WorkflowID: workflowID, Version: number}
+	var nodesJSON, connectionsJSON, settingsJSON []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT name, description, nodes, connections, settings, user_id, COALESCE(change_description, ''), created_at
+		FROM workflow_service.workflow_versions WHERE workflow_id = $1 AND version = $2`, workflowID, number,
+	).Scan(&version.Name, &version.Description, &nodesJSON, &connectionsJSON, &settingsJSON, &version.Author, &version.Message, &version.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load version %d of workflow %s: %w", number, workflowID, err)
+	}
+
+	json.Unmarshal(nodesJSON, &version.Nodes)
+	json.Unmarshal(connectionsJSON, &version.Connections)
+	json.Unmarshal(settingsJSON, &version.Settings)
+	return version, nil
+}
+
+// VersionDiff is the structural difference between two versions of a
+// workflow. Nodes are matched by ID and connections by all their fields, so
+// a connection that changed shows as removed and added.
+type VersionDiff struct {
+	WorkflowID         string                   `json:"workflowId"`
+	From               int                      `json:"from"`
+	To                 int                      `json:"to"`
+	Changes            []ValueChange            `json:"changes"` // Of the name and description
+	NodesAdded         []map[string]interface{} `json:"nodesAdded"`
+	NodesRemoved       []map[string]interface{} `json:"nodesRemoved"`
+	NodesChanged       []NodeChange             `json:"nodesChanged"`
+	ConnectionsAdded   []map[string]interface{} `json:"connectionsAdded"`
+	ConnectionsRemoved []map[string]interface{} `json:"connectionsRemoved"`
+	SettingsChanged    []ValueChange            `json:"settingsChanged"`
+}
+
+// NodeChange lists the fields of a node that differ between two versions
+type NodeChange struct {
+	NodeID  string        `json:"nodeId"`
+	Name    string        `json:"name"`
+	Changes []ValueChange `json:"changes"`
+}
+
+// ValueChange is a field that differs between two versions. Fields of a
+// node's config are named config.<field>.
+type ValueChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Empty reports whether the two versions are structurally the same
+func (d *VersionDiff) Empty() bool {
+	return len(d.Changes) == 0 && len(d.NodesAdded) == 0 && len(d.NodesRemoved) == 0 && len(d.NodesChanged) == 0 &&
+		len(d.ConnectionsAdded) == 0 && len(d.ConnectionsRemoved) == 0 && len(d.SettingsChanged) == 0
+}
+
+// DiffVersions compares version from with version to. Pinned data is test
+// data rather than part of a node's definition and is not compared.
+func DiffVersions(from, to *WorkflowVersion) *VersionDiff {
+	diff := &VersionDiff{
+		WorkflowID:         to.WorkflowID,
+		From:               from.Version,
+		To:                 to.Version,
+		Changes:            diffValues("", map[string]interface{}{"name": from.Name, "description": from.Description}, map[string]interface{}{"name": to.Name, "description": to.Description}),
+		NodesAdded:         []map[string]interface{}{},
+		NodesRemoved:       []map[string]interface{}{},
+		NodesChanged:       []NodeChange{},
+		ConnectionsAdded:   []map[string]interface{}{},
+		ConnectionsRemoved: []map[string]interface{}{},
+		SettingsChanged:    diffValues("", from.Settings, to.Settings),
+	}
+
+	fromNodes := nodesByID(from.Nodes)
+	toNodes := nodesByID(to.Nodes)
+	for _, node := range to.Nodes {
+		id, _ := node["id"].(string)
+		before, ok := fromNodes[id]
+		if !ok {
+			diff.NodesAdded = append(diff.NodesAdded, node)
+			continue
+		}
+		if changes := diffNode(before, node); len(changes) > 0 {
+			name, _ := node["name"].(string)
+			diff.NodesChanged = append(diff.NodesChanged, NodeChange{NodeID: id, Name: name, Changes: changes})
+		}
+	}
+	for _, node := range from.Nodes {
+		if id, _ := node["id"].(string); toNodes[id] == nil {
+			diff.NodesRemoved = append(diff.NodesRemoved, node)
+		}
+	}
+
+	fromConnections := connectionKeys(from.Connections)
+	toConnections := connectionKeys(to.Connections)
+	for _, connection := range to.Connections {
+		if !fromConnections[connectionKey(connection)] {
+			diff.ConnectionsAdded = append(diff.ConnectionsAdded, connection)
+		}
+	}
+	for _, connection := range from.Connections {
+		if !toConnections[connectionKey(connection)] {
+			diff.ConnectionsRemoved = append(diff.ConnectionsRemoved, connection)
+		}
+	}
+
+	return diff
+}
+
+// diffNode compares the fields of a node, and the fields of its config one
+// by one
+func diffNode(before, after map[string]interface{}) []ValueChange {
+	fields := func(node map[string]interface{}) map[string]interface{} {
+		fields := copyMap(node)
+		delete(fields, "id")
+		delete(fields, "pinnedData")
+		delete(fields, "config")
+		return fields
+	}
+	changes := diffValues("", fields(before), fields(after))
+
+	beforeConfig, _ := before["config"].(map[string]interface{})
+	afterConfig, _ := after["config"].(map[string]interface{})
+	return append(changes, diffValues("config.", beforeConfig, afterConfig)...)
+}
+
+// diffValues lists the keys whose values differ between a and b, in key
+// order, prefixing the field names with prefix
+func diffValues(prefix string, a, b map[string]interface{}) []ValueChange {
+	changes := []ValueChange{}
+	for _, key := range sortedKeys(a, b) {
+		if !equalValues(a[key], b[key]) {
+			changes = append(changes, ValueChange{Field: prefix + key, From: a[key], To: b[key]})
+		}
+	}
+	return changes
+}
+
+func nodesByID(nodes []map[string]interface{}) map[string]map[string]interface{} {
+	byID := make(map[string]map[string]interface{}, len(nodes))
+	for _, node := range nodes {
+		if id, _ := node["id"].(string); id != "" {
+			byID[id] = node
+		}
+	}
+	return byID
+}
+
+func connectionKeys(connections []map[string]interface{}) map[string]bool {
+	keys := make(map[string]bool, len(connections))
+	for _, connection := range connections {
+		keys[connectionKey(connection)] = true
+	}
+	return keys
+}
+
+// connectionKey identifies a connection by all its fields; maps encode to
+// JSON with sorted keys
+func connectionKey(connection map[string]interface{}) string {
+	key, _ := json.Marshal(connection)
+	return string(key)
+}
@@ -0,0 +1,179 @@
+package features
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testVersion(number int) *WorkflowVersion {
+	return &WorkflowVersion{
+		WorkflowID:  "wf-1",
+		Version:     number,
+		Name:        "Orders",
+		Description: "Syncs orders",
+		Nodes: []map[string]interface{}{
+			{"id": "trigger", "name": "Webhook", "type": "webhook_trigger", "config": map[string]interface{}{"path": "orders"}},
+			{"id": "fetch", "name": "Fetch", "type": "http_request", "config": map[string]interface{}{"url": "https://a.example.com", "method": "GET"}},
+			{"id": "notify", "name": "Notify", "type": "slack", "config": map[string]interface{}{"channel": "#orders"}},
+		},
+		Connections: []map[string]interface{}{
+			{"sourceNodeId": "trigger", "targetNodeId": "fetch"},
+			{"sourceNodeId": "fetch", "targetNodeId": "notify"},
+		},
+		Settings: map[string]interface{}{"timeout": 30},
+	}
+}
+
+func TestDiffVersions_ReportsStructuralChanges(t *testing.T) {
+	from, to := testVersion(1), testVersion(2)
+	to.Name = "Orders v2"
+	to.Nodes[1] = map[string]interface{}{"id": "fetch", "name": "Fetch", "type": "http_request", "config": map[string]interface{}{"url": "https://b.example.com", "method": "GET"}}
+	to.Nodes = append(to.Nodes[:2], map[string]interface{}{"id": "email", "name": "Email", "type": "email"})
+	to.Connections = []map[string]interface{}{
+		{"sourceNodeId": "trigger", "targetNodeId": "fetch"},
+		{"sourceNodeId": "fetch", "targetNodeId": "email"},
+	}
+	to.Settings = map[string]interface{}{"timeout": 60, "retries": 3}
+
+	diff := DiffVersions(from, to)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, "wf-1", diff.WorkflowID)
+	assert.Equal(t, 1, diff.From)
+	assert.Equal(t, 2, diff.To)
+	assert.Equal(t, []ValueChange{{Field: "name", From: "Orders", To: "Orders v2"}}, diff.Changes)
+
+	require.Len(t, diff.NodesAdded, 1)
+	assert.Equal(t, "email", diff.NodesAdded[0]["id"])
+	require.Len(t, diff.NodesRemoved, 1)
+	assert.Equal(t, "notify", diff.NodesRemoved[0]["id"])
+	assert.Equal(t, []NodeChange{{
+		NodeID:  "fetch",
+		Name:    "Fetch",
+		Changes: []ValueChange{{Field: "config.url", From: "https://a.example.com", To: "https://b.example.com"}},
+	}}, diff.NodesChanged)
+
+	// A connection that changed shows as removed and added
+	assert.Equal(t, []map[string]interface{}{{"sourceNodeId": "fetch", "targetNodeId": "email"}}, diff.ConnectionsAdded)
+	assert.Equal(t, []map[string]interface{}{{"sourceNodeId": "fetch", "targetNodeId": "notify"}}, diff.ConnectionsRemoved)
+
+	assert.Equal(t, []ValueChange{
+		{Field: "retries", From: nil, To: 3},
+		{Field: "timeout", From: 30, To: 60},
+	}, diff.SettingsChanged)
+}
+
+func TestDiffVersions_IgnoresPinnedDataAndOrder(t *testing.T) {
+	from, to := testVersion(1), testVersion(2)
+	to.Nodes[1]["pinnedData"] = []interface{}{map[string]interface{}{"id": 1}}
+	to.Nodes[0], to.Nodes[2] = to.Nodes[2], to.Nodes[0]
+	to.Connections[0], to.Connections[1] = to.Connections[1], to.Connections[0]
+
+	diff := DiffVersions(from, to)
+	assert.True(t, diff.Empty(), "%+v", diff)
+	assert.Empty(t, diff.NodesAdded)
+	assert.Empty(t, diff.NodesChanged)
+}
+
+// versionTestDB connects to the database named by TEST_DATABASE_URL and
+// creates the workflows table and the version table of its migration
+func versionTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migration, err := os.ReadFile(filepath.Join("..", "..", "..", "migrations", "000028_workflow_service_versions.up.sql"))
+	require.NoError(t, err)
+	for _, statement := range []string{
+		`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`,
+		`CREATE SCHEMA IF NOT EXISTS workflow_service`,
+		`CREATE TABLE IF NOT EXISTS workflow_service.workflows (id UUID PRIMARY KEY, user_id VARCHAR(255) NOT NULL)`,
+		string(migration),
+	} {
+		_, err := db.Exec(statement)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func TestPostgresVersionStore_SaveListGetAndRestore(t *testing.T) {
+	db := versionTestDB(t)
+	ctx := context.Background()
+	store := NewPostgresVersionStore(db)
+
+	workflowID := uuid.New().String()
+	_, err := db.Exec("INSERT INTO workflow_service.workflows (id, user_id) VALUES ($1, 'user-1')", workflowID)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Exec("DELETE FROM workflow_service.workflows WHERE id = $1", workflowID) })
+
+	save := func(version *WorkflowVersion, message string) {
+		version.WorkflowID, version.Author, version.Message = workflowID, "user-1", message
+		version.CreatedAt = time.Now().Truncate(time.Millisecond)
+		require.NoError(t, store.Save(ctx, nil, version))
+	}
+	first := testVersion(1)
+	save(first, "Created")
+	second := testVersion(2)
+	second.Nodes = second.Nodes[:2]
+	second.Connections = second.Connections[:1]
+	save(second, "Dropped the notification")
+
+	// A version is stored once
+	assert.Error(t, store.Save(ctx, nil, second))
+
+	versions, err := store.List(ctx, workflowID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+	assert.Equal(t, "Dropped the notification", versions[0].Message)
+	assert.Nil(t, versions[0].Nodes, "listed versions carry no nodes")
+	assert.Equal(t, 1, versions[1].Version)
+
+	loaded, err := store.Get(ctx, workflowID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Orders", loaded.Name)
+	assert.Equal(t, "user-1", loaded.Author)
+	assert.True(t, DiffVersions(first, loaded).Empty())
+	_, err = store.Get(ctx, workflowID, 9)
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+
+	// Restoring saves a copy of the old version as the newest one
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	restored := *loaded
+	restored.Version, restored.Message = 3, "Restored version 1"
+	require.NoError(t, store.Save(ctx, tx, &restored))
+	require.NoError(t, tx.Commit())
+
+	current, err := store.Get(ctx, workflowID, 3)
+	require.NoError(t, err)
+	assert.True(t, DiffVersions(first, current).Empty())
+	assert.False(t, DiffVersions(second, current).Empty())
+	versions, err = store.List(ctx, workflowID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+
+	// Versions belong to workflows of the workflow_service schema and go
+	// with them
+	orphan := testVersion(1)
+	orphan.WorkflowID, orphan.Author = uuid.New().String(), "user-1"
+	assert.Error(t, store.Save(ctx, nil, orphan))
+	_, err = db.Exec("DELETE FROM workflow_service.workflows WHERE id = $1", workflowID)
+	require.NoError(t, err)
+	versions, err = store.List(ctx, workflowID)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
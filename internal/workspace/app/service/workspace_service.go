@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
 	"github.com/linkflow-ai/linkflow-ai/internal/workspace/domain/model"
 )
 
@@ -189,6 +190,9 @@ func (s *WorkspaceService) UpdateWorkspace(ctx context.Context, input UpdateWork
 		workspace.Description = input.Description
 	}
 	if input.Settings != nil {
+		if err := input.Settings.Egress.Validate(); err != nil {
+			return nil, err
+		}
 		workspace.Settings = *input.Settings
 	}
 	workspace.UpdatedAt = time.Now()
@@ -451,3 +455,31 @@ func (s *WorkspaceService) ListAuditLogs(ctx context.Context, workspaceID string
 func (s *WorkspaceService) LogAction(ctx context.Context, workspaceID, userID, action, resource, resourceID string, details map[string]interface{}) {
 	s.auditRepo.Create(ctx, model.NewAuditLog(workspaceID, userID, action, resource, resourceID, details))
 }
+
+// Egress
+
+// EgressPolicy returns the egress policy of a workspace; it serves as the
+// egress.PolicyLoader of the guard that workflow requests go through
+func (s *WorkspaceService) EgressPolicy(ctx context.Context, workspaceID string) (*egress.Policy, error) {
+	workspace, err := s.workspaceRepo.FindByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return &workspace.Settings.Egress, nil
+}
+
+// RecordEgressViolation audits a request the egress policy blocked; it
+// serves as the guard's egress.ViolationHandler
+func (s *WorkspaceService) RecordEgressViolation(ctx context.Context, violation egress.Violation) {
+	if violation.WorkspaceID == "" {
+		return
+	}
+	s.auditRepo.Create(ctx, model.NewAuditLog(
+		violation.WorkspaceID, "", model.ActionEgressBlocked,
+		model.ResourceEgress, violation.Host, map[string]interface{}{
+			"source": violation.Source,
+			"ip":     violation.IP,
+			"reason": violation.Reason,
+		},
+	))
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/linkflow-ai/linkflow-ai/internal/platform/egress"
 )
 
 var slugRegex = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
@@ -54,6 +56,7 @@ type WorkspaceSettings struct {
 	RequireApproval    bool   `json:"requireApproval"`
 	AuditLogEnabled    bool   `json:"auditLogEnabled"`
 	SSOEnabled         bool   `json:"ssoEnabled"`
+	Egress             egress.Policy `json:"egress"` // Destinations workflows of the workspace may call
 }
 
 // WorkspaceLimits defines resource limits
@@ -295,6 +298,7 @@ const (
 	ActionLogout         = "logout"
 	ActionAPIKeyCreated  = "api_key_created"
 	ActionAPIKeyRevoked  = "api_key_revoked"
+	ActionEgressBlocked  = "egress_blocked"
 )
 
 // Resource types
@@ -306,6 +310,7 @@ const (
 	ResourceMember      = "member"
 	ResourceWorkspace   = "workspace"
 	ResourceAPIKey      = "api_key"
+	ResourceEgress      = "egress"
 )
 
 // Errors
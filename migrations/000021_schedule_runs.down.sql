@@ -0,0 +1,6 @@
+-- ============================================================================
+-- Migration: 000021_schedule_runs (ROLLBACK)
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_schedule_runs_claimed_at;
+DROP TABLE IF EXISTS schedule_runs;
@@ -0,0 +1,14 @@
+-- ============================================================================
+-- Migration: 000021_schedule_runs
+-- Description: Claimed schedule runs, so each run executes on one replica
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS schedule_runs (
+    schedule_id VARCHAR(255) NOT NULL,
+    run_at TIMESTAMPTZ NOT NULL,
+    claimed_by VARCHAR(255) NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (schedule_id, run_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_runs_claimed_at ON schedule_runs(claimed_at);
@@ -0,0 +1,8 @@
+-- ============================================================================
+-- Migration: 000022_webhook_routes (ROLLBACK)
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_received_at;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhooks_active_path;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS trigger_node_id;
@@ -0,0 +1,19 @@
+-- ============================================================================
+-- Migration: 000022_webhook_routes
+-- Description: Webhook trigger routes shared by all replicas, and the
+--              deliveries seen on them for replay protection
+-- ============================================================================
+
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS trigger_node_id VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhooks_active_path
+    ON webhooks(path) WHERE status = 'active' AND path IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    delivery_key VARCHAR(255) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (webhook_id, delivery_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_received_at ON webhook_deliveries(received_at);
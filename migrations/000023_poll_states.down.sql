@@ -0,0 +1,5 @@
+-- ============================================================================
+-- Migration: 000023_poll_states (ROLLBACK)
+-- ============================================================================
+
+DROP TABLE IF EXISTS poll_states;
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- Migration: 000023_poll_states
+-- Description: Cursors of polling triggers and the items they already saw
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS poll_states (
+    trigger_id VARCHAR(255) PRIMARY KEY,
+    poll_cursor TEXT NOT NULL DEFAULT '',
+    seen JSONB NOT NULL DEFAULT '[]',
+    polled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
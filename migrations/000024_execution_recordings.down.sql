@@ -0,0 +1,6 @@
+-- ============================================================================
+-- Migration: 000024_execution_recordings (ROLLBACK)
+-- ============================================================================
+
+DROP TABLE IF EXISTS execution_node_recordings;
+DROP TABLE IF EXISTS execution_recordings;
@@ -0,0 +1,40 @@
+-- ============================================================================
+-- Migration: 000024_execution_recordings
+-- Description: Recorded inputs and outputs of the nodes of executions, which
+-- re-runs from a node reuse instead of running upstream nodes again
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS execution_recordings (
+    execution_id VARCHAR(255) PRIMARY KEY,
+    id VARCHAR(255) NOT NULL,
+    workflow_id VARCHAR(255) NOT NULL,
+    workflow_name VARCHAR(255) NOT NULL DEFAULT '',
+    mode VARCHAR(50) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    trigger_node_id VARCHAR(255) NOT NULL DEFAULT '',
+    trigger_data JSONB,
+    final_output JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    started_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    total_duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_execution_recordings_workflow ON execution_recordings(workflow_id, started_at DESC);
+
+CREATE TABLE IF NOT EXISTS execution_node_recordings (
+    execution_id VARCHAR(255) NOT NULL REFERENCES execution_recordings(execution_id) ON DELETE CASCADE,
+    sequence INTEGER NOT NULL,
+    node_id VARCHAR(255) NOT NULL,
+    node_name VARCHAR(255) NOT NULL DEFAULT '',
+    node_type VARCHAR(100) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    input JSONB,
+    output JSONB,
+    items JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (execution_id, sequence)
+);
@@ -0,0 +1,6 @@
+-- ============================================================================
+-- Migration: 000025_workflow_version_history (ROLLBACK)
+-- ============================================================================
+
+ALTER TABLE workflow_versions DROP COLUMN IF EXISTS description;
+ALTER TABLE workflow_versions DROP COLUMN IF EXISTS name;
@@ -0,0 +1,8 @@
+-- ============================================================================
+-- Migration: 000025_workflow_version_history
+-- Description: Name and description of each saved workflow version, so that
+-- a version holds everything a rollback restores
+-- ============================================================================
+
+ALTER TABLE workflow_versions ADD COLUMN IF NOT EXISTS name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE workflow_versions ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
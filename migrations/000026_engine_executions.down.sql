@@ -0,0 +1,5 @@
+-- ============================================================================
+-- Migration: 000026_engine_executions (ROLLBACK)
+-- ============================================================================
+
+DROP TABLE IF EXISTS execution_service.engine_executions;
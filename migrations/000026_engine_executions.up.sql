@@ -0,0 +1,31 @@
+-- ============================================================================
+-- Migration: 000026_engine_executions
+-- Description: Execution records of the API's workflow engine, with the
+--              checkpoints that parked and interrupted runs resume from
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS execution_service.engine_executions (
+    id VARCHAR(255) PRIMARY KEY,
+    workflow_id VARCHAR(255) NOT NULL,
+    workflow_name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    mode VARCHAR(50) NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    trigger_data JSONB,
+    node_outputs JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    parent_id VARCHAR(255),
+    user_id VARCHAR(255) NOT NULL DEFAULT '',
+    workspace_id VARCHAR(255) NOT NULL DEFAULT '',
+    metadata JSONB,
+    checkpoint JSONB,
+    wait_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_engine_executions_workflow ON execution_service.engine_executions(workflow_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_engine_executions_unfinished ON execution_service.engine_executions(status, updated_at) WHERE status IN ('running', 'waiting');
@@ -0,0 +1,22 @@
+-- ============================================================================
+-- Migration: 000027_webhook_service_routes (ROLLBACK)
+-- ============================================================================
+
+DROP TABLE IF EXISTS webhook_service.webhook_deliveries;
+DROP INDEX IF EXISTS webhook_service.idx_webhooks_workflow_id;
+DROP INDEX IF EXISTS webhook_service.idx_webhooks_active_path;
+DROP INDEX IF EXISTS webhook_service.idx_webhooks_endpoint_url;
+
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS failure_count;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS success_count;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS trigger_count;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS last_triggered_at;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS status;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS authentication_config;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS authentication_type;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS headers;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS secret;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS endpoint_url;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS name;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS trigger_node_id;
+ALTER TABLE webhook_service.webhooks DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,35 @@
+-- ============================================================================
+-- Migration: 000027_webhook_service_routes
+-- Description: Webhook trigger routes in the webhook_service schema the API
+--              serves webhooks from, and the deliveries seen on them
+-- ============================================================================
+
+ALTER TABLE webhook_service.webhooks ALTER COLUMN endpoint_id DROP NOT NULL;
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS user_id VARCHAR(255);
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS trigger_node_id VARCHAR(255);
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS endpoint_url VARCHAR(500);
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS secret VARCHAR(255);
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS headers JSONB DEFAULT '{}';
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS authentication_type VARCHAR(50);
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS authentication_config JSONB DEFAULT '{}';
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS status VARCHAR(50) NOT NULL DEFAULT 'inactive';
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS last_triggered_at TIMESTAMPTZ;
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS trigger_count INTEGER DEFAULT 0;
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS success_count INTEGER DEFAULT 0;
+ALTER TABLE webhook_service.webhooks ADD COLUMN IF NOT EXISTS failure_count INTEGER DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhooks_endpoint_url
+    ON webhook_service.webhooks(endpoint_url) WHERE endpoint_url IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhooks_active_path
+    ON webhook_service.webhooks(path) WHERE status = 'active' AND path IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_webhooks_workflow_id ON webhook_service.webhooks(workflow_id);
+
+CREATE TABLE IF NOT EXISTS webhook_service.webhook_deliveries (
+    webhook_id UUID NOT NULL REFERENCES webhook_service.webhooks(id) ON DELETE CASCADE,
+    delivery_key VARCHAR(255) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (webhook_id, delivery_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_received_at ON webhook_service.webhook_deliveries(received_at);
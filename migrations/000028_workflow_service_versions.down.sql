@@ -0,0 +1,5 @@
+-- ============================================================================
+-- Migration: 000028_workflow_service_versions (ROLLBACK)
+-- ============================================================================
+
+DROP TABLE IF EXISTS workflow_service.workflow_versions;
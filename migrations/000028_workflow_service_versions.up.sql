@@ -0,0 +1,21 @@
+-- ============================================================================
+-- Migration: 000028_workflow_service_versions
+-- Description: Version history of the workflows the API stores in the
+--              workflow_service schema, which the public workflow_versions
+--              table cannot reference
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS workflow_service.workflow_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    workflow_id UUID NOT NULL REFERENCES workflow_service.workflows(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    user_id VARCHAR(255) NOT NULL,
+    nodes JSONB NOT NULL,
+    connections JSONB NOT NULL,
+    settings JSONB,
+    change_description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(workflow_id, version)
+);
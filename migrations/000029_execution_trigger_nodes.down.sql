@@ -0,0 +1,5 @@
+-- ============================================================================
+-- Migration: 000029_execution_trigger_nodes (ROLLBACK)
+-- ============================================================================
+
+ALTER TABLE execution_service.executions DROP COLUMN IF EXISTS trigger_node_id;
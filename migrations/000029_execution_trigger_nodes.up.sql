@@ -0,0 +1,7 @@
+-- ============================================================================
+-- Migration: 000029_execution_trigger_nodes
+-- Description: The trigger node an execution of the API started from, so
+--              that a retry starts from the same one
+-- ============================================================================
+
+ALTER TABLE execution_service.executions ADD COLUMN IF NOT EXISTS trigger_node_id VARCHAR(255);
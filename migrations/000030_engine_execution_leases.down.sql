@@ -0,0 +1,8 @@
+-- ============================================================================
+-- Migration: 000030_engine_execution_leases (ROLLBACK)
+-- ============================================================================
+
+DROP INDEX IF EXISTS execution_service.idx_engine_executions_lease;
+
+ALTER TABLE execution_service.engine_executions DROP COLUMN IF EXISTS lease_until;
+ALTER TABLE execution_service.engine_executions DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- Migration: 000030_engine_execution_leases
+-- Description: Leases on the executions the engine runs, renewed while they
+--              run, so that replicas only resume executions whose lease
+--              expired and claim each before resuming it
+-- ============================================================================
+
+ALTER TABLE execution_service.engine_executions ADD COLUMN IF NOT EXISTS owner VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE execution_service.engine_executions ADD COLUMN IF NOT EXISTS lease_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_engine_executions_lease ON execution_service.engine_executions(lease_until) WHERE status = 'running';
@@ -0,0 +1,327 @@
+// Package expression provides the expression syntax tree and its parser
+package expression
+
+import (
+	"strconv"
+)
+
+// node is an expression syntax tree node
+type node interface {
+	position() int
+}
+
+type literalNode struct {
+	pos   int
+	value interface{}
+}
+
+type identNode struct {
+	pos  int
+	name string
+}
+
+type arrayNode struct {
+	pos      int
+	elements []node
+}
+
+type memberNode struct {
+	pos    int
+	object node
+	name   string
+}
+
+type indexNode struct {
+	pos    int
+	object node
+	index  node
+}
+
+type callNode struct {
+	pos    int
+	callee node
+	args   []node
+}
+
+type unaryNode struct {
+	pos     int
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	pos   int
+	op    string
+	left  node
+	right node
+}
+
+type conditionalNode struct {
+	pos        int
+	test       node
+	consequent node
+	alternate  node
+}
+
+func (n *literalNode) position() int     { return n.pos }
+func (n *identNode) position() int       { return n.pos }
+func (n *arrayNode) position() int       { return n.pos }
+func (n *memberNode) position() int      { return n.pos }
+func (n *indexNode) position() int       { return n.pos }
+func (n *callNode) position() int        { return n.pos }
+func (n *unaryNode) position() int       { return n.pos }
+func (n *binaryNode) position() int      { return n.pos }
+func (n *conditionalNode) position() int { return n.pos }
+
+// binary operator precedence, higher binds tighter
+var precedence = map[string]int{
+	"??":  1,
+	"||":  2,
+	"&&":  3,
+	"==":  4,
+	"!=":  4,
+	"===": 4,
+	"!==": 4,
+	"<":   5,
+	"<=":  5,
+	">":   5,
+	">=":  5,
+	"+":   6,
+	"-":   6,
+	"*":   7,
+	"/":   7,
+	"%":   7,
+}
+
+// astParser is a recursive descent parser over a token stream
+type astParser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+// parse parses an expression into a syntax tree
+func parse(expr string) (node, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &astParser{expr: expr, tokens: tokens}
+	root, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, newError(expr, tok.pos, "unexpected %q", tok.text)
+	}
+
+	return root, nil
+}
+
+func (p *astParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *astParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *astParser) expect(kind tokenKind, text string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		if tok.kind == tokenEOF {
+			return tok, newError(p.expr, tok.pos, "expected %q but expression ended", text)
+		}
+		return tok, newError(p.expr, tok.pos, "expected %q but found %q", text, tok.text)
+	}
+	return tok, nil
+}
+
+func (p *astParser) parseConditional() (node, error) {
+	test, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenQuestion {
+		return test, nil
+	}
+	tok := p.next()
+
+	consequent, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokenColon, ":"); err != nil {
+		return nil, err
+	}
+	alternate, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+
+	return &conditionalNode{pos: tok.pos, test: test, consequent: consequent, alternate: alternate}, nil
+}
+
+// parseBinary implements precedence climbing for binary operators
+func (p *astParser) parseBinary(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		prec, ok := precedence[tok.text]
+		if tok.kind != tokenOperator || !ok || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseBinary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: tok.pos, op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *astParser) parseUnary() (node, error) {
+	tok := p.peek()
+	if tok.kind == tokenOperator && (tok.text == "!" || tok.text == "-" || tok.text == "+") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: tok.pos, op: tok.text, operand: operand}, nil
+	}
+
+	return p.parsePostfix()
+}
+
+func (p *astParser) parsePostfix() (node, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		switch tok.kind {
+		case tokenDot:
+			p.next()
+			name := p.next()
+			if name.kind != tokenIdent && name.kind != tokenNumber {
+				return nil, newError(p.expr, name.pos, "expected property name after '.'")
+			}
+			expr = &memberNode{pos: name.pos, object: expr, name: name.text}
+
+		case tokenLBracket:
+			p.next()
+			index, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokenRBracket, "]"); err != nil {
+				return nil, err
+			}
+			expr = &indexNode{pos: tok.pos, object: expr, index: index}
+
+		case tokenLParen:
+			p.next()
+			args, err := p.parseList(tokenRParen, ")")
+			if err != nil {
+				return nil, err
+			}
+			expr = &callNode{pos: tok.pos, callee: expr, args: args}
+
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func (p *astParser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, newError(p.expr, tok.pos, "invalid number %q", tok.text)
+		}
+		return &literalNode{pos: tok.pos, value: value}, nil
+
+	case tokenString:
+		return &literalNode{pos: tok.pos, value: tok.value}, nil
+
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{pos: tok.pos, value: true}, nil
+		case "false":
+			return &literalNode{pos: tok.pos, value: false}, nil
+		case "null", "undefined":
+			return &literalNode{pos: tok.pos, value: nil}, nil
+		}
+		return &identNode{pos: tok.pos, name: tok.text}, nil
+
+	case tokenLParen:
+		expr, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+
+	case tokenLBracket:
+		elements, err := p.parseList(tokenRBracket, "]")
+		if err != nil {
+			return nil, err
+		}
+		return &arrayNode{pos: tok.pos, elements: elements}, nil
+
+	case tokenEOF:
+		return nil, newError(p.expr, tok.pos, "unexpected end of expression")
+	}
+
+	return nil, newError(p.expr, tok.pos, "unexpected %q", tok.text)
+}
+
+// parseList parses comma separated expressions up to the closing token
+func (p *astParser) parseList(closing tokenKind, closingText string) ([]node, error) {
+	var list []node
+
+	if p.peek().kind == closing {
+		p.next()
+		return list, nil
+	}
+
+	for {
+		item, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+
+		tok := p.next()
+		if tok.kind == closing {
+			return list, nil
+		}
+		if tok.kind != tokenComma {
+			if tok.kind == tokenEOF {
+				return nil, newError(p.expr, tok.pos, "expected %q but expression ended", closingText)
+			}
+			return nil, newError(p.expr, tok.pos, "expected ',' or %q but found %q", closingText, tok.text)
+		}
+	}
+}
@@ -0,0 +1,544 @@
+// Package expression provides evaluation of expression syntax trees
+package expression
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// accessor is a special root such as $node or $input whose properties are
+// resolved lazily. When used as a plain value it evaluates to value.
+type accessor struct {
+	value interface{}
+	get   func(name string) (interface{}, error)
+}
+
+// evaluator evaluates a syntax tree against a context
+type evaluator struct {
+	parser *Parser
+	ctx    *Context
+	expr   string
+}
+
+func (ev *evaluator) errorf(n node, format string, args ...interface{}) error {
+	return newError(ev.expr, n.position(), format, args...)
+}
+
+func (ev *evaluator) eval(n node) (interface{}, error) {
+	v, err := ev.evalNode(n)
+	if err != nil {
+		return nil, err
+	}
+	return unwrap(v), nil
+}
+
+func (ev *evaluator) evalNode(n node) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		return ev.evalIdent(n)
+
+	case *arrayNode:
+		result := make([]interface{}, len(n.elements))
+		for i, el := range n.elements {
+			v, err := ev.eval(el)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = v
+		}
+		return result, nil
+
+	case *memberNode:
+		object, err := ev.evalNode(n.object)
+		if err != nil {
+			return nil, err
+		}
+		return ev.property(n, object, n.name)
+
+	case *indexNode:
+		object, err := ev.evalNode(n.object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := ev.eval(n.index)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := toNumber(index); ok {
+			if arr, ok := toArray(object); ok {
+				i := int(f)
+				if i < 0 || i >= len(arr) {
+					return nil, nil
+				}
+				return arr[i], nil
+			}
+		}
+		return ev.property(n, object, toString(index))
+
+	case *callNode:
+		return ev.evalCall(n)
+
+	case *unaryNode:
+		operand, err := ev.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "!":
+			return !toBool(operand), nil
+		case "-", "+":
+			f, ok := toNumber(operand)
+			if !ok {
+				return nil, ev.errorf(n, "cannot apply unary %s to %s", n.op, typeName(operand))
+			}
+			if n.op == "-" {
+				return -f, nil
+			}
+			return f, nil
+		}
+
+	case *binaryNode:
+		return ev.evalBinary(n)
+
+	case *conditionalNode:
+		test, err := ev.eval(n.test)
+		if err != nil {
+			return nil, err
+		}
+		if toBool(test) {
+			return ev.evalNode(n.consequent)
+		}
+		return ev.evalNode(n.alternate)
+	}
+
+	return nil, ev.errorf(n, "unsupported expression")
+}
+
+func (ev *evaluator) evalIdent(n *identNode) (interface{}, error) {
+	ctx := ev.ctx
+
+	switch n.name {
+	case "$json":
+		return ctx.Input, nil
+
+	case "$input":
+		return &accessor{value: ctx.Input, get: ev.inputProperty}, nil
+
+	case "$node":
+		return &accessor{value: ctx.NodeOutputs, get: func(nodeID string) (interface{}, error) {
+			output, exists := ctx.NodeOutputs[nodeID]
+			if !exists {
+				return nil, fmt.Errorf("node '%s' not found in context", nodeID)
+			}
+			return &accessor{value: output, get: func(field string) (interface{}, error) {
+				switch field {
+				case "data", "json":
+					return output, nil
+				}
+				return output[field], nil
+			}}, nil
+		}}, nil
+
+	case "$env":
+		env := make(map[string]interface{}, len(ctx.Env))
+		for k, v := range ctx.Env {
+			env[k] = v
+		}
+		return env, nil
+
+	case "$vars":
+		return ctx.Variables, nil
+
+	case "$execution":
+		return &accessor{get: func(field string) (interface{}, error) {
+			switch field {
+			case "id":
+				return ctx.Execution.ID, nil
+			case "mode":
+				return ctx.Execution.Mode, nil
+			case "timestamp":
+				return ctx.Execution.Timestamp.Format(time.RFC3339), nil
+			case "resumeUrl":
+				return ctx.Execution.ResumeURL, nil
+			}
+			return nil, fmt.Errorf("unknown execution field: %s", field)
+		}}, nil
+
+	case "$workflow":
+		return &accessor{get: func(field string) (interface{}, error) {
+			switch field {
+			case "id":
+				return ctx.Workflow.ID, nil
+			case "name":
+				return ctx.Workflow.Name, nil
+			case "active":
+				return ctx.Workflow.Active, nil
+			}
+			return nil, fmt.Errorf("unknown workflow field: %s", field)
+		}}, nil
+
+	case "$func":
+		return &accessor{get: func(name string) (interface{}, error) {
+			fn, exists := ev.parser.functions[name]
+			if !exists {
+				return nil, fmt.Errorf("unknown function: %s", name)
+			}
+			return fn, nil
+		}}, nil
+
+	case "$now":
+		return time.Now().Format(time.RFC3339), nil
+	case "$today":
+		return time.Now().Format("2006-01-02"), nil
+	case "$timestamp":
+		return time.Now().Unix(), nil
+	case "$itemIndex":
+		return ctx.ItemIndex, nil
+	}
+
+	if fn, exists := ev.parser.functions[n.name]; exists {
+		return fn, nil
+	}
+
+	return nil, ev.errorf(n, "unknown identifier %q", n.name)
+}
+
+// inputProperty resolves $input.item, $input.all and friends; any other name
+// is looked up on the current input
+func (ev *evaluator) inputProperty(name string) (interface{}, error) {
+	ctx := ev.ctx
+
+	switch name {
+	case "item":
+		return ctx.Input, nil
+	case "all":
+		if ctx.Items != nil {
+			return ctx.Items, nil
+		}
+		return ctx.Input, nil
+	case "first":
+		if len(ctx.Items) > 0 {
+			return ctx.Items[0], nil
+		}
+		return ctx.Input, nil
+	case "last":
+		if len(ctx.Items) > 0 {
+			return ctx.Items[len(ctx.Items)-1], nil
+		}
+		return ctx.Input, nil
+	case "index":
+		return ctx.ItemIndex, nil
+	}
+
+	return lookup(ctx.Input, name)
+}
+
+func (ev *evaluator) property(n node, object interface{}, name string) (interface{}, error) {
+	if acc, ok := object.(*accessor); ok {
+		v, err := acc.get(name)
+		if err != nil {
+			return nil, ev.errorf(n, "%v", err)
+		}
+		return v, nil
+	}
+
+	v, err := lookup(object, name)
+	if err != nil {
+		return nil, ev.errorf(n, "%v", err)
+	}
+	return v, nil
+}
+
+func (ev *evaluator) evalCall(n *callNode) (interface{}, error) {
+	callee, err := ev.evalNode(n.callee)
+	if err != nil {
+		return nil, err
+	}
+
+	name := "expression"
+	switch c := n.callee.(type) {
+	case *memberNode:
+		name = c.name
+	case *identNode:
+		name = c.name
+	}
+
+	fn, ok := callee.(Function)
+	if !ok {
+		return nil, ev.errorf(n, "%s is not a function", name)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := ev.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	result, err := fn(args...)
+	if err != nil {
+		return nil, ev.errorf(n, "%s: %v", name, err)
+	}
+	return result, nil
+}
+
+func (ev *evaluator) evalBinary(n *binaryNode) (interface{}, error) {
+	left, err := ev.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit operators return one of their operands
+	switch n.op {
+	case "&&":
+		if !toBool(left) {
+			return left, nil
+		}
+		return ev.eval(n.right)
+	case "||":
+		if toBool(left) {
+			return left, nil
+		}
+		return ev.eval(n.right)
+	case "??":
+		if left != nil {
+			return left, nil
+		}
+		return ev.eval(n.right)
+	}
+
+	right, err := ev.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "===":
+		return looseEqual(left, right), nil
+	case "!=", "!==":
+		return !looseEqual(left, right), nil
+
+	case "<", "<=", ">", ">=":
+		cmp, ok := compare(left, right)
+		if !ok {
+			return nil, ev.errorf(n, "cannot compare %s and %s", typeName(left), typeName(right))
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+
+	case "+":
+		if la, ok := left.([]interface{}); ok {
+			if ra, ok := right.([]interface{}); ok {
+				return append(append([]interface{}{}, la...), ra...), nil
+			}
+		}
+		_, ls := left.(string)
+		_, rs := right.(string)
+		if ls || rs {
+			return toString(left) + toString(right), nil
+		}
+	}
+
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, ev.errorf(n, "cannot apply %s to %s and %s", n.op, typeName(left), typeName(right))
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, ev.errorf(n, "division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, ev.errorf(n, "division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+
+	return nil, ev.errorf(n, "unknown operator %s", n.op)
+}
+
+// lookup reads a property of a value. Missing fields and properties of null
+// evaluate to null rather than failing.
+func lookup(object interface{}, name string) (interface{}, error) {
+	switch o := object.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return o[name], nil
+	case map[string]map[string]interface{}:
+		if v, ok := o[name]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case map[string]string:
+		if v, ok := o[name]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case string:
+		if name == "length" {
+			return len(o), nil
+		}
+	}
+
+	if arr, ok := toArray(object); ok {
+		if name == "length" {
+			return len(arr), nil
+		}
+		if i, err := strconv.Atoi(name); err == nil {
+			if i < 0 || i >= len(arr) {
+				return nil, nil
+			}
+			return arr[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot read property '%s' of %s", name, typeName(object))
+}
+
+// unwrap converts lazily resolved roots into plain values
+func unwrap(v interface{}) interface{} {
+	if acc, ok := v.(*accessor); ok {
+		return acc.value
+	}
+	return v
+}
+
+func toArray(v interface{}) ([]interface{}, bool) {
+	switch a := v.(type) {
+	case []interface{}:
+		return a, true
+	case []map[string]interface{}:
+		result := make([]interface{}, len(a))
+		for i, m := range a {
+			result[i] = m
+		}
+		return result, true
+	case []string:
+		result := make([]interface{}, len(a))
+		for i, s := range a {
+			result[i] = s
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// toNumber converts numeric values and numeric strings to float64
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	case nil:
+		return 0, false
+	}
+	return 0, false
+}
+
+func isNumeric(v interface{}) bool {
+	switch v.(type) {
+	case float64, float32, int, int32, int64, uint, uint64:
+		return true
+	}
+	return false
+}
+
+func looseEqual(a, b interface{}) bool {
+	if isNumeric(a) && isNumeric(b) {
+		x, _ := toNumber(a)
+		y, _ := toNumber(b)
+		return x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare orders numbers numerically and strings lexically
+func compare(a, b interface{}) (int, bool) {
+	if isNumeric(a) || isNumeric(b) {
+		x, xok := toNumber(a)
+		y, yok := toNumber(b)
+		if !xok || !yok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if !aok || !bok {
+		return 0, false
+	}
+	switch {
+	case as < bs:
+		return -1, true
+	case as > bs:
+		return 1, true
+	}
+	return 0, true
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if isNumeric(v) {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
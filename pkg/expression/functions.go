@@ -62,11 +62,11 @@ func funcSubstring(args ...interface{}) (interface{}, error) {
 	}
 	s := fmt.Sprintf("%v", args[0])
 	start := toInt(args[1])
-	
+
 	if start < 0 || start >= len(s) {
 		return "", nil
 	}
-	
+
 	if len(args) >= 3 {
 		end := toInt(args[2])
 		if end > len(s) {
@@ -74,7 +74,7 @@ func funcSubstring(args ...interface{}) (interface{}, error) {
 		}
 		return s[start:end], nil
 	}
-	
+
 	return s[start:], nil
 }
 
@@ -185,7 +185,7 @@ func funcMin(args ...interface{}) (interface{}, error) {
 	if len(args) < 1 {
 		return nil, fmt.Errorf("min requires at least 1 argument")
 	}
-	
+
 	// Handle array input
 	if arr, ok := args[0].([]interface{}); ok {
 		if len(arr) == 0 {
@@ -199,7 +199,7 @@ func funcMin(args ...interface{}) (interface{}, error) {
 		}
 		return min, nil
 	}
-	
+
 	// Handle multiple arguments
 	min := toFloat(args[0])
 	for _, v := range args[1:] {
@@ -214,7 +214,7 @@ func funcMax(args ...interface{}) (interface{}, error) {
 	if len(args) < 1 {
 		return nil, fmt.Errorf("max requires at least 1 argument")
 	}
-	
+
 	// Handle array input
 	if arr, ok := args[0].([]interface{}); ok {
 		if len(arr) == 0 {
@@ -228,7 +228,7 @@ func funcMax(args ...interface{}) (interface{}, error) {
 		}
 		return max, nil
 	}
-	
+
 	// Handle multiple arguments
 	max := toFloat(args[0])
 	for _, v := range args[1:] {
@@ -243,9 +243,9 @@ func funcSum(args ...interface{}) (interface{}, error) {
 	if len(args) < 1 {
 		return nil, fmt.Errorf("sum requires at least 1 argument")
 	}
-	
+
 	var sum float64
-	
+
 	if arr, ok := args[0].([]interface{}); ok {
 		for _, v := range arr {
 			sum += toFloat(v)
@@ -255,7 +255,7 @@ func funcSum(args ...interface{}) (interface{}, error) {
 			sum += toFloat(v)
 		}
 	}
-	
+
 	return sum, nil
 }
 
@@ -263,10 +263,10 @@ func funcAvg(args ...interface{}) (interface{}, error) {
 	if len(args) < 1 {
 		return nil, fmt.Errorf("avg requires at least 1 argument")
 	}
-	
+
 	var sum float64
 	var count int
-	
+
 	if arr, ok := args[0].([]interface{}); ok {
 		for _, v := range arr {
 			sum += toFloat(v)
@@ -278,11 +278,11 @@ func funcAvg(args ...interface{}) (interface{}, error) {
 			count++
 		}
 	}
-	
+
 	if count == 0 {
 		return 0, nil
 	}
-	
+
 	return sum / float64(count), nil
 }
 
@@ -296,16 +296,16 @@ func funcFormatDate(args ...interface{}) (interface{}, error) {
 	if len(args) < 2 {
 		return nil, fmt.Errorf("formatDate requires 2 arguments")
 	}
-	
+
 	dateStr := fmt.Sprintf("%v", args[0])
 	format := fmt.Sprintf("%v", args[1])
-	
+
 	// Parse the date
 	t, err := parseAnyDate(dateStr)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// Convert format from common patterns to Go format
 	goFormat := convertDateFormat(format)
 	return t.Format(goFormat), nil
@@ -315,13 +315,13 @@ func funcParseDate(args ...interface{}) (interface{}, error) {
 	if len(args) < 1 {
 		return nil, fmt.Errorf("parseDate requires 1 argument")
 	}
-	
+
 	dateStr := fmt.Sprintf("%v", args[0])
 	t, err := parseAnyDate(dateStr)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	return t.Format(time.RFC3339), nil
 }
 
@@ -329,15 +329,15 @@ func funcAddDays(args ...interface{}) (interface{}, error) {
 	if len(args) < 2 {
 		return nil, fmt.Errorf("addDays requires 2 arguments")
 	}
-	
+
 	dateStr := fmt.Sprintf("%v", args[0])
 	days := toInt(args[1])
-	
+
 	t, err := parseAnyDate(dateStr)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	return t.AddDate(0, 0, days).Format(time.RFC3339), nil
 }
 
@@ -345,15 +345,15 @@ func funcAddHours(args ...interface{}) (interface{}, error) {
 	if len(args) < 2 {
 		return nil, fmt.Errorf("addHours requires 2 arguments")
 	}
-	
+
 	dateStr := fmt.Sprintf("%v", args[0])
 	hours := toInt(args[1])
-	
+
 	t, err := parseAnyDate(dateStr)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	return t.Add(time.Duration(hours) * time.Hour).Format(time.RFC3339), nil
 }
 
@@ -476,14 +476,14 @@ func funcSort(args ...interface{}) (interface{}, error) {
 	if !ok {
 		return nil, fmt.Errorf("argument must be an array")
 	}
-	
+
 	result := make([]interface{}, len(arr))
 	copy(result, arr)
-	
+
 	sort.Slice(result, func(i, j int) bool {
 		return fmt.Sprintf("%v", result[i]) < fmt.Sprintf("%v", result[j])
 	})
-	
+
 	return result, nil
 }
 
@@ -495,10 +495,10 @@ func funcUnique(args ...interface{}) (interface{}, error) {
 	if !ok {
 		return nil, fmt.Errorf("argument must be an array")
 	}
-	
+
 	seen := make(map[string]bool)
 	result := make([]interface{}, 0)
-	
+
 	for _, v := range arr {
 		key := fmt.Sprintf("%v", v)
 		if !seen[key] {
@@ -506,7 +506,7 @@ func funcUnique(args ...interface{}) (interface{}, error) {
 			result = append(result, v)
 		}
 	}
-	
+
 	return result, nil
 }
 
@@ -519,14 +519,14 @@ func funcFilter(args ...interface{}) (interface{}, error) {
 	if !ok {
 		return nil, fmt.Errorf("argument must be an array")
 	}
-	
+
 	result := make([]interface{}, 0)
 	for _, v := range arr {
 		if v != nil && v != "" {
 			result = append(result, v)
 		}
 	}
-	
+
 	return result, nil
 }
 
@@ -540,14 +540,14 @@ func funcMap(args ...interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("first argument must be an array")
 	}
 	field := fmt.Sprintf("%v", args[1])
-	
+
 	result := make([]interface{}, len(arr))
 	for i, item := range arr {
 		if m, ok := item.(map[string]interface{}); ok {
 			result[i] = m[field]
 		}
 	}
-	
+
 	return result, nil
 }
 
@@ -680,7 +680,7 @@ func funcHash(args ...interface{}) (interface{}, error) {
 	if len(args) >= 2 {
 		algo = fmt.Sprintf("%v", args[1])
 	}
-	
+
 	switch algo {
 	case "md5":
 		hash := md5.Sum([]byte(s))
@@ -748,18 +748,18 @@ func parseAnyDate(s string) (time.Time, error) {
 		"Jan 2, 2006",
 		"January 2, 2006",
 	}
-	
+
 	for _, format := range formats {
 		if t, err := time.Parse(format, s); err == nil {
 			return t, nil
 		}
 	}
-	
+
 	// Try Unix timestamp
 	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
 		return time.Unix(ts, 0), nil
 	}
-	
+
 	return time.Time{}, fmt.Errorf("unable to parse date: %s", s)
 }
 
@@ -774,7 +774,7 @@ func convertDateFormat(format string) string {
 		"ss":   "05",
 		"SSS":  "000",
 	}
-	
+
 	result := format
 	for from, to := range replacements {
 		result = strings.ReplaceAll(result, from, to)
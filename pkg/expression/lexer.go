@@ -0,0 +1,193 @@
+// Package expression provides the tokenizer for the expression language
+package expression
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind identifies the kind of a lexical token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenDot
+	tokenComma
+	tokenQuestion
+	tokenColon
+)
+
+// token is a single lexical token with its byte offset in the source
+type token struct {
+	kind  tokenKind
+	text  string
+	value string // Unescaped value for string literals
+	pos   int
+}
+
+// Error describes an expression that failed to parse or evaluate
+type Error struct {
+	Expr    string
+	Pos     int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d in %q", e.Message, e.Pos, e.Expr)
+}
+
+func newError(expr string, pos int, format string, args ...interface{}) *Error {
+	return &Error{Expr: expr, Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// multi-character operators, longest first
+var operators = []string{"===", "!==", "==", "!=", "<=", ">=", "&&", "||", "??", "+", "-", "*", "/", "%", "<", ">", "!"}
+
+// tokenize splits an expression into tokens
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+	for i < len(expr) {
+		c := expr[i]
+
+		// Node IDs after $node. may contain dashes or start with a digit (UUIDs)
+		if n := len(tokens); n >= 2 && tokens[n-1].kind == tokenDot && tokens[n-2].text == "$node" {
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t\n.[]()?:,", rune(expr[i])) {
+				i++
+			}
+			if i > start {
+				tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+				continue
+			}
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '$' || c == '_' || isLetter(c):
+			start := i
+			i++
+			for i < len(expr) && (expr[i] == '_' || expr[i] == '$' || isLetter(expr[i]) || isDigit(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+
+		case isDigit(c):
+			start := i
+			for i < len(expr) && (isDigit(expr[i]) || (expr[i] == '.' && i+1 < len(expr) && isDigit(expr[i+1]))) {
+				i++
+			}
+			if i < len(expr) && (expr[i] == 'e' || expr[i] == 'E') {
+				i++
+				if i < len(expr) && (expr[i] == '+' || expr[i] == '-') {
+					i++
+				}
+				for i < len(expr) && isDigit(expr[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[start:i], pos: start})
+
+		case c == '"' || c == '\'':
+			start := i
+			value, end, err := scanString(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokenString, text: expr[start:i], value: value, pos: start})
+
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{kind: tokenLBracket, text: "[", pos: i})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{kind: tokenRBracket, text: "]", pos: i})
+			i++
+		case c == '.':
+			tokens = append(tokens, token{kind: tokenDot, text: ".", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+		case c == '?' && !strings.HasPrefix(expr[i:], "??"):
+			tokens = append(tokens, token{kind: tokenQuestion, text: "?", pos: i})
+			i++
+		case c == ':':
+			tokens = append(tokens, token{kind: tokenColon, text: ":", pos: i})
+			i++
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, newError(expr, i, "unexpected character %q", c)
+			}
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(expr)})
+	return tokens, nil
+}
+
+// scanString reads a quoted string starting at start and returns its
+// unescaped value and the offset just past the closing quote
+func scanString(expr string, start int) (string, int, error) {
+	quote := expr[start]
+	var sb strings.Builder
+
+	for i := start + 1; i < len(expr); i++ {
+		c := expr[i]
+		if c == quote {
+			return sb.String(), i + 1, nil
+		}
+		if c == '\\' && i+1 < len(expr) {
+			i++
+			switch expr[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(expr[i])
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+
+	return "", 0, newError(expr, start, "unterminated string literal")
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
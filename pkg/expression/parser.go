@@ -4,50 +4,22 @@ package expression
 import (
 	"encoding/json"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// Expression patterns
-var (
-	// {{$node.nodeName.data.field}} or {{$json.field}} or {{$env.VAR}}
-	expressionPattern = regexp.MustCompile(`\{\{([^}]+)\}\}`)
-	
-	// $node.nodeName.data.field
-	nodeDataPattern = regexp.MustCompile(`^\$node\.([^.]+)\.([^.]+)(?:\.(.+))?$`)
-	
-	// $json.field or $json["field"]
-	jsonPattern = regexp.MustCompile(`^\$json(?:\.|\[)(.+)$`)
-	
-	// $env.VARIABLE
-	envPattern = regexp.MustCompile(`^\$env\.(.+)$`)
-	
-	// $input.item or $input.all
-	inputPattern = regexp.MustCompile(`^\$input\.(.+)$`)
-	
-	// $now, $today, $timestamp
-	datePattern = regexp.MustCompile(`^\$(now|today|timestamp)$`)
-	
-	// $execution.id, $execution.mode
-	executionPattern = regexp.MustCompile(`^\$execution\.(.+)$`)
-	
-	// $workflow.id, $workflow.name
-	workflowPattern = regexp.MustCompile(`^\$workflow\.(.+)$`)
-	
-	// Function calls: $func.uppercase(value)
-	funcPattern = regexp.MustCompile(`^\$func\.(\w+)\((.+)\)$`)
-)
-
 // Context holds the evaluation context
 type Context struct {
-	NodeOutputs   map[string]map[string]interface{} // nodeID -> output data
-	Input         interface{}                        // Current input data
-	Env           map[string]string                  // Environment variables
-	Variables     map[string]interface{}             // User variables
-	Execution     ExecutionContext                   // Execution metadata
-	Workflow      WorkflowContext                    // Workflow metadata
+	NodeOutputs map[string]map[string]interface{} // nodeID -> output data
+	Input       interface{}                       // Current input data
+	Items       []interface{}                     // All input items of the node
+	ItemIndex   int                               // Index of the current item
+	Env         map[string]string                 // Environment variables
+	Variables   map[string]interface{}            // User variables
+	Execution   ExecutionContext                  // Execution metadata
+	Workflow    WorkflowContext                   // Workflow metadata
 }
 
 // ExecutionContext holds execution metadata
@@ -55,13 +27,14 @@ type ExecutionContext struct {
 	ID        string
 	Mode      string // manual, webhook, schedule
 	Timestamp time.Time
+	ResumeURL string
 }
 
 // WorkflowContext holds workflow metadata
 type WorkflowContext struct {
-	ID          string
-	Name        string
-	Active      bool
+	ID     string
+	Name   string
+	Active bool
 }
 
 // NewContext creates a new evaluation context
@@ -86,9 +59,20 @@ func (c *Context) SetInput(input interface{}) {
 	c.Input = input
 }
 
+// SetItems sets the input items and the index of the item being processed.
+// The current item also becomes the input used by $json.
+func (c *Context) SetItems(items []interface{}, index int) {
+	c.Items = items
+	c.ItemIndex = index
+	if index >= 0 && index < len(items) {
+		c.Input = items[index]
+	}
+}
+
 // Parser handles expression parsing and evaluation
 type Parser struct {
 	functions map[string]Function
+	programs  sync.Map // expression source -> parsed syntax tree
 }
 
 // Function represents a built-in function
@@ -117,7 +101,7 @@ func (p *Parser) registerBuiltinFunctions() {
 	p.functions["contains"] = funcContains
 	p.functions["startsWith"] = funcStartsWith
 	p.functions["endsWith"] = funcEndsWith
-	
+
 	// Number functions
 	p.functions["round"] = funcRound
 	p.functions["floor"] = funcFloor
@@ -127,20 +111,20 @@ func (p *Parser) registerBuiltinFunctions() {
 	p.functions["max"] = funcMax
 	p.functions["sum"] = funcSum
 	p.functions["avg"] = funcAvg
-	
+
 	// Date functions
 	p.functions["now"] = funcNow
 	p.functions["formatDate"] = funcFormatDate
 	p.functions["parseDate"] = funcParseDate
 	p.functions["addDays"] = funcAddDays
 	p.functions["addHours"] = funcAddHours
-	
+
 	// JSON functions
 	p.functions["toJson"] = funcToJSON
 	p.functions["fromJson"] = funcFromJSON
 	p.functions["keys"] = funcKeys
 	p.functions["values"] = funcValues
-	
+
 	// Array functions
 	p.functions["first"] = funcFirst
 	p.functions["last"] = funcLast
@@ -150,7 +134,7 @@ func (p *Parser) registerBuiltinFunctions() {
 	p.functions["unique"] = funcUnique
 	p.functions["filter"] = funcFilter
 	p.functions["map"] = funcMap
-	
+
 	// Type functions
 	p.functions["toString"] = funcToString
 	p.functions["toNumber"] = funcToNumber
@@ -158,7 +142,7 @@ func (p *Parser) registerBuiltinFunctions() {
 	p.functions["isNull"] = funcIsNull
 	p.functions["isEmpty"] = funcIsEmpty
 	p.functions["typeof"] = funcTypeof
-	
+
 	// Utility functions
 	p.functions["if"] = funcIf
 	p.functions["default"] = funcDefault
@@ -168,388 +152,189 @@ func (p *Parser) registerBuiltinFunctions() {
 	p.functions["hash"] = funcHash
 }
 
-// Evaluate evaluates an expression string with the given context
+// Evaluate evaluates a template string with the given context. Text outside
+// {{ }} is kept as-is; a string consisting of a single expression returns the
+// typed value. Parse and evaluation failures are returned as *Error.
 func (p *Parser) Evaluate(expr string, ctx *Context) (interface{}, error) {
 	// Check if it's a simple expression (no template syntax)
 	if !strings.Contains(expr, "{{") {
 		return expr, nil
 	}
-	
-	// Find and replace all expressions
-	result := expressionPattern.ReplaceAllStringFunc(expr, func(match string) string {
-		// Extract expression without {{ }}
-		inner := strings.TrimPrefix(strings.TrimSuffix(match, "}}"), "{{")
-		inner = strings.TrimSpace(inner)
-		
-		val, err := p.evaluateExpression(inner, ctx)
-		if err != nil {
-			return match // Return original on error
-		}
-		
-		return toString(val)
-	})
-	
+
+	segments, err := splitTemplate(expr)
+	if err != nil {
+		return nil, err
+	}
+
 	// If the entire string was a single expression, return the typed value
-	if strings.HasPrefix(strings.TrimSpace(expr), "{{") && strings.HasSuffix(strings.TrimSpace(expr), "}}") {
-		inner := strings.TrimPrefix(strings.TrimSuffix(strings.TrimSpace(expr), "}}"), "{{")
-		inner = strings.TrimSpace(inner)
-		return p.evaluateExpression(inner, ctx)
+	if len(segments) == 1 && segments[0].isExpr && strings.TrimSpace(expr) == strings.TrimSpace(segments[0].raw) {
+		return p.evaluateSegment(expr, segments[0], ctx)
 	}
-	
-	return result, nil
-}
 
-// EvaluateTemplate evaluates all expressions in a map
-func (p *Parser) EvaluateTemplate(data map[string]interface{}, ctx *Context) (map[string]interface{}, error) {
-	result := make(map[string]interface{})
-	
-	for key, value := range data {
-		evaluated, err := p.evaluateValue(value, ctx)
+	var sb strings.Builder
+	for _, seg := range segments {
+		if !seg.isExpr {
+			sb.WriteString(seg.text)
+			continue
+		}
+		val, err := p.evaluateSegment(expr, seg, ctx)
 		if err != nil {
-			return nil, fmt.Errorf("error evaluating %s: %w", key, err)
+			return nil, err
 		}
-		result[key] = evaluated
+		sb.WriteString(toString(val))
 	}
-	
-	return result, nil
-}
 
-func (p *Parser) evaluateValue(value interface{}, ctx *Context) (interface{}, error) {
-	switch v := value.(type) {
-	case string:
-		return p.Evaluate(v, ctx)
-	case map[string]interface{}:
-		return p.EvaluateTemplate(v, ctx)
-	case []interface{}:
-		result := make([]interface{}, len(v))
-		for i, item := range v {
-			evaluated, err := p.evaluateValue(item, ctx)
-			if err != nil {
-				return nil, err
-			}
-			result[i] = evaluated
-		}
-		return result, nil
-	default:
-		return value, nil
-	}
+	return sb.String(), nil
 }
 
-// evaluateExpression evaluates a single expression
-func (p *Parser) evaluateExpression(expr string, ctx *Context) (interface{}, error) {
-	expr = strings.TrimSpace(expr)
-	
-	// Check for function call
-	if matches := funcPattern.FindStringSubmatch(expr); len(matches) == 3 {
-		return p.evaluateFunction(matches[1], matches[2], ctx)
-	}
-	
-	// Check for node data reference
-	if matches := nodeDataPattern.FindStringSubmatch(expr); len(matches) >= 3 {
-		return p.evaluateNodeData(matches, ctx)
-	}
-	
-	// Check for $json reference
-	if matches := jsonPattern.FindStringSubmatch(expr); len(matches) == 2 {
-		return p.evaluateJSONPath(matches[1], ctx.Input)
-	}
-	
-	// Check for $env reference
-	if matches := envPattern.FindStringSubmatch(expr); len(matches) == 2 {
-		return ctx.Env[matches[1]], nil
-	}
-	
-	// Check for $input reference
-	if matches := inputPattern.FindStringSubmatch(expr); len(matches) == 2 {
-		return p.evaluateInput(matches[1], ctx)
-	}
-	
-	// Check for date shortcuts
-	if matches := datePattern.FindStringSubmatch(expr); len(matches) == 2 {
-		return p.evaluateDate(matches[1])
-	}
-	
-	// Check for $execution reference
-	if matches := executionPattern.FindStringSubmatch(expr); len(matches) == 2 {
-		return p.evaluateExecution(matches[1], ctx)
-	}
-	
-	// Check for $workflow reference
-	if matches := workflowPattern.FindStringSubmatch(expr); len(matches) == 2 {
-		return p.evaluateWorkflow(matches[1], ctx)
-	}
-	
-	// Check for variable reference
-	if strings.HasPrefix(expr, "$vars.") {
-		varName := strings.TrimPrefix(expr, "$vars.")
-		return ctx.Variables[varName], nil
+// EvaluateExpression evaluates a bare expression without {{ }} delimiters,
+// e.g. a breakpoint condition or a pagination completion check
+func (p *Parser) EvaluateExpression(expr string, ctx *Context) (interface{}, error) {
+	tree, err := p.compile(expr)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Return as literal
-	return expr, nil
+	ev := &evaluator{parser: p, ctx: ctx, expr: expr}
+	return ev.eval(tree)
 }
 
-func (p *Parser) evaluateNodeData(matches []string, ctx *Context) (interface{}, error) {
-	nodeID := matches[1]
-	dataType := matches[2] // "data", "json", "binary"
-	path := ""
-	if len(matches) > 3 {
-		path = matches[3]
-	}
-	
-	nodeOutput, exists := ctx.NodeOutputs[nodeID]
-	if !exists {
-		return nil, fmt.Errorf("node '%s' not found in context", nodeID)
-	}
-	
-	var data interface{}
-	switch dataType {
-	case "data", "json":
-		data = nodeOutput
-	default:
-		data = nodeOutput[dataType]
+// Validate reports syntax errors in a template without evaluating it
+func (p *Parser) Validate(expr string) error {
+	segments, err := splitTemplate(expr)
+	if err != nil {
+		return err
 	}
-	
-	if path == "" {
-		return data, nil
+	for _, seg := range segments {
+		if !seg.isExpr {
+			continue
+		}
+		if _, err := p.compile(seg.text); err != nil {
+			return relocate(err, expr, seg.offset)
+		}
 	}
-	
-	return getValueByPath(data, path)
+	return nil
 }
 
-func (p *Parser) evaluateJSONPath(path string, data interface{}) (interface{}, error) {
-	// Handle bracket notation: ["field"] -> field
-	path = strings.ReplaceAll(path, `["`, ".")
-	path = strings.ReplaceAll(path, `"]`, "")
-	path = strings.TrimPrefix(path, ".")
-	
-	return getValueByPath(data, path)
-}
-
-func (p *Parser) evaluateInput(field string, ctx *Context) (interface{}, error) {
-	switch field {
-	case "item":
-		return ctx.Input, nil
-	case "all":
-		return ctx.Input, nil
-	default:
-		return getValueByPath(ctx.Input, field)
+func (p *Parser) compile(expr string) (node, error) {
+	if cached, ok := p.programs.Load(expr); ok {
+		return cached.(node), nil
 	}
-}
-
-func (p *Parser) evaluateDate(dateType string) (interface{}, error) {
-	now := time.Now()
-	switch dateType {
-	case "now":
-		return now.Format(time.RFC3339), nil
-	case "today":
-		return now.Format("2006-01-02"), nil
-	case "timestamp":
-		return now.Unix(), nil
+	tree, err := parse(expr)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("unknown date type: %s", dateType)
+	p.programs.Store(expr, tree)
+	return tree, nil
 }
 
-func (p *Parser) evaluateExecution(field string, ctx *Context) (interface{}, error) {
-	switch field {
-	case "id":
-		return ctx.Execution.ID, nil
-	case "mode":
-		return ctx.Execution.Mode, nil
-	case "timestamp":
-		return ctx.Execution.Timestamp.Format(time.RFC3339), nil
+func (p *Parser) evaluateSegment(template string, seg segment, ctx *Context) (interface{}, error) {
+	val, err := p.EvaluateExpression(seg.text, ctx)
+	if err != nil {
+		return nil, relocate(err, template, seg.offset)
 	}
-	return nil, fmt.Errorf("unknown execution field: %s", field)
+	return val, nil
 }
 
-func (p *Parser) evaluateWorkflow(field string, ctx *Context) (interface{}, error) {
-	switch field {
-	case "id":
-		return ctx.Workflow.ID, nil
-	case "name":
-		return ctx.Workflow.Name, nil
-	case "active":
-		return ctx.Workflow.Active, nil
-	}
-	return nil, fmt.Errorf("unknown workflow field: %s", field)
+// segment is a piece of a template: literal text or an expression
+type segment struct {
+	text   string // Literal text, or expression source without delimiters
+	raw    string // Source including delimiters
+	offset int    // Offset of text within the template
+	isExpr bool
 }
 
-func (p *Parser) evaluateFunction(name, argsStr string, ctx *Context) (interface{}, error) {
-	fn, exists := p.functions[name]
-	if !exists {
-		return nil, fmt.Errorf("unknown function: %s", name)
-	}
-	
-	// Parse arguments
-	args, err := p.parseArguments(argsStr, ctx)
-	if err != nil {
-		return nil, err
-	}
-	
-	return fn(args...)
-}
+// splitTemplate splits a template into literal and {{ expression }} segments.
+// Braces inside string literals do not terminate an expression.
+func splitTemplate(template string) ([]segment, error) {
+	var segments []segment
+	i := 0
 
-func (p *Parser) parseArguments(argsStr string, ctx *Context) ([]interface{}, error) {
-	if argsStr == "" {
-		return []interface{}{}, nil
-	}
-	
-	// Simple argument splitting (doesn't handle nested functions well)
-	parts := splitArguments(argsStr)
-	args := make([]interface{}, len(parts))
-	
-	for i, part := range parts {
-		part = strings.TrimSpace(part)
-		
-		// Check if it's an expression
-		if strings.HasPrefix(part, "$") {
-			val, err := p.evaluateExpression(part, ctx)
-			if err != nil {
-				return nil, err
-			}
-			args[i] = val
-		} else if strings.HasPrefix(part, `"`) && strings.HasSuffix(part, `"`) {
-			// String literal
-			args[i] = strings.Trim(part, `"`)
-		} else if strings.HasPrefix(part, `'`) && strings.HasSuffix(part, `'`) {
-			// String literal (single quotes)
-			args[i] = strings.Trim(part, `'`)
-		} else if num, err := strconv.ParseFloat(part, 64); err == nil {
-			// Number
-			args[i] = num
-		} else if part == "true" {
-			args[i] = true
-		} else if part == "false" {
-			args[i] = false
-		} else if part == "null" {
-			args[i] = nil
-		} else {
-			args[i] = part
+	for i < len(template) {
+		start := strings.Index(template[i:], "{{")
+		if start == -1 {
+			segments = append(segments, segment{text: template[i:], raw: template[i:], offset: i})
+			break
+		}
+		start += i
+		if start > i {
+			segments = append(segments, segment{text: template[i:start], raw: template[i:start], offset: i})
 		}
-	}
-	
-	return args, nil
-}
-
-// Helper functions
 
-func getValueByPath(data interface{}, path string) (interface{}, error) {
-	if path == "" {
-		return data, nil
-	}
-	
-	parts := strings.Split(path, ".")
-	current := data
-	
-	for _, part := range parts {
-		// Handle array index: field[0]
-		if idx := strings.Index(part, "["); idx != -1 {
-			fieldName := part[:idx]
-			indexStr := strings.TrimSuffix(part[idx+1:], "]")
-			index, err := strconv.Atoi(indexStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid array index: %s", indexStr)
-			}
-			
-			// Get field first if fieldName is not empty
-			if fieldName != "" {
-				current, err = getField(current, fieldName)
+		end := -1
+		for j := start + 2; j < len(template); j++ {
+			c := template[j]
+			if c == '"' || c == '\'' {
+				_, next, err := scanString(template, j)
 				if err != nil {
 					return nil, err
 				}
+				j = next - 1
+				continue
 			}
-			
-			// Then get array element
-			arr, ok := current.([]interface{})
-			if !ok {
-				return nil, fmt.Errorf("expected array at %s", part)
-			}
-			if index < 0 || index >= len(arr) {
-				return nil, fmt.Errorf("array index out of bounds: %d", index)
-			}
-			current = arr[index]
-		} else {
-			var err error
-			current, err = getField(current, part)
-			if err != nil {
-				return nil, err
+			if c == '}' && j+1 < len(template) && template[j+1] == '}' {
+				end = j
+				break
 			}
 		}
+		if end == -1 {
+			return nil, newError(template, start, "unclosed expression, missing '}}'")
+		}
+
+		segments = append(segments, segment{
+			text:   template[start+2 : end],
+			raw:    template[start : end+2],
+			offset: start + 2,
+			isExpr: true,
+		})
+		i = end + 2
 	}
-	
-	return current, nil
+
+	return segments, nil
 }
 
-func getField(data interface{}, field string) (interface{}, error) {
-	switch d := data.(type) {
-	case map[string]interface{}:
-		val, exists := d[field]
-		if !exists {
-			return nil, fmt.Errorf("field '%s' not found", field)
-		}
-		return val, nil
-	case map[string]string:
-		val, exists := d[field]
-		if !exists {
-			return nil, fmt.Errorf("field '%s' not found", field)
+// relocate rewrites an expression error so its position refers to the
+// enclosing template
+func relocate(err error, template string, offset int) error {
+	if exprErr, ok := err.(*Error); ok {
+		return &Error{Expr: template, Pos: exprErr.Pos + offset, Message: exprErr.Message}
+	}
+	return err
+}
+
+// EvaluateTemplate evaluates all expressions in a map
+func (p *Parser) EvaluateTemplate(data map[string]interface{}, ctx *Context) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+
+	for key, value := range data {
+		evaluated, err := p.evaluateValue(value, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating %s: %w", key, err)
 		}
-		return val, nil
-	default:
-		return nil, fmt.Errorf("cannot get field '%s' from %T", field, data)
+		result[key] = evaluated
 	}
+
+	return result, nil
 }
 
-func splitArguments(s string) []string {
-	var result []string
-	var current strings.Builder
-	depth := 0
-	inString := false
-	stringChar := byte(0)
-	
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		
-		if inString {
-			current.WriteByte(c)
-			if c == stringChar && (i == 0 || s[i-1] != '\\') {
-				inString = false
+func (p *Parser) evaluateValue(value interface{}, ctx *Context) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return p.Evaluate(v, ctx)
+	case map[string]interface{}:
+		return p.EvaluateTemplate(v, ctx)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			evaluated, err := p.evaluateValue(item, ctx)
+			if err != nil {
+				return nil, err
 			}
-			continue
-		}
-		
-		if c == '"' || c == '\'' {
-			inString = true
-			stringChar = c
-			current.WriteByte(c)
-			continue
-		}
-		
-		if c == '(' || c == '[' || c == '{' {
-			depth++
-			current.WriteByte(c)
-			continue
-		}
-		
-		if c == ')' || c == ']' || c == '}' {
-			depth--
-			current.WriteByte(c)
-			continue
-		}
-		
-		if c == ',' && depth == 0 {
-			result = append(result, current.String())
-			current.Reset()
-			continue
+			result[i] = evaluated
 		}
-		
-		current.WriteByte(c)
-	}
-	
-	if current.Len() > 0 {
-		result = append(result, current.String())
+		return result, nil
+	default:
+		return value, nil
 	}
-	
-	return result
 }
 
 func toString(v interface{}) string {
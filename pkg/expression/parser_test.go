@@ -0,0 +1,220 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContext() *Context {
+	ctx := NewContext()
+	ctx.SetInput(map[string]interface{}{
+		"a":    float64(2),
+		"name": "  Ada ",
+		"tags": []interface{}{"x", "y"},
+		"user": map[string]interface{}{"age": float64(36)},
+	})
+	ctx.SetNodeOutput("node-1", map[string]interface{}{"status": "ok"})
+	ctx.Env["REGION"] = "eu"
+	ctx.Variables["limit"] = float64(10)
+	ctx.Execution.ID = "exec-1"
+	return ctx
+}
+
+func TestParser_Evaluate(t *testing.T) {
+	p := NewParser()
+	ctx := newTestContext()
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"{{ $json.a + 1 }}", float64(3)},
+		{"{{ $json.a * (3 - 1) % 3 }}", float64(1)},
+		{"{{ $func.uppercase($func.trim($json.name)) }}", "ADA"},
+		{"{{ uppercase('hi') }}", "HI"},
+		{"{{ $json.user.age >= 18 && $json.a != 3 }}", true},
+		{"{{ $json.a > 5 ? 'big' : 'small' }}", "small"},
+		{"{{ $json.tags[1] }}", "y"},
+		{`{{ $json["user"]["age"] }}`, float64(36)},
+		{"{{ $json.missing ?? 'default' }}", "default"},
+		{"{{ $node.node-1.data.status }}", "ok"},
+		{"{{ $node.node-1.status == 'ok' }}", true},
+		{"{{ $env.REGION }}", "eu"},
+		{"{{ $vars.limit }}", float64(10)},
+		{"{{ $execution.id }}", "exec-1"},
+		{"{{ $input.item.a }}", float64(2)},
+		{"{{ '}}' + 'x' }}", "}}x"},
+		{"Hello {{ $func.trim($json.name) }}, you are {{ $json.user.age }}", "Hello Ada, you are 36"},
+		{"plain text", "plain text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := p.Evaluate(tt.expr, ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParser_EvaluateErrors(t *testing.T) {
+	p := NewParser()
+	ctx := newTestContext()
+
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{"{{ $json.a + }}", 13},
+		{"{{ $func.nope(1) }}", 9},
+		{"{{ $json.a / 0 }}", 11},
+		{"{{ $node.missing.data }}", 9},
+		{"Hi {{ $json.a", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := p.Evaluate(tt.expr, ctx)
+			require.Error(t, err)
+
+			exprErr, ok := err.(*Error)
+			require.True(t, ok, "expected *Error, got %T", err)
+			assert.Equal(t, tt.pos, exprErr.Pos)
+		})
+	}
+}
+
+func TestParser_EvaluatePrecedence(t *testing.T) {
+	p := NewParser()
+	ctx := newTestContext()
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"{{ 1 + 2 * 3 }}", float64(7)},
+		{"{{ (1 + 2) * 3 }}", float64(9)},
+		{"{{ 10 - 4 - 3 }}", float64(3)},
+		{"{{ 24 / 4 / 2 }}", float64(3)},
+		{"{{ 7 - 5 % 3 }}", float64(5)},
+		{"{{ -$json.a * 3 }}", float64(-6)},
+		{"{{ !false && false }}", false},
+		{"{{ true || false && false }}", true},
+		{"{{ (true || false) && false }}", false},
+		{"{{ 1 + 1 == 2 && 3 > 2 }}", true},
+		{"{{ $json.a < 3 == true }}", true},
+		{"{{ $json.missing ?? 1 + 1 }}", float64(2)},
+		{"{{ $json.missing || 'fallback' }}", "fallback"},
+		{"{{ 'a' + 1 + 2 }}", "a12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := p.Evaluate(tt.expr, ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParser_EvaluateConditional(t *testing.T) {
+	p := NewParser()
+	ctx := newTestContext()
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"{{ $json.a == 2 ? 'two' : 'other' }}", "two"},
+		{"{{ $json.a > 1 ? $json.a + 1 : $json.a - 1 }}", float64(3)},
+		// Conditionals are right associative
+		{"{{ $json.a > 5 ? 'big' : $json.a > 1 ? 'medium' : 'small' }}", "medium"},
+		{"{{ $json.a > 1 ? ($json.a > 5 ? 'big' : 'medium') : 'small' }}", "medium"},
+		{"{{ ($json.a > 1 ? 10 : 20) + 1 }}", float64(11)},
+		{"{{ $json.missing ? 'set' : 'unset' }}", "unset"},
+		{"{{ $json.tags ? $json.tags[0] : 'none' }}", "x"},
+		// Only the branch taken is evaluated
+		{"{{ true ? 'safe' : $json.a / 0 }}", "safe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := p.Evaluate(tt.expr, ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParser_EvaluateNestedAccess(t *testing.T) {
+	p := NewParser()
+	ctx := NewContext()
+	ctx.SetInput(map[string]interface{}{
+		"orders": []interface{}{
+			map[string]interface{}{"id": "o-1", "lines": []interface{}{
+				map[string]interface{}{"sku": "A", "qty": float64(2)},
+				map[string]interface{}{"sku": "B", "qty": float64(5)},
+			}},
+		},
+		"matrix": []interface{}{[]interface{}{float64(1), float64(2)}, []interface{}{float64(3), float64(4)}},
+		"keys":   map[string]interface{}{"field": "sku", "index": float64(1)},
+		"meta":   map[string]interface{}{"content-type": "json"},
+	})
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"{{ $json.orders[0].lines[1].sku }}", "B"},
+		{"{{ $json.orders[0]['lines'][0]['qty'] }}", float64(2)},
+		{"{{ $json.orders[0].lines[$json.keys.index][$json.keys.field] }}", "B"},
+		{"{{ $json.matrix[1][0] + $json.matrix[0][1] }}", float64(5)},
+		{"{{ $json.matrix[0 + 1][1] }}", float64(4)},
+		{`{{ $json.meta["content-type"] }}`, "json"},
+		{"{{ $json.orders[0].lines[1].qty * 2 }}", float64(10)},
+		{"{{ $json.orders[0].missing.deeper ?? 'none' }}", "none"},
+		{"{{ $json.orders[5] ?? 'none' }}", "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := p.Evaluate(tt.expr, ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParser_EvaluateSyntaxErrors(t *testing.T) {
+	p := NewParser()
+	ctx := newTestContext()
+
+	tests := []struct {
+		expr    string
+		pos     int
+		message string
+	}{
+		{"{{ 'abc + 1 }}", 3, "unterminated string literal"},
+		{`{{ "abc }}`, 3, "unterminated string literal"},
+		{`{{ 'it\'s }}`, 3, "unterminated string literal"},
+		{"{{ $json.a ? 1 }}", 15, `expected ":"`},
+		{"{{ $json.tags[0 }}", 16, `expected "]"`},
+		{"{{ (1 + 2 }}", 10, `expected ")"`},
+		{"{{ $json. }}", 10, "expected property name"},
+		{"{{ 1 2 }}", 5, "unexpected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := p.Evaluate(tt.expr, ctx)
+			require.Error(t, err)
+
+			exprErr, ok := err.(*Error)
+			require.True(t, ok, "expected *Error, got %T", err)
+			assert.Equal(t, tt.pos, exprErr.Pos)
+			assert.Contains(t, exprErr.Message, tt.message)
+		})
+	}
+}